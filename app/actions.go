@@ -88,15 +88,158 @@ type AppActionSaveAs struct{}
 // AppActionOpen - open and load a project from a file
 type AppActionOpen struct{}
 
-func (a AppActionSwitchMode) Target() ActionTarget { return TargetApp }
-func (a AppActionSave) Target() ActionTarget       { return TargetApp }
-func (a AppActionSaveAs) Target() ActionTarget     { return TargetApp }
-func (a AppActionOpen) Target() ActionTarget       { return TargetApp }
+// AppActionOpenRecent - open and load a project from the recent files list
+type AppActionOpenRecent struct{ Filepath string }
+
+// AppActionCompare - compare the scene with a project file
+type AppActionCompare struct{}
+
+// AppActionStopCompare - stop comparing the scene with a project file
+type AppActionStopCompare struct{}
+
+// AppActionUndo - undo the last scene operation
+type AppActionUndo struct{}
+
+// AppActionRedo - redo the last undone scene operation
+type AppActionRedo struct{}
+
+// AppActionRotate - rotate the new object or the selection, depending on the current mode
+type AppActionRotate struct{}
+
+// AppActionMirror - mirror the new building or the selection horizontally or vertically
+type AppActionMirror struct{ Vertical bool }
+
+// AppActionDuplicate - begin duplicating the selection
+type AppActionDuplicate struct{}
+
+// AppActionArray - begin duplicating the selection as an array
+type AppActionArray struct{}
+
+// AppActionAlign - align or distribute the selected buildings and text boxes
+type AppActionAlign struct{ Alignment Alignment }
+
+// AppActionDrag - begin dragging the selection
+type AppActionDrag struct{}
+
+// AppActionDelete - delete the selection
+type AppActionDelete struct{}
+
+func (a AppActionSwitchMode) Target() ActionTarget  { return TargetApp }
+func (a AppActionNew) Target() ActionTarget         { return TargetApp }
+func (a AppActionSave) Target() ActionTarget        { return TargetApp }
+func (a AppActionSaveAs) Target() ActionTarget      { return TargetApp }
+func (a AppActionOpen) Target() ActionTarget        { return TargetApp }
+func (a AppActionOpenRecent) Target() ActionTarget  { return TargetApp }
+func (a AppActionCompare) Target() ActionTarget     { return TargetApp }
+func (a AppActionStopCompare) Target() ActionTarget { return TargetApp }
+func (a AppActionUndo) Target() ActionTarget        { return TargetApp }
+func (a AppActionRedo) Target() ActionTarget        { return TargetApp }
+func (a AppActionRotate) Target() ActionTarget      { return TargetApp }
+func (a AppActionMirror) Target() ActionTarget      { return TargetApp }
+func (a AppActionDuplicate) Target() ActionTarget   { return TargetApp }
+func (a AppActionArray) Target() ActionTarget       { return TargetApp }
+func (a AppActionAlign) Target() ActionTarget       { return TargetApp }
+func (a AppActionDrag) Target() ActionTarget        { return TargetApp }
+func (a AppActionDelete) Target() ActionTarget      { return TargetApp }
 
 ////////////////////////////////////////////////////////////////////////////////////////////////////
 // [TargetGui] actions
 ////////////////////////////////////////////////////////////////////////////////////////////////////
 
+// GuiActionSelectTextBox - sidebar text box toggle clicked
+type GuiActionSelectTextBox struct{}
+
+// GuiActionSelectPath - sidebar path toggle clicked
+type GuiActionSelectPath struct{ Idx int32 }
+
+// GuiActionSelectCategory - sidebar building category toggle clicked
+type GuiActionSelectCategory struct{ Idx int32 }
+
+// GuiActionSelectBuilding - sidebar building toggle clicked (index in the active category)
+type GuiActionSelectBuilding struct{ Idx int32 }
+
+// GuiActionUpdateTextBox - update the selected text box content from the details bar
+type GuiActionUpdateTextBox struct{ Content string }
+
+// GuiActionTransformSelection - rotate the selection by Rot degrees then move its bounds top left
+// corner to Origin, from the details bar
+type GuiActionTransformSelection struct {
+	Origin rl.Vector2
+	Rot    int32
+}
+
+// GuiActionArraySelection - add copies of the selection laid out with Pattern, from the details bar
+type GuiActionArraySelection struct{ Pattern ArrayPattern }
+
+// GuiActionSetModifiers - set the clock speed, power shards and somersloops of the selected
+// clockable buildings, from the details bar
+type GuiActionSetModifiers struct{ Mods Modifiers }
+
+// GuiActionTogglePlanner - open or close the production planner panel
+type GuiActionTogglePlanner struct{}
+
+// GuiActionSetPlanTarget - set the planner target rate of Item, in items per minute, 0 removes it
+type GuiActionSetPlanTarget struct {
+	Item string
+	Rate float32
+}
+
+// GuiActionSetPlanClock - set the clock speed of the planner machines, in percent, 0 for the default
+type GuiActionSetPlanClock struct{ Clock float32 }
+
+// GuiActionToggleAlternate - allow or disallow an alternate recipe in the planner
+type GuiActionToggleAlternate struct{ Recipe string }
+
+// GuiActionPlacePlanBuilding - place a building of a planner step, as if selected in the sidebar
+type GuiActionPlacePlanBuilding struct{ Class string }
+
+// GuiActionGeneratePlanLayout - add a draft layout of the planner plan to the scene, centered on
+// Center (in world coordinates)
+type GuiActionGeneratePlanLayout struct{ Center rl.Vector2 }
+
+// GuiActionSetBOMTier - set the tier of the paths of definition DefIdx in the bill of materials
+type GuiActionSetBOMTier struct{ DefIdx, Tier int }
+
+// GuiActionExportBOM - export the bill of materials to a file, as Markdown or CSV
+type GuiActionExportBOM struct{ Markdown bool }
+
+// GuiActionSetSnapStep - set the grid snap step, in world units, 0 disables snapping
+type GuiActionSetSnapStep struct{ Step float32 }
+
+// GuiActionToggleIssues - open or close the design rules issues panel
+type GuiActionToggleIssues struct{}
+
+// GuiActionToggleLintRule - enable or disable the design rule named Rule
+type GuiActionToggleLintRule struct{ Rule string }
+
+// GuiActionSelectIssue - select the Object of a design rule issue and center the view on Pos (in
+// world coordinates)
+type GuiActionSelectIssue struct {
+	Object Object
+	Pos    rl.Vector2
+}
+
+func (a GuiActionSelectTextBox) Target() ActionTarget      { return TargetGui }
+func (a GuiActionSelectPath) Target() ActionTarget         { return TargetGui }
+func (a GuiActionSelectCategory) Target() ActionTarget     { return TargetGui }
+func (a GuiActionSelectBuilding) Target() ActionTarget     { return TargetGui }
+func (a GuiActionUpdateTextBox) Target() ActionTarget      { return TargetGui }
+func (a GuiActionTransformSelection) Target() ActionTarget { return TargetGui }
+func (a GuiActionArraySelection) Target() ActionTarget     { return TargetGui }
+func (a GuiActionSetModifiers) Target() ActionTarget       { return TargetGui }
+func (a GuiActionTogglePlanner) Target() ActionTarget      { return TargetGui }
+func (a GuiActionSetPlanTarget) Target() ActionTarget      { return TargetGui }
+func (a GuiActionSetPlanClock) Target() ActionTarget       { return TargetGui }
+func (a GuiActionToggleAlternate) Target() ActionTarget    { return TargetGui }
+func (a GuiActionPlacePlanBuilding) Target() ActionTarget  { return TargetGui }
+func (a GuiActionGeneratePlanLayout) Target() ActionTarget { return TargetGui }
+func (a GuiActionSetBOMTier) Target() ActionTarget         { return TargetGui }
+func (a GuiActionExportBOM) Target() ActionTarget          { return TargetGui }
+func (a GuiActionSetSnapStep) Target() ActionTarget        { return TargetGui }
+func (a GuiActionToggleIssues) Target() ActionTarget       { return TargetGui }
+func (a GuiActionToggleLintRule) Target() ActionTarget     { return TargetGui }
+func (a GuiActionSelectIssue) Target() ActionTarget        { return TargetGui }
+
 ////////////////////////////////////////////////////////////////////////////////////////////////////
 // [TargetCamera] actions
 ////////////////////////////////////////////////////////////////////////////////////////////////////
@@ -142,7 +285,20 @@ func (a SelectorActionSelect) Target() ActionTarget { return TargetSelector }
 type NewPathActionInit struct{ DefIdx int }
 
 // NewPathActionMoveTo - update the new path position (either start or end, depending on internal state)
-type NewPathActionMoveTo struct{ Pos rl.Vector2 }
+type NewPathActionMoveTo struct {
+	Pos rl.Vector2
+	// If true, route the last segment with an horizontal and a vertical segment
+	LRoute bool
+}
+
+// NewPathActionAddVertex - place the new path end as a vertex
+type NewPathActionAddVertex struct{}
+
+// NewPathActionToggleSpline - toggle the new path between straight segments and a curve
+type NewPathActionToggleSpline struct{}
+
+// NewPathActionToggleRoute - toggle the routing tool, placing a path routed between 2 building ports
+type NewPathActionToggleRoute struct{}
 
 // NewPathActionReverse - reverse the new path direction
 type NewPathActionReverse struct{}
@@ -153,11 +309,14 @@ type NewPathActionPlaceStart struct{}
 // NewPathActionPlace - add a new path to the scene
 type NewPathActionPlace struct{}
 
-func (a NewPathActionInit) Target() ActionTarget       { return TargetNewPath }
-func (a NewPathActionMoveTo) Target() ActionTarget     { return TargetNewPath }
-func (a NewPathActionReverse) Target() ActionTarget    { return TargetNewPath }
-func (a NewPathActionPlaceStart) Target() ActionTarget { return TargetNewPath }
-func (a NewPathActionPlace) Target() ActionTarget      { return TargetNewPath }
+func (a NewPathActionInit) Target() ActionTarget         { return TargetNewPath }
+func (a NewPathActionMoveTo) Target() ActionTarget       { return TargetNewPath }
+func (a NewPathActionReverse) Target() ActionTarget      { return TargetNewPath }
+func (a NewPathActionPlaceStart) Target() ActionTarget   { return TargetNewPath }
+func (a NewPathActionAddVertex) Target() ActionTarget    { return TargetNewPath }
+func (a NewPathActionToggleSpline) Target() ActionTarget { return TargetNewPath }
+func (a NewPathActionToggleRoute) Target() ActionTarget  { return TargetNewPath }
+func (a NewPathActionPlace) Target() ActionTarget        { return TargetNewPath }
 
 ////////////////////////////////////////////////////////////////////////////////////////////////////
 // [TargetNewBuilding] actions
@@ -172,12 +331,16 @@ type NewBuildingActionMoveTo struct{ Pos rl.Vector2 }
 // NewBuildingActionRotate - rotate the new building direction
 type NewBuildingActionRotate struct{}
 
+// NewBuildingActionMirror - mirror the new building horizontally or vertically
+type NewBuildingActionMirror struct{ Vertical bool }
+
 // NewBuildingActionPlace - place a new building
 type NewBuildingActionPlace struct{}
 
 func (a NewBuildingActionInit) Target() ActionTarget   { return TargetNewBuilding }
 func (a NewBuildingActionMoveTo) Target() ActionTarget { return TargetNewBuilding }
 func (a NewBuildingActionRotate) Target() ActionTarget { return TargetNewBuilding }
+func (a NewBuildingActionMirror) Target() ActionTarget { return TargetNewBuilding }
 func (a NewBuildingActionPlace) Target() ActionTarget  { return TargetNewBuilding }
 
 ////////////////////////////////////////////////////////////////////////////////////////////////////
@@ -223,7 +386,7 @@ type SelectionActionInitSelection struct{ Selection ObjectSelection }
 // SelectionActionDelete - delete the current selection ([SelectionNormal])
 type SelectionActionDelete struct{}
 
-// SelectionActionBeginTransformation - switch selection to either [SelectionDrag] or [SelectionDuplicate]
+// SelectionActionBeginTransformation - switch selection to either [SelectionDrag], [SelectionDuplicate] or [SelectionArray]
 type SelectionActionBeginTransformation struct {
 	// Selection mode
 	Mode SelectionMode
@@ -239,8 +402,34 @@ type SelectionActionMoveTo struct{ Pos rl.Vector2 }
 // SelectionActionMoveBy - translate the selection by a given delta
 type SelectionActionMoveBy struct{ Delta rl.Vector2 }
 
-// SelectionActionRotate - rotate the selection transformation
-type SelectionActionRotate struct{}
+// SelectionActionRotate - rotate the selection transformation by Angle degrees
+type SelectionActionRotate struct{ Angle int32 }
+
+// SelectionActionMirror - mirror the selection transformation horizontally or vertically
+type SelectionActionMirror struct{ Vertical bool }
+
+// SelectionActionBeginVertexDrag - switch selection to [SelectionPathVertex]
+type SelectionActionBeginVertexDrag struct {
+	// Moved vertex index, or segment index if Insert is true
+	Idx int
+	// If true, insert a vertex in the middle of the segment
+	Insert bool
+	// Start position for transformation
+	Pos rl.Vector2
+}
+
+// SelectionActionArraySpacing - change the array copies spacing by Delta ([SelectionArray])
+type SelectionActionArraySpacing struct{ Delta rl.Vector2 }
+
+// SelectionActionAlign - align or distribute the selected buildings and text boxes ([SelectionNormal])
+type SelectionActionAlign struct{ Alignment Alignment }
+
+// SelectionActionToggleSpline - switch the selected paths between straight segments and curves
+type SelectionActionToggleSpline struct{}
+
+// SelectionActionSelectChain - select the buildings and paths linked to the selected ones, in the
+// Dir direction ([SelectionNormal])
+type SelectionActionSelectChain struct{ Dir ChainDir }
 
 // SelectionActionEndTransformation - commit the selection transformation to the scene
 type SelectionActionEndTransformation struct {
@@ -255,4 +444,10 @@ func (a SelectionActionBeginTransformation) Target() ActionTarget { return Targe
 func (a SelectionActionMoveTo) Target() ActionTarget              { return TargetSelection }
 func (a SelectionActionMoveBy) Target() ActionTarget              { return TargetSelection }
 func (a SelectionActionRotate) Target() ActionTarget              { return TargetSelection }
+func (a SelectionActionMirror) Target() ActionTarget              { return TargetSelection }
+func (a SelectionActionBeginVertexDrag) Target() ActionTarget     { return TargetSelection }
+func (a SelectionActionToggleSpline) Target() ActionTarget        { return TargetSelection }
+func (a SelectionActionArraySpacing) Target() ActionTarget        { return TargetSelection }
+func (a SelectionActionAlign) Target() ActionTarget               { return TargetSelection }
+func (a SelectionActionSelectChain) Target() ActionTarget         { return TargetSelection }
 func (a SelectionActionEndTransformation) Target() ActionTarget   { return TargetSelection }
@@ -20,9 +20,9 @@ type Animations struct {
 
 // Update animations state
 //
-// Do not depends on any other state
+// Depends on [Input]
 func (a *Animations) Update() {
-	t := float32(rl.GetTime())
+	t := float32(input.Frame.Time)
 	a.Timer = t
 	a.SelectedLerp = 0.1 + 0.2*math32.Sin(2.*rl.Pi*t)
 	a.BeltOffset = math32.Mod(t, 1.)
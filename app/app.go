@@ -3,6 +3,7 @@ package app
 import (
 	"embed"
 	"fmt"
+	"log/slog"
 	"os"
 	"path"
 	"path/filepath"
@@ -16,14 +17,12 @@ import (
 )
 
 const (
-	// Version of the save file format
-	version          = 0
 	windowTitle      = "Satisfied"
 	extFilter        = "*.satisfied"
 	extFilterDesc    = "Satisfied project"
 	windowWidth      = 1080
 	windowHeight     = 720
-	windowFlags      = rl.FlagWindowResizable | rl.FlagWindowMaximized
+	windowFlags      = rl.FlagWindowResizable
 	DefaultTargetFPS = 30
 )
 
@@ -52,22 +51,34 @@ type DrawCounts struct {
 // Load / save
 ////////////////////////////////////////////////////////////////////////////////////////////////////
 
-// save project to file and updates window title and [App.filepath] on success
-func (a *App) saveFile(filepath string) error {
-	log.Info("saving project", "path", filepath)
-	file, err := os.Create(filepath)
-	if err != nil {
-		log.Error("cannot create file", "path", filepath, "err", err)
-		return err
+// writeProject atomically writes the scene to the given file, after rotating its backups
+//
+// It does not update any state, see [App.saveFile].
+func writeProject(filepath string) error {
+	if err := rotateBackups(filepath, settings.Backups); err != nil {
+		// not critical, continue saving
+		log.Warn("cannot rotate backups", "path", filepath, "err", err)
 	}
-	defer file.Close()
-	err = scene.SaveToText(file)
+	err := WriteFileAtomic(filepath, scene.SaveToText)
 	if err != nil {
 		log.Error("cannot write to file", "path", filepath, "err", err)
 		return err
 	}
+	return nil
+}
+
+// save project to file and updates window title and [App.filepath] on success
+func (a *App) saveFile(filepath string) error {
+	log.Info("saving project", "path", filepath)
+	if err := writeProject(filepath); err != nil {
+		return err
+	}
+	autosave.Discard(a.filepath)
+	autosave.Discard(filepath)
 	a.filepath = filepath
 	scene.ResetModified()
+	autosave.Reset()
+	settings.AddRecentFile(filepath)
 	log.Info("project saved", "path", filepath)
 	return nil
 }
@@ -89,8 +100,20 @@ func (a *App) loadFile(filepath string) error {
 		tfd.MessageBox(windowTitle+" -Error loading file", msg, tfd.DialogOk, tfd.IconError, tfd.ButtonOkYes)
 		return err
 	}
+	settings.StoreCamera(a.filepath)
 	a.filepath = filepath
 	scene = fileScene
+	compare.Reset()
+	power.Reset()
+	lint.Reset()
+	chain.Reset()
+	autosave.Reset()
+	settings.AddRecentFile(filepath)
+	if cam, ok := settings.Camera(filepath); ok {
+		camera.doRestore(cam)
+	} else {
+		camera.doReset()
+	}
 	log.Info("project loaded", "path", filepath)
 	return nil
 }
@@ -107,6 +130,10 @@ func (a *App) loadFile(filepath string) error {
 //   - discard the current changes, returns true
 //   - cancel the operation, returns false
 func (a *App) checkUnsavedChanges() bool {
+	if replay.Replaying() {
+		log.Debug("check unsaved changes", "action", "discard", "reason", "replaying")
+		return true
+	}
 	if a.filepath == "" {
 		log.Debug("check unsaved changes", "project", "new", "isEmpty", scene.IsEmpty())
 		if scene.IsEmpty() {
@@ -125,6 +152,7 @@ func (a *App) checkUnsavedChanges() bool {
 				return true
 			case tfd.ButtonNo:
 				log.Debug("check unsaved changes", "action", "discard")
+				autosave.Discard(a.filepath)
 				return true
 			case tfd.ButtonCancelNo:
 				log.Debug("check unsaved changes", "action", "cancel")
@@ -151,6 +179,7 @@ func (a *App) checkUnsavedChanges() bool {
 				return true
 			case tfd.ButtonNo:
 				log.Debug("check unsaved changes", "action", "discard")
+				autosave.Discard(a.filepath)
 				return true
 			case tfd.ButtonCancelNo:
 				log.Debug("check unsaved changes", "action", "cancel")
@@ -167,14 +196,23 @@ func (a *App) doNew() Action {
 	if !a.checkUnsavedChanges() {
 		return nil
 	}
+	settings.StoreCamera(a.filepath)
 	app.filepath = ""
-	scene.Buildings = scene.Buildings[:0]
-	scene.Paths = scene.Paths[:0]
+	scene = Scene{}
+	compare.Reset()
+	power.Reset()
+	lint.Reset()
+	chain.Reset()
+	autosave.Reset()
 	return a.doSwitchMode(ModeNormal, ResetAll().WithCamera(true))
 }
 
 func (a *App) doOpen() Action {
 	log.Info("open project")
+	if replay.Replaying() {
+		log.Warn("open project", "action", "skip", "reason", "replaying")
+		return nil
+	}
 	if !a.checkUnsavedChanges() {
 		return nil
 	}
@@ -192,8 +230,65 @@ func (a *App) doOpen() Action {
 	return nil
 }
 
+// doCompare compares the scene with a project file chosen by the user
+func (a *App) doCompare() Action {
+	log.Info("compare with project")
+	if replay.Replaying() {
+		log.Warn("compare with project", "action", "skip", "reason", "replaying")
+		return nil
+	}
+	filepath, ok := tfd.OpenFileDialog("Compare with project", "", []string{extFilter}, extFilterDesc)
+	if !ok {
+		log.Debug("compare with project", "action", "cancel")
+		return nil
+	}
+	if err := compare.load(filepath); err != nil {
+		log.Error("compare with project", "action", "cancel", "path", filepath, "err", err)
+		msg := fmt.Sprintf("Cannot load project: %s\n\nError: %s", filepath, RemoveQuotes(err.Error()))
+		tfd.MessageBox(windowTitle+" -Error loading file", msg, tfd.DialogOk, tfd.IconError, tfd.ButtonOkYes)
+	}
+	return nil
+}
+
+// doStopCompare stops comparing the scene with a project file
+func (a *App) doStopCompare() Action {
+	log.Info("stop comparing with project", "path", compare.filepath)
+	compare.Reset()
+	return nil
+}
+
+// doOpenRecent opens a project from the recent files list
+//
+// If the file does not exist anymore, it is removed from the list.
+func (a *App) doOpenRecent(filepath string) Action {
+	log.Info("open recent project", "path", filepath)
+	if replay.Replaying() {
+		log.Warn("open recent project", "action", "skip", "reason", "replaying")
+		return nil
+	}
+	if _, err := os.Stat(filepath); err != nil {
+		log.Error("open recent project", "action", "cancel", "path", filepath, "err", err)
+		msg := fmt.Sprintf("Cannot open project: %s\n\nError: %s", filepath, RemoveQuotes(err.Error()))
+		tfd.MessageBox(windowTitle+" - Error opening file", msg, tfd.DialogOk, tfd.IconError, tfd.ButtonOkYes)
+		settings.RemoveRecentFile(filepath)
+		return nil
+	}
+	if !a.checkUnsavedChanges() {
+		return nil
+	}
+	if err := app.loadFile(filepath); err != nil {
+		log.Error("open recent project", "action", "cancel", "err", err)
+		return nil
+	}
+	return a.doSwitchMode(ModeNormal, ResetAll())
+}
+
 func (a *App) doSaveAs() Action {
 	log.Info("save project as")
+	if replay.Replaying() {
+		log.Warn("save project as", "action", "skip", "reason", "replaying")
+		return nil
+	}
 	filepath, ok := tfd.SaveFileDialog("Save project as...", a.filepath, []string{extFilter}, extFilterDesc)
 	if ok {
 		return a.doSave(filepath)
@@ -205,6 +300,10 @@ func (a *App) doSave(filepath string) Action {
 	if filepath == "" {
 		return a.doSaveAs()
 	}
+	if replay.Replaying() {
+		log.Warn("save project", "action", "skip", "reason", "replaying")
+		return nil
+	}
 	if err := a.saveFile(filepath); err != nil {
 		msg := fmt.Sprintf("Cannot save file: %s\n\nError: %s", filepath, RemoveQuotes(err.Error()))
 		tfd.MessageBox(windowTitle+" - Error saving file", msg, tfd.DialogOk, tfd.IconError, tfd.ButtonOkYes)
@@ -248,7 +347,17 @@ func (a *App) doRotate() Action {
 	case ModeNewBuilding:
 		return newBuilding.doRotate()
 	case ModeSelection:
-		return selection.doRotate()
+		return selection.doRotate(90)
+	}
+	return nil
+}
+
+func (a *App) doMirror(vertical bool) Action {
+	switch app.Mode {
+	case ModeNewBuilding:
+		return newBuilding.doMirror(vertical)
+	case ModeSelection:
+		return selection.doMirror(vertical)
 	}
 	return nil
 }
@@ -263,6 +372,26 @@ func (a *App) doDuplicate() Action {
 	return nil
 }
 
+func (a *App) doArray() Action {
+	switch app.Mode {
+	case ModeSelection:
+		if selection.mode == SelectionNormal || selection.mode == SelectionSingleTextBox {
+			return selection.doBeginTransformation(SelectionArray, selection.Bounds.Center(), false)
+		}
+	}
+	return nil
+}
+
+func (a *App) doAlign(alignment Alignment) Action {
+	switch app.Mode {
+	case ModeSelection:
+		if selection.mode == SelectionNormal || selection.mode == SelectionSingleTextBox {
+			return selection.doAlign(alignment)
+		}
+	}
+	return nil
+}
+
 func (a *App) doDrag() Action {
 	switch app.Mode {
 	case ModeSelection:
@@ -317,8 +446,14 @@ func (a *App) isNormal() bool {
 type AppOptions struct {
 	// A file to load
 	File string
-	// Target / Max FPS
+	// Target / Max FPS (0 to use the preferred FPS from the settings)
 	Fps int
+	// A file to record inputs to (see [Replay])
+	Record string
+	// A recorded inputs file to replay (see [Replay]), File is ignored
+	Replay string
+	// A directory to read the definitions files from instead of the embedded ones (see [LoadDefsDir])
+	DefsDir string
 }
 
 // Init initializes the application.
@@ -328,27 +463,41 @@ type AppOptions struct {
 // It must only be called once at startup.
 func Init(assets embed.FS, opts *AppOptions) error {
 	if opts == nil {
-		opts = &AppOptions{
-			Fps: DefaultTargetFPS,
-		}
-	}
-	if opts.Fps <= 0 {
-		opts.Fps = DefaultTargetFPS
+		opts = &AppOptions{}
 	}
 
 	log.Info("initializing application")
-	log.Info("options", "targetFPS", opts.Fps)
+	settings.Load()
+	if opts.Fps > 0 {
+		// remember the FPS set with the --fps flag
+		settings.Fps = opts.Fps
+	}
+	grid.SnapStep = settings.SnapStep
+	log.Info("options", "targetFPS", settings.Fps, "snapStep", grid.SnapStep)
 	// Loading assets
 	if err := LoadAssets(assets); err != nil {
 		return err
 	}
+	if opts.DefsDir != "" {
+		if err := LoadDefsDir(opts.DefsDir); err != nil {
+			msg := fmt.Sprintf("Cannot load the definitions directory: %s\n\nError: %s\n\nUsing the built-in definitions.", opts.DefsDir, RemoveQuotes(err.Error()))
+			tfd.MessageBox(windowTitle+" - Error loading definitions", msg, tfd.DialogOk, tfd.IconWarning, tfd.ButtonOkYes)
+		}
+	}
+	if err := LoadPacks(); err != nil {
+		msg := fmt.Sprintf("Some definitions packs are not loaded:\n\n%s", RemoveQuotes(err.Error()))
+		tfd.MessageBox(windowTitle+" - Error loading packs", msg, tfd.DialogOk, tfd.IconWarning, tfd.ButtonOkYes)
+	}
 	log.Info("assets loaded")
 
-	// Init window
+	// Init window, raylib logs are written with ours
+	rl.SetTraceLogLevel(rl.TraceLogLevel(log.MinLevel()))
+	rl.SetTraceLogCallback(func(l int, message string) { log.Log(slog.Level(l), message, "source", "raylib") })
 	rl.SetConfigFlags(rl.FlagWindowHighdpi | rl.FlagMsaa4xHint)
-	rl.InitWindow(windowWidth, windowHeight, windowTitle)
-	rl.SetTargetFPS(int32(opts.Fps))
+	rl.InitWindow(int32(settings.Window.Width), int32(settings.Window.Height), windowTitle)
+	rl.SetTargetFPS(int32(settings.Fps))
 	rl.SetWindowState(windowFlags)
+	settings.ApplyWindow()
 	rl.SetExitKey(rl.KeyNull)
 	if icon, err := LoadIcon(assets); err == nil {
 		rl.SetWindowIcon(*icon)
@@ -363,23 +512,60 @@ func Init(assets embed.FS, opts *AppOptions) error {
 	log.Info("fonts loaded")
 
 	// Initializing state
+	input.Frame = pollInputFrame()
 	dims.Update()
 	gui.Init()
+	hotReload.Reset()
 	camera.doReset()
 	log.Info("state initialized")
 
 	app.Mode = ModeNormal
 
+	if opts.Replay != "" {
+		if err := replay.LoadReplay(opts.Replay); err != nil {
+			log.Error("cannot load recording", "path", opts.Replay, "err", err)
+			return err
+		}
+		autosave.Reset()
+		return nil
+	}
+
 	if opts.File != "" {
 		if err := app.loadFile(opts.File); err != nil {
 			log.Error("init app with empty scene", "err", err)
 		}
 	}
+	autosave.Reset()
+	autosave.doRecover()
+
+	if opts.Record != "" {
+		if err := replay.StartRecording(opts.Record); err != nil {
+			log.Error("cannot start recording", "path", opts.Record, "err", err)
+		}
+	}
 	return nil
 }
 
 // Close cleanup resources used by the application before exiting.
 func Close() {
+	if replay.Replaying() {
+		// don't touch settings nor autosave
+		rl.UnloadFont(font)
+		rl.UnloadFont(labelFont)
+		rl.CloseWindow()
+		return
+	}
+	replay.Close()
+	settings.StoreWindow()
+	settings.StoreCamera(app.filepath)
+	settings.Save()
+	if scene.IsModified() {
+		// keep unsaved changes, they will be proposed for recovery on next launch
+		autosave.doAutosave()
+	} else {
+		autosave.Discard(app.filepath)
+	}
+
 	rl.UnloadFont(font)
 	rl.UnloadFont(labelFont)
 	rl.CloseWindow()
@@ -387,9 +573,12 @@ func Close() {
 
 // ShouldQuit returns true if the application should exit.
 func ShouldExit() bool {
-	return rl.WindowShouldClose() || app.hasPanicked
+	return rl.WindowShouldClose() || app.hasPanicked || replay.Done()
 }
 
+// ReplayMismatches returns the number of differences found between a replay and its recording
+func ReplayMismatches() int { return replay.Mismatches() }
+
 // Step updates and draw a frame.
 func Step() {
 	defer panicHandler()
@@ -425,6 +614,38 @@ func (app *App) dispatch(action Action) Action {
 	switch action := action.(type) {
 	case AppActionSwitchMode:
 		return app.doSwitchMode(action.Mode, action.Resets)
+	case AppActionNew:
+		return app.doNew()
+	case AppActionOpen:
+		return app.doOpen()
+	case AppActionOpenRecent:
+		return app.doOpenRecent(action.Filepath)
+	case AppActionSave:
+		return app.doSave(action.Filepath)
+	case AppActionSaveAs:
+		return app.doSaveAs()
+	case AppActionCompare:
+		return app.doCompare()
+	case AppActionStopCompare:
+		return app.doStopCompare()
+	case AppActionUndo:
+		return app.doUndo()
+	case AppActionRedo:
+		return app.doRedo()
+	case AppActionRotate:
+		return app.doRotate()
+	case AppActionMirror:
+		return app.doMirror(action.Vertical)
+	case AppActionDuplicate:
+		return app.doDuplicate()
+	case AppActionArray:
+		return app.doArray()
+	case AppActionAlign:
+		return app.doAlign(action.Alignment)
+	case AppActionDrag:
+		return app.doDrag()
+	case AppActionDelete:
+		return app.doDelete()
 	default:
 		panic(fmt.Sprintf("appDispatch: cannot handle: %T", action))
 	}
@@ -434,6 +655,7 @@ func (app *App) dispatch(action Action) Action {
 //
 // Most of the time it will returns `nil`.
 func dispatchAction(action Action) Action {
+	replay.RecordAction(action)
 	switch action.Target() {
 	case TargetApp:
 		return app.dispatch(action)
@@ -497,6 +719,7 @@ func (a *App) update() {
 // Update the application state based on mouse and keyboard input(s).
 func update() {
 	// input updates
+	input.Update()
 	animations.Update()
 
 	keyboard.Update()
@@ -508,6 +731,10 @@ func update() {
 
 	app.update()
 	scene.Update()
+	autosave.Update()
+	for action := hotReload.Update(); action != nil; action = dispatchAction(action) {
+		// empty loop body, reloading the definitions resets the current mode
+	}
 
 	for action := getAction(); action != nil; action = dispatchAction(action) {
 		// empty loop body
@@ -555,7 +782,12 @@ func draw() {
 //
 // See: [ActionHandler]
 func updateAndDrawGui() {
-	for action := gui.UpdateAndDraw(); action != nil; action = dispatchAction(action) {
+	if replay.Replaying() {
+		// GUI actions are read from the recording
+		raygui.Lock()
+		defer raygui.Unlock()
+	}
+	for action := replay.GuiAction(gui.UpdateAndDraw()); action != nil; action = dispatchAction(action) {
 		// empty loop body
 		// [GetAction] is called once per frame
 		// [Update] is called in a loop until action chain is terminated
@@ -570,33 +802,17 @@ func updateAndDrawGui() {
 
 const panicTitle = "Satisfied has crashed"
 
-const panicMessageNoBackupFile = `Satisfied has crashed and failed to backup current project.
+const panicMessage = `Satisfied has crashed.
 
 Error: %s
 
-Failed to backup reason: Cannot find user home directory.
-
-Sorry for the inconvenience, please report this issue to the developer.`
-
-const panicMessageErrBackup = `Satisfied has crashed and failed to backup current project.
-
-Error: %s
-
-Tried to backup in file: %s
-But failed because: %s
-
-Sorry for the inconvenience, please report this issue to the developer.`
+%s
 
-const panicMessageBackupOk = `Satisfied has crashed.
+%s
 
-Error: %s
-
-Current project has been saved in file: %s
-
-Sorry for the inconvenience, please report this issue to the developer.`
+Sorry for the inconvenience, please report this issue to the developer and attach the crash report.`
 
 func panicHandler() {
-	// TODO: save logs, link repo in error message.
 	panicErr := recover()
 	if panicErr == nil {
 		return
@@ -604,33 +820,47 @@ func panicHandler() {
 	app.hasPanicked = true // schedule app exit
 	log.Fatal("application panic", "err", panicErr)
 
-	os.Stderr.Write(fullStack())
+	stack := fullStack()
+	os.Stderr.Write(stack)
+	replay.Flush()
 
-	savepath := app.filepath
-	if savepath == "" {
-		home, err := os.UserHomeDir()
-		if err != nil {
-			msg := fmt.Sprintf(panicMessageNoBackupFile, panicErr)
-			tfd.MessageBox(panicTitle, msg, tfd.DialogOk, tfd.IconError, tfd.ButtonOkYes)
-			return
-		}
-		savepath = NormalizePath(filepath.Join(home, "recover.satisfied"))
+	backupMsg := ""
+	if savepath, err := recoverPath(); err != nil {
+		backupMsg = "Failed to backup current project: cannot find user home directory."
+	} else if err := writeProject(savepath); err != nil {
+		log.Error("cannot backup project", "path", savepath, "err", err)
+		backupMsg = fmt.Sprintf("Tried to backup current project in file: %s\nBut failed because: %s", savepath, err)
 	} else {
-		savepath = NormalizePath(savepath)
-		ext := filepath.Ext(savepath)
-		savepath = savepath[:len(savepath)-len(ext)] + ".recover" + ext
+		backupMsg = fmt.Sprintf("Current project has been saved in file: %s", savepath)
 	}
 
-	if err := app.saveFile(savepath); err != nil {
-		msg := fmt.Sprintf(panicMessageErrBackup, panicErr, savepath, err)
-		tfd.MessageBox(panicTitle, msg, tfd.DialogOk, tfd.IconError, tfd.ButtonOkYes)
-		return
+	reportMsg := ""
+	if bundlePath, err := writeCrashBundle(panicErr, stack); err != nil {
+		log.Error("cannot write crash report", "err", err)
+		reportMsg = fmt.Sprintf("Failed to write the crash report: %s", err)
+	} else {
+		log.Info("crash report written", "path", bundlePath)
+		reportMsg = fmt.Sprintf("A crash report has been saved in file: %s", bundlePath)
 	}
 
-	msg := fmt.Sprintf(panicMessageBackupOk, panicErr, savepath)
+	msg := fmt.Sprintf(panicMessage, panicErr, backupMsg, reportMsg)
 	tfd.MessageBox(panicTitle, msg, tfd.DialogOk, tfd.IconError, tfd.ButtonOkYes)
 }
 
+// recoverPath returns the path where the current project is saved on panic
+func recoverPath() (string, error) {
+	if app.filepath == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+		return NormalizePath(filepath.Join(home, "recover.satisfied")), nil
+	}
+	savepath := NormalizePath(app.filepath)
+	ext := filepath.Ext(savepath)
+	return savepath[:len(savepath)-len(ext)] + ".recover" + ext, nil
+}
+
 // fullStack captures the full stack trace, ensuring the buffer is big enough
 func fullStack() []byte {
 	buf := make([]byte, 4096)
@@ -4,8 +4,8 @@ import "strings"
 
 // TODO: Change AppMode to:
 //   - ModeNormal <- combine current [ModeNormal], [SelectionNormal] [SelectionSingleTextBox] : selection empty or not, no transformation occuring
-//   - ModeTransform <- [SelectionDrag], [SelectionTextBoxResize] : Selection is being modified
-//   - ModeNew <- [ModeNewPath], [ModeNewBuilding], [ModeNewTextBox], [SelectionDuplicate] : new object are being placed
+//   - ModeTransform <- [SelectionDrag], [SelectionTextBoxResize], [SelectionPathVertex] : Selection is being modified
+//   - ModeNew <- [ModeNewPath], [ModeNewBuilding], [ModeNewTextBox], [SelectionDuplicate], [SelectionArray] : new object are being placed
 //   - ModeGuiDetails <- [SelectionSingleTextBox] & [guiDetailsbar.focused] : details panel is focused
 //       (maybe ?), we would need a Gui.GetAction that would unfocus the details panel calls into ModeNormal GetAction ?
 
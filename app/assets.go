@@ -3,8 +3,14 @@ package app
 import (
 	"embed"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
 
 	"github.com/bonoboris/satisfied/log"
+	sc "github.com/bonoboris/satisfied/scene"
 	"github.com/gen2brain/raylib-go/raygui"
 	rl "github.com/gen2brain/raylib-go/raylib"
 )
@@ -12,6 +18,8 @@ import (
 const (
 	fontLoadSize = 32
 	fontFilter   = rl.FilterBilinear
+	// Directory of the user definitions packs, in the config directory
+	packsDirName = "packs"
 )
 
 var (
@@ -19,6 +27,8 @@ var (
 	buildingDefs BuildingDefs
 	// Path defs
 	pathDefs PathDefs
+	// Recipes and raw resources, for the production planner
+	recipeBook sc.RecipeBook
 	// Application font
 	font rl.Font
 	// Label font
@@ -78,39 +88,177 @@ func LoadFonts(assets embed.FS) error {
 	return nil
 }
 
+// Definitions files names, in the embedded assets and the definitions directory
+const (
+	buildingDefsFile = "building_defs.json"
+	pathDefsFile     = "path_defs.json"
+	recipeDefsFile   = "recipe_defs.json"
+)
+
+var (
+	// Directory the definitions files are read from instead of the embedded assets, empty to use the
+	// embedded ones (see [LoadDefsDir])
+	defsDir string
+	// Building defs, without the packs ones
+	baseBuildingDefs BuildingDefs
+	// Path defs, without the packs ones
+	basePathDefs PathDefs
+)
+
+// parseDefs decodes the content of the building and path definitions files
+func parseDefs(buildingData, pathData []byte) (BuildingDefs, PathDefs, error) {
+	var buildings BuildingDefs
+	var paths PathDefs
+	if err := json.Unmarshal(buildingData, &buildings); err != nil {
+		return nil, nil, fmt.Errorf("%s: %w", buildingDefsFile, err)
+	}
+	log.Debug("assets.buildingsDefs", "status", "parsed", "count", len(buildings))
+	if log.WillTrace() {
+		for i, def := range buildings {
+			log.Trace("assets.buildingDefs", "i", i, "value", def)
+		}
+	}
+	if err := json.Unmarshal(pathData, &paths); err != nil {
+		return nil, nil, fmt.Errorf("%s: %w", pathDefsFile, err)
+	}
+	log.Debug("assets.pathDefs", "status", "parsed", "count", len(paths))
+	if log.WillTrace() {
+		for i, def := range paths {
+			log.Trace("assets.pathDefs", "i", i, "value", def)
+		}
+	}
+	return buildings, paths, nil
+}
+
+// readDefsDir reads the building and path definitions files of dir
+func readDefsDir(dir string) (BuildingDefs, PathDefs, error) {
+	buildingData, err := os.ReadFile(filepath.Join(dir, buildingDefsFile))
+	if err != nil {
+		return nil, nil, err
+	}
+	pathData, err := os.ReadFile(filepath.Join(dir, pathDefsFile))
+	if err != nil {
+		return nil, nil, err
+	}
+	return parseDefs(buildingData, pathData)
+}
+
+// LoadAssets loads the embedded building and path definitions, and the recipes
 func LoadAssets(assets embed.FS) error {
-	data, err := readFile(assets, "assets/building_defs.json")
+	buildingData, err := readFile(assets, "assets/"+buildingDefsFile)
 	if err != nil {
 		return err
 	}
-	err = json.Unmarshal(data, &buildingDefs)
+	pathData, err := readFile(assets, "assets/"+pathDefsFile)
 	if err != nil {
-		log.Fatal("cannot parse building defs", "err", err)
 		return err
 	}
-	log.Debug("assets.buildingsDefs", "status", "parsed", "count", len(buildingDefs))
-	if log.WillTrace() {
-		for i, def := range buildingDefs {
-			log.Trace("assets.buildingDefs", "i", i, "value", def)
-		}
+	baseBuildingDefs, basePathDefs, err = parseDefs(buildingData, pathData)
+	if err != nil {
+		log.Fatal("cannot parse defs", "err", err)
+		return err
 	}
+	buildingDefs, pathDefs = baseBuildingDefs, basePathDefs
+	sc.SetDefs(buildingDefs, pathDefs)
 
-	data, err = readFile(assets, "assets/path_defs.json")
+	recipeData, err := readFile(assets, "assets/"+recipeDefsFile)
 	if err != nil {
 		return err
 	}
-	err = json.Unmarshal(data, &pathDefs)
+	if err := json.Unmarshal(recipeData, &recipeBook); err != nil {
+		log.Fatal("cannot parse recipes", "err", err)
+		return err
+	}
+	log.Debug("assets.recipeDefs", "status", "parsed", "count", len(recipeBook.Recipes), "resources", len(recipeBook.Resources))
+	return nil
+}
+
+// LoadDefsDir loads the building and path definitions from the files of dir instead of the
+// embedded ones, to edit them without rebuilding; it must be called after [LoadAssets] and before
+// [LoadPacks].
+//
+// On error, the embedded definitions are kept, until the files are fixed (see [HotReload]).
+func LoadDefsDir(dir string) error {
+	defsDir = dir
+	buildings, paths, err := readDefsDir(dir)
 	if err != nil {
-		log.Fatal("cannot parse path defs", "err", err)
+		log.Error("cannot load defs directory", "dir", dir, "err", err)
 		return err
 	}
-	log.Debug("assets.pathDefs", "status", "parsed", "count", len(pathDefs))
-	if log.WillTrace() {
-		for i, def := range pathDefs {
-			log.Trace("assets.pathDefs", "i", i, "value", def)
+	log.Info("assets.defsDir", "status", "loaded", "dir", dir)
+	baseBuildingDefs, basePathDefs = buildings, paths
+	buildingDefs, pathDefs = buildings, paths
+	sc.SetDefs(buildingDefs, pathDefs)
+	return nil
+}
+
+// packsDir returns the user definitions packs directory
+func packsDir() (string, error) {
+	dir, err := ConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, packsDirName), nil
+}
+
+// packFiles returns the user definitions packs files
+func packFiles() ([]string, error) {
+	dir, err := packsDir()
+	if err != nil {
+		return nil, err
+	}
+	return filepath.Glob(filepath.Join(dir, "*"+sc.PackFileSuffix))
+}
+
+// readPacks reads the user definitions packs and returns the given definitions followed by the
+// packs ones.
+//
+// Invalid or conflicting packs are left out, the returned error joins their errors.
+func readPacks(buildings BuildingDefs, paths PathDefs) (BuildingDefs, PathDefs, error) {
+	files, err := packFiles()
+	if err != nil {
+		log.Error("cannot list packs", "err", err)
+		return buildings, paths, err
+	}
+
+	var packs []sc.Pack
+	var errs []error
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			log.Error("cannot read pack", "path", file, "err", err)
+			errs = append(errs, err)
+			continue
 		}
+		pack, err := sc.ParsePack(strings.TrimSuffix(filepath.Base(file), sc.PackFileSuffix), data)
+		if err != nil {
+			log.Error("cannot parse pack", "path", file, "err", err)
+			errs = append(errs, err)
+			continue
+		}
+		log.Info("assets.pack", "status", "parsed", "name", pack.Name, "buildings", len(pack.Buildings), "paths", len(pack.Paths))
+		packs = append(packs, pack)
 	}
-	return nil
+
+	buildings, paths, err = sc.MergePacks(buildings, paths, packs)
+	if err != nil {
+		log.Error("cannot merge packs", "err", err)
+		errs = append(errs, err)
+	}
+	log.Debug("assets.packs", "status", "merged", "buildingDefs", len(buildings), "pathDefs", len(paths))
+	return buildings, paths, errors.Join(errs...)
+}
+
+// LoadPacks loads the user definitions packs ("[pack]_defs.json" files in the packs directory of
+// the config directory) and adds them to the built-in definitions, it must be called after
+// [LoadAssets].
+//
+// Invalid or conflicting packs are left out, the returned error joins their errors.
+func LoadPacks() error {
+	var err error
+	buildingDefs, pathDefs, err = readPacks(baseBuildingDefs, basePathDefs)
+	sc.SetDefs(buildingDefs, pathDefs)
+	return err
 }
 
 // LoadIcon loads the application icon
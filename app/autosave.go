@@ -0,0 +1,203 @@
+// autosave - Periodic autosave to a sidecar file, rotating backups and recovery at startup
+
+package app
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/bonoboris/satisfied/log"
+	tfd "github.com/bonoboris/satisfied/tinyfiledialogs"
+)
+
+const (
+	// Extension appended to the project path to get its autosave sidecar file
+	autosaveExt = ".autosave"
+	// Autosave file name (in the config directory) for projects not saved yet
+	untitledAutosaveName = "untitled.satisfied" + autosaveExt
+	// Extension of the rotating backups files
+	backupExt = ".bak"
+)
+
+// Autosave holds the periodic autosave state
+var autosave Autosave
+
+// Autosave holds the periodic autosave state
+type Autosave struct {
+	// Time of the last autosave (see [Animations.Timer])
+	lastTime float32
+	// Scene revision at the last autosave (see [Scene.Revision])
+	lastRevision int
+}
+
+func (as Autosave) traceState(key, val string) {
+	if key != "" && val != "" {
+		log.Trace("autosave", key, val, "lastTime", as.lastTime, "lastRevision", as.lastRevision)
+	} else {
+		log.Trace("autosave", "lastTime", as.lastTime, "lastRevision", as.lastRevision)
+	}
+}
+
+// Reset resets the autosave timer and revision, must be called when the scene is replaced
+func (as *Autosave) Reset() {
+	as.lastTime = animations.Timer
+	as.lastRevision = scene.Revision()
+	as.traceState("after", "Reset")
+}
+
+// AutosavePath returns the autosave sidecar file path of the given project path
+//
+// Projects not saved yet (empty path) share a single autosave file in the config directory.
+func AutosavePath(projectPath string) (string, error) {
+	if projectPath != "" {
+		return projectPath + autosaveExt, nil
+	}
+	dir, err := ConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, untitledAutosaveName), nil
+}
+
+// BackupPath returns the path of the i-th backup (1 being the most recent) of the given project path
+func BackupPath(projectPath string, i int) string {
+	return fmt.Sprintf("%s.%d%s", projectPath, i, backupExt)
+}
+
+// Update autosaves the scene if it has been modified since the last autosave and
+// [Settings.AutosaveInterval] has elapsed.
+//
+// Depends on [Animations] and [Scene]
+func (as *Autosave) Update() {
+	if settings.AutosaveInterval <= 0 || replay.Replaying() || !scene.IsModified() || scene.Revision() == as.lastRevision {
+		return
+	}
+	if animations.Timer-as.lastTime < settings.AutosaveInterval {
+		return
+	}
+	as.doAutosave()
+}
+
+// doAutosave writes the scene in the autosave sidecar file of the current project
+func (as *Autosave) doAutosave() error {
+	as.traceState("before", "doAutosave")
+	as.lastTime = animations.Timer
+	as.lastRevision = scene.Revision()
+	path, err := AutosavePath(app.filepath)
+	if err != nil {
+		log.Error("cannot autosave", "reason", "cannot find autosave path", "err", err)
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		log.Error("cannot autosave", "reason", "cannot create directory", "path", path, "err", err)
+		return err
+	}
+	if err := WriteFileAtomic(path, scene.SaveToText); err != nil {
+		log.Error("cannot autosave", "path", path, "err", err)
+		return err
+	}
+	log.Info("project autosaved", "path", path)
+	as.traceState("after", "doAutosave")
+	return nil
+}
+
+// Discard removes the autosave sidecar file of the given project path (if any)
+func (as *Autosave) Discard(projectPath string) {
+	path, err := AutosavePath(projectPath)
+	if err != nil {
+		return
+	}
+	if err := os.Remove(path); err == nil {
+		log.Debug("autosave discarded", "path", path)
+	} else if !errors.Is(err, fs.ErrNotExist) {
+		log.Warn("cannot remove autosave file", "path", path, "err", err)
+	}
+}
+
+const recoverMessage = `An autosave more recent than the project was found:
+
+%s
+
+It may contain changes that were not saved before Satisfied was closed or crashed.
+
+Do you want to recover it ?`
+
+// doRecover checks for an autosave newer than the current project and asks the user whether to
+// recover it.
+//
+//   - Yes: the autosave is loaded in the scene, which is marked as modified
+//   - No: the autosave is deleted
+//
+// Autosaves older than the project are silently deleted.
+func (as *Autosave) doRecover() Action {
+	path, err := AutosavePath(app.filepath)
+	if err != nil {
+		return nil
+	}
+	autosaveInfo, err := os.Stat(path)
+	if err != nil {
+		return nil
+	}
+	if app.filepath != "" {
+		if projectInfo, err := os.Stat(app.filepath); err == nil && !autosaveInfo.ModTime().After(projectInfo.ModTime()) {
+			log.Info("autosave older than project", "action", "discard", "path", path)
+			as.Discard(app.filepath)
+			return nil
+		}
+	}
+	log.Info("autosave found", "path", path, "modTime", autosaveInfo.ModTime())
+	msg := fmt.Sprintf(recoverMessage, path)
+	if tfd.MessageBox(windowTitle+" - Recover autosave", msg, tfd.DialogYesNo, tfd.IconQuestion, tfd.ButtonOkYes) != tfd.ButtonOkYes {
+		log.Info("recover autosave", "action", "discard", "path", path)
+		as.Discard(app.filepath)
+		return nil
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		log.Error("cannot open autosave", "path", path, "err", err)
+		return nil
+	}
+	defer file.Close()
+	fileScene := Scene{}
+	if err := fileScene.LoadFromText(file); err != nil {
+		log.Error("error parsing autosave", "path", path, "err", err)
+		msg := fmt.Sprintf("Cannot recover autosave: %s\n\nError: %s", path, RemoveQuotes(err.Error()))
+		tfd.MessageBox(windowTitle+" - Error loading file", msg, tfd.DialogOk, tfd.IconError, tfd.ButtonOkYes)
+		return nil
+	}
+	scene = fileScene
+	scene.SetModified()
+	power.Reset()
+	lint.Reset()
+	chain.Reset()
+	as.Reset()
+	log.Info("autosave recovered", "path", path)
+	return app.doSwitchMode(ModeNormal, ResetAll())
+}
+
+// rotateBackups copies the given project file into its first backup, shifting the existing
+// backups and keeping at most n of them.
+//
+// Noop if n <= 0 or if the project file does not exist.
+func rotateBackups(projectPath string, n int) error {
+	if n <= 0 {
+		return nil
+	}
+	data, err := os.ReadFile(projectPath)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	for i := n - 1; i >= 1; i-- {
+		err := os.Rename(BackupPath(projectPath, i), BackupPath(projectPath, i+1))
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+	}
+	return os.WriteFile(BackupPath(projectPath, 1), data, 0o644)
+}
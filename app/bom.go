@@ -0,0 +1,185 @@
+// bom - Bill of materials panel of the details bar, and its export (see [sc.BOM])
+
+package app
+
+import (
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"github.com/bonoboris/satisfied/colors"
+	"github.com/bonoboris/satisfied/log"
+	sc "github.com/bonoboris/satisfied/scene"
+	"github.com/bonoboris/satisfied/text"
+	tfd "github.com/bonoboris/satisfied/tinyfiledialogs"
+	"github.com/gen2brain/raylib-go/raygui"
+	rl "github.com/gen2brain/raylib-go/raylib"
+)
+
+const (
+	// Bill of materials panel line height, in px
+	bomLineHeight = 24.0
+	// Minimum height of the panel to draw it, in px
+	bomMinHeight = 150.0
+)
+
+// guiBOMPanel is the bill of materials of the selection, or of the scene if there is none
+type guiBOMPanel struct {
+	// Tier of the paths of each definition (see [sc.NewBOM])
+	tiers []int
+	// Number of items lines scrolled out of view
+	scroll int
+}
+
+// bomObjects returns the selected objects, or the scene ones if there is no selection, and whether
+// they are the selected ones
+func bomObjects() (ObjectCollection, bool) {
+	if app.Mode != ModeSelection {
+		return scene.ObjectCollection, false
+	}
+	var oc ObjectCollection
+	oc.Buildings = sc.CopyIdxs(oc.Buildings, scene.Buildings, selection.BuildingIdxs)
+	oc.Paths = sc.CopyIdxs(oc.Paths, scene.Paths, selection.FullPathIdxs())
+	oc.TextBoxes = sc.CopyIdxs(oc.TextBoxes, scene.TextBoxes, selection.TextBoxIdxs)
+	return oc, true
+}
+
+// doSetTier sets the tier of the paths of definition defIdx
+func (p *guiBOMPanel) doSetTier(defIdx, tier int) Action {
+	if defIdx < 0 || defIdx >= len(pathDefs) {
+		log.Warn("bom.doSetTier", "reason", "invalid path definition", "defIdx", defIdx)
+		return nil
+	}
+	for len(p.tiers) <= defIdx {
+		p.tiers = append(p.tiers, 0)
+	}
+	p.tiers[defIdx] = tier
+	log.Debug("bom.doSetTier", "class", pathDefs[defIdx].Class, "tier", tier)
+	return nil
+}
+
+// doExport writes the bill of materials to a file chosen by the user, as Markdown or CSV
+func (p *guiBOMPanel) doExport(markdown bool) Action {
+	log.Info("bom export", "markdown", markdown)
+	if replay.Replaying() {
+		log.Warn("bom export", "action", "skip", "reason", "replaying")
+		return nil
+	}
+	oc, isSelection := bomObjects()
+	bom := sc.NewBOM(oc, p.tiers)
+
+	ext, desc := ".csv", "CSV file"
+	if markdown {
+		ext, desc = ".md", "Markdown file"
+	}
+	name := "bom" + ext
+	if app.filepath != "" {
+		name = strings.TrimSuffix(app.filepath, filepath.Ext(app.filepath)) + "-bom" + ext
+	}
+	path, ok := tfd.SaveFileDialog("Export bill of materials...", name, []string{"*" + ext}, desc)
+	if !ok {
+		return nil
+	}
+
+	write := bom.WriteCSV
+	if markdown {
+		title := "Bill of materials"
+		if app.filepath != "" {
+			title += " - " + filepath.Base(app.filepath)
+		}
+		if isSelection {
+			title += " (selection)"
+		}
+		write = func(w io.Writer) error { return bom.WriteMarkdown(w, title) }
+	}
+	err := WriteFileAtomic(path, write)
+	if err != nil {
+		log.Error("bom export", "path", path, "err", err)
+		gui.Statusbar.notify("Cannot export the bill of materials:\n"+RemoveQuotes(err.Error()), true)
+		return nil
+	}
+	gui.Statusbar.notify("Bill of materials exported to "+filepath.Base(path), false)
+	return nil
+}
+
+// updateAndDraw draws the panel in bounds, if large enough
+func (p *guiBOMPanel) updateAndDraw(bounds rl.Rectangle) (action Action) {
+	if bounds.Height < bomMinHeight {
+		return nil
+	}
+	oc, isSelection := bomObjects()
+	bom := sc.NewBOM(oc, p.tiers)
+
+	title := "Bill of materials (scene)"
+	if isSelection {
+		title = "Bill of materials (selection)"
+	}
+	text.DrawText(rl.NewRectangle(bounds.X, bounds.Y, bounds.Width, 30), title, text.Options{Font: font, Size: 24, Color: colors.Gray700})
+	y := bounds.Y + 40
+
+	pTextSize := raygui.GetStyle(raygui.DEFAULT, raygui.TEXT_SIZE)
+	raygui.SetStyle(raygui.DEFAULT, raygui.TEXT_SIZE, 20)
+	textOpts := text.Options{Font: font, Size: 20, Color: colors.Gray700}
+	// tier of the paths with several tiers
+	for defIdx, def := range pathDefs {
+		if len(def.Tiers) < 2 {
+			continue
+		}
+		tier := 0
+		if defIdx < len(p.tiers) {
+			tier = p.tiers[defIdx]
+		}
+		names := make([]string, len(def.Tiers))
+		for i, t := range def.Tiers {
+			names[i] = t.Name
+		}
+		half := bounds.Width / 2
+		text.DrawText(rl.NewRectangle(bounds.X, y, half, 30), def.Class, textOpts)
+		if newTier := int(raygui.ComboBox(rl.NewRectangle(bounds.X+half, y, half, 28), strings.Join(names, ";"), int32(tier))); newTier != tier {
+			log.Debug("bom tier clicked", "class", def.Class, "tier", newTier)
+			action = GuiActionSetBOMTier{DefIdx: defIdx, Tier: newTier}
+		}
+		y += 36
+	}
+
+	// items, then the objects without cost
+	type line struct {
+		text  string
+		color rl.Color
+	}
+	var lines []line
+	for _, ia := range bom.Items {
+		lines = append(lines, line{fmt.Sprintf("%v  %s", ia.Amount, ia.Item), colors.Gray700})
+	}
+	if len(lines) == 0 {
+		lines = append(lines, line{"Nothing to build", colors.Gray500})
+	}
+	if len(bom.Missing) > 0 {
+		lines = append(lines, line{"No cost: " + strings.Join(bom.Missing, ", "), colors.Amber700})
+	}
+	listBounds := rl.NewRectangle(bounds.X, y, bounds.Width, bounds.Y+bounds.Height-y-40)
+	visible := max(int(listBounds.Height/bomLineHeight), 1)
+	if rl.CheckCollisionPointRec(input.Frame.MousePos, listBounds) && input.Frame.Wheel != 0 {
+		p.scroll -= int(input.Frame.Wheel)
+	}
+	p.scroll = min(max(p.scroll, 0), max(len(lines)-visible, 0))
+	for i, l := range lines[p.scroll:min(p.scroll+visible, len(lines))] {
+		opts := textOpts
+		opts.Color = l.color
+		text.DrawText(rl.NewRectangle(listBounds.X, listBounds.Y+float32(i)*bomLineHeight, listBounds.Width, bomLineHeight), l.text, opts)
+	}
+
+	half := (bounds.Width - 10) / 2
+	buttonY := bounds.Y + bounds.Height - 30
+	if raygui.Button(rl.NewRectangle(bounds.X, buttonY, half, 30), "Export CSV") {
+		log.Debug("bom export csv clicked")
+		action = GuiActionExportBOM{Markdown: false}
+	}
+	if raygui.Button(rl.NewRectangle(bounds.X+half+10, buttonY, half, 30), "Export MD") {
+		log.Debug("bom export markdown clicked")
+		action = GuiActionExportBOM{Markdown: true}
+	}
+	raygui.SetStyle(raygui.DEFAULT, raygui.TEXT_SIZE, pTextSize)
+	return action
+}
@@ -1,15 +1,14 @@
+// buildings - Buildings drawing
+
 package app
 
 import (
-	"encoding/json"
-	"errors"
 	"fmt"
-	"slices"
-	"sort"
 	"strings"
 
 	"github.com/bonoboris/satisfied/colors"
 	"github.com/bonoboris/satisfied/matrix"
+	sc "github.com/bonoboris/satisfied/scene"
 	"github.com/bonoboris/satisfied/text"
 	rl "github.com/gen2brain/raylib-go/raylib"
 )
@@ -18,31 +17,6 @@ import (
 // Building
 ////////////////////////////////////////////////////////////////////////////////////////////////////
 
-type Building struct {
-	DefIdx int
-	Pos    rl.Vector2
-	Rot    int32
-}
-
-func (b Building) String() string {
-	if b.DefIdx == -1 {
-		return fmt.Sprintf("%s{%v %v %d}", "<invalid>", b.Pos.X, b.Pos.Y, b.Rot)
-	}
-	return fmt.Sprintf("%s{%v %v %d}", b.Def().Class, b.Pos.X, b.Pos.Y, b.Rot)
-}
-
-func (b Building) Def() BuildingDef { return buildingDefs[b.DefIdx] }
-
-func (b Building) matrix() matrix.Matrix {
-	mid := grid.Snap(b.Def().Dims.Scale(0.5))
-	return matrix.NewTranslateV(b.Pos).Rotate(b.Rot).TranslateV(mid.Negate())
-}
-
-func (b Building) Bounds() rl.Rectangle {
-	dims := b.Def().Dims
-	return b.matrix().ApplyRec(0, 0, dims.X, dims.Y)
-}
-
 const (
 	labelFontSize    = 24.
 	labelLineSpacing = -5.
@@ -50,7 +24,7 @@ const (
 
 var labelColor = rl.Color{0, 0, 0, 127}
 
-func (b Building) DrawLabel(bounds rl.Rectangle) {
+func drawBuildingLabel(b Building, bounds rl.Rectangle) {
 	bounds.X += 0.5
 	bounds.Y += 0.5
 	bounds.Width -= 1
@@ -63,14 +37,18 @@ func (b Building) DrawLabel(bounds rl.Rectangle) {
 		Align:         text.AlignMiddle,
 		VerticalAlign: text.AlignMiddle,
 	}
-	text.DrawText(bounds, strings.ReplaceAll(b.Def().Class, " ", "\n"), labelOpts)
+	label := strings.ReplaceAll(b.Def().Class, " ", "\n")
+	if clock := b.Mods.ClockSpeed(); clock != sc.DefaultClock {
+		label += fmt.Sprintf("\n%v%%", clock)
+	}
+	text.DrawText(bounds, label, labelOpts)
 }
 
-func (b Building) Draw(state DrawState) {
+func drawBuilding(b Building, state DrawState) {
 	if state == DrawSkip {
 		return
 	}
-	mat := b.matrix()
+	mat := b.Matrix()
 	def := b.Def()
 	bounds := mat.ApplyRec(0, 0, def.Dims.X, def.Dims.Y)
 
@@ -88,169 +66,66 @@ func (b Building) Draw(state DrawState) {
 
 	rl.DrawRectangleLinesEx(bounds, 0.5, state.transformColor(colors.Blue500))
 
-	for i := 0; i < def.BeltIn.len; i++ {
-		def.BeltIn.arr[i].drawBeltIn(mat, state)
+	for i := 0; i < def.BeltIn.Len(); i++ {
+		drawBeltIn(def.BeltIn.Get(i), mat, state)
 	}
-	for i := 0; i < def.BeltOut.len; i++ {
-		def.BeltOut.arr[i].drawBeltOut(mat, state)
+	for i := 0; i < def.BeltOut.Len(); i++ {
+		drawBeltOut(def.BeltOut.Get(i), mat, state)
 	}
-	for i := 0; i < def.PipeIn.len; i++ {
-		def.PipeIn.arr[i].drawPipeIn(mat, state)
+	for i := 0; i < def.PipeIn.Len(); i++ {
+		drawPipeIn(def.PipeIn.Get(i), mat, state)
 	}
-	for i := 0; i < def.PipeOut.len; i++ {
-		def.PipeOut.arr[i].drawPipeOut(mat, state)
+	for i := 0; i < def.PipeOut.Len(); i++ {
+		drawPipeOut(def.PipeOut.Get(i), mat, state)
 	}
-	b.DrawLabel(bounds)
+	drawBuildingLabel(b, bounds)
 }
 
 ////////////////////////////////////////////////////////////////////////////////////////////////////
 // (Belt, Pipe)  input / output drawing
 ////////////////////////////////////////////////////////////////////////////////////////////////////
 
-type inputOutput struct {
-	Pos rl.Vector2
-	Rot int32
-}
-
-func (io inputOutput) String() string {
-	if io.Rot == 0 {
-		return fmt.Sprintf("(%v,%v)", io.Pos.X, io.Pos.Y)
-	} else {
-		return fmt.Sprintf("(%v,%v, r=%d°)", io.Pos.X, io.Pos.Y, io.Rot)
+func drawInOutTri(mat matrix.Matrix, x, y float32, c rl.Color) {
+	v1, v2, v3 := mat.Apply(x-0.25, y+0.25), mat.Apply(x+0.25, y+0.25), mat.Apply(x, y-0.25)
+	if mat.IsMirroring() {
+		// vertices must be in counter-clockwise order
+		v1, v2 = v2, v1
 	}
+	rl.DrawTriangle(v1, v2, v3, c)
 }
 
-func (io inputOutput) matrix() matrix.Matrix {
-	return matrix.NewTranslateV(io.Pos).Rotate(io.Rot)
-}
-
-func (inputOutput) drawTri(mat matrix.Matrix, x, y float32, c rl.Color) {
-	rl.DrawTriangle(
-		mat.Apply(x-0.25, y+0.25),
-		mat.Apply(x+0.25, y+0.25),
-		mat.Apply(x, y-0.25),
-		c,
-	)
-}
-
-func (io inputOutput) drawBeltIn(mat matrix.Matrix, state DrawState) {
-	mat = mat.Mult(io.matrix())
+func drawBeltIn(io sc.InputOutput, mat matrix.Matrix, state DrawState) {
+	mat = mat.Mult(io.Matrix())
 	bounds := rl.NewRectangle(-1, -0.5, 2, 0.5)
 	rl.DrawRectangleRec(mat.ApplyRecRec(bounds), state.transformColor(colors.Orange500))
 	c := state.transformColor(colors.Black)
-	io.drawTri(mat, -0.5, -0.25, c)
-	io.drawTri(mat, 0, -0.25, c)
-	io.drawTri(mat, 0.5, -0.25, c)
+	drawInOutTri(mat, -0.5, -0.25, c)
+	drawInOutTri(mat, 0, -0.25, c)
+	drawInOutTri(mat, 0.5, -0.25, c)
 }
 
-func (io inputOutput) drawBeltOut(mat matrix.Matrix, state DrawState) {
-	mat = mat.Mult(io.matrix())
+func drawBeltOut(io sc.InputOutput, mat matrix.Matrix, state DrawState) {
+	mat = mat.Mult(io.Matrix())
 	bounds := rl.NewRectangle(-1, 0, 2, 0.5)
 	rl.DrawRectangleRec(mat.ApplyRecRec(bounds), state.transformColor(colors.Green500))
 	c := state.transformColor(colors.Black)
-	io.drawTri(mat, -0.5, 0.25, c)
-	io.drawTri(mat, 0, 0.25, c)
-	io.drawTri(mat, 0.5, 0.25, c)
+	drawInOutTri(mat, -0.5, 0.25, c)
+	drawInOutTri(mat, 0, 0.25, c)
+	drawInOutTri(mat, 0.5, 0.25, c)
 }
 
-func (io inputOutput) drawPipeIn(mat matrix.Matrix, state DrawState) {
-	mat = mat.Mult(io.matrix())
+func drawPipeIn(io sc.InputOutput, mat matrix.Matrix, state DrawState) {
+	mat = mat.Mult(io.Matrix())
 	bounds := rl.NewRectangle(-0.5, -1, 1, 0.5)
 	rl.DrawRectangleRec(mat.ApplyRecRec(bounds), state.transformColor(colors.Orange500))
 	c := state.transformColor(colors.Black)
-	io.drawTri(mat, 0, -0.25, c)
+	drawInOutTri(mat, 0, -0.25, c)
 }
 
-func (io inputOutput) drawPipeOut(mat matrix.Matrix, state DrawState) {
-	mat = mat.Mult(io.matrix())
+func drawPipeOut(io sc.InputOutput, mat matrix.Matrix, state DrawState) {
+	mat = mat.Mult(io.Matrix())
 	bounds := rl.NewRectangle(-0.5, 0, 1, 0.5)
 	rl.DrawRectangleRec(mat.ApplyRecRec(bounds), state.transformColor(colors.Green500))
 	c := state.transformColor(colors.Black)
-	io.drawTri(mat, 0, 0.25, c)
-}
-
-const MAX_INOUT = 4
-
-type inputOutputs struct {
-	arr [MAX_INOUT]inputOutput
-	len int
-}
-
-func (inouts *inputOutputs) UnmarshalJSON(data []byte) error {
-	var s []inputOutput
-	err := json.Unmarshal(data, &s)
-	if err != nil {
-		return err
-	}
-	if len(s) > MAX_INOUT {
-		return errors.New("elements count exceeds MAX_INOUT")
-	}
-	copy(inouts.arr[:], s)
-	inouts.len = len(s)
-	return err
-}
-
-func (inouts inputOutputs) String() string {
-	return fmt.Sprintf("%v", inouts.arr[:inouts.len])
-}
-
-////////////////////////////////////////////////////////////////////////////////////////////////////
-// BuildingDef
-////////////////////////////////////////////////////////////////////////////////////////////////////
-
-type BuildingDef struct {
-	Class    string
-	Category string
-	Dims     rl.Vector2
-	BeltIn   inputOutputs
-	BeltOut  inputOutputs
-	PipeIn   inputOutputs
-	PipeOut  inputOutputs
-}
-
-func (b BuildingDef) String() string {
-	s := fmt.Sprintf("{%s(%s) W=%v H=%v", b.Class, b.Category, b.Dims.X, b.Dims.Y)
-	if b.BeltIn.len > 0 {
-		s += fmt.Sprintf(" BeltIn=%s", b.BeltIn)
-	}
-	if b.BeltOut.len > 0 {
-		s += fmt.Sprintf(" BeltOut=%s", b.BeltOut)
-	}
-	if b.PipeIn.len > 0 {
-		s += fmt.Sprintf(" PipeIn=%s", b.PipeIn)
-	}
-	if b.PipeOut.len > 0 {
-		s += fmt.Sprintf(" PipeOut=%s", b.PipeOut)
-	}
-	return fmt.Sprintf("%s}", s)
-}
-
-type BuildingDefs []BuildingDef
-
-func (defs BuildingDefs) Classes() []string {
-	classes := make([]string, len(defs))
-	for i, def := range defs {
-		classes[i] = def.Class
-	}
-	return classes
-}
-
-func (defs BuildingDefs) Categories() []string {
-	var categories []string
-	for _, def := range defs {
-		if !slices.Contains(categories, def.Category) {
-			categories = append(categories, def.Category)
-		}
-	}
-	sort.Strings(categories)
-	return categories
-}
-
-func (defs BuildingDefs) Index(class string) int {
-	for i, def := range defs {
-		if def.Class == class {
-			return i
-		}
-	}
-	return -1
+	drawInOutTri(mat, 0, 0.25, c)
 }
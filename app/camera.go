@@ -45,6 +45,19 @@ func (c Camera) traceState(key, val string) {
 	}
 }
 
+// CameraState is a serializable camera state (see [Settings.Cameras])
+type CameraState struct {
+	// World position at the center of the scene area
+	Center rl.Vector2
+	// Zoom level
+	Zoom float32
+}
+
+// State returns the current camera state
+func (c *Camera) State() CameraState {
+	return CameraState{Center: c.WorldPos(dims.Scene.Center()), Zoom: c.camera.Zoom}
+}
+
 // Zoom returns the current zoom level
 func (c *Camera) Zoom() float32 { return c.camera.Zoom }
 
@@ -127,6 +140,17 @@ func (c *Camera) doReset() Action {
 	return nil
 }
 
+// doRestore restores a camera state saved with [Camera.State]
+func (c *Camera) doRestore(state CameraState) Action {
+	c.traceState("before", "doRestore")
+	log.Debug("camera.doRestore", "center", state.Center, "zoom", state.Zoom)
+	c.camera.Zoom = min(max(state.Zoom, zoomMin), zoomMax)
+	c.camera.Target = state.Center
+	c.camera.Offset = dims.Scene.Center()
+	c.traceState("after", "doRestore")
+	return nil
+}
+
 // doZoom zooms the camera by a given amount at a given position
 func (c *Camera) doZoom(by float32, at rl.Vector2) Action {
 	c.traceState("before", "doZoom")
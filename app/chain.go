@@ -0,0 +1,72 @@
+// chain - Supply chain of the hovered object (see [sc.ObjectCollection.SelectChain]) and its drawing
+
+package app
+
+import (
+	"github.com/bonoboris/satisfied/log"
+	sc "github.com/bonoboris/satisfied/scene"
+)
+
+// chain holds the supply chain of the hovered object
+var chain Chain
+
+// Chain holds the upstream and downstream chains of the hovered building or path, computed when
+// the hovered object or the scene changes
+type Chain struct {
+	// Object of the chains, with its paths as [TypePath]
+	obj Object
+	// Scene revision when the chains were computed, -1 to recompute them
+	revision int
+	// Objects the items of obj come from, and go to
+	upstream, downstream ObjectSelection
+}
+
+// Reset forces the chains computation, must be called when the scene is replaced
+func (c *Chain) Reset() { *c = Chain{revision: -1} }
+
+// update recomputes the chains of obj if needed
+func (c *Chain) update(obj Object) {
+	if obj == c.obj && c.revision == scene.Revision() {
+		return
+	}
+	c.obj, c.revision = obj, scene.Revision()
+	var sel ObjectSelection
+	if obj.Type == TypeBuilding {
+		sel.BuildingIdxs = []int{obj.Idx}
+	} else {
+		sel.PathIdxs = []PathSel{{Idx: obj.Idx, Start: true, End: true}}
+	}
+	c.upstream = scene.SelectChain(sel, sc.ChainUpstream)
+	c.downstream = scene.SelectChain(sel, sc.ChainDownstream)
+	log.Debug("chain.update", "object", obj, "upstream", len(c.upstream.BuildingIdxs)+len(c.upstream.PathIdxs),
+		"downstream", len(c.downstream.BuildingIdxs)+len(c.downstream.PathIdxs))
+}
+
+// Draw highlights the upstream and downstream chains of the hovered building or path while Alt is
+// held, when hovering draws the hovered object
+func (c *Chain) Draw() {
+	if !keyboard.Alt || !(app.Mode == ModeNormal && !selector.selecting ||
+		app.Mode == ModeSelection && (selection.mode == SelectionNormal || selection.mode == SelectionSingleTextBox)) {
+		return
+	}
+	obj := scene.Hovered()
+	switch obj.Type {
+	case TypeBuilding, TypePath:
+	case TypePathStart, TypePathEnd:
+		obj.Type = TypePath
+	default:
+		return
+	}
+	c.update(obj)
+	for _, hl := range [2]struct {
+		sel   ObjectSelection
+		state DrawState
+	}{{c.upstream, DrawUpstream}, {c.downstream, DrawDownstream}} {
+		for _, pSel := range hl.sel.PathIdxs {
+			drawPath(scene.Paths[pSel.Idx], hl.state)
+		}
+		for _, idx := range hl.sel.BuildingIdxs {
+			drawBuilding(scene.Buildings[idx], hl.state)
+		}
+	}
+}
@@ -0,0 +1,101 @@
+// compare - Overlay of the differences between the scene and a project file
+
+package app
+
+import (
+	"github.com/bonoboris/satisfied/log"
+	sc "github.com/bonoboris/satisfied/scene"
+)
+
+// compare holds the project file the scene is compared with
+var compare Compare
+
+// Compare holds a project file and its differences with the scene (see [sc.ComputeDiff])
+type Compare struct {
+	// Compared project file path, empty when not comparing
+	filepath string
+	// Compared project file objects
+	other sc.ObjectCollection
+	// Differences from the compared project file to the scene, at revision
+	diff sc.Diff
+	// Scene revision when diff was computed, -1 if it has never been computed
+	revision int
+}
+
+// IsActive returns true if the scene is compared with a project file
+func (c *Compare) IsActive() bool { return c.filepath != "" }
+
+// Reset stops comparing the scene
+func (c *Compare) Reset() { *c = Compare{} }
+
+// load loads the project file to compare the scene with
+func (c *Compare) load(filepath string) error {
+	other, err := sc.LoadFile(filepath)
+	if err != nil {
+		return err
+	}
+	*c = Compare{filepath: filepath, other: other, revision: -1}
+	log.Info("compare.load", "path", filepath)
+	return nil
+}
+
+// refresh recomputes the differences if the scene has changed
+func (c *Compare) refresh() {
+	if c.revision == scene.Revision() {
+		return
+	}
+	c.diff = sc.ComputeDiff(c.other, scene.ObjectCollection)
+	c.revision = scene.Revision()
+	for _, typ := range []ObjectType{TypeBuilding, TypePath, TypeTextBox} {
+		log.Debug("compare.refresh", "type", typ,
+			"added", c.diff.Count(typ, sc.Added), "removed", c.diff.Count(typ, sc.Removed),
+			"moved", c.diff.Count(typ, sc.Moved), "changed", c.diff.Count(typ, sc.Changed))
+	}
+}
+
+// remapDefs remaps the compared project file objects to new definitions (see [sc.DefsRemap])
+func (c *Compare) remapDefs(r *sc.DefsRemap) {
+	if !c.IsActive() {
+		return
+	}
+	c.other = r.Collection(c.other)
+	c.revision = -1
+}
+
+// drawDiffObject draws an object of a collection
+func drawDiffObject(oc sc.ObjectCollection, typ ObjectType, idx int, state DrawState) {
+	switch typ {
+	case TypeBuilding:
+		drawBuilding(oc.Buildings[idx], state)
+	case TypePath:
+		drawPath(oc.Paths[idx], state)
+	case TypeTextBox:
+		drawTextBox(oc.TextBoxes[idx], state, false)
+	}
+}
+
+// Draw draws the differences over the scene objects.
+//
+// Removed objects are drawn in [DrawRemoved] state, added ones in [DrawAdded] state, and moved or
+// changed ones in [DrawChanged] state, over their previous version in [DrawShadow] state.
+func (c *Compare) Draw() {
+	if !c.IsActive() {
+		return
+	}
+	c.refresh()
+	for _, ch := range c.diff.Changes {
+		if ch.Kind == sc.Moved || ch.Kind == sc.Changed {
+			drawDiffObject(c.diff.Old, ch.Type, ch.OldIdx, DrawShadow)
+		}
+	}
+	for _, ch := range c.diff.Changes {
+		switch ch.Kind {
+		case sc.Removed:
+			drawDiffObject(c.diff.Old, ch.Type, ch.OldIdx, DrawRemoved)
+		case sc.Added:
+			drawDiffObject(c.diff.New, ch.Type, ch.NewIdx, DrawAdded)
+		case sc.Moved, sc.Changed:
+			drawDiffObject(c.diff.New, ch.Type, ch.NewIdx, DrawChanged)
+		}
+	}
+}
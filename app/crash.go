@@ -0,0 +1,124 @@
+// crash - Crash report bundle written by the panic handler
+
+package app
+
+import (
+	"archive/zip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"runtime/debug"
+	"strings"
+	"time"
+
+	"github.com/bonoboris/satisfied/log"
+	sc "github.com/bonoboris/satisfied/scene"
+)
+
+// Name of the crash reports directory in the application data directory
+const crashDirName = "crashes"
+
+// DataDir returns the application directory in the user data directory
+//
+// On Linux it is $XDG_DATA_HOME/satisfied (defaults to ~/.local/share/satisfied), on other platforms
+// it is the same as [ConfigDir].
+func DataDir() (string, error) {
+	if runtime.GOOS == "linux" {
+		if dir := os.Getenv("XDG_DATA_HOME"); filepath.IsAbs(dir) {
+			return filepath.Join(dir, configDirName), nil
+		}
+		if home, err := os.UserHomeDir(); err == nil {
+			return filepath.Join(home, ".local", "share", configDirName), nil
+		}
+	}
+	return ConfigDir()
+}
+
+// writeCrashBundle writes a crash report zip archive in the application data directory and returns
+// its path.
+//
+// The archive contains:
+//   - info.txt: panic error, application and save format versions, platform and app state
+//   - stack.txt: the stack trace
+//   - log.txt: the last log records (see [log.RecentLines])
+//   - history.txt: the scene operations history
+//   - recover.satisfied: the current project
+func writeCrashBundle(panicErr any, stack []byte) (string, error) {
+	dir, err := DataDir()
+	if err != nil {
+		return "", err
+	}
+	dir = filepath.Join(dir, crashDirName)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", err
+	}
+	now := time.Now()
+	path := filepath.Join(dir, fmt.Sprintf("crash-%s.zip", now.Format("20060102-150405")))
+
+	err = WriteFileAtomic(path, func(w io.Writer) error {
+		zw := zip.NewWriter(w)
+		files := []struct {
+			name  string
+			write func(io.Writer) error
+		}{
+			{"info.txt", func(w io.Writer) error { return writeCrashInfo(w, panicErr, now) }},
+			{"stack.txt", func(w io.Writer) error { _, err := w.Write(stack); return err }},
+			{"log.txt", writeCrashLogs},
+			{"history.txt", scene.WriteHistory},
+			{"recover.satisfied", scene.SaveToText},
+		}
+		for _, file := range files {
+			fw, err := zw.Create(file.name)
+			if err != nil {
+				return err
+			}
+			if err := file.write(fw); err != nil {
+				return fmt.Errorf("%s: %w", file.name, err)
+			}
+		}
+		return zw.Close()
+	})
+	if err != nil {
+		return "", err
+	}
+	return NormalizePath(path), nil
+}
+
+func writeCrashInfo(w io.Writer, panicErr any, now time.Time) error {
+	appVersion, revision := "unknown", "unknown"
+	if info, ok := debug.ReadBuildInfo(); ok {
+		appVersion = info.Main.Version
+		for _, setting := range info.Settings {
+			if setting.Key == "vcs.revision" {
+				revision = setting.Value
+			}
+		}
+	}
+	lines := []string{
+		fmt.Sprintf("error: %v", panicErr),
+		fmt.Sprintf("time: %s", now.Format(time.RFC3339)),
+		fmt.Sprintf("app version: %s", appVersion),
+		fmt.Sprintf("vcs revision: %s", revision),
+		fmt.Sprintf("save format version: %d", sc.Version),
+		fmt.Sprintf("go version: %s", runtime.Version()),
+		fmt.Sprintf("platform: %s/%s", runtime.GOOS, runtime.GOARCH),
+		fmt.Sprintf("mode: %s", app.Mode),
+		fmt.Sprintf("project: %s", app.filepath),
+		fmt.Sprintf("modified: %t", scene.IsModified()),
+		fmt.Sprintf("objects: %d buildings, %d paths, %d text boxes",
+			len(scene.Buildings), len(scene.Paths), len(scene.TextBoxes)),
+	}
+	_, err := io.WriteString(w, strings.Join(lines, "\n")+"\n")
+	return err
+}
+
+func writeCrashLogs(w io.Writer) error {
+	for _, line := range log.RecentLines() {
+		if _, err := io.WriteString(w, line+"\n"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
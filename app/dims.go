@@ -27,18 +27,22 @@ func (d Dims) traceState() {
 
 // Update screen and scene dimensions state
 //
-// Depends on [Camera]
+// Depends on [Input] and [Camera]
 func (d *Dims) Update() {
 	d.pScreen = d.Screen
-	width := rl.GetScreenWidth()
-	height := rl.GetScreenHeight()
-	d.Screen = vec2(float32(width), float32(height))
+	d.Screen = input.Frame.Screen
 	d.Scene = rl.NewRectangle(
 		SidebarWidth,
 		TopbarHeight,
 		d.Screen.X-SidebarWidth-DetailsBarWidth,
 		d.Screen.Y-TopbarHeight-StatusBarHeight,
 	)
+	if gui.Planner.open {
+		d.Scene.Width -= PlannerWidth
+	}
+	if gui.Issues.open {
+		d.Scene.Width -= IssuesWidth
+	}
 	d.World = rl.NewRectangleV(camera.WorldPos(d.Scene.TopLeft()), d.Scene.Size().Scale(1/camera.Zoom()))
 	d.ExWorld = rl.NewRectangleV(d.World.TopLeft().SubtractValue(1), d.World.Size().AddValue(2))
 	if d.Screen != d.pScreen {
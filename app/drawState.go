@@ -11,12 +11,17 @@ type DrawState uint8
 const (
 	// States
 
-	DrawNormal   DrawState = 0
-	DrawNew      DrawState = 1
-	DrawSelected DrawState = 2
-	DrawInvalid  DrawState = 3
-	DrawShadow   DrawState = 4
-	DrawSkip     DrawState = 5
+	DrawNormal     DrawState = 0
+	DrawNew        DrawState = 1
+	DrawSelected   DrawState = 2
+	DrawInvalid    DrawState = 3
+	DrawShadow     DrawState = 4
+	DrawSkip       DrawState = 5
+	DrawAdded      DrawState = 6
+	DrawRemoved    DrawState = 7
+	DrawChanged    DrawState = 8
+	DrawUpstream   DrawState = 9
+	DrawDownstream DrawState = 10
 
 	// Modifiers
 
@@ -46,6 +51,16 @@ func (state DrawState) transformColor(color rl.Color) rl.Color {
 		color = shadowColor
 	case DrawSkip:
 		return colors.Blank // FIXME: should panic ?
+	case DrawAdded:
+		color = colors.Lerp(color, colors.Green500, 0.6)
+	case DrawRemoved:
+		color = colors.WithAlpha(colors.Lerp(color, colors.Red500, 0.6), 0.5)
+	case DrawChanged:
+		color = colors.Lerp(color, colors.Orange500, 0.6)
+	case DrawUpstream:
+		color = colors.Lerp(color, colors.Violet500, 0.6)
+	case DrawDownstream:
+		color = colors.Lerp(color, colors.Blue500, 0.6)
 	default:
 		panic("transformColor: invalid ToolState")
 	}
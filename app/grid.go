@@ -4,6 +4,7 @@ import (
 	"strconv"
 
 	"github.com/bonoboris/satisfied/colors"
+	"github.com/bonoboris/satisfied/log"
 	"github.com/bonoboris/satisfied/math32"
 	rl "github.com/gen2brain/raylib-go/raylib"
 )
@@ -20,7 +21,25 @@ func (g Grid) Snap(v rl.Vector2) rl.Vector2 {
 	if g.SnapStep == 0 {
 		return v
 	}
-	return vec2(g.SnapStep*math32.Round(v.X)/g.SnapStep, g.SnapStep*math32.Round(v.Y)/g.SnapStep)
+	return vec2(g.SnapStep*math32.Round(v.X/g.SnapStep), g.SnapStep*math32.Round(v.Y/g.SnapStep))
+}
+
+// snapSteps are the snap steps proposed in the top bar, in world units
+var snapSteps = [...]float32{0, 0.5, 1, 2, 4, 8}
+
+// doSetSnapStep sets the snap step, 0 to disable snapping, and stores it in the settings
+func (g *Grid) doSetSnapStep(step float32) Action {
+	if step < 0 {
+		log.Warn("grid.doSetSnapStep", "reason", "negative step", "step", step)
+		return nil
+	}
+	log.Debug("grid.doSetSnapStep", "step", step)
+	g.SnapStep = step
+	if !replay.Replaying() {
+		settings.SnapStep = step
+		settings.Save()
+	}
+	return nil
 }
 
 // Draw grid
@@ -2,11 +2,16 @@ package app
 
 import (
 	"fmt"
+	"math"
+	"path/filepath"
 	"slices"
+	"strconv"
 	"strings"
 
 	"github.com/bonoboris/satisfied/colors"
 	"github.com/bonoboris/satisfied/log"
+	"github.com/bonoboris/satisfied/math32"
+	sc "github.com/bonoboris/satisfied/scene"
 	"github.com/bonoboris/satisfied/text"
 	"github.com/gen2brain/raylib-go/raygui"
 	rl "github.com/gen2brain/raylib-go/raylib"
@@ -31,6 +36,8 @@ type Gui struct {
 	Sidebar    guiSidebar
 	Detailsbar guiDetailsbar
 	Statusbar  guiStatusbar
+	Planner    guiPlanner
+	Issues     guiIssues
 }
 
 // Precompute and store some static data
@@ -46,6 +53,8 @@ func (g *Gui) UpdateAndDraw() (action Action) {
 	// we cannot press 2 buttons at the same time
 	action = orAction(action, g.Statusbar.updateAndDraw())
 	action = orAction(action, g.Detailsbar.updateAndDraw())
+	action = orAction(action, g.Planner.updateAndDraw())
+	action = orAction(action, g.Issues.updateAndDraw())
 	action = orAction(action, g.Sidebar.updateAndDraw())
 	action = orAction(action, g.Topbar.updateAndDraw())
 	return action
@@ -57,7 +66,10 @@ func (g *Gui) Reset() {
 }
 
 // Whether the gui should captures key presses
-func (g *Gui) CapturesKeyPress() bool { return g.Detailsbar.textarea.Focused() }
+func (g *Gui) CapturesKeyPress() bool {
+	return g.Detailsbar.textarea.Focused() || g.Detailsbar.transform.isEditing() || g.Detailsbar.modifiers.isEditing() ||
+		g.Planner.rateEditing
+}
 
 func (g *Gui) traceState() {
 	log.Trace("gui.sidebar", "activePath", g.Sidebar.activePath, "activeCategory", g.Sidebar.activeCategory, "activeBuilding", g.Sidebar.activeBuilding)
@@ -68,12 +80,59 @@ func (g *Gui) traceState() {
 // See: [ActionHandler]
 func (g *Gui) Dispatch(action Action) Action {
 	switch action := action.(type) {
+	case GuiActionSelectTextBox:
+		return g.Sidebar.doSelectTextBox()
+	case GuiActionSelectPath:
+		return g.Sidebar.doSelectPath(action.Idx)
+	case GuiActionSelectCategory:
+		return g.Sidebar.doSelectCategory(action.Idx)
+	case GuiActionSelectBuilding:
+		return g.Sidebar.doSelectBuilding(action.Idx)
+	case GuiActionUpdateTextBox:
+		return g.Detailsbar.doUpdateTextBoxContent(action.Content)
+	case GuiActionTransformSelection:
+		return g.Detailsbar.doTransformSelection(action.Origin, action.Rot)
+	case GuiActionArraySelection:
+		return g.Detailsbar.doArraySelection(action.Pattern)
+	case GuiActionSetModifiers:
+		return g.Detailsbar.doSetModifiers(action.Mods)
+	case GuiActionTogglePlanner:
+		return g.Planner.doToggle()
+	case GuiActionSetPlanTarget:
+		return g.Planner.doSetTarget(action.Item, action.Rate)
+	case GuiActionSetPlanClock:
+		return g.Planner.doSetClock(action.Clock)
+	case GuiActionToggleAlternate:
+		return g.Planner.doToggleAlternate(action.Recipe)
+	case GuiActionPlacePlanBuilding:
+		return g.Planner.doPlaceBuilding(action.Class)
+	case GuiActionGeneratePlanLayout:
+		return g.Planner.doGenerateLayout(action.Center)
+	case GuiActionSetBOMTier:
+		return g.Detailsbar.bom.doSetTier(action.DefIdx, action.Tier)
+	case GuiActionExportBOM:
+		return g.Detailsbar.bom.doExport(action.Markdown)
+	case GuiActionSetSnapStep:
+		return grid.doSetSnapStep(action.Step)
+	case GuiActionToggleIssues:
+		return g.Issues.doToggle()
+	case GuiActionToggleLintRule:
+		return g.Issues.doToggleRule(action.Rule)
+	case GuiActionSelectIssue:
+		return g.Issues.doSelectIssue(action.Object, action.Pos)
 	default:
 		panic(fmt.Sprintf("Gui.Dispatch: cannot handle: %T", action))
 	}
 }
 
-type guiTopbar struct{}
+type guiTopbar struct {
+	// Whether the recent projects dropdown is open
+	recentEditMode bool
+	// Whether the align dropdown is open
+	alignEditMode bool
+	// Whether the snap step dropdown is open
+	snapEditMode bool
+}
 
 func (tb *guiTopbar) updateAndDraw() (action Action) {
 	bar := rl.NewRectangle(0, 0, dims.Screen.X, TopbarHeight)
@@ -93,28 +152,43 @@ func (tb *guiTopbar) updateAndDraw() (action Action) {
 	raygui.SetTooltip("New file")
 	if raygui.Button(bounds, raygui.IconText(raygui.ICON_FILE_NEW, "")) {
 		log.Debug("topbar new file clicked")
-		action = app.doNew()
+		action = AppActionNew{}
 	}
 
 	bounds.X += 50
 	raygui.SetTooltip("Open file")
 	if raygui.Button(bounds, raygui.IconText(raygui.ICON_FILE_OPEN, "")) {
 		log.Debug("topbar open file clicked")
-		action = app.doOpen()
+		action = AppActionOpen{}
 	}
 
 	bounds.X += 50
 	raygui.SetTooltip("Save file (Ctrl+S)")
 	if raygui.Button(bounds, raygui.IconText(raygui.ICON_FILE_SAVE, "")) {
 		log.Debug("topbar save file clicked")
-		action = app.doSave(app.filepath)
+		action = AppActionSave{Filepath: app.filepath}
 	}
 
 	bounds.X += 50
 	raygui.SetTooltip("Save file as...")
 	if raygui.Button(bounds, raygui.IconText(raygui.ICON_FILE_SAVE_CLASSIC, "")) {
 		log.Debug("topbar save file as clicked")
-		action = app.doSaveAs()
+		action = AppActionSaveAs{}
+	}
+
+	bounds.X += 50
+	if compare.IsActive() {
+		raygui.SetTooltip("Stop comparing with " + filepath.Base(compare.filepath))
+		if raygui.Button(bounds, raygui.IconText(raygui.ICON_EYE_OFF, "")) {
+			log.Debug("topbar stop compare clicked")
+			action = AppActionStopCompare{}
+		}
+	} else {
+		raygui.SetTooltip("Compare with file")
+		if raygui.Button(bounds, raygui.IconText(raygui.ICON_LAYERS_VISIBLE, "")) {
+			log.Debug("topbar compare clicked")
+			action = AppActionCompare{}
+		}
 	}
 	raygui.Enable() // end file controls
 
@@ -128,7 +202,7 @@ func (tb *guiTopbar) updateAndDraw() (action Action) {
 	raygui.SetTooltip("Undo (Ctrl+Z)")
 	if raygui.Button(bounds, raygui.IconText(raygui.ICON_UNDO, "")) {
 		log.Debug("topbar undo clicked")
-		action = app.doUndo()
+		action = AppActionUndo{}
 	}
 	raygui.Enable() // end undo control
 
@@ -139,7 +213,7 @@ func (tb *guiTopbar) updateAndDraw() (action Action) {
 	raygui.SetTooltip("Redo (Ctrl+Y / Ctrl+Shift+Z)")
 	if raygui.Button(bounds, raygui.IconText(raygui.ICON_REDO, "")) {
 		log.Debug("topbar redo clicked")
-		action = app.doRedo()
+		action = AppActionRedo{}
 	}
 	raygui.Enable() // end redo control
 
@@ -147,16 +221,34 @@ func (tb *guiTopbar) updateAndDraw() (action Action) {
 	rl.DrawLineEx(bounds.TopLeft(), bounds.BottomLeft(), 2, colors.Gray300)
 
 	bounds.X += 20
-	raygui.SetTooltip("Rotate (R)")
+	raygui.SetTooltip("Rotate (R, Shift+R by 15° without buildings)")
 	if !(app.Mode == ModeSelection || app.Mode == ModeNewPath || app.Mode == ModeNewBuilding) { // begin rotate control
 		raygui.Disable()
 	}
 	if raygui.Button(bounds, raygui.IconText(raygui.ICON_ROTATE, "")) {
 		log.Debug("topbar rotate clicked")
-		action = app.doRotate()
+		action = AppActionRotate{}
 	}
 	raygui.Enable() // end rotate control
 
+	if !(app.Mode == ModeSelection || app.Mode == ModeNewBuilding) { // begin mirror controls
+		raygui.Disable()
+	}
+	bounds.X += 50
+	raygui.SetTooltip("Mirror horizontally (H)")
+	if raygui.Button(bounds, raygui.IconText(raygui.ICON_SYMMETRY_HORIZONTAL, "")) {
+		log.Debug("topbar mirror horizontally clicked")
+		action = AppActionMirror{Vertical: false}
+	}
+
+	bounds.X += 50
+	raygui.SetTooltip("Mirror vertically (Shift+H)")
+	if raygui.Button(bounds, raygui.IconText(raygui.ICON_SYMMETRY_VERTICAL, "")) {
+		log.Debug("topbar mirror vertically clicked")
+		action = AppActionMirror{Vertical: true}
+	}
+	raygui.Enable() // end mirror controls
+
 	if !(app.Mode == ModeSelection && selection.mode == SelectionNormal || selection.mode == SelectionSingleTextBox) { // begin selection transform controls
 		raygui.Disable()
 	}
@@ -164,24 +256,57 @@ func (tb *guiTopbar) updateAndDraw() (action Action) {
 	raygui.SetTooltip("Duplicate (D)")
 	if raygui.Button(bounds, raygui.IconText(raygui.ICON_LAYERS, "")) {
 		log.Debug("topbar duplicate clicked")
-		action = app.doDuplicate()
+		action = AppActionDuplicate{}
+	}
+
+	bounds.X += 50
+	raygui.SetTooltip("Array duplicate (Shift+D)")
+	if raygui.Button(bounds, raygui.IconText(raygui.ICON_BOX_GRID, "")) {
+		log.Debug("topbar array clicked")
+		action = AppActionArray{}
 	}
 
 	bounds.X += 50
 	raygui.SetTooltip("Drag (LMB drag / V)")
 	if raygui.Button(bounds, raygui.IconText(raygui.ICON_CURSOR_MOVE_FILL, "")) {
 		log.Debug("topbar drag clicked")
-		action = app.doDrag()
+		action = AppActionDrag{}
 	}
 
 	bounds.X += 50
 	raygui.SetTooltip("Delete (X / Del)")
 	if raygui.Button(bounds, raygui.IconText(raygui.ICON_BIN, "")) {
 		log.Debug("topbar delete clicked")
-		action = app.doDelete()
+		action = AppActionDelete{}
 	}
 	raygui.Enable() // end selection transform controls
 
+	bounds.X += 50
+	rl.DrawLineEx(bounds.TopLeft(), bounds.BottomLeft(), 2, colors.Gray300)
+
+	bounds.X += 20
+	action = orAction(action, tb.drawAlignControls(rl.NewRectangle(bounds.X, bounds.Y, 250, bounds.Height)))
+
+	bounds.X += 270
+	action = orAction(action, tb.drawRecentControls(rl.NewRectangle(bounds.X, bounds.Y, 250, bounds.Height)))
+
+	bounds.X += 270
+	action = orAction(action, tb.drawSnapControls(rl.NewRectangle(bounds.X, bounds.Y, 150, bounds.Height)))
+
+	bounds.X += 170
+	raygui.SetTooltip("Production planner")
+	if raygui.Button(bounds, raygui.IconText(raygui.ICON_GEAR, "")) {
+		log.Debug("topbar planner clicked")
+		action = GuiActionTogglePlanner{}
+	}
+
+	bounds.X += 50
+	raygui.SetTooltip("Design rules issues")
+	if raygui.Button(bounds, raygui.IconText(raygui.ICON_ALARM, "")) {
+		log.Debug("topbar issues clicked")
+		action = GuiActionToggleIssues{}
+	}
+
 	// Reset style and tooltip
 	raygui.DisableTooltip()
 	raygui.SetStyle(raygui.DEFAULT, raygui.TEXT_SIZE, pTextSize)
@@ -189,6 +314,102 @@ func (tb *guiTopbar) updateAndDraw() (action Action) {
 	return action
 }
 
+// alignItems are the align dropdown items, after its title
+var alignItems = [...]struct {
+	icon      int32
+	text      string
+	alignment Alignment
+}{
+	{raygui.ICON_BOX_LEFT, "Align left (Alt+Left)", sc.AlignLeft},
+	{raygui.ICON_BOX_RIGHT, "Align right (Alt+Right)", sc.AlignRight},
+	{raygui.ICON_BOX_TOP, "Align top (Alt+Up)", sc.AlignTop},
+	{raygui.ICON_BOX_BOTTOM, "Align bottom (Alt+Down)", sc.AlignBottom},
+	{raygui.ICON_BOX_CENTER, "Center X (Alt+C)", sc.AlignCenterX},
+	{raygui.ICON_BOX_CENTER, "Center Y (Alt+Shift+C)", sc.AlignCenterY},
+	{raygui.ICON_BOX_DOTS_BIG, "Distribute X (Alt+H)", sc.DistributeX},
+	{raygui.ICON_BOX_DOTS_BIG, "Distribute Y (Alt+V)", sc.DistributeY},
+}
+
+// drawAlignControls draws the align and distribute dropdown
+func (tb *guiTopbar) drawAlignControls(bounds rl.Rectangle) (action Action) {
+	if !(app.Mode == ModeSelection && (selection.mode == SelectionNormal || selection.mode == SelectionSingleTextBox)) { // begin align controls
+		raygui.Disable()
+		tb.alignEditMode = false
+	}
+	names := make([]string, 0, len(alignItems)+1)
+	names = append(names, "Align / distribute")
+	for _, item := range alignItems {
+		names = append(names, raygui.IconText(item.icon, item.text))
+	}
+	active := int32(0)
+	raygui.SetTooltip("Align or distribute the selected buildings and text boxes")
+	if raygui.DropdownBox(bounds, strings.Join(names, ";"), &active, tb.alignEditMode) {
+		tb.alignEditMode = !tb.alignEditMode
+		if !tb.alignEditMode && active > 0 {
+			alignment := alignItems[active-1].alignment
+			log.Debug("topbar align clicked", "alignment", alignment)
+			action = AppActionAlign{Alignment: alignment}
+		}
+	}
+	raygui.Enable() // end align controls
+	return action
+}
+
+// drawRecentControls draws the recent projects dropdown
+func (tb *guiTopbar) drawRecentControls(bounds rl.Rectangle) (action Action) {
+	if !app.isNormal() || len(settings.RecentFiles) == 0 { // begin recent controls
+		raygui.Disable()
+		tb.recentEditMode = false
+	}
+	names := make([]string, 0, len(settings.RecentFiles)+1)
+	names = append(names, "Recent projects")
+	for _, p := range settings.RecentFiles {
+		names = append(names, filepath.Base(p))
+	}
+	active := int32(0)
+	raygui.SetTooltip("Open a recent project")
+	if raygui.DropdownBox(bounds, strings.Join(names, ";"), &active, tb.recentEditMode) {
+		tb.recentEditMode = !tb.recentEditMode
+		if !tb.recentEditMode && active > 0 {
+			path := settings.RecentFiles[active-1]
+			log.Debug("topbar recent project clicked", "path", path)
+			action = AppActionOpenRecent{Filepath: path}
+		}
+	}
+	raygui.Enable() // end recent controls
+	return action
+}
+
+// drawSnapControls draws the snap step dropdown, showing the current step
+func (tb *guiTopbar) drawSnapControls(bounds rl.Rectangle) (action Action) {
+	names := make([]string, len(snapSteps))
+	active := int32(-1)
+	for i, step := range snapSteps {
+		if step == 0 {
+			names[i] = "Snap off"
+		} else {
+			names[i] = "Snap " + strconv.FormatFloat(float64(step), 'f', -1, 32) + "m"
+		}
+		if step == grid.SnapStep {
+			active = int32(i)
+		}
+	}
+	if active < 0 {
+		// custom step from the settings file
+		names = append(names, "Snap "+strconv.FormatFloat(float64(grid.SnapStep), 'f', -1, 32)+"m")
+		active = int32(len(names) - 1)
+	}
+	raygui.SetTooltip("Grid snap step")
+	if raygui.DropdownBox(bounds, strings.Join(names, ";"), &active, tb.snapEditMode) {
+		tb.snapEditMode = !tb.snapEditMode
+		if !tb.snapEditMode && int(active) < len(snapSteps) && snapSteps[active] != grid.SnapStep {
+			log.Debug("topbar snap step clicked", "step", snapSteps[active])
+			action = GuiActionSetSnapStep{Step: snapSteps[active]}
+		}
+	}
+	return action
+}
+
 // guiSidebar represents the sidebar of the application
 type guiSidebar struct {
 	// Active text box index
@@ -275,18 +496,21 @@ func (sb *guiSidebar) drawTextBoxControls(bounds rl.Rectangle, yOffset float32)
 		// newActive is guaranteed to be != -1 because ToggleGroup returns the index of the newly
 		// active toggle (after a click) and we cannot goes from an active one (sb.activeTextBox != 1)
 		// to an inactive one (newActive == -1) by clicking on the same toggle
-		sb.activeTextBox = newActive
-		sb.activePath = -1
-		sb.activeCategory = -1
-		sb.activeBuilding = -1
 		log.Debug("sidebar text box clicked", "index", newActive)
-		gui.traceState()
-		// newActive matches with actual index in [pathDefs]
-		return newTextBox.doInit()
+		return GuiActionSelectTextBox{}
 	}
 	return nil
 }
 
+func (sb *guiSidebar) doSelectTextBox() Action {
+	sb.activeTextBox = 0
+	sb.activePath = -1
+	sb.activeCategory = -1
+	sb.activeBuilding = -1
+	gui.traceState()
+	return newTextBox.doInit()
+}
+
 func (sb *guiSidebar) drawPathsControls(bounds rl.Rectangle, yOffset float32) Action {
 	bounds = rl.NewRectangle(bounds.X, bounds.Y+yOffset, (bounds.Width-10)/float32(sb.numPath), 40)
 	newActive := raygui.ToggleGroup(bounds, sb.pathText, int32(sb.activePath))
@@ -294,18 +518,22 @@ func (sb *guiSidebar) drawPathsControls(bounds rl.Rectangle, yOffset float32) Ac
 		// newActive is guaranteed to be != -1 because ToggleGroup returns the index of the newly
 		// active toggle (after a click) and we cannot goes from an active one (sb.activePath != 1)
 		// to an inactive one (newActive == -1) by clicking on the same toggle
-		sb.activePath = newActive
-		sb.activeTextBox = -1
-		sb.activeCategory = -1
-		sb.activeBuilding = -1
 		log.Debug("sidebar path clicked", "defIdx", newActive)
-		gui.traceState()
-		// newActive matches with actual index in [pathDefs]
-		return newPath.doInit(int(newActive))
+		return GuiActionSelectPath{Idx: newActive}
 	}
 	return nil
 }
 
+func (sb *guiSidebar) doSelectPath(idx int32) Action {
+	sb.activePath = idx
+	sb.activeTextBox = -1
+	sb.activeCategory = -1
+	sb.activeBuilding = -1
+	gui.traceState()
+	// idx matches with actual index in [pathDefs]
+	return newPath.doInit(int(idx))
+}
+
 func (sb *guiSidebar) drawCategoryControls(bounds rl.Rectangle, yOffset float32) Action {
 	bounds = rl.NewRectangle(bounds.X, bounds.Y+yOffset, bounds.Width, 40)
 	newActive := raygui.ToggleGroup(bounds, sb.categoryText, sb.activeCategory)
@@ -313,17 +541,21 @@ func (sb *guiSidebar) drawCategoryControls(bounds rl.Rectangle, yOffset float32)
 		// newActive is guaranteed to be != -1 because ToggleGroup returns the index of the newly
 		// active toggle (after a click) and we cannot goes from an active one (sb.activeCategory != 1)
 		// to an inactive one (newActive == -1) by clicking on the same toggle
-		sb.activeCategory = newActive
-		sb.activeTextBox = -1
-		sb.activePath = -1
-		sb.activeBuilding = -1
-		log.Debug("sidebar category clicked", "catIdx", sb.activeCategory)
-		gui.traceState()
-		return app.doSwitchMode(ModeNormal, ResetAll().WithGui(false))
+		log.Debug("sidebar category clicked", "catIdx", newActive)
+		return GuiActionSelectCategory{Idx: newActive}
 	}
 	return nil
 }
 
+func (sb *guiSidebar) doSelectCategory(idx int32) Action {
+	sb.activeCategory = idx
+	sb.activeTextBox = -1
+	sb.activePath = -1
+	sb.activeBuilding = -1
+	gui.traceState()
+	return app.doSwitchMode(ModeNormal, ResetAll().WithGui(false))
+}
+
 func (sb *guiSidebar) drawBuildingControls(bounds rl.Rectangle, yOffset float32) Action {
 	bounds = rl.NewRectangle(bounds.X, bounds.Y+yOffset, bounds.Width, 40)
 	newActive := raygui.ToggleGroup(bounds, sb.buildingTexts[sb.activeCategory], sb.activeBuilding)
@@ -331,17 +563,39 @@ func (sb *guiSidebar) drawBuildingControls(bounds rl.Rectangle, yOffset float32)
 		// newActive is guaranteed to be != -1 because ToggleGroup returns the index of the newly
 		// active toggle (after a click) and we cannot goes from an active one (sb.activeBuilding != 1)
 		// to an inactive one (newActive == -1) by clicking on the same toggle
-		sb.activeBuilding = newActive
-		sb.activeTextBox = -1
-		sb.activePath = -1
-		defIdx := sb.buildingIndices[sb.activeCategory][newActive]
-		log.Debug("sidebar building clicked", "defIdx", defIdx)
-		gui.traceState()
-		return newBuilding.doInit(defIdx)
+		log.Debug("sidebar building clicked", "idx", newActive)
+		return GuiActionSelectBuilding{Idx: newActive}
 	}
 	return nil
 }
 
+func (sb *guiSidebar) doSelectBuilding(idx int32) Action {
+	if sb.activeCategory < 0 {
+		log.Warn("sidebar select building", "reason", "no active category", "idx", idx)
+		return nil
+	}
+	sb.activeBuilding = idx
+	sb.activeTextBox = -1
+	sb.activePath = -1
+	defIdx := sb.buildingIndices[sb.activeCategory][idx]
+	log.Debug("sidebar select building", "defIdx", defIdx)
+	gui.traceState()
+	return newBuilding.doInit(defIdx)
+}
+
+// doSelectBuildingDef activates the toggle of the building definition of index defIdx, as if it was
+// clicked
+func (sb *guiSidebar) doSelectBuildingDef(defIdx int) Action {
+	for cat, idxs := range sb.buildingIndices {
+		if idx := slices.Index(idxs, defIdx); idx >= 0 {
+			sb.activeCategory = int32(cat)
+			return sb.doSelectBuilding(int32(idx))
+		}
+	}
+	log.Warn("sidebar select building def", "reason", "no toggle", "defIdx", defIdx)
+	return nil
+}
+
 func (sb *guiSidebar) updateAndDraw() (action Action) {
 	bar := rl.NewRectangle(0, TopbarHeight, SidebarWidth, dims.Screen.Y-TopbarHeight-StatusBarHeight)
 
@@ -384,8 +638,11 @@ func (sb *guiSidebar) updateAndDraw() (action Action) {
 }
 
 type guiDetailsbar struct {
-	areaInit bool
-	textarea text.Area
+	areaInit  bool
+	textarea  text.Area
+	transform guiTransformPanel
+	modifiers guiModifiersPanel
+	bom       guiBOMPanel
 }
 
 func textAreaOpts() text.AreaOptions {
@@ -397,10 +654,61 @@ func (db *guiDetailsbar) reset() {
 	db.textarea = text.NewArea(rl.Rectangle{}, "", textAreaOpts())
 }
 
-func (db *guiDetailsbar) doUpdateTextBoxContent() Action {
-	if newText := db.textarea.Text(); newText != scene.TextBoxes[selection.TextBoxIdxs[0]].Content {
+// doTransformSelection moves the selection bounds top left corner to origin after rotating it by
+// rot degrees
+func (db *guiDetailsbar) doTransformSelection(origin rl.Vector2, rot int32) Action {
+	if app.Mode != ModeSelection {
+		log.Warn("details bar transform selection", "reason", "no selection")
+		return nil
+	}
+	if err := selection.transformTo(origin, rot); err != nil {
+		log.Info("details bar transform selection", "reason", err)
+		db.transform.err = err.Error()
+		return nil
+	}
+	db.transform.reset()
+	return nil
+}
+
+// doArraySelection adds copies of the selection laid out with pat
+func (db *guiDetailsbar) doArraySelection(pat ArrayPattern) Action {
+	if app.Mode != ModeSelection {
+		log.Warn("details bar array selection", "reason", "no selection")
+		return nil
+	}
+	if err := selection.arrayTo(pat); err != nil {
+		log.Info("details bar array selection", "reason", err)
+		db.transform.err = err.Error()
+		return nil
+	}
+	db.transform.reset()
+	return nil
+}
+
+// doSetModifiers sets the clock speed, power shards and somersloops of the selected clockable
+// buildings
+func (db *guiDetailsbar) doSetModifiers(mods Modifiers) Action {
+	if app.Mode != ModeSelection {
+		log.Warn("details bar set modifiers", "reason", "no selection")
+		return nil
+	}
+	if err := selection.setModifiers(mods); err != nil {
+		log.Info("details bar set modifiers", "reason", err)
+		db.modifiers.err = err.Error()
+		return nil
+	}
+	db.modifiers.reset()
+	return nil
+}
+
+func (db *guiDetailsbar) doUpdateTextBoxContent(content string) Action {
+	if app.Mode != ModeSelection || len(selection.TextBoxIdxs) != 1 {
+		log.Warn("details bar update text box", "reason", "no single text box selected")
+		return nil
+	}
+	if content != scene.TextBoxes[selection.TextBoxIdxs[0]].Content {
 		tb := scene.TextBoxes[selection.TextBoxIdxs[0]]
-		tb.Content = newText
+		tb.Content = content
 		db.textarea.SetFocused(false)
 		scene.ModifyObjects(selection.ObjectSelection, ObjectCollection{TextBoxes: []TextBox{tb}})
 	}
@@ -421,6 +729,22 @@ func (db *guiDetailsbar) updateAndDraw() Action {
 	// padded dimensions
 	bar = rl.NewRectangle(bar.X+20, bar.Y+20, bar.Width-40, bar.Height-40)
 
+	if app.Mode == ModeSelection {
+		action = db.transform.updateAndDraw(bar)
+		bar.Y += transformPanelHeight
+		bar.Height -= transformPanelHeight
+	} else {
+		db.transform.reset()
+	}
+	if idxs := selection.clockableIdxs(); app.Mode == ModeSelection && len(idxs) > 0 {
+		action = orAction(action, db.modifiers.updateAndDraw(bar, scene.Buildings[idxs[0]].Mods))
+		bar.Y += modifiersPanelHeight
+		bar.Height -= modifiersPanelHeight
+	} else {
+		db.modifiers.reset()
+	}
+	bar.Height -= drawPowerPanel(bar, power.Network())
+
 	// db.textarea.SetBounds(bounds)
 	// db.textarea.Draw(keyboard.Pressed)
 	if app.Mode == ModeSelection && len(selection.TextBoxIdxs) == 1 && len(selection.BuildingIdxs) == 0 && len(selection.PathIdxs) == 0 {
@@ -431,7 +755,7 @@ func (db *guiDetailsbar) updateAndDraw() Action {
 
 		areaBounds := bar
 		areaBounds.Y += 40
-		areaBounds.Height = bar.Height - areaBounds.Y - 50
+		areaBounds.Height = bar.Height - 90
 
 		if !db.areaInit {
 			db.textarea = text.NewArea(areaBounds, scene.TextBoxes[selection.TextBoxIdxs[0]].Content, textAreaOpts())
@@ -444,7 +768,7 @@ func (db *guiDetailsbar) updateAndDraw() Action {
 		db.textarea.Draw(keyboard.Pressed)
 
 		if keyboard.Pressed == rl.KeyEnter && keyboard.Ctrl {
-			action = db.doUpdateTextBoxContent()
+			action = GuiActionUpdateTextBox{Content: db.textarea.Text()}
 		}
 		buttonBounds := bar
 		buttonBounds.Y = areaBounds.Y + areaBounds.Height + 10
@@ -453,16 +777,440 @@ func (db *guiDetailsbar) updateAndDraw() Action {
 			raygui.Disable()
 		}
 		if raygui.Button(buttonBounds, "Update (Ctrl+Enter)") {
-			action = db.doUpdateTextBoxContent()
+			action = GuiActionUpdateTextBox{Content: db.textarea.Text()}
 		}
 		raygui.Enable()
 	} else {
 		db.reset()
+		action = orAction(action, db.bom.updateAndDraw(bar))
+	}
+	return action
+}
+
+// Power panel dimensions, in px
+const (
+	powerPanelLineHeight = 26.0
+	// Maximum number of circuits listed, the overloaded ones first
+	powerPanelMaxCircuits = 6
+)
+
+// formatMW formats a power in MW, rounded to 0.1 MW
+func formatMW(mw float32) string {
+	return strconv.FormatFloat(math.Round(float64(mw)*10)/10, 'f', -1, 64)
+}
+
+// drawPowerPanel draws the power consumption and generation of the circuits at the bottom of the
+// details bar, and returns its height (0 if there is no power network)
+func drawPowerPanel(bar rl.Rectangle, net sc.PowerNetwork) float32 {
+	if len(net.Circuits) == 0 && len(net.Unpowered) == 0 && len(net.Issues) == 0 {
+		return 0
+	}
+	type line struct {
+		text  string
+		color rl.Color
+	}
+	lines := []line{
+		{"Power (used / generated)", colors.Gray700},
+		{fmt.Sprintf("Total: %s / %s MW", formatMW(net.Consumption()), formatMW(net.Generation())), colors.Gray700},
+	}
+	order := sc.Range(0, len(net.Circuits))
+	slices.SortStableFunc(order, func(i, j int) int {
+		switch oi, oj := net.Circuits[i].IsOverloaded(), net.Circuits[j].IsOverloaded(); {
+		case oi && !oj:
+			return -1
+		case oj && !oi:
+			return 1
+		}
+		return 0
+	})
+	for n, i := range order {
+		if n == powerPanelMaxCircuits {
+			lines = append(lines, line{fmt.Sprintf("and %d more circuits", len(order)-n), colors.Gray500})
+			break
+		}
+		c := net.Circuits[i]
+		l := line{fmt.Sprintf("Circuit %d: %s / %s MW", i+1, formatMW(c.Consumption), formatMW(c.Generation)), colors.Gray700}
+		if c.IsOverloaded() {
+			l.color = colors.Red500
+		}
+		lines = append(lines, l)
+	}
+	if len(net.Unpowered) > 0 {
+		lines = append(lines, line{fmt.Sprintf("Unpowered buildings: %d", len(net.Unpowered)), colors.Amber700})
+	}
+	if len(net.Issues) > 0 {
+		lines = append(lines, line{fmt.Sprintf("Power line issues: %d", len(net.Issues)), colors.Red500})
+	}
+
+	height := float32(len(lines))*powerPanelLineHeight + 20
+	y := bar.Y + bar.Height - height
+	rl.DrawLineV(vec2(bar.X, y), vec2(bar.X+bar.Width, y), colors.Gray300)
+	for i, l := range lines {
+		bounds := rl.NewRectangle(bar.X, y+10+float32(i)*powerPanelLineHeight, bar.Width, powerPanelLineHeight)
+		size := float32(20)
+		if i == 0 {
+			size = 24
+		}
+		text.DrawText(bounds, l.text, text.Options{Font: font, Size: size, Color: l.color})
+	}
+	return height
+}
+
+// maxPanelFields is the maximum number of text fields of a [guiFieldsPanel], the transform panel
+// has the most
+const maxPanelFields = transformFieldCount
+
+// guiFieldsPanel holds the numeric text fields of a details bar panel (see [guiTransformPanel] and
+// [guiModifiersPanel])
+type guiFieldsPanel struct {
+	// text of the fields
+	fields [maxPanelFields]string
+	// whether a field is being edited
+	editing [maxPanelFields]bool
+	// whether a field was edited since the last reset
+	edited [maxPanelFields]bool
+	// why the last input was not applied, if any
+	err string
+}
+
+// reset stops editing the fields and forgets they were edited
+func (fp *guiFieldsPanel) reset() {
+	fp.editing = [maxPanelFields]bool{}
+	fp.edited = [maxPanelFields]bool{}
+	fp.err = ""
+}
+
+// isEditing returns whether a field is being edited
+func (fp *guiFieldsPanel) isEditing() bool {
+	return slices.Contains(fp.editing[:], true)
+}
+
+// isEdited returns whether a field was edited since the last reset
+func (fp *guiFieldsPanel) isEdited() bool {
+	return slices.Contains(fp.edited[:], true)
+}
+
+// textBox draws the i-th field, and returns whether the edition was validated with Enter
+func (fp *guiFieldsPanel) textBox(bounds rl.Rectangle, i int) bool {
+	if raygui.TextBox(bounds, &fp.fields[i], 16, fp.editing[i]) {
+		fp.editing[i] = !fp.editing[i]
+		if fp.editing[i] {
+			fp.edited[i] = true
+			fp.err = ""
+		}
+		return !fp.editing[i] && keyboard.Pressed == rl.KeyEnter
+	}
+	return false
+}
+
+// parseFloat returns the finite number of the i-th field, name is the field name in the error
+func (fp *guiFieldsPanel) parseFloat(i int, name string) (float64, error) {
+	v, err := strconv.ParseFloat(strings.TrimSpace(fp.fields[i]), 32)
+	if err != nil || math.IsNaN(v) || math.IsInf(v, 0) {
+		return 0, fmt.Errorf("invalid %s: %q", name, fp.fields[i])
+	}
+	return v, nil
+}
+
+// parseInt returns the integer of the i-th field, name is the field name in the error
+func (fp *guiFieldsPanel) parseInt(i int, name string) (int, error) {
+	v, err := strconv.ParseInt(strings.TrimSpace(fp.fields[i]), 10, 32)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s: %q", name, fp.fields[i])
+	}
+	return int(v), nil
+}
+
+// drawError draws why the last input was not applied, if any, at y
+func (fp *guiFieldsPanel) drawError(bar rl.Rectangle, y float32) {
+	if fp.err != "" {
+		text.DrawText(rl.NewRectangle(bar.X, y, bar.Width, 50), fp.err, text.Options{Font: font, Size: 20, Color: colors.Red500})
+	}
+}
+
+// Numeric transform panel fields
+const (
+	transformFieldX = iota
+	transformFieldY
+	transformFieldRot
+	transformFieldDX
+	transformFieldDY
+	transformFieldCols
+	transformFieldRows
+	transformFieldSpacingX
+	transformFieldSpacingY
+	transformFieldCount
+)
+
+// transformPanelHeight is the height of the numeric transform panel, in px
+const transformPanelHeight = 370.0
+
+// guiTransformPanel is the details bar panel to move and rotate the selection to precise values,
+// and to duplicate it as an array
+type guiTransformPanel struct {
+	guiFieldsPanel
+}
+
+// reset stops editing the fields and resets them to the selection bounds origin, without rotation
+// nor move, and to a single copy side by side
+func (tp *guiTransformPanel) reset() {
+	tp.guiFieldsPanel.reset()
+	tp.fields[transformFieldX] = ""
+	tp.fields[transformFieldY] = ""
+	tp.fields[transformFieldRot] = "0"
+	tp.fields[transformFieldDX] = "0"
+	tp.fields[transformFieldDY] = "0"
+	tp.fields[transformFieldCols] = "2"
+	tp.fields[transformFieldRows] = "1"
+}
+
+// parse returns the transformation entered in the fields: the new selection bounds origin and the
+// rotation
+func (tp *guiTransformPanel) parse() (origin rl.Vector2, rot int32, err error) {
+	names := [...]string{"X", "Y", "rotation", "move by X", "move by Y"}
+	var values [len(names)]float64
+	for i, name := range names {
+		if values[i], err = tp.parseFloat(i, name); err != nil {
+			return origin, rot, err
+		}
+	}
+	if values[transformFieldRot] != math.Trunc(values[transformFieldRot]) {
+		return origin, rot, fmt.Errorf("invalid rotation: %q, must be in whole degrees", tp.fields[transformFieldRot])
+	}
+	origin = vec2(
+		float32(values[transformFieldX]+values[transformFieldDX]),
+		float32(values[transformFieldY]+values[transformFieldDY]),
+	)
+	return origin, int32(values[transformFieldRot]), nil
+}
+
+// parseArray returns the array layout entered in the fields
+func (tp *guiTransformPanel) parseArray() (pat ArrayPattern, err error) {
+	if pat.Cols, err = tp.parseInt(transformFieldCols, "columns"); err != nil {
+		return pat, err
+	}
+	if pat.Rows, err = tp.parseInt(transformFieldRows, "rows"); err != nil {
+		return pat, err
+	}
+	spacingX, err := tp.parseFloat(transformFieldSpacingX, "spacing X")
+	if err != nil {
+		return pat, err
+	}
+	spacingY, err := tp.parseFloat(transformFieldSpacingY, "spacing Y")
+	if err != nil {
+		return pat, err
+	}
+	pat.Spacing = vec2(float32(spacingX), float32(spacingY))
+	return pat, nil
+}
+
+func (tp *guiTransformPanel) updateAndDraw(bar rl.Rectangle) (action Action) {
+	// follow the selection bounds when not edited
+	if !tp.editing[transformFieldX] {
+		tp.fields[transformFieldX] = fmt.Sprint(selection.Bounds.X)
+	}
+	if !tp.editing[transformFieldY] {
+		tp.fields[transformFieldY] = fmt.Sprint(selection.Bounds.Y)
 	}
+	if !tp.edited[transformFieldSpacingX] && !tp.edited[transformFieldSpacingY] {
+		// copies side by side, like the array mode
+		tp.fields[transformFieldSpacingX] = fmt.Sprint(max(1, math32.Ceil(selection.Bounds.Width)))
+		tp.fields[transformFieldSpacingY] = fmt.Sprint(max(1, math32.Ceil(selection.Bounds.Height)))
+	}
+
+	textOpts := text.Options{Font: font, Size: 24, Color: colors.Gray700}
+	pTextSize := raygui.GetStyle(raygui.DEFAULT, raygui.TEXT_SIZE)
+	raygui.SetStyle(raygui.DEFAULT, raygui.TEXT_SIZE, 24)
+	if selection.mode != SelectionNormal && selection.mode != SelectionSingleTextBox {
+		raygui.Disable()
+	}
+
+	text.DrawText(rl.NewRectangle(bar.X, bar.Y, bar.Width, 30), "Transform selection", textOpts)
+
+	// labels on the left, 1 or 2 fields on the right
+	labelWidth := float32(100)
+	fieldsX := bar.X + labelWidth
+	fieldsWidth := bar.Width - labelWidth
+	halfWidth := (fieldsWidth - 10) / 2
+	validated := false
+
+	y := bar.Y + 40
+	text.DrawText(rl.NewRectangle(bar.X, y, labelWidth, 30), "X / Y", textOpts)
+	validated = tp.textBox(rl.NewRectangle(fieldsX, y, halfWidth, 30), transformFieldX) || validated
+	validated = tp.textBox(rl.NewRectangle(fieldsX+halfWidth+10, y, halfWidth, 30), transformFieldY) || validated
+
+	y += 40
+	text.DrawText(rl.NewRectangle(bar.X, y, labelWidth, 30), "Rotate", textOpts)
+	validated = tp.textBox(rl.NewRectangle(fieldsX, y, fieldsWidth, 30), transformFieldRot) || validated
+
+	y += 40
+	text.DrawText(rl.NewRectangle(bar.X, y, labelWidth, 30), "Move by", textOpts)
+	validated = tp.textBox(rl.NewRectangle(fieldsX, y, halfWidth, 30), transformFieldDX) || validated
+	validated = tp.textBox(rl.NewRectangle(fieldsX+halfWidth+10, y, halfWidth, 30), transformFieldDY) || validated
+
+	y += 40
+	if raygui.Button(rl.NewRectangle(bar.X, y, bar.Width, 30), "Apply (Enter)") || validated {
+		origin, rot, err := tp.parse()
+		if err != nil {
+			log.Info("details bar transform panel", "reason", err)
+			tp.err = err.Error()
+		} else {
+			action = GuiActionTransformSelection{Origin: origin, Rot: rot}
+		}
+	}
+
+	y += 40
+	validated = false
+	text.DrawText(rl.NewRectangle(bar.X, y, labelWidth, 30), "Array", textOpts)
+	validated = tp.textBox(rl.NewRectangle(fieldsX, y, halfWidth, 30), transformFieldCols) || validated
+	validated = tp.textBox(rl.NewRectangle(fieldsX+halfWidth+10, y, halfWidth, 30), transformFieldRows) || validated
+
+	y += 40
+	text.DrawText(rl.NewRectangle(bar.X, y, labelWidth, 30), "Spacing", textOpts)
+	validated = tp.textBox(rl.NewRectangle(fieldsX, y, halfWidth, 30), transformFieldSpacingX) || validated
+	validated = tp.textBox(rl.NewRectangle(fieldsX+halfWidth+10, y, halfWidth, 30), transformFieldSpacingY) || validated
+
+	y += 40
+	if raygui.Button(rl.NewRectangle(bar.X, y, bar.Width, 30), "Duplicate as array") || validated {
+		pat, err := tp.parseArray()
+		if err != nil {
+			log.Info("details bar transform panel", "reason", err)
+			tp.err = err.Error()
+		} else {
+			action = GuiActionArraySelection{Pattern: pat}
+		}
+	}
+
+	tp.drawError(bar, y+40)
+
+	raygui.Enable()
+	raygui.SetStyle(raygui.DEFAULT, raygui.TEXT_SIZE, pTextSize)
 	return action
 }
 
-type guiStatusbar struct{}
+// Modifiers panel fields
+const (
+	modifiersFieldClock = iota
+	modifiersFieldShards
+	modifiersFieldSomersloops
+	modifiersFieldCount
+)
+
+// modifiersPanelHeight is the height of the modifiers panel, in px
+const modifiersPanelHeight = 250.0
+
+// guiModifiersPanel is the details bar panel to set the clock speed, power shards and somersloops
+// of the selected clockable buildings, its fields follow the selection until edited
+type guiModifiersPanel struct {
+	guiFieldsPanel
+}
+
+// parse returns the modifiers entered in the fields
+func (mp *guiModifiersPanel) parse() (mods Modifiers, err error) {
+	clock, err := mp.parseFloat(modifiersFieldClock, "clock speed")
+	if err != nil {
+		return mods, err
+	}
+	shards, err := mp.parseInt(modifiersFieldShards, "power shards")
+	if err != nil {
+		return mods, err
+	}
+	sloops, err := mp.parseInt(modifiersFieldSomersloops, "somersloops")
+	if err != nil {
+		return mods, err
+	}
+	return Modifiers{Clock: float32(clock), Shards: int32(shards), Somersloops: int32(sloops)}, nil
+}
+
+// updateAndDraw draws the panel, until edited the fields show current, the modifiers of the first
+// selected clockable building
+func (mp *guiModifiersPanel) updateAndDraw(bar rl.Rectangle, current Modifiers) (action Action) {
+	if !mp.isEdited() {
+		mp.fields[modifiersFieldClock] = fmt.Sprint(current.ClockSpeed())
+		mp.fields[modifiersFieldShards] = fmt.Sprint(current.Shards)
+		mp.fields[modifiersFieldSomersloops] = fmt.Sprint(current.Somersloops)
+	}
+
+	textOpts := text.Options{Font: font, Size: 24, Color: colors.Gray700}
+	pTextSize := raygui.GetStyle(raygui.DEFAULT, raygui.TEXT_SIZE)
+	raygui.SetStyle(raygui.DEFAULT, raygui.TEXT_SIZE, 24)
+	if selection.mode != SelectionNormal {
+		raygui.Disable()
+	}
+
+	text.DrawText(rl.NewRectangle(bar.X, bar.Y, bar.Width, 30), "Clock speed", textOpts)
+
+	labelWidth := float32(160)
+	fieldsX := bar.X + labelWidth
+	fieldsWidth := bar.Width - labelWidth
+	validated := false
+
+	y := bar.Y + 40
+	for i, label := range [modifiersFieldCount]string{"Clock (%)", "Shards", "Somersloops"} {
+		text.DrawText(rl.NewRectangle(bar.X, y, labelWidth, 30), label, textOpts)
+		validated = mp.textBox(rl.NewRectangle(fieldsX, y, fieldsWidth, 30), i) || validated
+		y += 40
+	}
+
+	if raygui.Button(rl.NewRectangle(bar.X, y, bar.Width, 30), "Set (Enter)") || validated {
+		mods, err := mp.parse()
+		if err != nil {
+			log.Info("details bar modifiers panel", "reason", err)
+			mp.err = err.Error()
+		} else {
+			action = GuiActionSetModifiers{Mods: mods}
+		}
+	}
+
+	mp.drawError(bar, y+40)
+
+	raygui.Enable()
+	raygui.SetStyle(raygui.DEFAULT, raygui.TEXT_SIZE, pTextSize)
+	return action
+}
+
+// Duration a status bar notification is shown, in seconds
+const notificationDuration = 10
+
+type guiStatusbar struct {
+	// Notification message, shown above the status bar until notificationEnd
+	notification string
+	// Whether the notification reports an error
+	notificationIsErr bool
+	// Time the notification is hidden at (see [Animations.Timer])
+	notificationEnd float32
+}
+
+// notify shows a message above the status bar, without blocking the application
+func (sb *guiStatusbar) notify(msg string, isErr bool) {
+	sb.notification = msg
+	sb.notificationIsErr = isErr
+	sb.notificationEnd = animations.Timer + notificationDuration
+}
+
+// drawNotification draws the notification, if any, at the bottom right of the scene
+func (sb *guiStatusbar) drawNotification(bar rl.Rectangle) {
+	if sb.notification == "" || animations.Timer > sb.notificationEnd {
+		return
+	}
+	const fontSize, pad = 20, 10
+	lines := strings.Split(sb.notification, "\n")
+	var width float32
+	for _, line := range lines {
+		width = max(width, rl.MeasureTextEx(font, line, fontSize, 1).X)
+	}
+	height := float32(len(lines)) * fontSize
+	box := rl.NewRectangle(bar.X+bar.Width-width-3*pad, bar.Y-height-3*pad, width+2*pad, height+2*pad)
+	color := colors.Gray700
+	if sb.notificationIsErr {
+		color = colors.Red500
+	}
+	rl.DrawRectangleRec(box, colors.White)
+	rl.DrawRectangleLinesEx(box, 2, color)
+	for i, line := range lines {
+		rl.DrawTextEx(font, line, vec2(box.X+pad, box.Y+pad+float32(i)*fontSize), fontSize, 1, color)
+	}
+}
 
 func (sb *guiStatusbar) updateAndDraw() Action {
 	bar := rl.NewRectangle(0, dims.Screen.Y-StatusBarHeight, dims.Screen.X, StatusBarHeight)
@@ -472,6 +1220,10 @@ func (sb *guiStatusbar) updateAndDraw() Action {
 	// left aligned text
 	lpos := bar.TopLeft().Add(vec2(5, 5))
 	ltext := fmt.Sprintf("FPS=% 3d | %12v | Building Draws=%d | Path Draws=%d", int(rl.GetFPS()), app.Mode, app.drawCounts.Buildings, app.drawCounts.Paths)
+	if app.Mode == ModeSelection && selection.mode == SelectionArray {
+		pat := selection.transform.array
+		ltext += fmt.Sprintf(" | Array %dx%d, spacing %vx%v, rotation %d°", pat.Cols, pat.Rows, math32.Abs(pat.Spacing.X), math32.Abs(pat.Spacing.Y), pat.Rot)
+	}
 	rl.DrawTextEx(font, ltext, lpos, 24, 1, colors.Gray700)
 
 	// right aligned text
@@ -480,6 +1232,7 @@ func (sb *guiStatusbar) updateAndDraw() Action {
 	rpos := bar.TopRight().Add(vec2(-5-width, 5))
 	rl.DrawTextEx(font, rtext, rpos, 24, 1, colors.Gray700)
 
+	sb.drawNotification(bar)
 	return nil
 }
 
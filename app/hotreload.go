@@ -0,0 +1,138 @@
+// hotreload - Reload of the definitions files when they change on disk
+
+package app
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/bonoboris/satisfied/log"
+	sc "github.com/bonoboris/satisfied/scene"
+)
+
+// Interval between 2 checks of the definitions files, in seconds
+const hotReloadInterval = 1
+
+// hotReload holds the definitions files watching state
+var hotReload HotReload
+
+// HotReload watches the definitions files (the definitions directory ones and the packs) and
+// reloads the definitions when they change
+type HotReload struct {
+	// Time of the last check (see [Animations.Timer])
+	lastTime float32
+	// Watched files at the last check
+	files []fileStamp
+}
+
+// fileStamp identifies a version of a file
+type fileStamp struct {
+	Path    string
+	ModTime time.Time
+	Size    int64
+}
+
+func (hr HotReload) traceState(key, val string) {
+	if key != "" && val != "" {
+		log.Trace("hotReload", key, val, "lastTime", hr.lastTime, "files", hr.files)
+	} else {
+		log.Trace("hotReload", "lastTime", hr.lastTime, "files", hr.files)
+	}
+}
+
+// Reset resets the check timer and the watched files versions, must be called once the
+// definitions are loaded
+func (hr *HotReload) Reset() {
+	hr.lastTime = animations.Timer
+	hr.files = watchedDefsFiles()
+	hr.traceState("after", "Reset")
+}
+
+// watchedDefsFiles returns the current versions of the definitions files, missing files are left out
+func watchedDefsFiles() []fileStamp {
+	var paths []string
+	if defsDir != "" {
+		paths = append(paths, filepath.Join(defsDir, buildingDefsFile), filepath.Join(defsDir, pathDefsFile))
+	}
+	if files, err := packFiles(); err == nil {
+		paths = append(paths, files...)
+	}
+	stamps := make([]fileStamp, 0, len(paths))
+	for _, path := range paths {
+		if info, err := os.Stat(path); err == nil {
+			stamps = append(stamps, fileStamp{Path: path, ModTime: info.ModTime(), Size: info.Size()})
+		}
+	}
+	return stamps
+}
+
+// Update reloads the definitions if a definitions file has been added, removed or modified since
+// the last check, every [hotReloadInterval], and returns the action to be performed.
+//
+// The definitions are not reloaded while recording, the recording could not be replayed without
+// the files.
+//
+// Depends on [Animations]
+func (hr *HotReload) Update() Action {
+	if replay.Replaying() || animations.Timer-hr.lastTime < hotReloadInterval {
+		return nil
+	}
+	hr.lastTime = animations.Timer
+	files := watchedDefsFiles()
+	if slices.Equal(files, hr.files) {
+		return nil
+	}
+	hr.files = files
+	if replay.Recording() {
+		log.Warn("defs not reloaded", "reason", "recording")
+		gui.Statusbar.notify("Definitions changed, they are not reloaded while recording", true)
+		return nil
+	}
+	return hr.doReload()
+}
+
+// doReload reloads the definitions directory and packs files, and replaces the definitions.
+//
+// Objects are remapped by class, objects whose class is no longer defined keep their previous
+// definition until the application is restarted. Errors are notified in the status bar.
+func (hr *HotReload) doReload() Action {
+	hr.traceState("before", "doReload")
+	base, basePaths := baseBuildingDefs, basePathDefs
+	if defsDir != "" {
+		var err error
+		if base, basePaths, err = readDefsDir(defsDir); err != nil {
+			log.Error("cannot reload defs directory", "dir", defsDir, "err", err)
+			gui.Statusbar.notify("Cannot reload the definitions:\n"+RemoveQuotes(err.Error()), true)
+			return nil
+		}
+	}
+	buildings, paths, packsErr := readPacks(base, basePaths)
+
+	// in progress operations hold copies of objects with the previous definitions
+	action := app.doSwitchMode(ModeNormal, ResetAll())
+	remap := sc.NewDefsRemap(buildings, paths)
+	scene.RemapDefs(remap)
+	compare.remapDefs(remap)
+	var missing []string
+	buildingDefs, pathDefs, missing = remap.Apply()
+	baseBuildingDefs, basePathDefs = base, basePaths
+	gui.Sidebar.init()
+	log.Info("defs reloaded", "buildingDefs", len(buildingDefs), "pathDefs", len(pathDefs), "missing", missing)
+
+	switch {
+	case packsErr != nil:
+		gui.Statusbar.notify("Definitions reloaded, some packs are not loaded:\n"+RemoveQuotes(packsErr.Error()), true)
+	case len(missing) > 0:
+		msg := fmt.Sprintf("Definitions reloaded, removed classes kept until restart: %s", strings.Join(missing, ", "))
+		gui.Statusbar.notify(msg, true)
+	default:
+		msg := fmt.Sprintf("Definitions reloaded: %d buildings, %d paths", len(buildingDefs), len(pathDefs))
+		gui.Statusbar.notify(msg, false)
+	}
+	hr.traceState("after", "doReload")
+	return action
+}
@@ -0,0 +1,78 @@
+// input - raw per-frame inputs, polled from raylib or read from a replay (see replay.go)
+
+package app
+
+import (
+	rl "github.com/gen2brain/raylib-go/raylib"
+)
+
+// Current frame raw inputs
+var input Input
+
+// InputFrame holds the raw inputs of a frame.
+//
+// All the inputs used by the update step ([Animations], [Dims], [Mouse] and [Keyboard]) are read
+// from here rather than from raylib, so that they can be recorded and replayed.
+type InputFrame struct {
+	// Elapsed time since window initialization (seconds)
+	Time float64
+	// Screen size
+	Screen rl.Vector2
+	// Mouse position (in screen coordinates)
+	MousePos rl.Vector2
+	// Mouse wheel movement
+	Wheel float32 `json:",omitempty"`
+	// Left, middle and right mouse buttons down state
+	Buttons [3]bool
+	// Modifier keys down state
+	Shift, Ctrl, Alt bool `json:",omitempty"`
+	// Key pressed this frame (see [rl.GetKeyPressed])
+	KeyPressed int32 `json:",omitempty"`
+	// Whether the last pressed key (see [Keyboard]) is still down
+	KeyDown bool `json:",omitempty"`
+	// Whether the last pressed key (see [Keyboard]) is repeated this frame
+	KeyRepeat bool `json:",omitempty"`
+}
+
+// Input holds the current frame raw inputs
+type Input struct {
+	Frame InputFrame
+}
+
+// Update reads the current frame inputs, from the replay when replaying, from raylib otherwise.
+//
+// Must be called first in the update step.
+func (in *Input) Update() {
+	if replay.Replaying() {
+		in.Frame = replay.NextFrame()
+	} else {
+		in.Frame = pollInputFrame()
+	}
+	replay.RecordFrame(in.Frame)
+}
+
+// pollInputFrame reads the current frame inputs from raylib
+//
+// Depends on [Keyboard] last pressed key
+func pollInputFrame() InputFrame {
+	f := InputFrame{
+		Time:     rl.GetTime(),
+		Screen:   vec2(float32(rl.GetScreenWidth()), float32(rl.GetScreenHeight())),
+		MousePos: rl.GetMousePosition(),
+		Wheel:    rl.GetMouseWheelMove(),
+		Buttons: [3]bool{
+			rl.IsMouseButtonDown(rl.MouseLeftButton),
+			rl.IsMouseButtonDown(rl.MouseMiddleButton),
+			rl.IsMouseButtonDown(rl.MouseRightButton),
+		},
+		Shift:      rl.IsKeyDown(rl.KeyLeftShift) || rl.IsKeyDown(rl.KeyRightShift),
+		Ctrl:       rl.IsKeyDown(rl.KeyLeftControl) || rl.IsKeyDown(rl.KeyRightControl),
+		Alt:        rl.IsKeyDown(rl.KeyLeftAlt) || rl.IsKeyDown(rl.KeyRightAlt),
+		KeyPressed: rl.GetKeyPressed(),
+	}
+	if keyboard.down != rl.KeyNull {
+		f.KeyDown = rl.IsKeyDown(keyboard.down)
+		f.KeyRepeat = rl.IsKeyPressedRepeat(keyboard.down)
+	}
+	return f
+}
@@ -37,17 +37,17 @@ func (kb *Keyboard) traceState() {
 
 // Update keyboard state
 //
-// Do not depends on any other state
+// Depends on [Input]
 func (kb *Keyboard) Update() {
-	kb.Shift = rl.IsKeyDown(rl.KeyLeftShift) || rl.IsKeyDown(rl.KeyRightShift)
-	kb.Ctrl = rl.IsKeyDown(rl.KeyLeftControl) || rl.IsKeyDown(rl.KeyRightControl)
-	kb.Alt = rl.IsKeyDown(rl.KeyLeftAlt) || rl.IsKeyDown(rl.KeyRightAlt)
+	kb.Shift = input.Frame.Shift
+	kb.Ctrl = input.Frame.Ctrl
+	kb.Alt = input.Frame.Alt
 
 	// reset pressed key
 	kb.Pressed = rl.KeyNull
 
 	// not KeyNull only on the first frame a key is pressed
-	key := rl.GetKeyPressed()
+	key := input.Frame.KeyPressed
 
 	if key != rl.KeyNull {
 		// key is pressed, set Pressed and down
@@ -57,9 +57,9 @@ func (kb *Keyboard) Update() {
 		kb.traceState()
 	} else if kb.down != rl.KeyNull {
 		// no new key pressed and a key was down, check if it's still down
-		if rl.IsKeyDown(kb.down) {
+		if input.Frame.KeyDown {
 			// key is still down, check if it's a repeat
-			if rl.IsKeyPressedRepeat(kb.down) {
+			if input.Frame.KeyRepeat {
 				switch kb.down {
 				case rl.KeyLeftControl, rl.KeyRightControl, rl.KeyLeftShift, rl.KeyRightShift, rl.KeyLeftAlt, rl.KeyRightAlt:
 					// don't log repeats of modifiers
@@ -126,9 +126,28 @@ const (
 	BindingUndo
 	BindingRedo
 	BindingDelete
+	// before the bindings of the same keys without alt (first match wins)
+	BindingAlignLeft
+	BindingAlignRight
+	BindingAlignTop
+	BindingAlignBottom
+	BindingAlignCenterX
+	BindingAlignCenterY
+	BindingDistributeX
+	BindingDistributeY
 	BindingDuplicate
+	BindingArray
 	BindingRotate
+	BindingRotateStep
+	BindingMirrorH
+	BindingMirrorV
+	BindingSpline
+	BindingRoute
+	BindingConfirm
 	BindingDrag
+	BindingConnected
+	BindingUpstream
+	BindingDownstream
 	BindingUp
 	BindingDown
 	BindingLeft
@@ -142,22 +161,40 @@ const (
 var keyBindings = [...][2]keyBindingDef{
 	// defines as an array for performance and we are using the index syntax for readability and correctness
 	// this is not a map
-	BindingEscape:    {{code: rl.KeyEscape}},
-	BindingDelete:    {{code: rl.KeyDelete}, {code: rl.KeyX}},
-	BindingSave:      {{code: rl.KeyS, ctrl: Yes, shift: No}},
-	BindingSaveAs:    {{code: rl.KeyS, ctrl: Yes, shift: Yes}},
-	BindingUndo:      {{code: rl.KeyZ, ctrl: Yes, shift: No}},
-	BindingRedo:      {{code: rl.KeyY, ctrl: Yes}, {code: rl.KeyZ, ctrl: Yes, shift: Yes}},
-	BindingDuplicate: {{code: rl.KeyD}},
-	BindingRotate:    {{code: rl.KeyR}},
-	BindingDrag:      {{code: rl.KeyV}},
-	BindingUp:        {{code: rl.KeyUp}},
-	BindingDown:      {{code: rl.KeyDown}},
-	BindingLeft:      {{code: rl.KeyLeft}},
-	BindingRight:     {{code: rl.KeyRight}},
-	BindingZoomIn:    {{code: rl.KeyEqual, shift: Yes}, {code: rl.KeyKpAdd}},
-	BindingZoomOut:   {{code: rl.KeyMinus}, {code: rl.KeyKpSubtract}},
-	BindingZoomReset: {{code: rl.KeyEqual, shift: No}, {code: rl.KeyKp0}},
+	BindingEscape:       {{code: rl.KeyEscape}},
+	BindingDelete:       {{code: rl.KeyDelete}, {code: rl.KeyX}},
+	BindingSave:         {{code: rl.KeyS, ctrl: Yes, shift: No}},
+	BindingSaveAs:       {{code: rl.KeyS, ctrl: Yes, shift: Yes}},
+	BindingUndo:         {{code: rl.KeyZ, ctrl: Yes, shift: No}},
+	BindingRedo:         {{code: rl.KeyY, ctrl: Yes}, {code: rl.KeyZ, ctrl: Yes, shift: Yes}},
+	BindingDuplicate:    {{code: rl.KeyD, shift: No}},
+	BindingArray:        {{code: rl.KeyD, shift: Yes}},
+	BindingRotate:       {{code: rl.KeyR, shift: No}},
+	BindingRotateStep:   {{code: rl.KeyR, shift: Yes}},
+	BindingMirrorH:      {{code: rl.KeyH, shift: No}},
+	BindingMirrorV:      {{code: rl.KeyH, shift: Yes}},
+	BindingSpline:       {{code: rl.KeyS, ctrl: No}},
+	BindingRoute:        {{code: rl.KeyA, ctrl: No}},
+	BindingConfirm:      {{code: rl.KeyEnter, ctrl: No}, {code: rl.KeyKpEnter, ctrl: No}},
+	BindingDrag:         {{code: rl.KeyV}},
+	BindingConnected:    {{code: rl.KeyC, ctrl: No, alt: No}},
+	BindingUpstream:     {{code: rl.KeyI, ctrl: No}},
+	BindingDownstream:   {{code: rl.KeyO, ctrl: No}},
+	BindingAlignLeft:    {{code: rl.KeyLeft, alt: Yes}},
+	BindingAlignRight:   {{code: rl.KeyRight, alt: Yes}},
+	BindingAlignTop:     {{code: rl.KeyUp, alt: Yes}},
+	BindingAlignBottom:  {{code: rl.KeyDown, alt: Yes}},
+	BindingAlignCenterX: {{code: rl.KeyC, alt: Yes, shift: No}},
+	BindingAlignCenterY: {{code: rl.KeyC, alt: Yes, shift: Yes}},
+	BindingDistributeX:  {{code: rl.KeyH, alt: Yes}},
+	BindingDistributeY:  {{code: rl.KeyV, alt: Yes}},
+	BindingUp:           {{code: rl.KeyUp}},
+	BindingDown:         {{code: rl.KeyDown}},
+	BindingLeft:         {{code: rl.KeyLeft}},
+	BindingRight:        {{code: rl.KeyRight}},
+	BindingZoomIn:       {{code: rl.KeyEqual, shift: Yes}, {code: rl.KeyKpAdd}},
+	BindingZoomOut:      {{code: rl.KeyMinus}, {code: rl.KeyKpSubtract}},
+	BindingZoomReset:    {{code: rl.KeyEqual, shift: No}, {code: rl.KeyKp0}},
 }
 
 func GetKeyName(key int32) string {
@@ -0,0 +1,205 @@
+// lint - Design rules issues of the scene (see [sc.Lint]), their panel and drawing
+
+package app
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/bonoboris/satisfied/colors"
+	"github.com/bonoboris/satisfied/log"
+	sc "github.com/bonoboris/satisfied/scene"
+	"github.com/bonoboris/satisfied/text"
+	"github.com/gen2brain/raylib-go/raygui"
+	rl "github.com/gen2brain/raylib-go/raylib"
+)
+
+const (
+	// Issues panel width in px, docked left of the details bar when open
+	IssuesWidth = 520.0
+	// Issues panel line height, in px
+	issuesLineHeight = 30.0
+)
+
+// lint holds the scene design rules issues
+var lint Lint
+
+// Lint holds the scene design rules issues, computed when the scene or the enabled rules change
+type Lint struct {
+	// Scene issues, at revision
+	issues []sc.LintIssue
+	// Scene revision when issues were computed, -1 to recompute them
+	revision int
+}
+
+// Reset forces the issues computation, must be called when the scene is replaced
+func (l *Lint) Reset() { *l = Lint{revision: -1} }
+
+// Issues returns the scene issues of the enabled rules, recomputed if the scene has changed
+func (l *Lint) Issues() []sc.LintIssue {
+	if l.revision != scene.Revision() {
+		l.issues = sc.Lint(scene.ObjectCollection, gui.Issues.disabled)
+		l.revision = scene.Revision()
+		log.Debug("lint.issues", "issues", len(l.issues), "disabled", gui.Issues.disabled)
+	}
+	return l.issues
+}
+
+// Draw circles the issues positions, when the issues panel is open
+func (l *Lint) Draw() {
+	if !gui.Issues.open {
+		return
+	}
+	for _, issue := range l.Issues() {
+		if dims.ExWorld.CheckCollisionPoint(issue.Pos) {
+			rl.DrawCircleLinesV(issue.Pos, 1.5, colors.Amber700)
+		}
+	}
+}
+
+// guiIssues is the design rules panel: the rules, enabled or not, and the issues of the scene
+type guiIssues struct {
+	// Whether the panel is open
+	open bool
+	// Names of the disabled rules
+	disabled []string
+	// Rules list scroll index
+	rulesScroll int32
+	// Number of issues lines scrolled out of view
+	issuesScroll int
+}
+
+// bounds returns the panel bounds
+func (p *guiIssues) bounds() rl.Rectangle {
+	return rl.NewRectangle(
+		dims.Screen.X-DetailsBarWidth-IssuesWidth,
+		TopbarHeight,
+		IssuesWidth,
+		dims.Screen.Y-TopbarHeight-StatusBarHeight)
+}
+
+// doToggle opens or closes the panel, the planner panel is closed when it opens
+func (p *guiIssues) doToggle() Action {
+	p.open = !p.open
+	if p.open && gui.Planner.open {
+		gui.Planner.doToggle()
+	}
+	log.Debug("issues.doToggle", "open", p.open)
+	return nil
+}
+
+// doToggleRule enables or disables the rule of the given name
+func (p *guiIssues) doToggleRule(rule string) Action {
+	if idx := slices.Index(p.disabled, rule); idx >= 0 {
+		p.disabled = slices.Delete(p.disabled, idx, idx+1)
+	} else {
+		p.disabled = append(p.disabled, rule)
+	}
+	log.Debug("issues.doToggleRule", "rule", rule, "disabled", p.disabled)
+	lint.revision = -1
+	p.issuesScroll = 0
+	return nil
+}
+
+// doSelectIssue selects the object of an issue and centers the view on the issue position
+func (p *guiIssues) doSelectIssue(obj Object, pos rl.Vector2) Action {
+	var sel ObjectSelection
+	switch {
+	case obj.Type == TypeBuilding && obj.Idx >= 0 && obj.Idx < len(scene.Buildings):
+		sel.BuildingIdxs = []int{obj.Idx}
+	case obj.Type == TypePath && obj.Idx >= 0 && obj.Idx < len(scene.Paths):
+		sel.PathIdxs = []PathSel{{Idx: obj.Idx, Start: true, End: true}}
+	case obj.Type == TypeTextBox && obj.Idx >= 0 && obj.Idx < len(scene.TextBoxes):
+		sel.TextBoxIdxs = []int{obj.Idx}
+	default:
+		log.Warn("issues.doSelectIssue", "reason", "invalid object", "object", obj)
+		return nil
+	}
+	log.Debug("issues.doSelectIssue", "object", obj, "pos", pos)
+	camera.doRestore(CameraState{Center: pos, Zoom: camera.Zoom()})
+	sel.RecomputeBounds(scene.ObjectCollection)
+	return selection.doInitSelection(sel)
+}
+
+func (p *guiIssues) updateAndDraw() (action Action) {
+	if !p.open {
+		return nil
+	}
+	panel := p.bounds()
+	rl.DrawRectangleRec(panel, colors.Gray100)
+	rl.DrawLineV(panel.TopLeft(), panel.BottomLeft(), colors.Gray300)
+	rl.DrawLineV(panel.TopRight(), panel.BottomRight(), colors.Gray300)
+
+	pTextSize := raygui.GetStyle(raygui.DEFAULT, raygui.TEXT_SIZE)
+	raygui.SetStyle(raygui.DEFAULT, raygui.TEXT_SIZE, 20)
+	titleOpts := text.Options{Font: font, Size: 24, Color: colors.Gray700}
+
+	// padded dimensions
+	bar := rl.NewRectangle(panel.X+20, panel.Y+20, panel.Width-40, panel.Height-40)
+	y := bar.Y
+
+	text.DrawText(rl.NewRectangle(bar.X, y, bar.Width-40, 30), "Design rules", titleOpts)
+	if raygui.Button(rl.NewRectangle(bar.X+bar.Width-30, y, 30, 30), raygui.IconText(raygui.ICON_CROSS, "")) {
+		log.Debug("issues close clicked")
+		action = GuiActionToggleIssues{}
+	}
+	y += 40
+
+	// rules, clicking one enables or disables it
+	rules := sc.LintRules()
+	entries := make([]string, len(rules))
+	for i, rule := range rules {
+		icon := raygui.ICON_OK_TICK
+		if slices.Contains(p.disabled, rule.Name) {
+			icon = raygui.ICON_BOX
+		}
+		entries[i] = raygui.IconText(icon, rule.Name)
+	}
+	listHeight := float32(min(len(rules), 7)) * issuesLineHeight
+	if idx := raygui.ListView(rl.NewRectangle(bar.X, y, bar.Width, listHeight), strings.Join(entries, ";"), &p.rulesScroll, -1); idx >= 0 && int(idx) < len(rules) {
+		log.Debug("issues rule clicked", "rule", rules[idx].Name)
+		action = GuiActionToggleLintRule{Rule: rules[idx].Name}
+	}
+	y += listHeight + 10
+
+	action = orAction(action, p.drawIssues(rl.NewRectangle(bar.X, y, bar.Width, bar.Y+bar.Height-y)))
+
+	raygui.SetStyle(raygui.DEFAULT, raygui.TEXT_SIZE, pTextSize)
+	return action
+}
+
+// drawIssues draws the issues list in bounds, scrolled with the mouse wheel, clicking an issue
+// selects its object
+func (p *guiIssues) drawIssues(bounds rl.Rectangle) (action Action) {
+	rl.DrawLineV(bounds.TopLeft(), bounds.TopRight(), colors.Gray300)
+	bounds.Y += 10
+	bounds.Height -= 10
+	issues := lint.Issues()
+	if len(issues) == 0 {
+		text.DrawText(bounds, "No issues", text.Options{Font: font, Size: 20, Color: colors.Gray500})
+		return nil
+	}
+	text.DrawText(rl.NewRectangle(bounds.X, bounds.Y, bounds.Width, issuesLineHeight),
+		fmt.Sprintf("%d issues", len(issues)), text.Options{Font: font, Size: 20, Color: colors.Gray500})
+	bounds.Y += issuesLineHeight
+	bounds.Height -= issuesLineHeight
+
+	visible := max(int(bounds.Height/issuesLineHeight), 1)
+	if rl.CheckCollisionPointRec(input.Frame.MousePos, bounds) && input.Frame.Wheel != 0 {
+		p.issuesScroll -= int(input.Frame.Wheel)
+	}
+	p.issuesScroll = min(max(p.issuesScroll, 0), max(len(issues)-visible, 0))
+
+	pAlignment := raygui.GetStyle(raygui.BUTTON, raygui.TEXT_ALIGNMENT)
+	raygui.SetStyle(raygui.BUTTON, raygui.TEXT_ALIGNMENT, raygui.TEXT_ALIGN_LEFT)
+	for i, issue := range issues[p.issuesScroll:min(p.issuesScroll+visible, len(issues))] {
+		y := bounds.Y + float32(i)*issuesLineHeight
+		if raygui.Button(rl.NewRectangle(bounds.X, y, bounds.Width, issuesLineHeight-4), issue.Msg) {
+			log.Debug("issues issue clicked", "issue", issue)
+			action = GuiActionSelectIssue{Object: issue.Object, Pos: issue.Pos}
+		}
+	}
+	raygui.SetStyle(raygui.BUTTON, raygui.TEXT_ALIGNMENT, pAlignment)
+	return action
+}
@@ -0,0 +1,56 @@
+// model - Aliases of the scene model types (see the scene package)
+
+package app
+
+import (
+	sc "github.com/bonoboris/satisfied/scene"
+)
+
+type (
+	Object              = sc.Object
+	ObjectType          = sc.ObjectType
+	ObjectCollection    = sc.ObjectCollection
+	ObjectSelection     = sc.ObjectSelection
+	PathSel             = sc.PathSel
+	MaskIterator        = sc.MaskIterator
+	PathSelMaskIterator = sc.PathSelMaskIterator
+	Alignment           = sc.Alignment
+	ChainDir            = sc.ChainDir
+	Modifiers           = sc.Modifiers
+	ArrayPattern        = sc.ArrayPattern
+	Building            = sc.Building
+	BuildingDef         = sc.BuildingDef
+	BuildingDefs        = sc.BuildingDefs
+	Path                = sc.Path
+	PathDef             = sc.PathDef
+	PathDefs            = sc.PathDefs
+	Port                = sc.Port
+	TextBox             = sc.TextBox
+)
+
+const (
+	TypeInvalid   = sc.TypeInvalid
+	TypeBuilding  = sc.TypeBuilding
+	TypePath      = sc.TypePath
+	TypePathStart = sc.TypePathStart
+	TypePathEnd   = sc.TypePathEnd
+	TypeTextBox   = sc.TypeTextBox
+)
+
+// drawObject draws a scene object in the given state
+//
+// Noop if [Object.Type] is [TypeInvalid]
+func drawObject(o Object, state DrawState) {
+	switch o.Type {
+	case TypeBuilding:
+		drawBuilding(scene.Buildings[o.Idx], state)
+	case TypePath:
+		drawPath(scene.Paths[o.Idx], state)
+	case TypePathStart:
+		drawPathStart(scene.Paths[o.Idx], state)
+	case TypePathEnd:
+		drawPathEnd(scene.Paths[o.Idx], state)
+	case TypeTextBox:
+		drawTextBox(scene.TextBoxes[o.Idx], state, false)
+	}
+}
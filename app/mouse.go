@@ -47,15 +47,15 @@ func (m *Mouse) traceState() {
 
 // Update mouse state
 //
-// Depends on [Dims] and [Camera]
+// Depends on [Input], [Dims] and [Camera]
 func (m *Mouse) Update() {
-	m.Wheel = rl.GetMouseWheelMove()
+	m.Wheel = input.Frame.Wheel
 	if m.Wheel != 0 {
 		log.Debug("mouse wheel", "wheel", m.Wheel)
 		defer mouse.traceState()
 	}
 
-	newScreenPos := rl.GetMousePosition()
+	newScreenPos := input.Frame.MousePos
 	newInScene := dims.Scene.CheckCollisionPoint(newScreenPos)
 
 	// Disable cursor in scene area
@@ -80,9 +80,9 @@ func (m *Mouse) Update() {
 	m.Pos = camera.WorldPos(newScreenPos)
 	m.SnappedPos = grid.Snap(m.Pos)
 	m.InScene = newInScene
-	m.Left.Update(rl.IsMouseButtonDown(rl.MouseLeftButton))
-	m.Middle.Update(rl.IsMouseButtonDown(rl.MouseMiddleButton))
-	m.Right.Update(rl.IsMouseButtonDown(rl.MouseRightButton))
+	m.Left.Update(input.Frame.Buttons[0])
+	m.Middle.Update(input.Frame.Buttons[1])
+	m.Right.Update(input.Frame.Buttons[2])
 }
 
 // button represents a mouse button state
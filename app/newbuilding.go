@@ -4,6 +4,7 @@ import (
 	"fmt"
 
 	"github.com/bonoboris/satisfied/log"
+	sc "github.com/bonoboris/satisfied/scene"
 	rl "github.com/gen2brain/raylib-go/raylib"
 )
 
@@ -44,6 +45,10 @@ func (nb *NewBuilding) GetAction() (action Action) {
 		return app.doSwitchMode(ModeNormal, ResetAll())
 	case BindingRotate:
 		return nb.doRotate()
+	case BindingMirrorH:
+		return nb.doMirror(false)
+	case BindingMirrorV:
+		return nb.doMirror(true)
 	}
 
 	if !mouse.InScene {
@@ -88,6 +93,24 @@ func (nb *NewBuilding) doRotate() Action {
 	return nil
 }
 
+// doMirror mirrors the new building horizontally, or vertically
+func (nb *NewBuilding) doMirror(vertical bool) Action {
+	nb.traceState("before", "doMirror")
+	log.Debug("newBuilding.doMirror", "vertical", vertical)
+	app.Mode.Assert(ModeNewBuilding)
+	// see [sc.Transform.MirrorH] and [sc.Transform.MirrorV]
+	tr := sc.Transform{Rot: nb.building.Rot % 360, Mirror: nb.building.Mirror}
+	if vertical {
+		tr = tr.MirrorV()
+	} else {
+		tr = tr.MirrorH()
+	}
+	nb.building.Rot, nb.building.Mirror = tr.Rot, tr.Mirror
+	nb.isValid = scene.IsBuildingValid(nb.building, -1)
+	nb.traceState("after", "doMirror")
+	return nil
+}
+
 func (nb *NewBuilding) doPlace() Action {
 	nb.traceState("before", "doPlace")
 	log.Debug("newBuilding.doPlace")
@@ -113,6 +136,8 @@ func (np *NewBuilding) Dispatch(action Action) Action {
 		return np.doMoveTo(action.Pos)
 	case NewBuildingActionRotate:
 		return np.doRotate()
+	case NewBuildingActionMirror:
+		return np.doMirror(action.Vertical)
 	case NewBuildingActionPlace:
 		return np.doPlace()
 
@@ -123,8 +148,8 @@ func (np *NewBuilding) Dispatch(action Action) Action {
 
 func (np NewBuilding) Draw() {
 	if np.isValid {
-		np.building.Draw(DrawNew)
+		drawBuilding(np.building, DrawNew)
 	} else {
-		np.building.Draw(DrawInvalid)
+		drawBuilding(np.building, DrawInvalid)
 	}
 }
@@ -3,6 +3,7 @@ package app
 import (
 	"fmt"
 
+	"github.com/bonoboris/satisfied/colors"
 	"github.com/bonoboris/satisfied/log"
 	rl "github.com/gen2brain/raylib-go/raylib"
 )
@@ -12,17 +13,24 @@ var newPath NewPath
 
 // NewPath represents a new path creation state (corresponding to [ModeNewPath])
 type NewPath struct {
+	// path being placed, in placement order: its end follows the mouse
 	path           Path
 	firstEndPlaced bool
 	reverse        bool
 	isValid        bool
+	// the last vertex is the corner of the L-routed last segment, not placed yet
+	corner bool
+	// routing tool: the path is routed between 2 building ports (see [sc.Scene.Route])
+	route bool
+	// source port, and target port the path is routed to (zero-valued if none), when routing
+	from, to Port
 }
 
 func (np NewPath) traceState(key, val string) {
 	if key != "" && val != "" {
-		log.Trace("newPath", key, val, "path", np.path, "firstEndPlaced", np.firstEndPlaced, "reverse", np.reverse, "isValid", np.isValid)
+		log.Trace("newPath", key, val, "path", np.path, "firstEndPlaced", np.firstEndPlaced, "reverse", np.reverse, "isValid", np.isValid, "corner", np.corner, "route", np.route, "from", np.from, "to", np.to)
 	} else {
-		log.Trace("newPath", "path", np.path, "firstEndPlaced", np.firstEndPlaced, "reverse", np.reverse, "isValid", np.isValid)
+		log.Trace("newPath", "path", np.path, "firstEndPlaced", np.firstEndPlaced, "reverse", np.reverse, "isValid", np.isValid, "corner", np.corner, "route", np.route, "from", np.from, "to", np.to)
 	}
 }
 
@@ -34,11 +42,22 @@ func (np *NewPath) Reset() {
 	np.firstEndPlaced = false
 	np.isValid = false
 	np.reverse = false
+	np.corner = false
+	np.route = false
+	np.from, np.to = Port{}, Port{}
 	np.traceState("after", "Reset")
 }
 
 // GetAction processes inputs in [ModeNewPath], and returns an action to be performed
 //
+// Each click places a vertex, clicking again on the last one or pressing enter adds the path to
+// the scene. Holding shift routes the last segment with an horizontal and a vertical segment.
+//
+// With the routing tool, the first click picks the source port and the second one the target port,
+// the path between them is routed around the buildings and paths.
+//
+// Power lines are a single segment, their ends snap to the building power connectors.
+//
 // See: [GetActionFunc]
 func (np *NewPath) GetAction() Action {
 	app.Mode.Assert(ModeNewPath)
@@ -46,7 +65,7 @@ func (np *NewPath) GetAction() Action {
 
 	switch keyboard.Binding() {
 	case BindingEscape:
-		// escape cancels first end placement then switches to normal mode
+		// escape cancels the path placement then switches to normal mode
 		if np.firstEndPlaced {
 			return np.doInit(np.path.DefIdx)
 		} else {
@@ -54,6 +73,14 @@ func (np *NewPath) GetAction() Action {
 		}
 	case BindingRotate:
 		return np.doReverse()
+	case BindingSpline:
+		return np.doToggleSpline()
+	case BindingRoute:
+		return np.doToggleRoute()
+	case BindingConfirm:
+		if np.firstEndPlaced {
+			return np.doPlace()
+		}
 	}
 
 	if !mouse.InScene {
@@ -61,23 +88,65 @@ func (np *NewPath) GetAction() Action {
 	}
 
 	if mouse.Left.Released {
-		if np.firstEndPlaced {
+		switch {
+		case !np.firstEndPlaced:
+			return np.doPlaceStart()
+		case np.route || np.isPowerLine() || mouse.SnappedPos == np.lastPoint():
 			return np.doPlace()
+		default:
+			return np.doAddVertex()
 		}
-		return np.doPlaceStart()
 	}
 	if !mouse.Left.Down {
-		return np.doMoveTo(mouse.SnappedPos)
+		if np.isPowerLine() {
+			pos := mouse.SnappedPos
+			if conn, ok := scene.PowerConnectorAt(mouse.Pos); ok {
+				pos = conn
+			}
+			return np.doMoveTo(pos, false)
+		}
+		return np.doMoveTo(mouse.SnappedPos, keyboard.Shift)
 	}
 	return nil
 }
 
+// isPowerLine returns whether the new path is a power line
+func (np NewPath) isPowerLine() bool {
+	return np.path.DefIdx >= 0 && np.path.Def().IsPowerLine
+}
+
+// lastPoint returns the last placed point of the new path
+func (np NewPath) lastPoint() rl.Vector2 {
+	n := np.path.Vertices.Len()
+	if np.corner {
+		n--
+	}
+	if n == 0 {
+		return np.path.Start
+	}
+	return np.path.Vertices.Get(n - 1)
+}
+
+// placedPath returns the new path as it will be added to the scene
+func (np NewPath) placedPath() Path {
+	if np.reverse && !np.route {
+		return np.path.Reverse()
+	}
+	return np.path
+}
+
 func (np *NewPath) doInit(defIdx int) Action {
 	np.traceState("before", "doInit")
 	log.Debug("newPath.doInit", "defIdx", defIdx)
-	np.path = Path{DefIdx: defIdx}
+	np.path = Path{DefIdx: defIdx, Spline: np.path.Spline}
+	if np.isPowerLine() {
+		np.path.Spline = false
+		np.route = false
+	}
 	np.firstEndPlaced = false
 	np.isValid = true
+	np.corner = false
+	np.from, np.to = Port{}, Port{}
 	resets := ResetAll().WithNewPath(false).WithGui(false)
 	np.traceState("after", "doInit")
 	return app.doSwitchMode(ModeNewPath, resets)
@@ -88,24 +157,96 @@ func (np *NewPath) doReverse() Action {
 	log.Debug("newPath.doReverse")
 	app.Mode.Assert(ModeNewPath)
 	np.reverse = !np.reverse
-	np.path.Start, np.path.End = np.path.End, np.path.Start
 	np.traceState("after", "doReverse")
 	return nil
 }
 
-func (np *NewPath) doMoveTo(pos rl.Vector2) Action {
+// doToggleSpline toggles between straight segments and a curve through the vertices
+func (np *NewPath) doToggleSpline() Action {
+	np.traceState("before", "doToggleSpline")
+	log.Debug("newPath.doToggleSpline")
+	app.Mode.Assert(ModeNewPath)
+	if np.isPowerLine() {
+		log.Debug("newPath.doToggleSpline", "action", "none", "reason", "power line")
+		return nil
+	}
+	np.path.Spline = !np.path.Spline
+	if np.firstEndPlaced && (!np.route || np.to != Port{}) {
+		np.isValid = scene.IsPathValid(np.path)
+	}
+	np.traceState("after", "doToggleSpline")
+	return nil
+}
+
+// doToggleRoute toggles the routing tool, and restarts the path placement
+func (np *NewPath) doToggleRoute() Action {
+	np.traceState("before", "doToggleRoute")
+	log.Debug("newPath.doToggleRoute")
+	app.Mode.Assert(ModeNewPath)
+	if np.isPowerLine() {
+		log.Debug("newPath.doToggleRoute", "action", "none", "reason", "power line")
+		return nil
+	}
+	np.route = !np.route
+	np.path = Path{DefIdx: np.path.DefIdx, Start: np.path.End, End: np.path.End, Spline: np.path.Spline}
+	np.firstEndPlaced = false
+	np.corner = false
+	np.isValid = true
+	np.from, np.to = Port{}, Port{}
+	np.traceState("after", "doToggleRoute")
+	return nil
+}
+
+// routeTo routes the new path from the source port to the port at pos, or to pos (invalid) if
+// there is no port or no route
+func (np *NewPath) routeTo(pos rl.Vector2) {
+	to, ok := scene.PortAt(pos)
+	if ok && to == np.to {
+		return // already routed
+	}
+	spline := np.path.Spline
+	if !ok || to == np.from {
+		np.to = Port{}
+		np.path = Path{DefIdx: np.path.DefIdx, Start: np.from.Pos, End: pos, Spline: spline}
+		np.isValid = false
+		return
+	}
+	np.to = to
+	path, err := scene.Route(np.path.DefIdx, np.from, to)
+	if err != nil {
+		log.Debug("newPath.routeTo", "from", np.from, "to", to, "err", err)
+		np.path = Path{DefIdx: np.path.DefIdx, Start: np.from.Pos, End: to.Pos, Spline: spline}
+		np.isValid = false
+		return
+	}
+	np.path = path
+	np.path.Spline = spline
+	// the spline route may be longer than the path maximum length
+	np.isValid = scene.IsPathValid(np.path)
+}
+
+// doMoveTo moves the new path end (or start if not placed yet) to pos, with an horizontal then
+// vertical last segment if lRoute is true
+func (np *NewPath) doMoveTo(pos rl.Vector2, lRoute bool) Action {
 	np.traceState("before", "doMoveTo")
-	log.Trace("newPath.doMoveTo", "pos", pos) // moving by mouse -> tracing
+	log.Trace("newPath.doMoveTo", "pos", pos, "lRoute", lRoute) // moving by mouse -> tracing
 	app.Mode.Assert(ModeNewPath)
 	if !np.firstEndPlaced {
 		np.path.Start = pos
 		np.path.End = pos
+	} else if np.route {
+		np.routeTo(pos)
 	} else {
-		if np.reverse {
-			np.path.Start = pos
-		} else {
-			np.path.End = pos
+		if np.corner {
+			np.path.Vertices.Remove(np.path.Vertices.Len() - 1)
+			np.corner = false
+		}
+		last := np.lastPoint()
+		if lRoute && last.X != pos.X && last.Y != pos.Y && !np.path.Vertices.IsFull() {
+			np.path.Vertices.Append(vec2(pos.X, last.Y))
+			np.corner = true
 		}
+		np.path.End = pos
 		np.isValid = scene.IsPathValid(np.path)
 	}
 	np.traceState("after", "doMoveTo")
@@ -116,23 +257,63 @@ func (np *NewPath) doPlaceStart() Action {
 	np.traceState("before", "doPlaceStart")
 	log.Debug("newPath.doPlaceStart")
 	app.Mode.Assert(ModeNewPath)
+	if np.route {
+		port, ok := scene.PortAt(np.path.Start)
+		if !ok {
+			log.Debug("newPath.doPlaceStart", "action", "none", "reason", "no port")
+			np.traceState("after", "doPlaceStart")
+			return nil
+		}
+		np.from, np.to = port, Port{}
+		np.path.Start, np.path.End = port.Pos, port.Pos
+	}
 	np.firstEndPlaced = true
 	np.traceState("after", "doPlaceStart")
 	return nil
 }
 
+// doAddVertex places the new path end (and its L-routing corner) as vertices, the path is added
+// to the scene if it cannot have more vertices
+func (np *NewPath) doAddVertex() Action {
+	np.traceState("before", "doAddVertex")
+	log.Debug("newPath.doAddVertex")
+	app.Mode.Assert(ModeNewPath)
+	assert(np.firstEndPlaced, "path start not placed")
+	np.corner = false
+	if !np.path.Vertices.Append(np.path.End) {
+		log.Debug("newPath.doAddVertex", "action", "place", "reason", "too many vertices")
+		np.traceState("after", "doAddVertex")
+		return np.doPlace()
+	}
+	np.isValid = false // last segment is empty until the mouse moves
+	np.traceState("after", "doAddVertex")
+	return nil
+}
+
 func (np *NewPath) doPlace() Action {
 	np.traceState("before", "doPlace")
 	log.Debug("newPath.doPlace")
 	app.Mode.Assert(ModeNewPath)
 	assert(np.firstEndPlaced, "path start not placed")
-	if np.isValid = scene.IsPathValid(np.path); np.isValid {
-		scene.AddPath(np.path)
+	if np.route {
+		// the routed path is added as a whole, in a single undo step
+		if np.isValid {
+			scene.AddPath(np.placedPath())
+		}
+	} else {
+		if n := np.path.Vertices.Len(); n > 0 && np.path.Vertices.Get(n-1) == np.path.End {
+			// placed by clicking on the last vertex
+			np.path.Vertices.Remove(n - 1)
+		}
+		if np.isValid = scene.IsPathValid(np.path); np.isValid {
+			scene.AddPath(np.placedPath())
+		}
 	}
-	np.path.Start = mouse.SnappedPos
-	np.path.End = mouse.SnappedPos
+	np.path = Path{DefIdx: np.path.DefIdx, Start: mouse.SnappedPos, End: mouse.SnappedPos, Spline: np.path.Spline}
 	np.firstEndPlaced = false
+	np.corner = false
 	np.isValid = true
+	np.from, np.to = Port{}, Port{}
 	np.traceState("after", "doPlace")
 	return nil
 }
@@ -145,11 +326,17 @@ func (np *NewPath) Dispatch(action Action) Action {
 	case NewPathActionInit:
 		return np.doInit(action.DefIdx)
 	case NewPathActionMoveTo:
-		return np.doMoveTo(action.Pos)
+		return np.doMoveTo(action.Pos, action.LRoute)
 	case NewPathActionReverse:
 		return np.doReverse()
 	case NewPathActionPlaceStart:
 		return np.doPlaceStart()
+	case NewPathActionAddVertex:
+		return np.doAddVertex()
+	case NewPathActionToggleSpline:
+		return np.doToggleSpline()
+	case NewPathActionToggleRoute:
+		return np.doToggleRoute()
 	case NewPathActionPlace:
 		return np.doPlace()
 
@@ -159,9 +346,27 @@ func (np *NewPath) Dispatch(action Action) Action {
 }
 
 func (np NewPath) Draw() {
+	if np.isPowerLine() {
+		// hovered power connector
+		if conn, ok := scene.PowerConnectorAt(mouse.Pos); ok {
+			rl.DrawCircleLinesV(conn, 1, colors.Blue500)
+		}
+	}
+	if np.route {
+		// source port, and hovered port
+		if np.firstEndPlaced {
+			rl.DrawCircleV(np.from.Pos, 0.5, colors.Blue500)
+		}
+		if port, ok := scene.PortAt(mouse.Pos); ok {
+			rl.DrawCircleLinesV(port.Pos, 1, colors.Blue500)
+		}
+		if !np.firstEndPlaced {
+			return
+		}
+	}
 	if np.isValid {
-		np.path.Draw(DrawNew)
+		drawPath(np.placedPath(), DrawNew)
 	} else {
-		np.path.Draw(DrawInvalid)
+		drawPath(np.placedPath(), DrawInvalid)
 	}
 }
@@ -127,5 +127,5 @@ func (np *NewTextBox) Dispatch(action Action) Action {
 }
 
 func (np NewTextBox) Draw() {
-	np.TextBox.Draw(DrawNew, false)
+	drawTextBox(np.TextBox, DrawNew, false)
 }
@@ -1,11 +1,11 @@
+// paths - Paths (belts & pipes) drawing
+
 package app
 
 import (
-	"encoding/json"
-	"fmt"
-
 	"github.com/bonoboris/satisfied/colors"
 	"github.com/bonoboris/satisfied/matrix"
+	sc "github.com/bonoboris/satisfied/scene"
 	rl "github.com/gen2brain/raylib-go/raylib"
 )
 
@@ -13,21 +13,9 @@ import (
 // Path
 ////////////////////////////////////////////////////////////////////////////////////////////////////
 
-type Path struct {
-	DefIdx     int
-	Start, End rl.Vector2
-}
-
-func (p Path) String() string {
-	if p.DefIdx == -1 {
-		return fmt.Sprintf("%s{%v %v %v %v}", "<invalid>", p.Start.X, p.Start.Y, p.End.X, p.End.Y)
-	}
-	return fmt.Sprintf("%s{%v %v %v %v}", p.Def().Class, p.Start.X, p.Start.Y, p.End.X, p.End.Y)
-}
-
-func (p Path) Def() PathDef { return pathDefs[p.DefIdx] }
+const pathHandleSize = 12 // size of the path vertex handles in pixels
 
-func (p Path) DrawStart(state DrawState) {
+func drawPathStart(p Path, state DrawState) {
 	if state == DrawSkip {
 		return
 	}
@@ -41,7 +29,7 @@ func (p Path) DrawStart(state DrawState) {
 	rl.DrawCircleV(p.Start, def.Width/2, color)
 }
 
-func (p Path) DrawEnd(state DrawState) {
+func drawPathEnd(p Path, state DrawState) {
 	if state == DrawSkip {
 		return
 	}
@@ -55,50 +43,28 @@ func (p Path) DrawEnd(state DrawState) {
 	rl.DrawCircleV(p.End, def.Width/2, color)
 }
 
-func (p Path) DrawBody(state DrawState) {
+func drawPathBody(p Path, state DrawState) {
 	if state == DrawSkip {
 		return
 	}
-	def := p.Def()
-	color := state.transformColor(def.Color)
-
-	if !CheckCollisionRecLine(dims.ExWorld, p.Start, p.End) {
-		// skip drawing if building is outside of the scene
+	if !dims.ExWorld.CheckCollisionRec(p.Bounds()) {
+		// skip drawing if path is outside of the scene
 		return
 	}
-	// we either call p.Draw(..) or p.DrawBody(..) p.DrawStart(..) and p.DrawEnd(..)
+	// we either call drawPath(..) or drawPathBody(..) drawPathStart(..) and drawPathEnd(..)
 	app.drawCounts.Paths++
-
-	// Path body
-	rl.DrawLineEx(p.Start, p.End, def.Width, color)
-
-	if !def.IsDirectional || state == DrawShadow {
-		return
-	}
-	// Draw directional arrows
-	color = state.transformColor(colors.Gray300)
-	length := p.Start.Distance(p.End)
-	angle := -p.Start.LineAngle(p.End)
-	mat := matrix.NewTranslateV(p.Start).RotateRad(angle)
-	for x := animations.BeltOffset; x < length; x += 1 {
-		rl.DrawTriangle(
-			mat.Apply(x-0.25, 0.5),
-			mat.Apply(x+0.25, 0),
-			mat.Apply(x-0.25, -0.5),
-			color,
-		)
-	}
+	drawPathPolyline(p, state)
 }
 
-func (p Path) Draw(state DrawState) {
+func drawPath(p Path, state DrawState) {
 	if state == DrawSkip {
 		return
 	}
 	def := p.Def()
 	color := state.transformColor(def.Color)
 
-	if !CheckCollisionRecLine(dims.ExWorld, p.Start, p.End) {
-		// skip drawing if building is outside of the scene
+	if !dims.ExWorld.CheckCollisionRec(p.Bounds()) {
+		// skip drawing if path is outside of the scene
 		return
 	}
 	app.drawCounts.Paths++
@@ -106,105 +72,92 @@ func (p Path) Draw(state DrawState) {
 	// Path start
 	// FIXME: DrawCircle is very expensive, use shader instead
 	rl.DrawCircleV(p.Start, def.Width/2, color)
-	if p.Start.Equals(p.End) {
+	if p.Start.Equals(p.End) && p.Vertices.Len() == 0 {
 		return
 	}
-	// Path body
-	rl.DrawLineEx(p.Start, p.End, def.Width, color)
+	drawPathPolyline(p, state)
 	// Path end
 	rl.DrawCircleV(p.End, def.Width/2, color)
+}
+
+// drawPathPolyline draws the path segments, their corner joins and the directional arrows
+func drawPathPolyline(p Path, state DrawState) {
+	def := p.Def()
+	color := state.transformColor(def.Color)
+	var buf [sc.MAX_POLYLINE_POINTS]rl.Vector2
+	points := p.Polyline(buf[:0])
+
+	// Path body
+	for i := 1; i < len(points); i++ {
+		rl.DrawLineEx(points[i-1], points[i], def.Width, color)
+		if i > 1 {
+			// round corner join
+			rl.DrawCircleV(points[i-1], def.Width/2, color)
+		}
+	}
 
 	if !def.IsDirectional || state == DrawShadow {
 		return
 	}
-	// Draw directional arrows
+	// Draw directional arrows, evenly spaced along the whole path
 	color = state.transformColor(colors.Gray300)
-	length := p.Start.Distance(p.End)
-	angle := -p.Start.LineAngle(p.End)
-	mat := matrix.NewTranslateV(p.Start).RotateRad(angle)
-	for x := animations.BeltOffset; x < length; x += 1 {
-		rl.DrawTriangle(
-			mat.Apply(x-0.25, 0.5),
-			mat.Apply(x+0.25, 0),
-			mat.Apply(x-0.25, -0.5),
-			color,
-		)
+	offset := animations.BeltOffset
+	for i := 1; i < len(points); i++ {
+		start, end := points[i-1], points[i]
+		length := start.Distance(end)
+		angle := -start.LineAngle(end)
+		mat := matrix.NewTranslateV(start).RotateRad(angle)
+		x := offset
+		for ; x < length; x += 1 {
+			rl.DrawTriangle(
+				mat.Apply(x-0.25, 0.5),
+				mat.Apply(x+0.25, 0),
+				mat.Apply(x-0.25, -0.5),
+				color,
+			)
+		}
+		offset = x - length
 	}
 }
 
-func (p Path) IsValid() bool {
-	return p.Start != p.End
-}
-
-// Returns true if the given position is inside the path start.
-func (p Path) CheckStartCollisionPoint(pos rl.Vector2) bool {
-	return rl.CheckCollisionPointCircle(pos, p.Start, p.Def().Width/2)
-}
-
-// Returns true if the given position is inside the path end.
-func (p Path) CheckEndCollisionPoint(pos rl.Vector2) bool {
-	return rl.CheckCollisionPointCircle(pos, p.End, p.Def().Width/2)
-}
-
-// Returns true if the given position is inside the path body.
-func (p Path) CheckCollisionPoint(pos rl.Vector2) bool {
-	width := p.Def().Width
-	lengthSqr := p.Start.DistanceSqr(p.End)
-	angle := p.Start.LineAngle(p.End)
-	transform := matrix.NewRotateRad(angle).TranslateV(p.Start.Negate())
-	tpos := transform.ApplyV(pos)
-	return tpos.X >= 0 && tpos.X*tpos.X <= lengthSqr && tpos.Y >= -width/2 && tpos.Y <= width/2
-}
-
 ////////////////////////////////////////////////////////////////////////////////////////////////////
-// PathDef
+// Vertex handles
 ////////////////////////////////////////////////////////////////////////////////////////////////////
 
-type PathDef struct {
-	Class         string
-	Width         float32
-	Color         rl.Color
-	IsDirectional bool
-}
-
-func (def PathDef) String() string {
-	return fmt.Sprintf("{%s W=%v, directional=%v}", def.Class, def.Width, def.IsDirectional)
-}
-
-func (def *PathDef) UnmarshalJSON(data []byte) error {
-	type JsonPathDef struct {
-		Class         string
-		Width         float32
-		Color         string
-		IsDirectional bool
-	}
-	var jsonDef JsonPathDef
-	err := json.Unmarshal(data, &jsonDef)
-	if err != nil {
-		return err
-	}
-	def.Class = jsonDef.Class
-	def.Width = jsonDef.Width
-	def.Color = colors.NewColorFromHex(jsonDef.Color)
-	def.IsDirectional = jsonDef.IsDirectional
-	return nil
-}
-
-type PathDefs []PathDef
-
-func (defs PathDefs) Classes() []string {
-	classes := make([]string, len(defs))
-	for i, def := range defs {
-		classes[i] = def.Class
+// pathHandleAt returns the path vertex handle at pos: either a vertex index, or a segment index
+// when insert is true (a vertex can be inserted in the segment middle)
+func pathHandleAt(p Path, pos rl.Vector2) (idx int, insert bool, ok bool) {
+	radius := pathHandleSize / 2 / camera.Zoom()
+	for i := range p.Vertices.Len() {
+		if rl.CheckCollisionPointCircle(pos, p.Vertices.Get(i), radius) {
+			return i, false, true
+		}
 	}
-	return classes
+	if p.Vertices.IsFull() {
+		return 0, false, false
+	}
+	for i := range p.Vertices.Len() + 1 {
+		if rl.CheckCollisionPointCircle(pos, p.SegmentMiddle(i), radius) {
+			return i, true, true
+		}
+	}
+	return 0, false, false
 }
 
-func (defs PathDefs) Index(class string) int {
-	for i, def := range defs {
-		if def.Class == class {
-			return i
-		}
+// drawPathHandles draws the path vertex handles and the segment middle handles
+func drawPathHandles(p Path) {
+	px := 1 / camera.Zoom()
+	size := pathHandleSize * px
+	for i := range p.Vertices.Len() {
+		v := p.Vertices.Get(i)
+		rec := rl.NewRectangle(v.X-size/2, v.Y-size/2, size, size)
+		rl.DrawRectangleRec(rec, colors.White)
+		rl.DrawRectangleLinesEx(rec, 1*px, colors.Blue500)
+	}
+	if p.Vertices.IsFull() {
+		return
+	}
+	for i := range p.Vertices.Len() + 1 {
+		rl.DrawCircleV(p.SegmentMiddle(i), size/3, colors.WithAlpha(colors.Blue500, 0.5))
 	}
-	return -1
 }
@@ -0,0 +1,370 @@
+// planner - Production planner panel (see [sc.SolvePlan])
+
+package app
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/bonoboris/satisfied/colors"
+	"github.com/bonoboris/satisfied/log"
+	sc "github.com/bonoboris/satisfied/scene"
+	"github.com/bonoboris/satisfied/text"
+	"github.com/gen2brain/raylib-go/raygui"
+	rl "github.com/gen2brain/raylib-go/raylib"
+)
+
+const (
+	// Planner panel width in px, docked left of the details bar when open
+	PlannerWidth = 520.0
+	// Planner panel line height, in px
+	plannerLineHeight = 30.0
+)
+
+// guiPlanner is the production planner panel: target production rates and allowed alternate
+// recipes in, machines and raw resources needed out
+type guiPlanner struct {
+	// Whether the panel is open
+	open bool
+	// Target production rates, in items per minute
+	targets []sc.ItemAmount
+	// Classes of the allowed alternate recipes
+	alternates []string
+	// Clock speed of the machines, in percent, 0 for the default
+	clock float32
+	// Plan of the targets, if err is empty
+	plan sc.Plan
+	// Why the targets cannot be planned, if any
+	err string
+
+	// Active item of the items list
+	activeItem int32
+	// Items list scroll index
+	itemsScroll int32
+	// Alternate recipes list scroll index
+	alternatesScroll int32
+	// Target rate field text, and whether it is being edited
+	rate        string
+	rateEditing bool
+	// Why the target rate field is invalid, if it is
+	rateErr string
+	// Clock speed field text, whether it is being edited, and why it is invalid, if it is
+	clockText    string
+	clockEditing bool
+	clockErr     string
+	// Number of plan lines scrolled out of view
+	planScroll int
+}
+
+// bounds returns the panel bounds
+func (p *guiPlanner) bounds() rl.Rectangle {
+	return rl.NewRectangle(
+		dims.Screen.X-DetailsBarWidth-PlannerWidth,
+		TopbarHeight,
+		PlannerWidth,
+		dims.Screen.Y-TopbarHeight-StatusBarHeight)
+}
+
+// solve recomputes the plan of the targets
+func (p *guiPlanner) solve() {
+	p.plan, p.err = sc.Plan{}, ""
+	if len(p.targets) == 0 {
+		return
+	}
+	plan, err := sc.SolvePlan(recipeBook, p.targets, p.alternates, p.clock)
+	if err != nil {
+		log.Info("planner.solve", "targets", p.targets, "alternates", p.alternates, "err", err)
+		p.err = err.Error()
+		return
+	}
+	log.Debug("planner.solve", "targets", p.targets, "alternates", p.alternates, "steps", len(plan.Steps))
+	p.plan = plan
+	p.planScroll = 0
+}
+
+// doToggle opens or closes the panel, the issues panel is closed when it opens
+func (p *guiPlanner) doToggle() Action {
+	p.open = !p.open
+	p.rateEditing = false
+	p.clockEditing = false
+	if p.open && gui.Issues.open {
+		gui.Issues.doToggle()
+	}
+	log.Debug("planner.doToggle", "open", p.open)
+	return nil
+}
+
+// doSetTarget sets the target rate of item, a null rate removes it
+func (p *guiPlanner) doSetTarget(item string, rate float32) Action {
+	idx := slices.IndexFunc(p.targets, func(t sc.ItemAmount) bool { return t.Item == item })
+	switch {
+	case rate <= 0 && idx >= 0:
+		p.targets = slices.Delete(p.targets, idx, idx+1)
+	case rate <= 0:
+		return nil
+	case idx >= 0:
+		p.targets[idx].Amount = rate
+	default:
+		p.targets = append(p.targets, sc.ItemAmount{Item: item, Amount: rate})
+	}
+	log.Debug("planner.doSetTarget", "item", item, "rate", rate, "targets", p.targets)
+	p.solve()
+	return nil
+}
+
+// doSetClock sets the clock speed of the machines, in percent, 0 for the default
+func (p *guiPlanner) doSetClock(clock float32) Action {
+	p.clock = clock
+	p.clockText = ""
+	if clock != 0 {
+		p.clockText = formatRate(clock)
+	}
+	log.Debug("planner.doSetClock", "clock", clock)
+	p.solve()
+	return nil
+}
+
+// doToggleAlternate allows or disallows the alternate recipe of the given class
+func (p *guiPlanner) doToggleAlternate(recipe string) Action {
+	if idx := slices.Index(p.alternates, recipe); idx >= 0 {
+		p.alternates = slices.Delete(p.alternates, idx, idx+1)
+	} else {
+		p.alternates = append(p.alternates, recipe)
+	}
+	log.Debug("planner.doToggleAlternate", "recipe", recipe, "alternates", p.alternates)
+	p.solve()
+	return nil
+}
+
+// doPlaceBuilding starts placing a building of the given class, as if selected in the sidebar
+func (p *guiPlanner) doPlaceBuilding(class string) Action {
+	defIdx := buildingDefs.Index(class)
+	if defIdx < 0 {
+		log.Warn("planner.doPlaceBuilding", "reason", "unknown class", "class", class)
+		return nil
+	}
+	return gui.Sidebar.doSelectBuildingDef(defIdx)
+}
+
+// doGenerateLayout adds a draft layout of the plan to the scene centered on center, in a single
+// undo step, and selects it
+func (p *guiPlanner) doGenerateLayout(center rl.Vector2) Action {
+	col, err := sc.PlanLayout(recipeBook, p.plan, center)
+	if err != nil {
+		log.Warn("planner.doGenerateLayout", "err", err)
+		gui.Statusbar.notify("Cannot generate the layout:\n"+err.Error(), true)
+		return nil
+	}
+	log.Debug("planner.doGenerateLayout", "center", center, "buildings", len(col.Buildings), "paths", len(col.Paths))
+	sel := ObjectSelection{
+		BuildingIdxs: sc.Range(len(scene.Buildings), len(scene.Buildings)+len(col.Buildings)),
+		TextBoxIdxs:  sc.Range(len(scene.TextBoxes), len(scene.TextBoxes)+len(col.TextBoxes)),
+	}
+	for i := range col.Paths {
+		sel.PathIdxs = append(sel.PathIdxs, PathSel{Idx: len(scene.Paths) + i, Start: true, End: true})
+	}
+	scene.AddObjects(col)
+	sel.RecomputeBounds(scene.ObjectCollection)
+	return selection.doInitSelection(sel)
+}
+
+// formatRate formats a rate or a number of machines, rounded to 0.01
+func formatRate(rate float32) string {
+	return strconv.FormatFloat(math.Round(float64(rate)*100)/100, 'f', -1, 64)
+}
+
+func (p *guiPlanner) updateAndDraw() (action Action) {
+	if !p.open {
+		return nil
+	}
+	panel := p.bounds()
+	rl.DrawRectangleRec(panel, colors.Gray100)
+	rl.DrawLineV(panel.TopLeft(), panel.BottomLeft(), colors.Gray300)
+	rl.DrawLineV(panel.TopRight(), panel.BottomRight(), colors.Gray300)
+
+	pTextSize := raygui.GetStyle(raygui.DEFAULT, raygui.TEXT_SIZE)
+	raygui.SetStyle(raygui.DEFAULT, raygui.TEXT_SIZE, 20)
+	titleOpts := text.Options{Font: font, Size: 24, Color: colors.Gray700}
+	textOpts := text.Options{Font: font, Size: 20, Color: colors.Gray700}
+
+	// padded dimensions
+	bar := rl.NewRectangle(panel.X+20, panel.Y+20, panel.Width-40, panel.Height-40)
+	y := bar.Y
+
+	text.DrawText(rl.NewRectangle(bar.X, y, bar.Width-40, 30), "Production planner", titleOpts)
+	if raygui.Button(rl.NewRectangle(bar.X+bar.Width-30, y, 30, 30), raygui.IconText(raygui.ICON_CROSS, "")) {
+		log.Debug("planner close clicked")
+		action = GuiActionTogglePlanner{}
+	}
+	y += 40
+
+	// targets: item list, rate field and the current targets
+	items := recipeBook.Items()
+	half := (bar.Width - 10) / 2
+	listHeight := float32(6 * plannerLineHeight)
+	p.activeItem = raygui.ListView(rl.NewRectangle(bar.X, y, half, listHeight), strings.Join(items, ";"), &p.itemsScroll, p.activeItem)
+
+	x := bar.X + half + 10
+	text.DrawText(rl.NewRectangle(x, y, half, plannerLineHeight), "Target (items/min)", textOpts)
+	validated := false
+	if raygui.TextBox(rl.NewRectangle(x, y+plannerLineHeight, half, plannerLineHeight), &p.rate, 16, p.rateEditing) {
+		p.rateEditing = !p.rateEditing
+		if p.rateEditing {
+			p.rateErr = ""
+		}
+		validated = !p.rateEditing && keyboard.Pressed == rl.KeyEnter
+	}
+	if p.activeItem < 0 || int(p.activeItem) >= len(items) {
+		raygui.Disable()
+	}
+	if raygui.Button(rl.NewRectangle(x, y+2*plannerLineHeight+10, half, plannerLineHeight), "Set target (Enter)") || validated {
+		if rate, err := strconv.ParseFloat(strings.TrimSpace(p.rate), 32); err != nil || rate < 0 {
+			log.Info("planner set target", "reason", "invalid rate", "rate", p.rate)
+			p.rateErr = fmt.Sprintf("invalid target: %q", p.rate)
+		} else if p.activeItem >= 0 && int(p.activeItem) < len(items) {
+			action = GuiActionSetPlanTarget{Item: items[p.activeItem], Rate: float32(rate)}
+		}
+	}
+	raygui.Enable()
+	if p.rateErr != "" {
+		errBounds := rl.NewRectangle(x, y+3*plannerLineHeight+20, half, 2*plannerLineHeight)
+		text.DrawText(errBounds, p.rateErr, text.Options{Font: font, Size: 20, Color: colors.Red500})
+	}
+	y += listHeight + 10
+
+	for _, t := range p.targets {
+		label := fmt.Sprintf("%s /min %s", formatRate(t.Amount), t.Item)
+		text.DrawText(rl.NewRectangle(bar.X, y, bar.Width-40, plannerLineHeight), label, textOpts)
+		if raygui.Button(rl.NewRectangle(bar.X+bar.Width-30, y, 30, plannerLineHeight-4), raygui.IconText(raygui.ICON_CROSS_SMALL, "")) {
+			log.Debug("planner remove target clicked", "item", t.Item)
+			action = GuiActionSetPlanTarget{Item: t.Item, Rate: 0}
+		}
+		y += plannerLineHeight
+	}
+	y += 10
+
+	// clock speed of the machines, applied when leaving the field, empty for the default
+	text.DrawText(rl.NewRectangle(bar.X, y, half, plannerLineHeight), "Clock speed (%)", textOpts)
+	if raygui.TextBox(rl.NewRectangle(x, y, 100, plannerLineHeight), &p.clockText, 8, p.clockEditing) {
+		p.clockEditing = !p.clockEditing
+		p.clockErr = ""
+		if !p.clockEditing {
+			clock, err := strconv.ParseFloat(strings.TrimSpace(p.clockText), 32)
+			switch {
+			case strings.TrimSpace(p.clockText) == "":
+				action = GuiActionSetPlanClock{Clock: 0}
+			case err != nil || !(clock >= sc.MinClock && clock <= sc.MaxClock):
+				log.Info("planner set clock", "reason", "invalid clock speed", "clock", p.clockText)
+				p.clockErr = fmt.Sprintf("%d%% to %d%%", sc.MinClock, sc.MaxClock)
+			default:
+				action = GuiActionSetPlanClock{Clock: float32(clock)}
+			}
+		}
+	}
+	if p.clockErr != "" {
+		errBounds := rl.NewRectangle(x+110, y, half-110, plannerLineHeight)
+		text.DrawText(errBounds, p.clockErr, text.Options{Font: font, Size: 20, Color: colors.Red500})
+	}
+	y += plannerLineHeight + 10
+
+	// alternate recipes, clicking one toggles it
+	text.DrawText(rl.NewRectangle(bar.X, y, bar.Width, plannerLineHeight), "Alternate recipes", titleOpts)
+	y += plannerLineHeight + 5
+	alternates := recipeBook.Alternates()
+	entries := make([]string, len(alternates))
+	for i, class := range alternates {
+		icon := raygui.ICON_BOX
+		if slices.Contains(p.alternates, class) {
+			icon = raygui.ICON_OK_TICK
+		}
+		entries[i] = raygui.IconText(icon, class)
+	}
+	listHeight = 5 * plannerLineHeight
+	if idx := raygui.ListView(rl.NewRectangle(bar.X, y, bar.Width, listHeight), strings.Join(entries, ";"), &p.alternatesScroll, -1); idx >= 0 && int(idx) < len(alternates) {
+		log.Debug("planner alternate clicked", "recipe", alternates[idx])
+		action = GuiActionToggleAlternate{Recipe: alternates[idx]}
+	}
+	y += listHeight + 10
+
+	action = orAction(action, p.drawPlan(rl.NewRectangle(bar.X, y, bar.Width, bar.Y+bar.Height-y)))
+
+	raygui.SetStyle(raygui.DEFAULT, raygui.TEXT_SIZE, pTextSize)
+	return action
+}
+
+// drawPlan draws the button generating the plan layout, and the plan table in bounds, scrolled with
+// the mouse wheel, each recipe row has a button to place its building
+func (p *guiPlanner) drawPlan(bounds rl.Rectangle) (action Action) {
+	rl.DrawLineV(bounds.TopLeft(), bounds.TopRight(), colors.Gray300)
+	bounds.Y += 10
+	bounds.Height -= 10
+	textOpts := text.Options{Font: font, Size: 20, Color: colors.Gray700}
+	if p.err != "" {
+		text.DrawText(bounds, p.err, text.Options{Font: font, Size: 20, Color: colors.Red500})
+		return nil
+	}
+	if len(p.plan.Steps) == 0 {
+		text.DrawText(bounds, "Set a target to plan its production", text.Options{Font: font, Size: 20, Color: colors.Gray500})
+		return nil
+	}
+	if raygui.Button(rl.NewRectangle(bounds.X, bounds.Y, 200, plannerLineHeight), "Generate layout") {
+		log.Debug("planner generate layout clicked")
+		action = GuiActionGeneratePlanLayout{Center: camera.WorldPos(dims.Scene.Center())}
+	}
+	bounds.Y += plannerLineHeight + 10
+	bounds.Height -= plannerLineHeight + 10
+
+	type line struct {
+		text  string
+		color rl.Color
+		// building class to place, empty for none
+		place string
+	}
+	lines := []line{{"Machines  Recipe (building)", colors.Gray500, ""}}
+	for _, s := range p.plan.Steps {
+		lines = append(lines, line{fmt.Sprintf("%s  %s (%s)", formatRate(s.Machines), s.Recipe, s.Building), colors.Gray700, s.Building})
+	}
+	lines = append(lines, line{"Buildings", colors.Gray500, ""})
+	for _, bc := range p.plan.Buildings {
+		lines = append(lines, line{fmt.Sprintf("%d  %s", bc.Count, bc.Class), colors.Gray700, bc.Class})
+	}
+	lines = append(lines, line{"Raw resources (/min)", colors.Gray500, ""})
+	for _, ia := range p.plan.Resources {
+		lines = append(lines, line{fmt.Sprintf("%s  %s", formatRate(ia.Amount), ia.Item), colors.Gray700, ""})
+	}
+	if len(p.plan.Byproducts) > 0 {
+		lines = append(lines, line{"Byproducts (/min)", colors.Gray500, ""})
+		for _, ia := range p.plan.Byproducts {
+			lines = append(lines, line{fmt.Sprintf("%s  %s", formatRate(ia.Amount), ia.Item), colors.Amber700, ""})
+		}
+	}
+
+	visible := max(int(bounds.Height/plannerLineHeight), 1)
+	if rl.CheckCollisionPointRec(input.Frame.MousePos, bounds) && input.Frame.Wheel != 0 {
+		p.planScroll -= int(input.Frame.Wheel)
+	}
+	p.planScroll = min(max(p.planScroll, 0), max(len(lines)-visible, 0))
+
+	for i, l := range lines[p.planScroll:min(p.planScroll+visible, len(lines))] {
+		y := bounds.Y + float32(i)*plannerLineHeight
+		width := bounds.Width
+		if l.place != "" {
+			width -= 80
+			if buildingDefs.Index(l.place) < 0 {
+				raygui.Disable()
+			}
+			if raygui.Button(rl.NewRectangle(bounds.X+width+10, y, 70, plannerLineHeight-4), "Place") {
+				log.Debug("planner place clicked", "class", l.place)
+				action = GuiActionPlacePlanBuilding{Class: l.place}
+			}
+			raygui.Enable()
+		}
+		opts := textOpts
+		opts.Color = l.color
+		text.DrawText(rl.NewRectangle(bounds.X, y, width, plannerLineHeight), l.text, opts)
+	}
+	return action
+}
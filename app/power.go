@@ -0,0 +1,56 @@
+// power - Power network of the scene (see [sc.PowerNetwork]) and its drawing
+
+package app
+
+import (
+	"github.com/bonoboris/satisfied/colors"
+	"github.com/bonoboris/satisfied/log"
+	sc "github.com/bonoboris/satisfied/scene"
+	rl "github.com/gen2brain/raylib-go/raylib"
+)
+
+// power holds the scene power network
+var power Power
+
+// Power holds the scene power network, computed when the scene changes
+type Power struct {
+	// Scene power network, at revision
+	network sc.PowerNetwork
+	// Scene revision when network was computed, -1 to recompute it
+	revision int
+}
+
+// Reset forces the network computation, must be called when the scene is replaced
+func (p *Power) Reset() { *p = Power{revision: -1} }
+
+// Network returns the scene power network, recomputed if the scene has changed
+func (p *Power) Network() sc.PowerNetwork {
+	if p.revision != scene.Revision() {
+		p.network = sc.ComputePowerNetwork(scene.ObjectCollection)
+		p.revision = scene.Revision()
+		log.Debug("power.network", "circuits", len(p.network.Circuits), "unpowered", len(p.network.Unpowered),
+			"issues", len(p.network.Issues), "generation", p.network.Generation(), "consumption", p.network.Consumption())
+	}
+	return p.network
+}
+
+// Draw highlights the power lines of the overloaded circuits, and the power network issues
+func (p *Power) Draw() {
+	net := p.Network()
+	for _, c := range net.Circuits {
+		if !c.IsOverloaded() {
+			continue
+		}
+		for _, idx := range c.PathIdxs {
+			path := scene.Paths[idx]
+			if dims.ExWorld.CheckCollisionRec(path.Bounds()) {
+				rl.DrawLineEx(path.Start, path.End, path.Def().Width, colors.Red500)
+			}
+		}
+	}
+	for _, issue := range net.Issues {
+		if dims.ExWorld.CheckCollisionPoint(issue.Pos) {
+			rl.DrawCircleLinesV(issue.Pos, 1, colors.Red500)
+		}
+	}
+}
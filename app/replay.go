@@ -0,0 +1,433 @@
+// replay - Input recording and deterministic replay, to reproduce bugs (eg: undo / redo)
+//
+// A recording is a JSON lines file:
+//   - a header line: initial project, camera and snap step
+//   - a line per frame: raw inputs (see [InputFrame]), root GUI action, and every [Action] passing
+//     through [dispatchAction]
+//   - an end line: final project and selection
+//
+// When replaying, inputs are read from the recording instead of raylib, the GUI is locked and the
+// recorded GUI actions are dispatched instead. Dispatched actions and the final state are compared
+// with the recording, and each difference is logged as a mismatch.
+
+package app
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/bonoboris/satisfied/log"
+	rl "github.com/gen2brain/raylib-go/raylib"
+)
+
+// Recording file format version
+const replayVersion = 1
+
+// Input recorder / replayer state
+var replay Replay
+
+// Replay holds the input recorder / replayer state
+type Replay struct {
+	// recording file (nil if not recording)
+	file *os.File
+	w    *bufio.Writer
+	enc  *json.Encoder
+
+	// whether we are replaying
+	replaying bool
+	// recorded frames (when replaying)
+	frames []replayFrame
+	// recorded end state (when replaying)
+	end *replayEnd
+	// index of the next frame to replay
+	pos int
+	// whether all frames have been replayed
+	done bool
+	// number of differences with the recording
+	mismatches int
+
+	// current frame (recorded or replayed), with the actual dispatched actions
+	current replayFrame
+	// whether current holds a frame
+	hasCurrent bool
+}
+
+type replayHeader struct {
+	Version int
+	// Initial project (save format)
+	Project string
+	// Initial camera
+	Camera rl.Camera2D
+	// Initial screen size
+	Screen rl.Vector2
+	// Snap step
+	SnapStep float32
+}
+
+type replayFrame struct {
+	Input InputFrame
+	// Root action returned by [Gui.UpdateAndDraw]
+	Gui *recordedAction `json:",omitempty"`
+	// Actions passing through [dispatchAction]
+	Actions []recordedAction `json:",omitempty"`
+	// Set on the last line only
+	End *replayEnd `json:",omitempty"`
+}
+
+type replayEnd struct {
+	// Final project (save format)
+	Project string
+	// Final app mode
+	Mode AppMode
+	// Final selection
+	Selection ObjectSelection
+}
+
+type recordedAction struct {
+	// Action type name
+	Type string
+	// JSON encoded action
+	Data json.RawMessage
+}
+
+// actionDecoders are the decoders of the actions returned by [Gui.UpdateAndDraw], by type name
+var actionDecoders = map[string]func(json.RawMessage) (Action, error){}
+
+func init() {
+	registerActionDecoder[AppActionNew]()
+	registerActionDecoder[AppActionOpen]()
+	registerActionDecoder[AppActionOpenRecent]()
+	registerActionDecoder[AppActionSave]()
+	registerActionDecoder[AppActionSaveAs]()
+	registerActionDecoder[AppActionCompare]()
+	registerActionDecoder[AppActionStopCompare]()
+	registerActionDecoder[AppActionUndo]()
+	registerActionDecoder[AppActionRedo]()
+	registerActionDecoder[AppActionRotate]()
+	registerActionDecoder[AppActionMirror]()
+	registerActionDecoder[AppActionDuplicate]()
+	registerActionDecoder[AppActionArray]()
+	registerActionDecoder[AppActionAlign]()
+	registerActionDecoder[AppActionDrag]()
+	registerActionDecoder[AppActionDelete]()
+	registerActionDecoder[GuiActionSelectTextBox]()
+	registerActionDecoder[GuiActionSelectPath]()
+	registerActionDecoder[GuiActionSelectCategory]()
+	registerActionDecoder[GuiActionSelectBuilding]()
+	registerActionDecoder[GuiActionUpdateTextBox]()
+	registerActionDecoder[GuiActionTransformSelection]()
+	registerActionDecoder[GuiActionArraySelection]()
+	registerActionDecoder[GuiActionSetModifiers]()
+	registerActionDecoder[GuiActionTogglePlanner]()
+	registerActionDecoder[GuiActionSetPlanTarget]()
+	registerActionDecoder[GuiActionSetPlanClock]()
+	registerActionDecoder[GuiActionToggleAlternate]()
+	registerActionDecoder[GuiActionPlacePlanBuilding]()
+	registerActionDecoder[GuiActionGeneratePlanLayout]()
+	registerActionDecoder[GuiActionSetBOMTier]()
+	registerActionDecoder[GuiActionExportBOM]()
+	registerActionDecoder[GuiActionSetSnapStep]()
+	registerActionDecoder[GuiActionToggleIssues]()
+	registerActionDecoder[GuiActionToggleLintRule]()
+	registerActionDecoder[GuiActionSelectIssue]()
+}
+
+func registerActionDecoder[T Action]() {
+	var zero T
+	actionDecoders[actionTypeName(zero)] = func(data json.RawMessage) (Action, error) {
+		var action T
+		err := json.Unmarshal(data, &action)
+		return action, err
+	}
+}
+
+// actionTypeName returns the action type name without package
+func actionTypeName(action Action) string {
+	name := fmt.Sprintf("%T", action)
+	return name[strings.LastIndexByte(name, '.')+1:]
+}
+
+func encodeAction(action Action) recordedAction {
+	data, err := json.Marshal(action)
+	if err != nil {
+		// should not happen, actions are plain data
+		data, _ = json.Marshal(err.Error())
+	}
+	return recordedAction{Type: actionTypeName(action), Data: data}
+}
+
+func (r recordedAction) equal(other recordedAction) bool {
+	return r.Type == other.Type && bytes.Equal(r.Data, other.Data)
+}
+
+func (r *Replay) traceState(key, val string) {
+	if key != "" && val != "" {
+		log.Trace("replay", key, val, "recording", r.Recording(), "replaying", r.replaying, "pos", r.pos, "frames", len(r.frames), "mismatches", r.mismatches)
+	} else {
+		log.Trace("replay", "recording", r.Recording(), "replaying", r.replaying, "pos", r.pos, "frames", len(r.frames), "mismatches", r.mismatches)
+	}
+}
+
+// Recording returns whether inputs are being recorded
+func (r *Replay) Recording() bool { return r.file != nil }
+
+// Replaying returns whether inputs are being replayed
+func (r *Replay) Replaying() bool { return r.replaying }
+
+// Done returns whether all the recorded frames have been replayed
+func (r *Replay) Done() bool { return r.done }
+
+// Mismatches returns the number of differences found between the replay and the recording
+func (r *Replay) Mismatches() int { return r.mismatches }
+
+// StartRecording creates the recording file and writes the current state in its header
+func (r *Replay) StartRecording(path string) error {
+	var project bytes.Buffer
+	if err := scene.SaveToText(&project); err != nil {
+		return err
+	}
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	r.file = file
+	r.w = bufio.NewWriter(file)
+	r.enc = json.NewEncoder(r.w)
+	header := replayHeader{
+		Version:  replayVersion,
+		Project:  project.String(),
+		Camera:   camera.camera,
+		Screen:   dims.Screen,
+		SnapStep: grid.SnapStep,
+	}
+	if err := r.enc.Encode(header); err != nil {
+		r.file.Close()
+		r.file = nil
+		return err
+	}
+	log.Info("recording inputs", "path", path)
+	return nil
+}
+
+// LoadReplay reads the recording file and restores the state from its header
+func (r *Replay) LoadReplay(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	var header replayHeader
+	if err := dec.Decode(&header); err != nil {
+		return fmt.Errorf("invalid recording header: %w", err)
+	}
+	if header.Version != replayVersion {
+		return fmt.Errorf("unsupported recording version: %d, expected %d", header.Version, replayVersion)
+	}
+	r.frames = nil
+	for dec.More() {
+		var frame replayFrame
+		if err := dec.Decode(&frame); err != nil {
+			return fmt.Errorf("invalid recording frame %d: %w", len(r.frames), err)
+		}
+		if frame.End != nil {
+			r.end = frame.End
+			break
+		}
+		r.frames = append(r.frames, frame)
+	}
+	if len(r.frames) == 0 {
+		return errors.New("empty recording")
+	}
+
+	fileScene := Scene{}
+	if err := fileScene.LoadFromText(strings.NewReader(header.Project)); err != nil {
+		return fmt.Errorf("invalid recording project: %w", err)
+	}
+	scene = fileScene
+	power.Reset()
+	lint.Reset()
+	chain.Reset()
+	grid.SnapStep = header.SnapStep
+	input.Frame.Screen = header.Screen
+	dims.Update()
+	camera.camera = header.Camera
+	dims.Update()
+
+	r.replaying = true
+	r.pos = 0
+	log.Info("replaying inputs", "path", path, "frames", len(r.frames))
+	r.traceState("after", "LoadReplay")
+	return nil
+}
+
+// NextFrame returns the next recorded frame inputs
+//
+// Once all frames have been replayed, the last one is repeated without key presses nor wheel
+// movement.
+func (r *Replay) NextFrame() InputFrame {
+	r.checkCurrent()
+	if r.pos >= len(r.frames) {
+		if !r.done {
+			r.done = true
+			r.checkEnd()
+		}
+		f := r.frames[len(r.frames)-1].Input
+		f.KeyPressed, f.KeyRepeat, f.Wheel = 0, false, 0
+		return f
+	}
+	f := r.frames[r.pos].Input
+	r.pos++
+	return f
+}
+
+// RecordFrame starts a new frame with the given inputs, writing the previous one when recording
+func (r *Replay) RecordFrame(f InputFrame) {
+	if !r.Recording() && !r.replaying {
+		return
+	}
+	r.writeCurrent()
+	r.current = replayFrame{Input: f}
+	r.hasCurrent = !r.done
+}
+
+// RecordAction records an action passing through [dispatchAction] in the current frame
+func (r *Replay) RecordAction(action Action) {
+	if r.hasCurrent {
+		r.current.Actions = append(r.current.Actions, encodeAction(action))
+	}
+}
+
+// GuiAction returns the root GUI action of the current frame: the given one, or the recorded one
+// when replaying.
+func (r *Replay) GuiAction(action Action) Action {
+	if !r.replaying {
+		if action != nil && r.hasCurrent {
+			rec := encodeAction(action)
+			r.current.Gui = &rec
+		}
+		return action
+	}
+	if r.done || r.pos == 0 {
+		return nil
+	}
+	rec := r.frames[r.pos-1].Gui
+	if rec == nil {
+		return nil
+	}
+	decode, ok := actionDecoders[rec.Type]
+	if !ok {
+		log.Error("replay: cannot decode gui action", "frame", r.pos-1, "type", rec.Type)
+		r.mismatches++
+		return nil
+	}
+	recorded, err := decode(rec.Data)
+	if err != nil {
+		log.Error("replay: cannot decode gui action", "frame", r.pos-1, "type", rec.Type, "err", err)
+		r.mismatches++
+		return nil
+	}
+	log.Debug("replay: gui action", "frame", r.pos-1, "type", rec.Type)
+	return recorded
+}
+
+// checkCurrent compares the actions dispatched during the current frame with the recorded ones
+func (r *Replay) checkCurrent() {
+	if !r.hasCurrent || r.pos == 0 {
+		return
+	}
+	r.hasCurrent = false
+	expected := r.frames[r.pos-1].Actions
+	actual := r.current.Actions
+	same := len(expected) == len(actual)
+	for i := 0; same && i < len(expected); i++ {
+		same = expected[i].equal(actual[i])
+	}
+	if !same {
+		r.mismatches++
+		log.Warn("replay: actions mismatch", "frame", r.pos-1, "expected", expected, "actual", actual)
+	}
+}
+
+// checkEnd compares the final state with the recorded one
+func (r *Replay) checkEnd() {
+	if r.end == nil {
+		log.Warn("replay: no recorded end state")
+	} else {
+		end := currentReplayEnd()
+		if end.Project != r.end.Project {
+			r.mismatches++
+			log.Warn("replay: final project mismatch", "expected", r.end.Project, "actual", end.Project)
+		}
+		if end.Mode != r.end.Mode {
+			r.mismatches++
+			log.Warn("replay: final mode mismatch", "expected", r.end.Mode, "actual", end.Mode)
+		}
+		expected, _ := json.Marshal(r.end.Selection)
+		actual, _ := json.Marshal(end.Selection)
+		if !bytes.Equal(expected, actual) {
+			r.mismatches++
+			log.Warn("replay: final selection mismatch", "expected", string(expected), "actual", string(actual))
+		}
+	}
+	if r.mismatches > 0 {
+		log.Error("replay finished with mismatches", "frames", len(r.frames), "mismatches", r.mismatches)
+	} else {
+		log.Info("replay finished", "frames", len(r.frames))
+	}
+}
+
+func currentReplayEnd() replayEnd {
+	var project bytes.Buffer
+	scene.SaveToText(&project)
+	return replayEnd{Project: project.String(), Mode: app.Mode, Selection: selection.ObjectSelection}
+}
+
+// writeCurrent writes the current frame in the recording file
+func (r *Replay) writeCurrent() {
+	if !r.Recording() || !r.hasCurrent {
+		return
+	}
+	if err := r.enc.Encode(r.current); err != nil {
+		log.Error("cannot write recording, stop recording", "err", err)
+		r.closeFile()
+	}
+}
+
+// Flush writes the pending frames in the recording file (eg: before a crash)
+func (r *Replay) Flush() {
+	if r.Recording() {
+		r.writeCurrent()
+		r.hasCurrent = false
+		r.w.Flush()
+	}
+}
+
+// Close writes the final state and closes the recording file
+func (r *Replay) Close() {
+	if !r.Recording() {
+		return
+	}
+	r.writeCurrent()
+	r.hasCurrent = false
+	end := currentReplayEnd()
+	if err := r.enc.Encode(replayFrame{End: &end}); err != nil {
+		log.Error("cannot write recording end", "err", err)
+	}
+	r.closeFile()
+}
+
+func (r *Replay) closeFile() {
+	if err := r.w.Flush(); err != nil {
+		log.Error("cannot write recording", "err", err)
+	}
+	if err := r.file.Close(); err != nil {
+		log.Error("cannot close recording", "err", err)
+	}
+	log.Info("recording saved", "path", r.file.Name())
+	r.file = nil
+}
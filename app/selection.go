@@ -3,17 +3,25 @@
 package app
 
 import (
+	"errors"
 	"fmt"
 	"slices"
 
 	"github.com/bonoboris/satisfied/colors"
 	"github.com/bonoboris/satisfied/log"
-	"github.com/bonoboris/satisfied/matrix"
+	"github.com/bonoboris/satisfied/math32"
+	sc "github.com/bonoboris/satisfied/scene"
 	rl "github.com/gen2brain/raylib-go/raylib"
 )
 
 var selection Selection
 
+// rotationStep is the free rotation step of paths and text boxes, in degrees
+const rotationStep = 15
+
+// maxArrayCount is the maximum number of columns and of rows of an array duplicate
+const maxArrayCount = 32
+
 ////////////////////////////////////////////////////////////////////////////////////////////////////
 // Selection
 ////////////////////////////////////////////////////////////////////////////////////////////////////
@@ -43,10 +51,7 @@ func (s Selection) traceState(key, val string) {
 
 func (s *Selection) Reset() {
 	log.Debug("selection.reset")
-	s.BuildingIdxs = s.BuildingIdxs[:0]
-	s.PathIdxs = s.PathIdxs[:0]
-	s.TextBoxIdxs = s.TextBoxIdxs[:0]
-	s.Bounds = rl.NewRectangle(0, 0, 0, 0)
+	s.ObjectSelection.Reset()
 	s.mode = SelectionNormal
 	s.transform.reset()
 }
@@ -69,6 +74,10 @@ const (
 	SelectionDuplicate
 	// A single text box is being resized
 	SelectionTextBoxResize
+	// A vertex of a single path is being moved
+	SelectionPathVertex
+	// Selection is being duplicated as an array, the mouse sets the number of columns and rows
+	SelectionArray
 )
 
 func (m SelectionMode) String() string {
@@ -83,6 +92,10 @@ func (m SelectionMode) String() string {
 		return "SelectionDuplicate"
 	case SelectionTextBoxResize:
 		return "SelectionTextBoxResize"
+	case SelectionPathVertex:
+		return "SelectionPathVertex"
+	case SelectionArray:
+		return "SelectionArray"
 	default:
 		return "Invalid"
 	}
@@ -92,216 +105,117 @@ func (m SelectionMode) String() string {
 // selection transform
 ////////////////////////////////////////////////////////////////////////////////////////////////////
 
-// Represent a transformation of the selection (drag or duplicate)
+// Represent a transformation of the selection (drag, duplicate or array)
 type selectionTransform struct {
 	// Transformation state
 
-	// transformation rotation
-	rot int32
+	// transformation rotation and mirroring (without translation)
+	linear sc.Transform
+	// moved path vertex index, or segment index if insertVertex is true ([SelectionPathVertex])
+	vertex int
+	// whether a vertex is inserted in the middle of the segment ([SelectionPathVertex])
+	insertVertex bool
+	// copies layout, columns, rows and spacing direction follow the mouse ([SelectionArray])
+	array sc.ArrayPattern
 	// start position of the transformation
 	startPos rl.Vector2
 	// end position of the transformation
 	endPos rl.Vector2
 
 	// Transformation results / data
-	ObjectCollection
-	// invalid transformed paths mask
-	invalidPaths []bool
-	// invalid transformed buildings mask
-	invalidBuildings []bool
-	// whether the every transformed object is valid
-	isValid bool
-	// bounds of the transformed selection
-	bounds rl.Rectangle
-
-	// transformed building bounds buffer (reduce allocs)
-	_buildingBounds []rl.Rectangle
+	sc.Transformed
 }
 
 func (st selectionTransform) traceState() {
 	if log.WillTrace() {
 		for i, p := range st.Paths {
-			log.Trace("selectionTransform.paths", "i", i, "value", p, "invalid", st.invalidPaths[i])
+			log.Trace("selectionTransform.paths", "i", i, "value", p, "invalid", st.InvalidPaths[i])
 		}
 		for i, b := range st.Buildings {
-			log.Trace("selectionTransform.buildings", "i", i, "value", b, "invalid", st.invalidBuildings[i])
+			log.Trace("selectionTransform.buildings", "i", i, "value", b, "invalid", st.InvalidBuildings[i])
 		}
 		for i, tb := range st.TextBoxes {
 			log.Trace("selectionTransform.textboxes", "i", i, "value", tb)
 		}
-		log.Trace("selectionTransform", "isValid", st.isValid, "bounds", st.bounds)
-		log.Trace("selectionTransform", "rot", st.rot, "startPos", st.startPos, "endPos", st.endPos)
+		log.Trace("selectionTransform", "isValid", st.IsValid, "bounds", st.Bounds)
+		log.Trace("selectionTransform", "vertex", st.vertex, "insertVertex", st.insertVertex, "array", st.array)
+		log.Trace("selectionTransform", "rot", st.linear.Rot, "mirror", st.linear.Mirror, "startPos", st.startPos, "endPos", st.endPos)
 	}
 }
 
 func (st *selectionTransform) reset() {
-	st.rot = 0
+	st.linear = sc.Transform{}
+	st.vertex = 0
+	st.insertVertex = false
+	st.array = sc.ArrayPattern{}
 	st.startPos = rl.Vector2{}
 	st.endPos = rl.Vector2{}
-
-	st.Paths = st.Paths[:0]
-	st.Buildings = st.Buildings[:0]
-	st.TextBoxes = st.TextBoxes[:0]
-	st.invalidPaths = st.invalidPaths[:0]
-	st.invalidBuildings = st.invalidBuildings[:0]
-	st.isValid = false
-	st.bounds = rl.Rectangle{}
-}
-
-func (s selectionTransform) isIdentity() bool {
-	translate := grid.Snap(s.endPos.Subtract(s.startPos))
-	return s.rot%360 == 0 && translate.X == 0 && translate.Y == 0
+	st.Transformed.Reset()
 }
 
-// Matrix returns the rotation matrix of the selection
-func (s selectionTransform) transformMatrix(baseBounds rl.Rectangle) matrix.Matrix {
-	center := baseBounds.Center()
-	translate := grid.Snap(s.endPos.Subtract(s.startPos))
-	return matrix.NewTranslateV(translate.Add(center)).Rotate(s.rot).TranslateV(center.Negate())
+// transform returns the selection transformation, with the translation snapped to the grid
+func (st selectionTransform) transform() sc.Transform {
+	tr := st.linear
+	tr.Translate = grid.Snap(st.endPos.Subtract(st.startPos))
+	return tr
 }
 
 // recompute recomputes the transformed objects and whether they are valid
 func (st *selectionTransform) recompute(sel ObjectSelection, mode SelectionMode) {
 	if mode == SelectionTextBoxResize {
 		// endPos is the new bottom right corner
-		st.isValid = true
+		st.IsValid = true
 		st.TextBoxes = st.TextBoxes[:0]
 		tb := scene.TextBoxes[sel.TextBoxIdxs[0]]
-		tb.Bounds = rl.NewRectangleCorners(tb.Bounds.TopLeft(), grid.Snap(st.endPos))
+		end := grid.Snap(st.endPos)
+		if tb.Rot != 0 {
+			// rotated text boxes bottom right corner is not snapped
+			end = textBoxLocalPos(tb, st.endPos)
+		}
+		tb.Bounds = rl.NewRectangleCorners(tb.Bounds.TopLeft(), end)
 		st.TextBoxes = append(st.TextBoxes, tb)
 		return
 	}
-
-	// fast path for identity transform
-	// TODO: not copying anything would be faster
-	if st.isIdentity() {
-		switch mode {
-		case SelectionDuplicate:
-			pathIdxs := sel.FullPathIdxs()
-			st.Buildings = CopyIdxs(st.Buildings, scene.Buildings, sel.BuildingIdxs)
-			st.invalidBuildings = Repeat(st.invalidBuildings, true, len(sel.BuildingIdxs))
-			st.Paths = CopyIdxs(st.Paths, scene.Paths, pathIdxs)
-			st.invalidPaths = Repeat(st.invalidPaths, true, len(pathIdxs))
-			st.TextBoxes = CopyIdxs(st.TextBoxes, scene.TextBoxes, sel.TextBoxIdxs)
-			st.isValid = false
-			st.bounds = sel.Bounds
-		default:
-			pathIdxs := sel.AnyPathIdxs()
-			st.Buildings = CopyIdxs(st.Buildings, scene.Buildings, sel.BuildingIdxs)
-			st.invalidBuildings = Repeat(st.invalidBuildings, false, len(sel.BuildingIdxs))
-			st.Paths = CopyIdxs(st.Paths, scene.Paths, pathIdxs)
-			st.invalidPaths = Repeat(st.invalidPaths, false, len(pathIdxs))
-			st.TextBoxes = CopyIdxs(st.TextBoxes, scene.TextBoxes, sel.TextBoxIdxs)
-			st.isValid = true
-			st.bounds = sel.Bounds
+	if mode == SelectionPathVertex {
+		p := scene.Paths[sel.PathIdxs[0].Idx]
+		var pos rl.Vector2
+		if st.insertVertex {
+			pos = p.SegmentMiddle(st.vertex)
+			p.Vertices.Insert(st.vertex, pos)
+		} else {
+			pos = p.Vertices.Get(st.vertex)
 		}
+		p = p.MoveVertex(st.vertex, pos.Add(st.transform().Translate))
+		st.Paths = append(st.Paths[:0], p)
+		st.IsValid = p.IsValid()
+		st.InvalidPaths = append(st.InvalidPaths[:0], !st.IsValid)
+		st.Bounds = sel.Bounds
 		return
 	}
-
-	// TODO: store transformMatrix and recompute only when needed
-
-	st.isValid = true
-
-	var pathIdxs []int
-	if mode == SelectionDuplicate {
-		// we only want to duplicate paths that are entirely inside the selection
-		pathIdxs = sel.FullPathIdxs()
-	} else {
-		pathIdxs = sel.AnyPathIdxs()
-	}
-
-	ntb := len(sel.TextBoxIdxs)
-	nb := len(sel.BuildingIdxs)
-	np := len(pathIdxs)
-
-	// clears slices
-	st._buildingBounds = slices.Grow(st._buildingBounds[:0], nb)
-	st.Buildings = slices.Grow(st.Buildings[:0], nb)
-	st.invalidBuildings = slices.Grow(st.invalidBuildings[:0], nb)
-	st.Paths = slices.Grow(st.Paths[:0], np)
-	st.invalidPaths = slices.Grow(st.invalidPaths[:0], np)
-	st.TextBoxes = slices.Grow(st.TextBoxes[:0], ntb)
-
-	mat := st.transformMatrix(sel.Bounds)
-	st.bounds = mat.ApplyRecRec(sel.Bounds)
-
-	// Buildings
-	for _, idx := range sel.BuildingIdxs {
-		b := scene.Buildings[idx]
-		b.Pos = mat.ApplyV(b.Pos)
-		b.Rot = (b.Rot + st.rot) % 360
-		st.Buildings = append(st.Buildings, b)
-	}
-
-	// TextBoxes
-	for _, idx := range sel.TextBoxIdxs {
-		tb := scene.TextBoxes[idx]
-		pos := mat.ApplyV(tb.Bounds.Position())
-		tb.Bounds.X = pos.X
-		tb.Bounds.Y = pos.Y
-		st.TextBoxes = append(st.TextBoxes, tb)
-	}
-
-	// Paths & invalidPaths
-	switch mode {
-	case SelectionDuplicate:
-		for _, idx := range pathIdxs {
-			p := scene.Paths[idx]
-			p.Start = mat.ApplyV(p.Start)
-			p.End = mat.ApplyV(p.End)
-			st.Paths = append(st.Paths, p)
-			if p.IsValid() {
-				st.invalidPaths = append(st.invalidPaths, false)
-			} else {
-				st.invalidPaths = append(st.invalidPaths, true)
-				st.isValid = false
-			}
-		}
-	case SelectionDrag, SelectionNormal:
-		for _, elt := range sel.PathIdxs {
-			p := scene.Paths[elt.Idx]
-			if elt.Start {
-				p.Start = mat.ApplyV(p.Start)
-			}
-			if elt.End {
-				p.End = mat.ApplyV(p.End)
-			}
-			st.Paths = append(st.Paths, p)
-			if p.IsValid() {
-				st.invalidPaths = append(st.invalidPaths, false)
-			} else {
-				st.invalidPaths = append(st.invalidPaths, true)
-				st.isValid = false
-			}
-		}
+	if mode == SelectionArray {
+		// the mouse offset gives the number of columns and rows, and their direction
+		delta := grid.Snap(st.endPos.Subtract(st.startPos))
+		st.array.Cols, st.array.Spacing.X = arrayAxis(delta.X, st.array.Spacing.X)
+		st.array.Rows, st.array.Spacing.Y = arrayAxis(delta.Y, st.array.Spacing.Y)
+		st.ComputeArray(scene.ObjectCollection, sel, st.array)
+		return
 	}
+	// TODO: store transform and recompute only when needed
+	st.Compute(scene.ObjectCollection, sel, st.transform(), mode == SelectionDuplicate)
+}
 
-	// precompute transformed building bounds & initialize invalid to false
-	for i := range nb {
-		st._buildingBounds = append(st._buildingBounds, st.Buildings[i].Bounds())
-		st.invalidBuildings = append(st.invalidBuildings, false)
+// arrayAxis returns the number of copies along an axis and their signed spacing, for a mouse offset
+// along this axis
+func arrayAxis(offset, spacing float32) (int, float32) {
+	step := math32.Abs(spacing)
+	if step == 0 {
+		return 1, 0
 	}
-
-	isSelectedIt := NewMaskIterator(sel.BuildingIdxs)
-	for _, sb := range scene.Buildings {
-		sb := sb.Bounds()
-		// TODO: use st.Buildings bounds only in the skip condition ?
-		if mode != SelectionDuplicate && isSelectedIt.Next() || !st.bounds.CheckCollisionRec(sb) {
-			// skip:
-			//   - scene building in selection (except when duplicating)
-			//   - scene building outside transformation outer bounds
-			continue
-		} else {
-			// check against every transformed building
-			for i, bounds := range st._buildingBounds {
-				// no need to call CheckCollisionRec if st.invalidBuildings[i] is already true
-				if !st.invalidBuildings[i] && bounds.CheckCollisionRec(sb) {
-					st.isValid = false
-					st.invalidBuildings[i] = true
-				}
-			}
-		}
+	n := min(int(math32.Round(math32.Abs(offset)/step))+1, maxArrayCount)
+	if offset < 0 {
+		return n, -step
 	}
+	return n, step
 }
 
 ////////////////////////////////////////////////////////////////////////////////////////////////////
@@ -321,12 +235,44 @@ func (s *Selection) GetAction() Action {
 		case BindingDuplicate:
 			// Duplicate use center of current selection as start position
 			return s.doBeginTransformation(SelectionDuplicate, s.Bounds.Center(), false)
+		case BindingArray:
+			return s.doBeginTransformation(SelectionArray, s.Bounds.Center(), false)
 		case BindingDrag:
 			return s.doBeginTransformation(SelectionDrag, s.Bounds.Center(), false)
 		case BindingDelete:
 			return s.doDelete()
 		case BindingRotate:
-			return s.doRotate()
+			return s.doRotate(90)
+		case BindingRotateStep:
+			return s.doRotate(rotationStep)
+		case BindingMirrorH:
+			return s.doMirror(false)
+		case BindingMirrorV:
+			return s.doMirror(true)
+		case BindingSpline:
+			return s.doToggleSpline()
+		case BindingAlignLeft:
+			return s.doAlign(sc.AlignLeft)
+		case BindingAlignRight:
+			return s.doAlign(sc.AlignRight)
+		case BindingAlignTop:
+			return s.doAlign(sc.AlignTop)
+		case BindingAlignBottom:
+			return s.doAlign(sc.AlignBottom)
+		case BindingAlignCenterX:
+			return s.doAlign(sc.AlignCenterX)
+		case BindingAlignCenterY:
+			return s.doAlign(sc.AlignCenterY)
+		case BindingDistributeX:
+			return s.doAlign(sc.DistributeX)
+		case BindingDistributeY:
+			return s.doAlign(sc.DistributeY)
+		case BindingConnected:
+			return s.doSelectChain(sc.ChainConnected)
+		case BindingUpstream:
+			return s.doSelectChain(sc.ChainUpstream)
+		case BindingDownstream:
+			return s.doSelectChain(sc.ChainDownstream)
 
 		case BindingLeft:
 			return s.doMoveBy(vec2(-1, 0))
@@ -338,26 +284,61 @@ func (s *Selection) GetAction() Action {
 			return s.doMoveBy(vec2(0, +1))
 		}
 		if mouse.Left.Pressed && mouse.InScene {
+			if idx, ok := s.vertexPathIdx(); ok {
+				if vertex, insert, ok := pathHandleAt(scene.Paths[idx], mouse.Pos); ok {
+					return s.doBeginVertexDrag(vertex, insert, mouse.Pos)
+				}
+			}
 			switch {
-			case scene.Hovered.IsEmpty():
+			case scene.Hovered().IsEmpty():
 				return selector.doInit(mouse.Pos)
-			case selection.Contains(scene.Hovered):
-				if s.mode == SelectionSingleTextBox && scene.TextBoxes[s.TextBoxIdxs[0]].HandleRect().CheckCollisionPoint(mouse.Pos) {
+			case selection.Contains(scene.Hovered()):
+				if s.mode == SelectionSingleTextBox && s.isOverTextBoxHandle(mouse.Pos) {
 					return s.doBeginTransformation(SelectionTextBoxResize, mouse.Pos, true)
 				}
 				// Drag use mouse position as start position
 				return s.doBeginTransformation(SelectionDrag, mouse.Pos, true)
 			default:
-				return s.doInitSingleDrag(scene.Hovered, mouse.Pos)
+				return s.doInitSingleDrag(scene.Hovered(), mouse.Pos)
 			}
 		}
-	case SelectionDuplicate, SelectionDrag, SelectionTextBoxResize:
+	case SelectionArray:
+		// arrow keys change the spacing
+		switch keyboard.Binding() {
+		case BindingEscape:
+			return s.doEndTransformation(true)
+		case BindingRotate:
+			return s.doRotate(90)
+		case BindingRotateStep:
+			return s.doRotate(rotationStep)
+		case BindingLeft:
+			return s.doArraySpacing(vec2(-1, 0))
+		case BindingRight:
+			return s.doArraySpacing(vec2(+1, 0))
+		case BindingUp:
+			return s.doArraySpacing(vec2(0, -1))
+		case BindingDown:
+			return s.doArraySpacing(vec2(0, +1))
+		}
+		switch {
+		case mouse.Left.Released:
+			return s.doEndTransformation(false)
+		case mouse.InScene && !mouse.Left.Down:
+			return s.doMoveTo(mouse.Pos)
+		}
+	case SelectionDuplicate, SelectionDrag, SelectionTextBoxResize, SelectionPathVertex:
 		// TODO: Implement arrow keys nudging ?
 		switch keyboard.Binding() {
 		case BindingEscape:
 			return s.doEndTransformation(true)
 		case BindingRotate:
-			return s.doRotate()
+			return s.doRotate(90)
+		case BindingRotateStep:
+			return s.doRotate(rotationStep)
+		case BindingMirrorH:
+			return s.doMirror(false)
+		case BindingMirrorV:
+			return s.doMirror(true)
 		}
 		switch {
 		case mouse.Left.Released:
@@ -371,6 +352,23 @@ func (s *Selection) GetAction() Action {
 	return nil
 }
 
+// vertexPathIdx returns the index of the path if the selection is a single full path whose
+// vertices can be edited (not a power line)
+func (s Selection) vertexPathIdx() (int, bool) {
+	if s.mode != SelectionNormal || len(s.BuildingIdxs) > 0 || len(s.TextBoxIdxs) > 0 ||
+		len(s.PathIdxs) != 1 || !s.PathIdxs[0].Start || !s.PathIdxs[0].End ||
+		scene.Paths[s.PathIdxs[0].Idx].Def().IsPowerLine {
+		return -1, false
+	}
+	return s.PathIdxs[0].Idx, true
+}
+
+// isOverTextBoxHandle returns true if pos is over the resize handle of the single selected text box
+func (s Selection) isOverTextBoxHandle(pos rl.Vector2) bool {
+	tb := scene.TextBoxes[s.TextBoxIdxs[0]]
+	return textBoxHandleRect(tb).CheckCollisionPoint(textBoxLocalPos(tb, pos))
+}
+
 // reset [Selection.mode]
 //
 // - empty selection -> [ModeNormal]
@@ -403,7 +401,7 @@ func (s *Selection) doInitSingleDrag(obj Object, pos rl.Vector2) Action {
 		s.Bounds = scene.Buildings[obj.Idx].Bounds()
 	case TypePath:
 		s.PathIdxs = append(s.PathIdxs, PathSel{Idx: obj.Idx, Start: true, End: true})
-		s.Bounds = rl.NewRectangleCorners(scene.Paths[obj.Idx].Start, scene.Paths[obj.Idx].End)
+		s.Bounds = scene.Paths[obj.Idx].Bounds()
 	case TypePathStart:
 		s.PathIdxs = append(s.PathIdxs, PathSel{Idx: obj.Idx, Start: true})
 		s.Bounds = rl.NewRectangleV(scene.Paths[obj.Idx].Start, rl.Vector2{})
@@ -412,7 +410,7 @@ func (s *Selection) doInitSingleDrag(obj Object, pos rl.Vector2) Action {
 		s.Bounds = rl.NewRectangleV(scene.Paths[obj.Idx].End, rl.Vector2{})
 	case TypeTextBox:
 		s.TextBoxIdxs = append(s.TextBoxIdxs, obj.Idx)
-		s.Bounds = scene.TextBoxes[obj.Idx].Bounds
+		s.Bounds = scene.TextBoxes[obj.Idx].AABB()
 	default:
 		panic("invalid object type")
 	}
@@ -433,7 +431,7 @@ func (s *Selection) doInitSingleDrag(obj Object, pos rl.Vector2) Action {
 // - other -> [ModeSelection] in [SelectionNormal]
 func (s *Selection) doInitSelection(sel ObjectSelection) Action {
 	log.Debug("selection.doInitSelection", "selected", sel)
-	sel.copy(&s.ObjectSelection)
+	sel.CopyTo(&s.ObjectSelection)
 	s.transform.reset()
 	s.transform.startPos = s.Bounds.Center()
 	s.transform.endPos = s.Bounds.Center()
@@ -460,7 +458,7 @@ func (s *Selection) doBeginTransformation(mode SelectionMode, pos rl.Vector2, mo
 	log.Debug("selection.doBeginTransformation", "mode", mode, "pos", pos)
 	app.Mode.Assert(ModeSelection)
 
-	assert(mode == SelectionDrag || mode == SelectionDuplicate || mode == SelectionTextBoxResize, "invalid selection transform mode")
+	assert(mode == SelectionDrag || mode == SelectionDuplicate || mode == SelectionTextBoxResize || mode == SelectionArray, "invalid selection transform mode")
 
 	s.transformMoveOnMouseDown = moveOnMouseDown
 
@@ -489,6 +487,11 @@ func (s *Selection) doBeginTransformation(mode SelectionMode, pos rl.Vector2, mo
 	}
 
 	s.transform.reset()
+	if mode == SelectionArray {
+		// copies side by side by default
+		spacing := vec2(max(1, math32.Ceil(s.Bounds.Width)), max(1, math32.Ceil(s.Bounds.Height)))
+		s.transform.array = sc.ArrayPattern{Cols: 1, Rows: 1, Spacing: spacing}
+	}
 
 	s.mode = mode
 	s.transform.startPos = pos
@@ -499,6 +502,63 @@ func (s *Selection) doBeginTransformation(mode SelectionMode, pos rl.Vector2, mo
 	return nil
 }
 
+// doBeginVertexDrag begins moving a vertex of the single selected path, or inserting a vertex in
+// the middle of a segment if insert is true
+func (s *Selection) doBeginVertexDrag(idx int, insert bool, pos rl.Vector2) Action {
+	s.traceState("before", "doBeginVertexDrag")
+	log.Debug("selection.doBeginVertexDrag", "idx", idx, "insert", insert, "pos", pos)
+	app.Mode.Assert(ModeSelection)
+
+	if _, ok := s.vertexPathIdx(); !ok {
+		log.Debug("selection.doBeginVertexDrag", "action", "skipped", "reason", "not a single path or a power line")
+		s.traceState("after", "doBeginVertexDrag")
+		return nil
+	}
+	s.transform.reset()
+	s.transformMoveOnMouseDown = true
+	s.mode = SelectionPathVertex
+	s.transform.vertex = idx
+	s.transform.insertVertex = insert
+	s.transform.startPos = pos
+	s.transform.endPos = pos
+	s.transform.recompute(s.ObjectSelection, s.mode)
+
+	s.traceState("after", "doBeginVertexDrag")
+	return nil
+}
+
+// doToggleSpline switches the fully selected paths between straight segments and curves
+func (s *Selection) doToggleSpline() Action {
+	s.traceState("before", "doToggleSpline")
+	log.Debug("selection.doToggleSpline", "selection.mode", s.mode)
+	app.Mode.Assert(ModeSelection)
+
+	// power lines are a single straight segment
+	idxs := slices.DeleteFunc(s.FullPathIdxs(), func(idx int) bool { return scene.Paths[idx].Def().IsPowerLine })
+	if len(idxs) == 0 {
+		log.Debug("selection.doToggleSpline", "action", "skipped", "reason", "no full path, other than a power line, selected")
+		return nil
+	}
+	// all curves if any is straight, all straight otherwise
+	spline := false
+	for _, idx := range idxs {
+		spline = spline || !scene.Paths[idx].Spline
+	}
+	var sel ObjectSelection
+	var oc ObjectCollection
+	for _, idx := range idxs {
+		sel.PathIdxs = append(sel.PathIdxs, PathSel{Idx: idx, Start: true, End: true})
+		p := scene.Paths[idx]
+		p.Spline = spline
+		oc.Paths = append(oc.Paths, p)
+	}
+	scene.ModifyObjects(sel, oc)
+	s.RecomputeBounds(scene.ObjectCollection)
+
+	s.traceState("after", "doToggleSpline")
+	return nil
+}
+
 func (s *Selection) doMoveBy(delta rl.Vector2) Action {
 	s.traceState("before", "doMoveBy")
 	log.Debug("selection.doMoveBy", "delta", delta, "selection.mode", s.mode)
@@ -510,7 +570,7 @@ func (s *Selection) doMoveBy(delta rl.Vector2) Action {
 		center := s.Bounds.Center()
 		s.transform.startPos = center
 		s.transform.endPos = center.Add(delta)
-		s.transform.rot = 0
+		s.transform.linear = sc.Transform{}
 		s.transform.recompute(s.ObjectSelection, s.mode)
 		s.traceState("after", "doMoveBy")
 		return s.doEndTransformation(false)
@@ -533,7 +593,7 @@ func (s *Selection) doMoveTo(pos rl.Vector2) Action {
 		center := s.Bounds.Center()
 		s.transform.startPos = center
 		s.transform.endPos = pos
-		s.transform.rot = 0
+		s.transform.linear = sc.Transform{}
 		s.transform.recompute(s.ObjectSelection, s.mode)
 		s.traceState("after", "doMoveTo")
 		return s.doEndTransformation(false)
@@ -545,30 +605,238 @@ func (s *Selection) doMoveTo(pos rl.Vector2) Action {
 	}
 }
 
-func (s *Selection) doRotate() Action {
+// doRotate rotates the selection by angle degrees, buildings can only be rotated by multiples of 90°
+func (s *Selection) doRotate(angle int32) Action {
 	s.traceState("before", "doRotate")
-	log.Debug("selection.doRotate", "selection.mode", s.mode)
+	log.Debug("selection.doRotate", "angle", angle, "selection.mode", s.mode)
 	app.Mode.Assert(ModeSelection)
 
-	switch s.mode {
-	case SelectionSingleTextBox, SelectionTextBoxResize:
-		// no-op text boxes cannot be rotated
+	if s.mode == SelectionTextBoxResize || s.mode == SelectionPathVertex {
+		// no-op: text boxes are resized along their unrotated bounds, vertices are only moved
 		s.traceState("after", "doRotate")
 		return nil
-	case SelectionNormal:
-		// instantly rotate
+	}
+	if angle%90 != 0 && len(s.BuildingIdxs) > 0 {
+		log.Debug("selection.doRotate", "action", "skipped", "reason", "buildings can only be rotated by multiples of 90°")
+		s.traceState("after", "doRotate")
+		return nil
+	}
+	if s.mode == SelectionArray {
+		// rotation between 2 consecutive copies
+		s.transform.array.Rot = sc.Transform{Rot: s.transform.array.Rot}.Rotate(angle).Rot
+		s.transform.recompute(s.ObjectSelection, s.mode)
+		s.traceState("after", "doRotate")
+		return nil
+	}
+	action := s.doTransformLinear(func(tr sc.Transform) sc.Transform { return tr.Rotate(angle) })
+	s.traceState("after", "doRotate")
+	return action
+}
+
+// doMirror mirrors the selection horizontally, or vertically
+func (s *Selection) doMirror(vertical bool) Action {
+	s.traceState("before", "doMirror")
+	log.Debug("selection.doMirror", "vertical", vertical, "selection.mode", s.mode)
+	app.Mode.Assert(ModeSelection)
+
+	if s.mode == SelectionTextBoxResize || s.mode == SelectionPathVertex || s.mode == SelectionArray {
+		// no-op: text boxes are resized along their unrotated bounds, vertices are only moved, array
+		// copies are only rotated
+		s.traceState("after", "doMirror")
+		return nil
+	}
+	action := s.doTransformLinear(func(tr sc.Transform) sc.Transform {
+		if vertical {
+			return tr.MirrorV()
+		}
+		return tr.MirrorH()
+	})
+	s.traceState("after", "doMirror")
+	return action
+}
+
+// doArraySpacing changes the spacing between the array copies by delta, keeping at least 1m
+func (s *Selection) doArraySpacing(delta rl.Vector2) Action {
+	s.traceState("before", "doArraySpacing")
+	log.Debug("selection.doArraySpacing", "delta", delta)
+	app.Mode.Assert(ModeSelection)
+	assert(s.mode == SelectionArray, "cannot change array spacing in "+s.mode.String())
+
+	spacing := s.transform.array.Spacing
+	// the spacing sign is the copies direction, set from the mouse position in recompute
+	s.transform.array.Spacing = vec2(max(1, math32.Abs(spacing.X)+delta.X), max(1, math32.Abs(spacing.Y)+delta.Y))
+	s.transform.recompute(s.ObjectSelection, s.mode)
+
+	s.traceState("after", "doArraySpacing")
+	return nil
+}
+
+// doAlign aligns or distributes the selected buildings and text boxes, the selected paths are not
+// moved
+func (s *Selection) doAlign(alignment Alignment) Action {
+	s.traceState("before", "doAlign")
+	log.Debug("selection.doAlign", "alignment", alignment, "selection.mode", s.mode)
+	app.Mode.Assert(ModeSelection)
+	assert(s.mode == SelectionNormal || s.mode == SelectionSingleTextBox, "cannot align in "+s.mode.String())
+
+	moved := s.transform.Align(scene.ObjectCollection, s.ObjectSelection, alignment)
+	switch {
+	case !moved:
+		log.Debug("selection.doAlign", "action", "skipped", "reason", "already aligned")
+	case !s.transform.IsValid:
+		log.Info("selection.doAlign", "action", "skipped", "reason", "buildings would overlap")
+	default:
+		sel := ObjectSelection{BuildingIdxs: s.BuildingIdxs, TextBoxIdxs: s.TextBoxIdxs}
+		scene.ModifyObjects(sel, s.transform.ObjectCollection)
+		s.RecomputeBounds(scene.ObjectCollection)
+	}
+	s.transform.reset()
+
+	s.traceState("after", "doAlign")
+	return nil
+}
+
+// doSelectChain selects the buildings and paths linked to the selected ones in the dir direction,
+// the selection is kept if none is linked
+func (s *Selection) doSelectChain(dir ChainDir) Action {
+	s.traceState("before", "doSelectChain")
+	log.Debug("selection.doSelectChain", "dir", dir, "selection.mode", s.mode)
+	app.Mode.Assert(ModeSelection)
+	assert(s.mode == SelectionNormal || s.mode == SelectionSingleTextBox, "cannot select a chain in "+s.mode.String())
+
+	sel := scene.SelectChain(s.ObjectSelection, dir)
+	if sel.IsEmpty() {
+		log.Debug("selection.doSelectChain", "action", "skipped", "reason", "no building nor path selected")
+		return nil
+	}
+	return s.doInitSelection(sel)
+}
+
+// transformTo rotates the selection by rot degrees around its center, then moves it so that its
+// bounds top left corner is at origin, without snapping to the grid.
+//
+// Only in [SelectionNormal] and [SelectionSingleTextBox] modes, returns why the transformation is
+// not performed if it is invalid.
+func (s *Selection) transformTo(origin rl.Vector2, rot int32) error {
+	s.traceState("before", "transformTo")
+	log.Debug("selection.transformTo", "origin", origin, "rot", rot, "selection.mode", s.mode)
+	app.Mode.Assert(ModeSelection)
+
+	if s.mode != SelectionNormal && s.mode != SelectionSingleTextBox {
+		return fmt.Errorf("cannot transform the selection in %v", s.mode)
+	}
+	if rot%90 != 0 && len(s.BuildingIdxs) > 0 {
+		return errors.New("buildings can only be rotated by multiples of 90°")
+	}
+
+	// the translation depends on the rotated bounds
+	tr := sc.Transform{}.Rotate(rot)
+	s.transform.Compute(scene.ObjectCollection, s.ObjectSelection, tr, false)
+	tr.Translate = origin.Subtract(s.transform.Bounds.TopLeft())
+	s.transform.Compute(scene.ObjectCollection, s.ObjectSelection, tr, false)
+	defer s.transform.reset()
+
+	if !s.transform.IsValid {
+		s.traceState("after", "transformTo")
+		return errors.New("the transformed selection overlaps other objects")
+	}
+	if !tr.IsIdentity() {
+		scene.ModifyObjects(s.ObjectSelection, s.transform.ObjectCollection)
+		s.RecomputeBounds(scene.ObjectCollection)
+	}
+	s.traceState("after", "transformTo")
+	return nil
+}
+
+// arrayTo adds copies of the selection laid out with pat, like [SelectionArray] does with the mouse
+func (s *Selection) arrayTo(pat sc.ArrayPattern) error {
+	s.traceState("before", "arrayTo")
+	log.Debug("selection.arrayTo", "pattern", pat, "selection.mode", s.mode)
+	app.Mode.Assert(ModeSelection)
+
+	if s.mode != SelectionNormal && s.mode != SelectionSingleTextBox {
+		return fmt.Errorf("cannot duplicate the selection in %v", s.mode)
+	}
+	if pat.Cols < 1 || pat.Cols > maxArrayCount || pat.Rows < 1 || pat.Rows > maxArrayCount {
+		return fmt.Errorf("the array must have 1 to %d columns and rows", maxArrayCount)
+	}
+	if pat.Count() == 0 {
+		return errors.New("the array has no copies")
+	}
+
+	s.transform.ComputeArray(scene.ObjectCollection, s.ObjectSelection, pat)
+	defer s.transform.reset()
+
+	if !s.transform.IsValid {
+		s.traceState("after", "arrayTo")
+		return errors.New("the array copies overlap other objects")
+	}
+	scene.AddObjects(s.transform.ObjectCollection)
+	s.traceState("after", "arrayTo")
+	return nil
+}
+
+// clockableIdxs returns the indices of the selected buildings whose clock speed can be changed
+func (s *Selection) clockableIdxs() []int {
+	var idxs []int
+	for _, idx := range s.BuildingIdxs {
+		if scene.Buildings[idx].Def().Clockable {
+			idxs = append(idxs, idx)
+		}
+	}
+	return idxs
+}
+
+// setModifiers sets the clock speed, power shards and somersloops of the selected clockable
+// buildings.
+//
+// Only in [SelectionNormal] mode, returns why the modifiers are not set if they are invalid for a
+// building.
+func (s *Selection) setModifiers(mods Modifiers) error {
+	s.traceState("before", "setModifiers")
+	log.Debug("selection.setModifiers", "mods", mods, "selection.mode", s.mode)
+	app.Mode.Assert(ModeSelection)
+
+	if s.mode != SelectionNormal {
+		return fmt.Errorf("cannot set the modifiers in %v", s.mode)
+	}
+	mods = mods.Normalized()
+	var sel ObjectSelection
+	var oc ObjectCollection
+	for _, idx := range s.clockableIdxs() {
+		b := scene.Buildings[idx]
+		if err := mods.Validate(b.Def()); err != nil {
+			return fmt.Errorf("%s: %w", b.Def().Class, err)
+		}
+		if b.Mods != mods {
+			b.Mods = mods
+			sel.BuildingIdxs = append(sel.BuildingIdxs, idx)
+			oc.Buildings = append(oc.Buildings, b)
+		}
+	}
+	if len(sel.BuildingIdxs) > 0 {
+		scene.ModifyObjects(sel, oc)
+	}
+	s.traceState("after", "setModifiers")
+	return nil
+}
+
+// doTransformLinear updates the transformation rotation and mirroring with f.
+//
+// In [SelectionNormal] and [SelectionSingleTextBox] modes, the selection is instantly transformed
+// around its center.
+func (s *Selection) doTransformLinear(f func(sc.Transform) sc.Transform) Action {
+	switch s.mode {
+	case SelectionNormal, SelectionSingleTextBox:
 		center := s.Bounds.Center()
 		s.transform.startPos = center
 		s.transform.endPos = center
-		s.transform.rot = 90
+		s.transform.linear = f(sc.Transform{})
 		s.transform.recompute(s.ObjectSelection, s.mode)
-		s.traceState("after", "doRotate")
 		return s.doEndTransformation(false)
 	default:
-		s.transform.rot += 90
+		s.transform.linear = f(s.transform.linear)
 		s.transform.recompute(s.ObjectSelection, s.mode)
-
-		s.traceState("after", "doRotate")
 		return nil
 	}
 }
@@ -578,20 +846,21 @@ func (s *Selection) doEndTransformation(discard bool) Action {
 	log.Debug("selection.doEndTransformation", "discard", discard, "selection.mode", s.mode)
 	app.Mode.Assert(ModeSelection)
 
-	if !discard && s.transform.isValid && !s.transform.isIdentity() {
+	// array copies are valid only if there are some, whatever the mouse position
+	if !discard && s.transform.IsValid && (s.mode == SelectionArray || !s.transform.transform().IsIdentity()) {
 		switch s.mode {
-		case SelectionDuplicate:
+		case SelectionDuplicate, SelectionArray:
 			scene.AddObjects(s.transform.ObjectCollection)
 		default:
 			scene.ModifyObjects(s.ObjectSelection, s.transform.ObjectCollection)
-			s.Bounds = s.transform.bounds
+			s.Bounds = s.transform.Bounds
 		}
 	}
 
 	if discard || s.mode != SelectionDuplicate {
 		s.transform.reset()
 		appMode, resets := s.resetMode()
-		s.recomputeBounds(scene.ObjectCollection)
+		s.RecomputeBounds(scene.ObjectCollection)
 		s.traceState("after", "doEndTransformation")
 		return app.doSwitchMode(appMode, resets)
 	}
@@ -613,7 +882,19 @@ func (s *Selection) Dispatch(action Action) Action {
 	case SelectionActionMoveTo:
 		return s.doMoveTo(action.Pos)
 	case SelectionActionRotate:
-		return s.doRotate()
+		return s.doRotate(action.Angle)
+	case SelectionActionMirror:
+		return s.doMirror(action.Vertical)
+	case SelectionActionBeginVertexDrag:
+		return s.doBeginVertexDrag(action.Idx, action.Insert, action.Pos)
+	case SelectionActionToggleSpline:
+		return s.doToggleSpline()
+	case SelectionActionArraySpacing:
+		return s.doArraySpacing(action.Delta)
+	case SelectionActionAlign:
+		return s.doAlign(action.Alignment)
+	case SelectionActionSelectChain:
+		return s.doSelectChain(action.Dir)
 	case SelectionActionEndTransformation:
 		return s.doEndTransformation(action.Discard)
 
@@ -642,23 +923,23 @@ func drawSelectionBounds(bounds rl.Rectangle, isValid bool) {
 
 // draws the transformed selection
 func (s *selectionTransform) draw(state DrawState) {
-	drawSelectionBounds(s.bounds, s.isValid)
+	drawSelectionBounds(s.Bounds, s.IsValid)
 	for i, p := range s.Paths {
-		if s.invalidPaths[i] {
-			p.Draw(DrawInvalid)
+		if s.InvalidPaths[i] {
+			drawPath(p, DrawInvalid)
 		} else {
-			p.Draw(state)
+			drawPath(p, state)
 		}
 	}
 	for i, b := range s.Buildings {
-		if s.invalidBuildings[i] {
-			b.Draw(DrawInvalid)
+		if s.InvalidBuildings[i] {
+			drawBuilding(b, DrawInvalid)
 		} else {
-			b.Draw(state)
+			drawBuilding(b, state)
 		}
 	}
 	for _, tb := range s.TextBoxes {
-		tb.Draw(state, selection.mode == SelectionTextBoxResize)
+		drawTextBox(tb, state, selection.mode == SelectionTextBoxResize)
 	}
 }
 
@@ -668,9 +949,15 @@ func (s Selection) Draw() {
 	case SelectionNormal, SelectionSingleTextBox:
 		// only draw the selection rectangle, buildings and paths are drawn in [Scene.Draw]
 		drawSelectionBounds(s.Bounds, true)
+		if idx, ok := s.vertexPathIdx(); ok {
+			drawPathHandles(scene.Paths[idx])
+		}
 	case SelectionDrag, SelectionTextBoxResize:
 		s.transform.draw(DrawClicked)
-	case SelectionDuplicate:
+	case SelectionPathVertex:
+		s.transform.draw(DrawClicked)
+		drawPathHandles(s.transform.Paths[0])
+	case SelectionDuplicate, SelectionArray:
 		s.transform.draw(DrawNew)
 	}
 }
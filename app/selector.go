@@ -43,7 +43,7 @@ func (s *Selector) Reset() {
 	s.selecting = false
 	s.start = rl.Vector2{}
 	s.end = rl.Vector2{}
-	s.ObjectSelection.reset()
+	s.ObjectSelection.Reset()
 	s.traceState("after", "Reset")
 }
 
@@ -54,10 +54,10 @@ func (s *Selector) GetAction() Action {
 	app.Mode.Assert(ModeNormal)
 
 	if mouse.Left.Pressed && mouse.InScene {
-		if scene.Hovered.IsEmpty() {
+		if scene.Hovered().IsEmpty() {
 			return s.doInit(mouse.Pos)
 		} else {
-			return selection.doInitSingleDrag(scene.Hovered, mouse.Pos)
+			return selection.doInitSingleDrag(scene.Hovered(), mouse.Pos)
 		}
 	}
 	if s.selecting {
@@ -73,7 +73,7 @@ func (s *Selector) GetAction() Action {
 func (s *Selector) doInit(pos rl.Vector2) Action {
 	s.traceState("before", "doInit")
 	log.Debug("selector.doInit", "pos", pos)
-	s.ObjectSelection.reset()
+	s.ObjectSelection.Reset()
 	s.selecting = true
 	s.start = pos
 	s.end = pos
@@ -88,7 +88,7 @@ func (s *Selector) doMoveTo(pos rl.Vector2) Action {
 	assert(s.selecting, "Selector.doMoveTo: selector not active")
 	s.end = pos
 	rect := rl.NewRectangleCorners(s.start, s.end)
-	s.ObjectSelection.reset()
+	s.ObjectSelection.Reset()
 	scene.SelectFromRect(&s.ObjectSelection, rect)
 	s.traceState("after", "doMoveTo")
 	return nil
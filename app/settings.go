@@ -0,0 +1,220 @@
+// settings - Persistent application settings and recent files cache
+
+package app
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"github.com/bonoboris/satisfied/log"
+	rl "github.com/gen2brain/raylib-go/raylib"
+)
+
+const (
+	// Name of the application directory in the user config directory
+	configDirName = "satisfied"
+	// Name of the settings file in the application config directory
+	settingsFileName = "settings.json"
+	// Maximum number of recent projects kept in the settings
+	maxRecentFiles = 10
+)
+
+// Settings holds the application settings persisted between sessions
+var settings Settings
+
+// Settings holds the application settings persisted between sessions
+type Settings struct {
+	// Window position and size
+	Window WindowSettings
+	// Last camera state by project path
+	Cameras map[string]CameraState
+	// Recently opened / saved projects, most recent first
+	RecentFiles []string
+	// Default grid snap step in world units
+	SnapStep float32
+	// Preferred target FPS (set with the --fps flag)
+	Fps int
+	// Number of rotating backups kept when saving a project (0 to disable)
+	Backups int
+	// Autosave interval in seconds (0 to disable)
+	AutosaveInterval float32
+
+	// path to the settings file (empty if it cannot be determined)
+	path string
+}
+
+// WindowSettings holds the window position and size
+type WindowSettings struct {
+	X, Y          int
+	Width, Height int
+	Maximized     bool
+}
+
+func defaultSettings() Settings {
+	return Settings{
+		Window:           WindowSettings{Width: windowWidth, Height: windowHeight, Maximized: true},
+		Cameras:          map[string]CameraState{},
+		SnapStep:         1,
+		Fps:              DefaultTargetFPS,
+		Backups:          3,
+		AutosaveInterval: 60,
+	}
+}
+
+func (s Settings) traceState(key, val string) {
+	if log.WillTrace() {
+		if key != "" && val != "" {
+			log.Trace("settings", key, val, "path", s.path)
+		}
+		log.Trace("settings", "window", s.Window, "snapStep", s.SnapStep, "fps", s.Fps)
+		log.Trace("settings", "backups", s.Backups, "autosaveInterval", s.AutosaveInterval)
+		log.Trace("settings", "recentFiles", s.RecentFiles)
+		for path, cam := range s.Cameras {
+			log.Trace("settings.cameras", "path", path, "value", cam)
+		}
+	}
+}
+
+// ConfigDir returns the application directory in the user config directory
+func ConfigDir() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, configDirName), nil
+}
+
+// Load loads the settings from the settings file.
+//
+// On error, default settings are used and the error is returned.
+func (s *Settings) Load() error {
+	*s = defaultSettings()
+	dir, err := ConfigDir()
+	if err != nil {
+		log.Error("cannot find user config directory", "err", err)
+		return err
+	}
+	s.path = filepath.Join(dir, settingsFileName)
+
+	data, err := os.ReadFile(s.path)
+	if errors.Is(err, fs.ErrNotExist) {
+		log.Info("settings file not found, using defaults", "path", s.path)
+		return nil
+	} else if err != nil {
+		log.Error("cannot read settings file", "path", s.path, "err", err)
+		return err
+	}
+	loaded := defaultSettings()
+	if err := json.Unmarshal(data, &loaded); err != nil {
+		log.Error("cannot parse settings file, using defaults", "path", s.path, "err", err)
+		return err
+	}
+	loaded.path = s.path
+	if loaded.Cameras == nil {
+		loaded.Cameras = map[string]CameraState{}
+	}
+	if loaded.SnapStep < 0 {
+		loaded.SnapStep = 1
+	}
+	if loaded.Fps <= 0 {
+		loaded.Fps = DefaultTargetFPS
+	}
+	*s = loaded
+	log.Info("settings loaded", "path", s.path)
+	s.traceState("after", "Load")
+	return nil
+}
+
+// Save writes the settings to the settings file.
+func (s *Settings) Save() error {
+	if s.path == "" {
+		log.Warn("cannot save settings", "reason", "unknown settings file path")
+		return nil
+	}
+	s.traceState("before", "Save")
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		log.Error("cannot encode settings", "err", err)
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0o755); err != nil {
+		log.Error("cannot create config directory", "path", filepath.Dir(s.path), "err", err)
+		return err
+	}
+	if err := os.WriteFile(s.path, data, 0o644); err != nil {
+		log.Error("cannot write settings file", "path", s.path, "err", err)
+		return err
+	}
+	log.Info("settings saved", "path", s.path)
+	return nil
+}
+
+// AddRecentFile moves (or adds) the given project path at the top of the recent files list
+func (s *Settings) AddRecentFile(path string) {
+	path = settingsKey(path)
+	s.RecentFiles = slices.DeleteFunc(s.RecentFiles, func(p string) bool { return p == path })
+	s.RecentFiles = slices.Insert(s.RecentFiles, 0, path)
+	if len(s.RecentFiles) > maxRecentFiles {
+		s.RecentFiles = s.RecentFiles[:maxRecentFiles]
+	}
+	log.Debug("settings.addRecentFile", "path", path)
+}
+
+// RemoveRecentFile removes the given project path from the recent files list
+func (s *Settings) RemoveRecentFile(path string) {
+	path = settingsKey(path)
+	s.RecentFiles = slices.DeleteFunc(s.RecentFiles, func(p string) bool { return p == path })
+	delete(s.Cameras, path)
+	log.Debug("settings.removeRecentFile", "path", path)
+}
+
+// StoreCamera stores the current camera state for the given project path (noop if path is empty)
+func (s *Settings) StoreCamera(path string) {
+	if path == "" {
+		return
+	}
+	s.Cameras[settingsKey(path)] = camera.State()
+}
+
+// Camera returns the stored camera state for the given project path and whether it exists
+func (s *Settings) Camera(path string) (CameraState, bool) {
+	if path == "" {
+		return CameraState{}, false
+	}
+	cam, ok := s.Cameras[settingsKey(path)]
+	return cam, ok
+}
+
+// StoreWindow stores the current window position and size
+func (s *Settings) StoreWindow() {
+	s.Window.Maximized = rl.IsWindowMaximized()
+	if !s.Window.Maximized {
+		pos := rl.GetWindowPosition()
+		s.Window.X, s.Window.Y = int(pos.X), int(pos.Y)
+		s.Window.Width, s.Window.Height = rl.GetScreenWidth(), rl.GetScreenHeight()
+	}
+}
+
+// ApplyWindow applies the stored window position and size (must be called after window creation)
+func (s *Settings) ApplyWindow() {
+	if s.Window.Maximized {
+		rl.MaximizeWindow()
+		return
+	}
+	if s.Window.Width > 0 && s.Window.Height > 0 {
+		rl.SetWindowSize(s.Window.Width, s.Window.Height)
+	}
+	rl.SetWindowPosition(s.Window.X, s.Window.Y)
+}
+
+// settingsKey returns the normalized absolute path used as key in the settings
+func settingsKey(path string) string {
+	if abs, err := filepath.Abs(path); err == nil {
+		path = abs
+	}
+	return NormalizePath(path)
+}
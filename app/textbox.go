@@ -1,9 +1,10 @@
-// text box - Define text boxes and handle [ModeEditTextBox]
+// text box - Text boxes drawing
 
 package app
 
 import (
 	"github.com/bonoboris/satisfied/colors"
+	"github.com/bonoboris/satisfied/matrix"
 	"github.com/bonoboris/satisfied/text"
 	rl "github.com/gen2brain/raylib-go/raylib"
 )
@@ -16,25 +17,37 @@ const (
 	textBoxDefaultText = "Text" // default text box content
 )
 
-type TextBox struct {
-	Bounds  rl.Rectangle
-	Content string
-}
-
-func (tb TextBox) HandleRect() rl.Rectangle {
+// textBoxHandleRect returns the text box resize handle rectangle (unrotated text box coordinates)
+func textBoxHandleRect(tb TextBox) rl.Rectangle {
 	br := tb.Bounds.BottomRight()
 	size := textBoxHandleSize / camera.Zoom()
 	return rl.NewRectangle(br.X-size, br.Y-size, size, size)
 }
 
-// Draw draws the textbox (must be called outside of Camera2D mode)
-func (tb *TextBox) Draw(state DrawState, drawHandle bool) {
+// textBoxLocalPos returns the world position pos in the unrotated text box coordinates
+func textBoxLocalPos(tb TextBox, pos rl.Vector2) rl.Vector2 {
+	if tb.Rot == 0 {
+		return pos
+	}
+	return matrix.NewRotateAroundV(-tb.Rot, tb.Bounds.Position()).ApplyV(pos)
+}
+
+// drawTextBox draws the textbox (must be called outside of Camera2D mode)
+func drawTextBox(tb TextBox, state DrawState, drawHandle bool) {
 	if state == DrawSkip {
 		return
 	}
-	if !dims.World.CheckCollisionRec(tb.Bounds) {
+	if !dims.World.CheckCollisionRec(tb.AABB()) {
 		return
 	}
+	if tb.Rot != 0 {
+		// draw the unrotated text box, rotated around its top-left corner
+		rl.PushMatrix()
+		defer rl.PopMatrix()
+		rl.Translatef(tb.Bounds.X, tb.Bounds.Y, 0)
+		rl.Rotatef(float32(tb.Rot), 0, 0, 1)
+		tb.Bounds.X, tb.Bounds.Y = 0, 0
+	}
 	color := state.transformColor(colors.WithAlpha(colors.Gray300, 0.5))
 	rl.DrawRectangleRec(tb.Bounds, color)
 
@@ -45,7 +58,7 @@ func (tb *TextBox) Draw(state DrawState, drawHandle bool) {
 	if drawHandle {
 		// FIXME: this is a hacky way to draw the resize handle only when needed
 		px := 1 / camera.Zoom()
-		handle := tb.HandleRect()
+		handle := textBoxHandleRect(tb)
 		rl.DrawRectangleLinesEx(handle, 1*px, colors.Gray500)
 
 		tl := handle.TopLeft()
@@ -1,10 +1,10 @@
 package app
 
 import (
+	"io"
+	"io/fs"
 	"os"
 	"path/filepath"
-	"slices"
-	"strconv"
 	"strings"
 
 	rl "github.com/gen2brain/raylib-go/raylib"
@@ -20,124 +20,6 @@ func assert(cond bool, msg string) {
 	}
 }
 
-// Range returns a slice of integers [i; j[
-func Range(i, j int) []int {
-	r := make([]int, j-i)
-	for k := range r {
-		r[k] = i + k
-	}
-	return r
-}
-
-// CopyIdxs clears dst and copies the elements at idxs from src into dst
-//
-// It reallocates of cap(dst) < len(idxs)
-func CopyIdxs[T any](dst, src []T, idxs []int) []T {
-	dst = slices.Grow(dst[:0], len(idxs))
-	for _, idx := range idxs {
-		dst = append(dst, src[idx])
-	}
-	return dst
-}
-
-// Repeat clears dst and fills it with n copies of the given value
-//
-// It reallocates of cap(dst) < n
-func Repeat[T any](dst []T, v T, n int) []T {
-	dst = slices.Grow(dst[:0], n)
-	for range n {
-		dst = append(dst, v)
-	}
-	return dst
-}
-
-// SwapDelete efficiently deletes the element at index i by swapping it with the last element
-// and then truncating the slice.
-//
-// Calling [SwapDelete] with i == len(s) - 1 simply removes the last element.
-//
-// The complexity is O(1) but the slice order is not preserved.
-func SwapDelete[T any](s []T, i int) []T {
-	last := len(s) - 1
-	if i < last {
-		s[i], s[last] = s[last], s[i]
-	}
-	return s[:last]
-}
-
-// SwapInsert efficiently inserts the given element at index i by pushing the current i-th element
-// to the back.
-//
-// Calling [SwapInsert] with i == len(s) simply appends the element.
-//
-// This is the reverse of [SwapDelete].
-//
-// The complexity is O(1) but the slice order is not preserved.
-func SwapInsert[T any](s []T, i int, v T) []T {
-	n := len(s)
-	if i == n {
-		s = append(s, v)
-		return s
-	}
-	s = append(s, s[i])
-	s[i] = v
-	return s
-}
-
-// SwapDeleteMany efficiently deletes the elements at given indices idxs by swapping them with the last elements
-// and then truncating the slice.
-//
-// idxs must be sorted in ascending order.
-//
-// The complexity is O(len(indices)) but the slice order is not preserved.
-func SwapDeleteMany[T any](s []T, idxs []int) []T {
-	// reverse order because we are deleting elements
-	for i := len(idxs) - 1; i >= 0; i-- {
-		s = SwapDelete(s, idxs[i])
-	}
-	return s
-}
-
-// SwapInsertMany efficiently inserts the given elements at given indices idxs by pushing the current$
-// elements at idxs to the back.
-//
-// Idxs values must all be between 0 and len(s)+len(idxs)-1.
-//
-// This is the reverse of [SwapDeleteMany].
-//
-// The complexity is O(len(indices)) but the slice order is not preserved.
-func SwapInsertMany[T any](s []T, idxs []int, vs []T) []T {
-	for i := 0; i < len(idxs); i++ {
-		s = SwapInsert(s, idxs[i], vs[i])
-	}
-	return s
-}
-
-// SortedIntsIndex returns the index of x in a ascending sorted int slice (-1 if not found)
-//
-// Binary search is used.
-func SortedIntsIndex(a []int, x int) int {
-	i, j := 0, len(a)
-	if len(a) == 0 {
-		return -1
-	}
-	for i < j {
-		k := int(uint(i+j) >> 1) // avoid overflow when computing h
-		if a[k] == x {
-			return k
-		} else if a[k] < x {
-			i = k + 1
-		} else {
-			j = k
-		}
-	}
-	if i < len(a) && a[i] == x {
-		println("!!! sortedIntsIndex: found after loop\n")
-		return i
-	}
-	return -1
-}
-
 // NormalizePath normalizes a path to use the OS path separator.
 //
 // It preserves the trailing separator if any.
@@ -162,23 +44,39 @@ func RemoveQuotes(s string) string {
 	return strings.ReplaceAll(strings.ReplaceAll(s, `"`, "*"), `'`, "*")
 }
 
-func CheckCollisionRecLine(rec rl.Rectangle, p1, p2 rl.Vector2) bool {
-	tl, tr, bl, br := rec.TopLeft(), rec.TopRight(), rec.BottomLeft(), rec.BottomRight()
-	var p rl.Vector2
-	return rec.CheckCollisionPoint(p1) || rec.CheckCollisionPoint(p2) ||
-		rl.CheckCollisionLines(p1, p2, tl, tr, &p) ||
-		rl.CheckCollisionLines(p1, p2, bl, br, &p) ||
-		rl.CheckCollisionLines(p1, p2, tl, bl, &p) ||
-		rl.CheckCollisionLines(p1, p2, tr, br, &p)
-}
-
-// ParseFloat32 parses a string to a float32 value.
+// WriteFileAtomic writes a file by calling write on a temporary file in the same directory,
+// syncing it to disk and then renaming it to path.
 //
-// See: [strconv.ParseFloat]
-func ParseFloat32(s string) (float32, error) {
-	f, err := strconv.ParseFloat(s, 32)
+// Either the whole new content or the previous file (if any) is on disk, even if the application
+// crashes mid-write.
+func WriteFileAtomic(path string, write func(w io.Writer) error) (err error) {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*.tmp")
 	if err != nil {
-		return 0, err
+		return err
+	}
+	defer func() {
+		if err != nil {
+			// cleanup, ignoring errors as the temporary file may already be closed / removed
+			tmp.Close()
+			os.Remove(tmp.Name())
+		}
+	}()
+	if err = write(tmp); err != nil {
+		return err
+	}
+	if err = tmp.Sync(); err != nil {
+		return err
+	}
+	// temporary files are created with mode 0600, keep the mode of the replaced file
+	mode := fs.FileMode(0o644)
+	if info, statErr := os.Stat(path); statErr == nil {
+		mode = info.Mode().Perm()
+	}
+	if err = tmp.Chmod(mode); err != nil {
+		return err
+	}
+	if err = tmp.Close(); err != nil {
+		return err
 	}
-	return float32(f), nil
+	return os.Rename(tmp.Name(), path)
 }
@@ -0,0 +1,156 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/bonoboris/satisfied/app"
+	"github.com/bonoboris/satisfied/log"
+	"github.com/bonoboris/satisfied/scene"
+)
+
+// Commands run without opening a window, selected by the first argument
+var commands = map[string]func(args []string) int{
+	"diff":  runDiff,
+	"merge": runMerge,
+}
+
+// Commands exit status
+const (
+	exitOk       = 0
+	exitChanges  = 1
+	exitConflict = 1
+	exitError    = 2
+)
+
+const diffUsage = `Usage: %[1]s diff OLD NEW
+
+Compares 2 project files and reports the added, removed, moved and changed objects.
+
+Exits with status 0 if the projects are identical, 1 if they differ and 2 on error.
+`
+
+const mergeUsage = `Usage: %[1]s merge [options] BASE OURS THEIRS
+
+Merges the changes made from BASE in OURS and THEIRS, and writes the result to OURS.
+It can be used as a git merge driver:
+
+  git config merge.satisfied.driver "%[1]s merge %%O %%A %%B"
+  echo "*.satisfied merge=satisfied" >> .gitattributes
+
+Exits with status 0 on success, 1 if there are conflicts (reported on stderr) and 2 on error.
+
+Options:
+`
+
+// newCommandFlagSet returns a flag set printing usage, formatted with the binary name, on error
+func newCommandFlagSet(name, usage string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(os.Stderr)
+	fs.Usage = func() {
+		binName := filepath.Base(os.Args[0])
+		fmt.Fprintf(os.Stderr, usage, binName)
+		fs.PrintDefaults()
+	}
+	return fs
+}
+
+// initCommand initializes logs and loads the building and path definitions, packs included, needed
+// to read projects, the caller must close the logs if it succeeds
+func initCommand() bool {
+	log.Init(log.Options{Level: log.WarnLevel})
+	if err := app.LoadAssets(assets); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: cannot load assets: %v\n", err)
+		log.Close()
+		return false
+	}
+	if err := app.LoadPacks(); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: some definitions packs are not loaded:\n%v\n", err)
+	}
+	return true
+}
+
+func runDiff(args []string) int {
+	fs := newCommandFlagSet("diff", diffUsage)
+	if err := fs.Parse(args); err == flag.ErrHelp {
+		return exitOk
+	} else if err != nil {
+		return exitError
+	}
+	if fs.NArg() != 2 {
+		fs.Usage()
+		return exitError
+	}
+	if !initCommand() {
+		return exitError
+	}
+	defer log.Close()
+
+	old, err := scene.LoadFile(fs.Arg(0))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: cannot load %s: %v\n", fs.Arg(0), err)
+		return exitError
+	}
+	new, err := scene.LoadFile(fs.Arg(1))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: cannot load %s: %v\n", fs.Arg(1), err)
+		return exitError
+	}
+	diff := scene.ComputeDiff(old, new)
+	if err := diff.WriteText(os.Stdout); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return exitError
+	}
+	if diff.IsEmpty() {
+		return exitOk
+	}
+	return exitChanges
+}
+
+func runMerge(args []string) int {
+	fs := newCommandFlagSet("merge", mergeUsage)
+	output := fs.String("o", "", "Write the merged project to `file` instead of OURS")
+	if err := fs.Parse(args); err == flag.ErrHelp {
+		return exitOk
+	} else if err != nil {
+		return exitError
+	}
+	if fs.NArg() != 3 {
+		fs.Usage()
+		return exitError
+	}
+	if !initCommand() {
+		return exitError
+	}
+	defer log.Close()
+
+	var sides [3]scene.ObjectCollection
+	for i := range sides {
+		var err error
+		if sides[i], err = scene.LoadFile(fs.Arg(i)); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: cannot load %s: %v\n", fs.Arg(i), err)
+			return exitError
+		}
+	}
+	merged, conflicts := scene.Merge(sides[0], sides[1], sides[2])
+
+	outPath := *output
+	if outPath == "" {
+		outPath = fs.Arg(1)
+	}
+	s := scene.Scene{ObjectCollection: merged}
+	if err := app.WriteFileAtomic(outPath, s.SaveToText); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: cannot write %s: %v\n", outPath, err)
+		return exitError
+	}
+
+	if len(conflicts) > 0 {
+		for _, c := range conflicts {
+			fmt.Fprintf(os.Stderr, "CONFLICT %v\n", c)
+		}
+		return exitConflict
+	}
+	return exitOk
+}
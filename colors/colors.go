@@ -1,9 +1,8 @@
 package colors
 
 import (
+	"image/color"
 	"strconv"
-
-	rl "github.com/gen2brain/raylib-go/raylib"
 )
 
 func parseHex(hex string) uint8 {
@@ -14,8 +13,8 @@ func parseHex(hex string) uint8 {
 	return uint8(val)
 }
 
-func NewColorFromHex(hex string) rl.Color {
-	return rl.Color{
+func NewColorFromHex(hex string) color.RGBA {
+	return color.RGBA{
 		R: parseHex(hex[1:3]),
 		G: parseHex(hex[3:5]),
 		B: parseHex(hex[5:7]),
@@ -27,8 +26,8 @@ func lerpUint8(a, b uint8, t float32) uint8 {
 	return uint8(float32(a)*(1-t) + float32(b)*t)
 }
 
-func Lerp(a, b rl.Color, t float32) rl.Color {
-	return rl.Color{
+func Lerp(a, b color.RGBA, t float32) color.RGBA {
+	return color.RGBA{
 		lerpUint8(a.R, b.R, t),
 		lerpUint8(a.G, b.G, t),
 		lerpUint8(a.B, b.B, t),
@@ -36,8 +35,8 @@ func Lerp(a, b rl.Color, t float32) rl.Color {
 	}
 }
 
-func WithAlpha(col rl.Color, a float32) rl.Color {
-	return rl.Color{
+func WithAlpha(col color.RGBA, a float32) color.RGBA {
+	return color.RGBA{
 		R: col.R,
 		G: col.G,
 		B: col.B,
@@ -46,9 +45,9 @@ func WithAlpha(col rl.Color, a float32) rl.Color {
 }
 
 var (
-	Blank = rl.Color{0, 0, 0, 0}
-	White = rl.Color{255, 255, 255, 255}
-	Black = rl.Color{0, 0, 0, 255}
+	Blank = color.RGBA{0, 0, 0, 0}
+	White = color.RGBA{255, 255, 255, 255}
+	Black = color.RGBA{0, 0, 0, 255}
 
 	// Colors from https://tailwindcss.com/docs/customizing-colors
 
@@ -64,4 +63,5 @@ var (
 	Orange500 = NewColorFromHex("#f97316")
 	Red500    = NewColorFromHex("#ef4444")
 	Amber700  = NewColorFromHex("#b45309")
+	Violet500 = NewColorFromHex("#8b5cf6")
 )
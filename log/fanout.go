@@ -0,0 +1,132 @@
+// A [slog.Handler] dispatching records to several outputs, each with its own level, and with
+// per-subsystem level overrides.
+
+package log
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+)
+
+// Output is a [FanoutHandler] output
+type Output struct {
+	Handler slog.Handler
+	// Minimum level of the records written to Handler (unless overridden for a subsystem)
+	Level slog.Level
+}
+
+// FanoutHandler is a [slog.Handler] dispatching records to several outputs.
+//
+// A subsystem is a message prefix, ending with '.' or ' ' (eg: "selection" matches "selection",
+// "selection.doDrag" and "selection updated"), its level overrides the level of all the outputs.
+// When several subsystems match, the longest one wins (eg: "scene.history" before "scene").
+//
+// The output handlers level must be lower or equal than the [Output] level since it is checked first.
+type FanoutHandler struct {
+	outputs    []Output
+	subsystems map[string]slog.Level
+	// minimum level of all outputs and subsystems
+	minLevel slog.Level
+}
+
+// NewFanoutHandler returns a [FanoutHandler] with the given subsystem levels (can be nil) and outputs.
+func NewFanoutHandler(subsystems map[string]slog.Level, outputs ...Output) *FanoutHandler {
+	h := &FanoutHandler{outputs: outputs, subsystems: subsystems, minLevel: FatalLevel + 1}
+	for _, out := range outputs {
+		h.minLevel = min(h.minLevel, out.Level)
+	}
+	for _, lvl := range subsystems {
+		h.minLevel = min(h.minLevel, lvl)
+	}
+	return h
+}
+
+// MinLevel returns the lowest level a record can have to be written in at least one output
+func (h *FanoutHandler) MinLevel() slog.Level { return h.minLevel }
+
+func (h *FanoutHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return level >= h.minLevel
+}
+
+func (h *FanoutHandler) Handle(ctx context.Context, r slog.Record) error {
+	subLevel, hasSub := h.subsystemLevel(r.Message)
+	var errs []error
+	for _, out := range h.outputs {
+		lvl := out.Level
+		if hasSub {
+			lvl = subLevel
+		}
+		if r.Level >= lvl {
+			errs = append(errs, out.Handler.Handle(ctx, r.Clone()))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (h *FanoutHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	panic("not implemented")
+}
+
+func (h *FanoutHandler) WithGroup(name string) slog.Handler {
+	panic("not implemented")
+}
+
+// subsystemLevel returns the level of the longest subsystem matching msg, if any
+func (h *FanoutHandler) subsystemLevel(msg string) (slog.Level, bool) {
+	best, bestLevel := "", slog.Level(0)
+	for sub, lvl := range h.subsystems {
+		if len(sub) <= len(best) || !strings.HasPrefix(msg, sub) {
+			continue
+		}
+		if len(msg) == len(sub) || msg[len(sub)] == '.' || msg[len(sub)] == ' ' {
+			best, bestLevel = sub, lvl
+		}
+	}
+	return bestLevel, best != ""
+}
+
+// ParseLevel parses a level name: all, trace, debug, info, warn, error or fatal (case insensitive)
+func ParseLevel(s string) (slog.Level, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "all":
+		return AllLevel, nil
+	case "trace":
+		return TraceLevel, nil
+	case "debug":
+		return DebugLevel, nil
+	case "info":
+		return InfoLevel, nil
+	case "warn":
+		return WarnLevel, nil
+	case "error":
+		return ErrorLevel, nil
+	case "fatal":
+		return FatalLevel, nil
+	default:
+		return 0, fmt.Errorf("invalid log level: %q", s)
+	}
+}
+
+// ParseSubsystemLevels parses a comma separated list of subsystem=level (eg: "selection=trace,mouse=info")
+func ParseSubsystemLevels(s string) (map[string]slog.Level, error) {
+	levels := map[string]slog.Level{}
+	for _, item := range strings.Split(s, ",") {
+		if strings.TrimSpace(item) == "" {
+			continue
+		}
+		sub, lvlStr, ok := strings.Cut(item, "=")
+		sub = strings.TrimSpace(sub)
+		if !ok || sub == "" {
+			return nil, fmt.Errorf("invalid subsystem level: %q, expected subsystem=level", item)
+		}
+		lvl, err := ParseLevel(lvlStr)
+		if err != nil {
+			return nil, err
+		}
+		levels[sub] = lvl
+	}
+	return levels, nil
+}
@@ -10,6 +10,7 @@ import (
 	"log/slog"
 	"runtime"
 	"strconv"
+	"time"
 )
 
 // ANSI modes
@@ -24,23 +25,19 @@ const (
 	ansiBrightRedFaint = "\033[91;2m"
 )
 
-const (
-	lowerLvlStr  = "TRC-"
-	traceLvlStr  = "TRC"
-	debugLvlStr  = ansiBrightBlue + "DBG" + ansiReset
-	infoLvlStr   = ansiBrightGreen + "INF" + ansiReset
-	warnLvlStr   = ansiBrightYellow + "WRN" + ansiReset
-	errorLvlStr  = ansiBrightRed + "ERR" + ansiReset
-	fatalLvlStr  = ansiBrightRed + "FTL" + ansiReset
-	higherLvlStr = "FTL+"
-)
-
 type Handler struct {
-	w     io.Writer
-	level slog.Level
+	w       io.Writer
+	level   slog.Level
+	colored bool
 }
 
+// NewHandler returns a colored [Handler], for terminals
 func NewHandler(w io.Writer, level slog.Level) *Handler {
+	return &Handler{w: w, level: level, colored: true}
+}
+
+// NewPlainHandler returns a [Handler] without ANSI escape codes and with timestamps, for files
+func NewPlainHandler(w io.Writer, level slog.Level) *Handler {
 	return &Handler{w: w, level: level}
 }
 
@@ -49,6 +46,10 @@ func (h *Handler) Enabled(_ context.Context, level slog.Level) bool {
 }
 
 func (h *Handler) Handle(_ context.Context, r slog.Record) error {
+	if !h.colored {
+		_, err := fmt.Fprintln(h.w, formatPlain(r))
+		return err
+	}
 	src := formatSource(r.PC)
 	lvl := h.formatLevel(r.Level)
 	s := fmt.Sprintf("%-30s %s %-40s", src, lvl, r.Message)
@@ -72,30 +73,70 @@ func (h *Handler) WithGroup(name string) slog.Handler {
 	panic("not implemented")
 }
 
+// formatLevel returns the [LevelName] of a level, colored
 func (h Handler) formatLevel(lvl slog.Level) string {
+	name := LevelName(lvl)
+	switch lvl {
+	case DebugLevel:
+		return ansiBrightBlue + name + ansiReset
+	case InfoLevel:
+		return ansiBrightGreen + name + ansiReset
+	case WarnLevel:
+		return ansiBrightYellow + name + ansiReset
+	case ErrorLevel, FatalLevel:
+		return ansiBrightRed + name + ansiReset
+	default:
+		return name
+	}
+}
+
+// formatPlain formats a record as a single line of text without ANSI escape codes
+func formatPlain(r slog.Record) string {
+	s := fmt.Sprintf("%s %s %s %s", r.Time.Format(time.DateTime+".000"), LevelName(r.Level), plainSource(r.PC), r.Message)
+	r.Attrs(func(attr slog.Attr) bool {
+		attr.Value = attr.Value.Resolve()
+		if attr.Value.Kind() == slog.KindString {
+			attr.Value = slog.StringValue(strconv.Quote(attr.Value.String()))
+		}
+		s = fmt.Sprintf("%s %s=%s", s, attr.Key, attr.Value)
+		return true
+	})
+	return s
+}
+
+// LevelName returns the 3 letters name of a level (without ANSI escape codes)
+func LevelName(lvl slog.Level) string {
 	switch lvl {
 	case TraceLevel:
-		return traceLvlStr
+		return "TRC"
 	case DebugLevel:
-		return debugLvlStr
+		return "DBG"
 	case InfoLevel:
-		return infoLvlStr
+		return "INF"
 	case WarnLevel:
-		return warnLvlStr
+		return "WRN"
 	case ErrorLevel:
-		return errorLvlStr
+		return "ERR"
 	case FatalLevel:
-		return fatalLvlStr
+		return "FTL"
 	default:
 		if lvl < TraceLevel {
-			return lowerLvlStr
+			return "TRC-"
 		} else {
-			return higherLvlStr
+			return "FTL+"
 		}
 	}
 }
 
 func formatSource(pc uintptr) string {
+	if src := plainSource(pc); src != "" {
+		return ansiFaint + src + ansiReset
+	}
+	return ""
+}
+
+// plainSource returns the "dir/file.go:line" source location of pc
+func plainSource(pc uintptr) string {
 	fs := runtime.CallersFrames([]uintptr{pc})
 	f, more := fs.Next()
 	if more {
@@ -115,7 +156,7 @@ func formatSource(pc uintptr) string {
 				}
 			}
 		}
-		return fmt.Sprintf("%s%s:%d%s", ansiFaint, f.File[idx:], f.Line, ansiReset)
+		return fmt.Sprintf("%s:%d", f.File[idx:], f.Line)
 	}
 	return ""
 }
@@ -2,12 +2,11 @@ package log
 
 import (
 	"context"
+	"io"
 	"log/slog"
 	"os"
 	"runtime"
 	"time"
-
-	rl "github.com/gen2brain/raylib-go/raylib"
 )
 
 const (
@@ -22,22 +21,111 @@ const (
 
 var level slog.Level
 
+// Number of records kept in memory (see [RecentLines])
+const ringSize = 1000
+
+var ring *RingHandler
+
+// Log file, if any
+var file *RotatingFile
+
+// Log format
+const (
+	FormatText = "text"
+	FormatJSON = "json"
+)
+
+// Options are the logging options
+type Options struct {
+	// Console (stderr) level
+	Level slog.Level
+	// Console and file format: [FormatText] (colored on the console) or [FormatJSON]
+	Format string
+	// Log file path (empty to disable)
+	File string
+	// Log file level
+	FileLevel slog.Level
+	// Log file maximum size in bytes before rotation (0 to disable)
+	FileMaxSize int64
+	// Number of rotated log files kept
+	FileMaxCount int
+	// Per-subsystem levels, overriding the console and file levels (see [FanoutHandler])
+	Subsystems map[string]slog.Level
+}
+
 // Initializes logging
-func Init(lvl slog.Level, colored bool) {
-	level = lvl
-	slog.SetLogLoggerLevel(lvl)
-	rl.SetTraceLogLevel(rl.TraceLogLevel(lvl))
-	rl.SetTraceLogCallback(func(l int, message string) { Log(slog.Level(l), message, "source", "raylib") })
-	var handler slog.Handler
-	if colored {
-		handler = NewHandler(os.Stderr, lvl)
-	} else {
-		handler = slog.NewJSONHandler(os.Stderr, nil)
+//
+// If the log file cannot be opened, logging to the console is still initialized and the error is returned.
+func Init(opts Options) error {
+	var fileErr error
+	outputs := []Output{{Handler: newHandler(os.Stderr, opts.Format, true), Level: opts.Level}}
+	if opts.File != "" {
+		file, fileErr = OpenRotatingFile(opts.File, opts.FileMaxSize, opts.FileMaxCount)
+		if fileErr == nil {
+			outputs = append(outputs, Output{Handler: newHandler(file, opts.Format, false), Level: opts.FileLevel})
+		}
 	}
-	logger := slog.New(handler)
+	fanout := NewFanoutHandler(opts.Subsystems, outputs...)
+	level = fanout.MinLevel()
+
+	slog.SetLogLoggerLevel(level)
+	// keep debug records in memory even if they are not written
+	ring = NewRingHandler(fanout, min(level, DebugLevel), ringSize)
+	logger := slog.New(ring)
 	slog.SetDefault(logger)
+	if fileErr != nil {
+		Error("cannot open log file", "path", opts.File, "err", fileErr)
+	}
+	return fileErr
+}
+
+// newHandler returns a handler of the given format, writing all records
+func newHandler(w io.Writer, format string, console bool) slog.Handler {
+	switch {
+	case format == FormatJSON:
+		return slog.NewJSONHandler(w, &slog.HandlerOptions{Level: AllLevel, ReplaceAttr: replaceLevel})
+	case console:
+		return NewHandler(w, AllLevel)
+	default:
+		return NewPlainHandler(w, AllLevel)
+	}
+}
+
+// replaceLevel writes the record level with its [LevelName], slog only names its own levels
+func replaceLevel(groups []string, a slog.Attr) slog.Attr {
+	if a.Key == slog.LevelKey && len(groups) == 0 {
+		if lvl, ok := a.Value.Any().(slog.Level); ok {
+			a.Value = slog.StringValue(LevelName(lvl))
+		}
+	}
+	return a
+}
+
+// Close closes the log file, if any
+func Close() error {
+	if file == nil {
+		return nil
+	}
+	return file.Close()
 }
 
+// StderrAvailable returns false if there is no usable stderr (eg: Windows GUI builds)
+func StderrAvailable() bool {
+	_, err := os.Stderr.Stat()
+	return err == nil
+}
+
+// RecentLines returns the last log records as plain text lines, oldest first
+func RecentLines() []string {
+	if ring == nil {
+		return nil
+	}
+	return ring.Lines()
+}
+
+// MinLevel returns the minimum level of the written logs
+func MinLevel() slog.Level { return level }
+
 // WillTrace returns true if [TraceLevel] logs will be written
 func WillTrace() bool { return level <= TraceLevel }
 
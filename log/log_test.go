@@ -0,0 +1,31 @@
+package log
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"testing"
+)
+
+func TestJSONHandlerLevel(t *testing.T) {
+	tests := []struct {
+		lvl  slog.Level
+		want string
+	}{
+		{TraceLevel, "TRC"},
+		{InfoLevel, "INF"},
+		{FatalLevel, "FTL"},
+	}
+	for _, tt := range tests {
+		var buf bytes.Buffer
+		slog.New(newHandler(&buf, FormatJSON, false)).Log(context.Background(), tt.lvl, "message")
+		var record struct{ Level, Msg string }
+		if err := json.Unmarshal(buf.Bytes(), &record); err != nil {
+			t.Fatalf("%q: %v", buf.String(), err)
+		}
+		if record.Level != tt.want || record.Msg != "message" {
+			t.Errorf("level %d record = %+v, want level %s", tt.lvl, record, tt.want)
+		}
+	}
+}
@@ -0,0 +1,70 @@
+// A [slog.Handler] decorator keeping the most recent log records in memory (eg: for crash reports)
+
+package log
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+)
+
+// RingHandler is a [slog.Handler] decorator which keeps the last records as plain text lines in a
+// ring buffer, and forwards them to the next handler.
+//
+// It has its own level, which can be lower than the next handler level, so that records not written
+// by the next handler can still be found in the ring buffer.
+type RingHandler struct {
+	next  slog.Handler
+	level slog.Level
+
+	mu    sync.Mutex
+	lines []string
+	// index of the next line to write in lines
+	pos int
+	// whether lines has wrapped around
+	full bool
+}
+
+// NewRingHandler returns a [RingHandler] keeping the last size records of at least the given level.
+func NewRingHandler(next slog.Handler, level slog.Level, size int) *RingHandler {
+	return &RingHandler{next: next, level: level, lines: make([]string, size)}
+}
+
+func (h *RingHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return level >= h.level || h.next.Enabled(ctx, level)
+}
+
+func (h *RingHandler) Handle(ctx context.Context, r slog.Record) error {
+	if r.Level >= h.level && len(h.lines) > 0 {
+		line := formatPlain(r)
+		h.mu.Lock()
+		h.lines[h.pos] = line
+		h.pos = (h.pos + 1) % len(h.lines)
+		h.full = h.full || h.pos == 0
+		h.mu.Unlock()
+	}
+	if h.next.Enabled(ctx, r.Level) {
+		return h.next.Handle(ctx, r)
+	}
+	return nil
+}
+
+func (h *RingHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	panic("not implemented")
+}
+
+func (h *RingHandler) WithGroup(name string) slog.Handler {
+	panic("not implemented")
+}
+
+// Lines returns a copy of the buffered lines, oldest first.
+func (h *RingHandler) Lines() []string {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if !h.full {
+		return append([]string(nil), h.lines[:h.pos]...)
+	}
+	lines := make([]string, 0, len(h.lines))
+	lines = append(lines, h.lines[h.pos:]...)
+	return append(lines, h.lines[:h.pos]...)
+}
@@ -4,7 +4,6 @@ import (
 	"embed"
 	"flag"
 	"fmt"
-	"log/slog"
 	"net/http"
 	_ "net/http/pprof"
 	"os"
@@ -26,14 +25,27 @@ var (
 	vverbose   *bool
 	quiet      *bool
 	fps        *int
+	logFile    *string
+	logFormat  *string
+	logFileLvl *string
+	logSize    *int
+	logLevels  *string
+	record     *string
+	replay     *string
+	defsDir    *string
 	cpuprofile *string
 	memprofile *string
 )
 
-const usage = `Usage: %s [options] [FILE]
+const usage = `Usage: %[1]s [options] [FILE]
+       %[1]s diff OLD NEW
+       %[1]s merge [options] BASE OURS THEIRS
 
 FILE is an optional path to a satisfied project file to load.
 
+The diff and merge commands compare and merge project files without opening a window,
+use '%[1]s diff -h' or '%[1]s merge -h' for details.
+
 Options:
 `
 
@@ -43,7 +55,18 @@ func init() {
 	quiet = fs.Bool("q", false, "WARN verbosity")
 	verbose = fs.Bool("v", false, "DEBUG verbosity")
 	vverbose = fs.Bool("vv", false, "TRACE verbosity")
-	fps = fs.Int("fps", app.DefaultTargetFPS, "Target / Max FPS, (use a low value when using -vv to reduce the ammount of logs)")
+	fps = fs.Int("fps", app.DefaultTargetFPS, "Target / Max FPS, remembered for next launches (use a low value when using -vv to reduce the ammount of logs)")
+
+	logFile = fs.String("log-file", "", "Also write logs to `file` (default to satisfied.log in the user data directory if there is no console)")
+	logFormat = fs.String("log-format", log.FormatText, "Logs `format`: text or json")
+	logFileLvl = fs.String("log-file-level", "debug", "Log file `level`: trace, debug, info, warn or error")
+	logSize = fs.Int("log-file-size", 10, "Log file maximum size in `MB` before rotation (0 to disable)")
+	logLevels = fs.String("log-levels", "", "Per subsystem `levels`, overriding verbosity flags (eg: selection=trace,mouse=info)")
+
+	record = fs.String("record", "", "Record inputs to `file`, to reproduce a bug with --replay")
+	replay = fs.String("replay", "", "Replay inputs recorded with --record from `file`, exits with status 1 if the replay differs from the recording")
+
+	defsDir = fs.String("defs-dir", "", "Read building_defs.json and path_defs.json from `dir` instead of the built-in definitions, reloaded on change")
 
 	cpuprofile = flag.String("cpuprofile", "", "write cpu profile to `file`")
 	memprofile = flag.String("memprofile", "", "write memory profile to `file`")
@@ -55,7 +78,10 @@ func init() {
 	}
 }
 
-func parseArgs() (slog.Level, *app.AppOptions) {
+// Number of rotated log files kept
+const logFileCount = 3
+
+func parseArgs() (log.Options, *app.AppOptions) {
 	if err := fs.Parse(os.Args[1:]); err != nil {
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 		os.Exit(1)
@@ -72,18 +98,61 @@ func parseArgs() (slog.Level, *app.AppOptions) {
 	default:
 		logLevel = log.InfoLevel
 	}
+	logOpts := log.Options{
+		Level:        logLevel,
+		Format:       *logFormat,
+		File:         *logFile,
+		FileMaxSize:  int64(*logSize) << 20,
+		FileMaxCount: logFileCount,
+	}
+	if logOpts.Format != log.FormatText && logOpts.Format != log.FormatJSON {
+		fmt.Fprintf(os.Stderr, "Error: invalid log format: %q\n", logOpts.Format)
+		os.Exit(1)
+	}
+	var err error
+	if logOpts.FileLevel, err = log.ParseLevel(*logFileLvl); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	if logOpts.Subsystems, err = log.ParseSubsystemLevels(*logLevels); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	// without console (eg: Windows GUI builds), log to a file in the user data directory
+	if logOpts.File == "" && !log.StderrAvailable() {
+		if dir, err := app.DataDir(); err == nil {
+			logOpts.File = filepath.Join(dir, "satisfied.log")
+		}
+	}
 	if fs.NArg() > 0 {
 		opts.File = app.NormalizePath(fs.Arg(0))
 	}
-	opts.Fps = *fps
-	return logLevel, opts
+	opts.Record = *record
+	opts.Replay = *replay
+	opts.DefsDir = *defsDir
+	// only override the preferred FPS from the settings when explicitly set
+	fs.Visit(func(f *flag.Flag) {
+		if f.Name == "fps" {
+			opts.Fps = *fps
+		}
+	})
+	return logOpts, opts
 }
 
 func main() {
-	logLevel, opts := parseArgs()
-	log.Init(logLevel, true)
+	if len(os.Args) > 1 {
+		if run, ok := commands[os.Args[1]]; ok {
+			os.Exit(run(os.Args[2:]))
+		}
+	}
+	logOpts, opts := parseArgs()
+	log.Init(logOpts)
+	defer log.Close()
 
-	app.Init(assets, opts)
+	if err := app.Init(assets, opts); err != nil && opts.Replay != "" {
+		log.Close()
+		os.Exit(1)
+	}
 
 	if (cpuprofile != nil && *cpuprofile != "") || (memprofile != nil && *memprofile != "") {
 		go func() {
@@ -99,6 +168,7 @@ func main() {
 		f, err := os.Create(*cpuprofile)
 		if err != nil {
 			log.Error("cannot create cpu profile", "path", *cpuprofile, "err", err)
+			log.Close()
 			os.Exit(1)
 		}
 		defer f.Close()
@@ -111,6 +181,10 @@ func main() {
 		app.Step()
 	}
 	app.Close()
+	if opts.Replay != "" && app.ReplayMismatches() > 0 {
+		log.Close()
+		os.Exit(1)
+	}
 
 	if memprofile != nil && *memprofile != "" {
 		if *memprofile == "" {
@@ -119,6 +193,7 @@ func main() {
 		f, err := os.Create(*memprofile)
 		if err != nil {
 			log.Error("cannot create mem profile", "path", *memprofile, "err", err)
+			log.Close()
 			os.Exit(1)
 		}
 		defer f.Close()
@@ -2,7 +2,7 @@ package matrix
 
 import (
 	"github.com/bonoboris/satisfied/math32"
-	rl "github.com/gen2brain/raylib-go/raylib"
+	rl "github.com/bonoboris/satisfied/rlmath"
 )
 
 // Matrix represents a 3x3 matrix (OpenGL style 3x3 - right handed, column major)
@@ -101,6 +101,15 @@ func NewScale(s float32) Matrix {
 	}
 }
 
+// NewMirrorX returns an horizontal mirroring transformation matrix (x -> -x).
+func NewMirrorX() Matrix {
+	return Matrix{
+		M0: -1, M3: 0, M6: 0,
+		M1: 0, M4: 1, M7: 0,
+		M2: 0, M5: 0, M8: 1,
+	}
+}
+
 func (mat Matrix) IsIdentity() bool {
 	return mat.M0 == 1 && mat.M1 == 0 && mat.M2 == 0 &&
 		mat.M3 == 0 && mat.M4 == 1 && mat.M5 == 0 &&
@@ -149,6 +158,12 @@ func (mat Matrix) RotateAroundV(angle int32, v rl.Vector2) Matrix {
 // Scale applies a scale transformation to the matrix.
 func (mat Matrix) Scale(s float32) Matrix { return mat.Mult(NewScale(s)) }
 
+// MirrorX applies an horizontal mirroring transformation to the matrix.
+func (mat Matrix) MirrorX() Matrix { return mat.Mult(NewMirrorX()) }
+
+// IsMirroring returns true if the transformation reverses the orientation (negative determinant).
+func (mat Matrix) IsMirroring() bool { return mat.M0*mat.M4-mat.M1*mat.M3 < 0 }
+
 // Apply applies transformation matrix to the vector (x, y).
 func (mat Matrix) Apply(x, y float32) rl.Vector2 {
 	return rl.Vector2{
@@ -185,3 +200,14 @@ func (mat Matrix) ApplyRecV(v rl.Vector2, s rl.Vector2) rl.Rectangle {
 func (mat Matrix) ApplyRecRec(r rl.Rectangle) rl.Rectangle {
 	return mat.ApplyRec(r.X, r.Y, r.Width, r.Height)
 }
+
+// ApplyRecAABB returns the axis aligned bounding box of the transformed rect r, for any rotation.
+func (mat Matrix) ApplyRecAABB(r rl.Rectangle) rl.Rectangle {
+	p0 := mat.Apply(r.X, r.Y)
+	p1 := mat.Apply(r.X+r.Width, r.Y)
+	p2 := mat.Apply(r.X, r.Y+r.Height)
+	p3 := mat.Apply(r.X+r.Width, r.Y+r.Height)
+	min := p0.Min(p1).Min(p2).Min(p3)
+	max := p0.Max(p1).Max(p2).Max(p3)
+	return rl.NewRectangleV(min, max.Subtract(min))
+}
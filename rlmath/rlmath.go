@@ -0,0 +1,30 @@
+//go:build cgo || windows
+
+// rlmath - raylib vector and rectangle types, usable without raylib
+//
+// With cgo (or on windows, where raylib is loaded at runtime), the types are aliases of the raylib
+// ones. Without cgo, where raylib cannot be built, they are pure Go copies of the subset used by
+// the scene, so that it can be built and tested without a window or a GPU.
+
+package rlmath
+
+import rl "github.com/gen2brain/raylib-go/raylib"
+
+const Deg2rad = rl.Deg2rad
+
+type (
+	Vector2   = rl.Vector2
+	Rectangle = rl.Rectangle
+)
+
+func NewVector2(x, y float32) Vector2 { return rl.NewVector2(x, y) }
+
+func NewRectangle(x, y, width, height float32) Rectangle {
+	return rl.NewRectangle(x, y, width, height)
+}
+
+func NewRectangleV(position, size Vector2) Rectangle { return rl.NewRectangleV(position, size) }
+
+func NewRectangleCorners(c1, c2 Vector2) Rectangle { return rl.NewRectangleCorners(c1, c2) }
+
+func Vector2Distance(v1, v2 Vector2) float32 { return rl.Vector2Distance(v1, v2) }
@@ -0,0 +1,94 @@
+//go:build !cgo && !windows
+
+package rlmath
+
+import "math"
+
+// Degrees to radians, as in raylib
+const Deg2rad = 0.017453292
+
+// Vector2 is a copy of the raylib type
+type Vector2 struct {
+	X float32
+	Y float32
+}
+
+// Rectangle is a copy of the raylib type
+type Rectangle struct {
+	X      float32
+	Y      float32
+	Width  float32
+	Height float32
+}
+
+func NewVector2(x, y float32) Vector2 { return Vector2{x, y} }
+
+func NewRectangle(x, y, width, height float32) Rectangle {
+	return Rectangle{x, y, width, height}
+}
+
+func NewRectangleV(position, size Vector2) Rectangle {
+	return Rectangle{position.X, position.Y, size.X, size.Y}
+}
+
+func NewRectangleCorners(c1, c2 Vector2) Rectangle {
+	pos, size := c1.Min(c2), c1.Subtract(c2).Abs()
+	return Rectangle{pos.X, pos.Y, size.X, size.Y}
+}
+
+func Vector2Distance(v1, v2 Vector2) float32 { return v1.Distance(v2) }
+
+func (v1 Vector2) Add(v2 Vector2) Vector2 { return Vector2{v1.X + v2.X, v1.Y + v2.Y} }
+
+func (v1 Vector2) Subtract(v2 Vector2) Vector2 { return Vector2{v1.X - v2.X, v1.Y - v2.Y} }
+
+func (v Vector2) Scale(scale float32) Vector2 { return Vector2{v.X * scale, v.Y * scale} }
+
+func (v Vector2) Negate() Vector2 { return Vector2{-v.X, -v.Y} }
+
+func (v Vector2) Abs() Vector2 {
+	return Vector2{float32(math.Abs(float64(v.X))), float32(math.Abs(float64(v.Y)))}
+}
+
+func (v1 Vector2) Min(v2 Vector2) Vector2 { return Vector2{min(v1.X, v2.X), min(v1.Y, v2.Y)} }
+
+func (v1 Vector2) Max(v2 Vector2) Vector2 { return Vector2{max(v1.X, v2.X), max(v1.Y, v2.Y)} }
+
+func (v Vector2) Length() float32 { return float32(math.Sqrt(float64(v.X*v.X + v.Y*v.Y))) }
+
+func (v1 Vector2) DotProduct(v2 Vector2) float32 { return v1.X*v2.X + v1.Y*v2.Y }
+
+func (v1 Vector2) Distance(v2 Vector2) float32 { return v1.Subtract(v2).Length() }
+
+func (v1 Vector2) DistanceSqr(v2 Vector2) float32 {
+	d := v1.Subtract(v2)
+	return d.X*d.X + d.Y*d.Y
+}
+
+func (v Vector2) Normalize() Vector2 {
+	if l := v.Length(); l > 0 {
+		return v.Scale(1 / l)
+	}
+	return v
+}
+
+func (v Vector2) Rotate(angle float32) Vector2 {
+	cos, sin := float32(math.Cos(float64(angle))), float32(math.Sin(float64(angle)))
+	return Vector2{v.X*cos - v.Y*sin, v.X*sin + v.Y*cos}
+}
+
+func (start Vector2) LineAngle(end Vector2) float32 {
+	return float32(-math.Atan2(float64(end.Y-start.Y), float64(end.X-start.X)))
+}
+
+func (r Rectangle) Position() Vector2 { return Vector2{r.X, r.Y} }
+
+func (r Rectangle) TopLeft() Vector2 { return Vector2{r.X, r.Y} }
+
+func (r Rectangle) TopRight() Vector2 { return Vector2{r.X + r.Width, r.Y} }
+
+func (r Rectangle) BottomLeft() Vector2 { return Vector2{r.X, r.Y + r.Height} }
+
+func (r Rectangle) BottomRight() Vector2 { return Vector2{r.X + r.Width, r.Y + r.Height} }
+
+func (r Rectangle) Center() Vector2 { return Vector2{r.X + r.Width/2, r.Y + r.Height/2} }
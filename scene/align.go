@@ -0,0 +1,209 @@
+// align - Alignment and distribution of the selected buildings and text boxes
+
+package scene
+
+import (
+	"cmp"
+	"slices"
+
+	"github.com/bonoboris/satisfied/math32"
+	rl "github.com/bonoboris/satisfied/rlmath"
+)
+
+// Alignment is a way to align or distribute objects, along their axis aligned bounds
+type Alignment int
+
+const (
+	// Align the left edges
+	AlignLeft Alignment = iota
+	// Align the right edges
+	AlignRight
+	// Align the top edges
+	AlignTop
+	// Align the bottom edges
+	AlignBottom
+	// Align the centers on a vertical line
+	AlignCenterX
+	// Align the centers on a horizontal line
+	AlignCenterY
+	// Space evenly horizontally, the leftmost and rightmost objects stay in place
+	DistributeX
+	// Space evenly vertically, the topmost and bottommost objects stay in place
+	DistributeY
+)
+
+func (a Alignment) String() string {
+	switch a {
+	case AlignLeft:
+		return "AlignLeft"
+	case AlignRight:
+		return "AlignRight"
+	case AlignTop:
+		return "AlignTop"
+	case AlignBottom:
+		return "AlignBottom"
+	case AlignCenterX:
+		return "AlignCenterX"
+	case AlignCenterY:
+		return "AlignCenterY"
+	case DistributeX:
+		return "DistributeX"
+	case DistributeY:
+		return "DistributeY"
+	default:
+		return "Invalid"
+	}
+}
+
+// alignItem is a building or text box to align, and its bounds
+type alignItem struct {
+	bounds    rl.Rectangle
+	isTextBox bool
+	// index in the transformed buildings or text boxes
+	idx int
+}
+
+// Align computes the buildings and text boxes of oc selected by sel aligned or distributed with a,
+// and checks their validity; the selected paths are left out. Returns whether any object moved.
+//
+// Objects are moved by whole meters to keep the buildings on the grid. The moved buildings are
+// checked against each other and every non selected building.
+func (t *Transformed) Align(oc ObjectCollection, sel ObjectSelection, a Alignment) bool {
+	t.Reset()
+	t.IsValid = true
+	t.Buildings = CopyIdxs(t.Buildings, oc.Buildings, sel.BuildingIdxs)
+	t.InvalidBuildings = Repeat(t.InvalidBuildings, false, len(t.Buildings))
+	t.TextBoxes = CopyIdxs(t.TextBoxes, oc.TextBoxes, sel.TextBoxIdxs)
+	t._buildingBounds = t._buildingBounds[:0]
+
+	items := make([]alignItem, 0, len(t.Buildings)+len(t.TextBoxes))
+	for i, b := range t.Buildings {
+		items = append(items, alignItem{bounds: b.Bounds(), idx: i})
+	}
+	for i, tb := range t.TextBoxes {
+		items = append(items, alignItem{bounds: tb.AABB(), isTextBox: true, idx: i})
+	}
+	if len(items) == 0 {
+		return false
+	}
+	bounds := items[0].bounds
+	for _, it := range items[1:] {
+		bounds = rectUnion(bounds, it.bounds)
+	}
+
+	// offset of each item
+	deltas := make([]rl.Vector2, len(items))
+	switch a {
+	case AlignLeft:
+		for i, it := range items {
+			deltas[i].X = bounds.X - it.bounds.X
+		}
+	case AlignRight:
+		for i, it := range items {
+			deltas[i].X = bounds.X + bounds.Width - it.bounds.X - it.bounds.Width
+		}
+	case AlignTop:
+		for i, it := range items {
+			deltas[i].Y = bounds.Y - it.bounds.Y
+		}
+	case AlignBottom:
+		for i, it := range items {
+			deltas[i].Y = bounds.Y + bounds.Height - it.bounds.Y - it.bounds.Height
+		}
+	case AlignCenterX:
+		for i, it := range items {
+			deltas[i].X = bounds.Center().X - it.bounds.Center().X
+		}
+	case AlignCenterY:
+		for i, it := range items {
+			deltas[i].Y = bounds.Center().Y - it.bounds.Center().Y
+		}
+	case DistributeX, DistributeY:
+		distribute(items, deltas, a == DistributeY)
+	}
+
+	moved := false
+	t.Bounds = rl.Rectangle{}
+	for i, it := range items {
+		delta := vec2(math32.Round(deltas[i].X), math32.Round(deltas[i].Y))
+		moved = moved || delta != rl.Vector2{}
+		if it.isTextBox {
+			tb := &t.TextBoxes[it.idx]
+			tb.Bounds.X += delta.X
+			tb.Bounds.Y += delta.Y
+			it.bounds = tb.AABB()
+		} else {
+			b := &t.Buildings[it.idx]
+			b.Pos = b.Pos.Add(delta)
+			it.bounds = b.Bounds()
+			t._buildingBounds = append(t._buildingBounds, it.bounds)
+		}
+		if i == 0 {
+			t.Bounds = it.bounds
+		} else {
+			t.Bounds = rectUnion(t.Bounds, it.bounds)
+		}
+	}
+
+	// moved buildings against each other and the non selected ones
+	for i, bi := range t._buildingBounds {
+		for j := i + 1; j < len(t._buildingBounds); j++ {
+			if checkCollisionRecs(bi, t._buildingBounds[j]) {
+				t.InvalidBuildings[i] = true
+				t.InvalidBuildings[j] = true
+				t.IsValid = false
+			}
+		}
+	}
+	isSelectedIt := NewMaskIterator(sel.BuildingIdxs)
+	for _, b := range oc.Buildings {
+		if isSelectedIt.Next() {
+			continue
+		}
+		sb := b.Bounds()
+		for i, bounds := range t._buildingBounds {
+			if !t.InvalidBuildings[i] && checkCollisionRecs(bounds, sb) {
+				t.InvalidBuildings[i] = true
+				t.IsValid = false
+			}
+		}
+	}
+	return moved
+}
+
+// distribute sets the offsets spacing evenly the items, along the X or Y axis. The first and last
+// items along the axis stay in place.
+func distribute(items []alignItem, deltas []rl.Vector2, vertical bool) {
+	if len(items) < 3 {
+		return
+	}
+	pos := func(r rl.Rectangle) float32 { return r.X }
+	size := func(r rl.Rectangle) float32 { return r.Width }
+	if vertical {
+		pos = func(r rl.Rectangle) float32 { return r.Y }
+		size = func(r rl.Rectangle) float32 { return r.Height }
+	}
+
+	order := Range(0, len(items))
+	slices.SortStableFunc(order, func(i, j int) int {
+		return cmp.Compare(pos(items[i].bounds), pos(items[j].bounds))
+	})
+
+	first, last := items[order[0]].bounds, items[order[len(order)-1]].bounds
+	var total float32
+	for _, it := range items {
+		total += size(it.bounds)
+	}
+	gap := (pos(last) + size(last) - pos(first) - total) / float32(len(items)-1)
+
+	next := pos(first)
+	for _, i := range order {
+		d := next - pos(items[i].bounds)
+		if vertical {
+			deltas[i].Y = d
+		} else {
+			deltas[i].X = d
+		}
+		next += size(items[i].bounds) + gap
+	}
+}
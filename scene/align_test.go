@@ -0,0 +1,60 @@
+package scene
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestAlign(t *testing.T) {
+	oc := testCollection()
+	// buildings bounds: (-4,-5 8x10), (16,-4 8x8) and (38,-2 4x4)
+	sel := selectAll(ObjectCollection{Buildings: oc.Buildings})
+
+	var tr Transformed
+	tests := []struct {
+		align Alignment
+		want  []Building
+	}{
+		{AlignTop, []Building{
+			building(defConstructor, 0, 0, 0),
+			building(defFoundation, 20, -1, 90),
+			building(defSplitter, 40, -3, 0),
+		}},
+		{AlignCenterY, []Building{
+			building(defConstructor, 0, 0, 0),
+			building(defFoundation, 20, 0, 90),
+			building(defSplitter, 40, 0, 0),
+		}},
+		{DistributeX, []Building{
+			building(defConstructor, 0, 0, 0),
+			building(defFoundation, 21, 0, 90),
+			building(defSplitter, 40, 0, 0),
+		}},
+	}
+	for _, tt := range tests {
+		moved := tr.Align(oc, sel, tt.align)
+		if !tr.IsValid || !slices.Equal(tr.Buildings, tt.want) {
+			t.Errorf("%v: IsValid=%v Buildings=%v, want true %v", tt.align, tr.IsValid, tr.Buildings, tt.want)
+		}
+		if wantMoved := !slices.Equal(tt.want, oc.Buildings); moved != wantMoved {
+			t.Errorf("%v: moved = %v, want %v", tt.align, moved, wantMoved)
+		}
+	}
+
+	// buildings on top of each other
+	tr.Align(oc, sel, AlignLeft)
+	if tr.IsValid || !slices.Equal(tr.InvalidBuildings, []bool{true, true, true}) {
+		t.Errorf("AlignLeft: IsValid=%v InvalidBuildings=%v, want false [true true true]", tr.IsValid, tr.InvalidBuildings)
+	}
+
+	// onto a non selected building, with a text box
+	sel = ObjectSelection{BuildingIdxs: []int{2}, TextBoxIdxs: []int{0}}
+	tr.Align(oc, sel, AlignLeft)
+	if tr.IsValid || tr.TextBoxes[0].Bounds.X != 0 {
+		t.Errorf("AlignLeft onto building: IsValid=%v TextBoxes=%v, want false and unmoved text box", tr.IsValid, tr.TextBoxes)
+	}
+	tr.Align(oc, sel, AlignRight)
+	if !tr.IsValid || tr.TextBoxes[0].Bounds.X != 32 || len(tr.Paths) != 0 {
+		t.Errorf("AlignRight: IsValid=%v TextBoxes=%v Paths=%v, want true, text box at x=32 and no path", tr.IsValid, tr.TextBoxes, tr.Paths)
+	}
+}
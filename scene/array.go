@@ -0,0 +1,92 @@
+// array - Copies of a selection laid out on a grid, and validity of the copies
+
+package scene
+
+import (
+	"fmt"
+
+	rl "github.com/bonoboris/satisfied/rlmath"
+)
+
+// ArrayPattern describes copies of a selection laid out on a grid, the selection being the copy of
+// the first column and row
+type ArrayPattern struct {
+	// Number of columns and rows (at least 1)
+	Cols, Rows int
+	// Offset between 2 columns (X) and between 2 rows (Y), already snapped to the grid
+	Spacing rl.Vector2
+	// Rotation added at each copy, in degrees, in row-major order
+	Rot int32
+}
+
+func (p ArrayPattern) String() string {
+	return fmt.Sprintf("{%dx%d spacing=(%v,%v) rot=%d}", p.Cols, p.Rows, p.Spacing.X, p.Spacing.Y, p.Rot)
+}
+
+// Count returns the number of copies, the selection excluded
+func (p ArrayPattern) Count() int { return p.Cols*p.Rows - 1 }
+
+// Transform returns the transformation of the selection into the copy at the given column and row
+func (p ArrayPattern) Transform(col, row int) Transform {
+	step := int32(row*p.Cols + col)
+	return Transform{
+		Rot:       normAngle(step * p.Rot),
+		Translate: vec2(float32(col)*p.Spacing.X, float32(row)*p.Spacing.Y),
+	}
+}
+
+// ComputeArray computes the copies of the objects of oc selected by sel laid out with pat, and checks
+// their validity.
+//
+// Like duplicating with [Transformed.Compute], only the fully selected paths are copied and the
+// copied buildings are checked against every building of oc; they are also checked against each
+// other. A pattern without copies is invalid.
+func (t *Transformed) ComputeArray(oc ObjectCollection, sel ObjectSelection, pat ArrayPattern) {
+	t.Reset()
+	t.IsValid = pat.Count() > 0
+	t.Bounds = sel.Bounds
+	if t._copy == nil {
+		t._copy = &Transformed{}
+	}
+	c := t._copy
+
+	for row := range pat.Rows {
+		for col := range pat.Cols {
+			if row == 0 && col == 0 {
+				continue
+			}
+			c.Compute(oc, sel, pat.Transform(col, row), true)
+			t.Buildings = append(t.Buildings, c.Buildings...)
+			t.InvalidBuildings = append(t.InvalidBuildings, c.InvalidBuildings...)
+			t.Paths = append(t.Paths, c.Paths...)
+			t.InvalidPaths = append(t.InvalidPaths, c.InvalidPaths...)
+			t.TextBoxes = append(t.TextBoxes, c.TextBoxes...)
+			t.IsValid = t.IsValid && c.IsValid
+			t.Bounds = rectUnion(t.Bounds, c.Bounds)
+		}
+	}
+
+	// copies against each other (a copy buildings do not overlap, they are copied from the scene)
+	nb := len(sel.BuildingIdxs)
+	t._buildingBounds = t._buildingBounds[:0]
+	for _, b := range t.Buildings {
+		t._buildingBounds = append(t._buildingBounds, b.Bounds())
+	}
+	for i, bi := range t._buildingBounds {
+		// nb > 0 since there is a building
+		for j := (i/nb + 1) * nb; j < len(t._buildingBounds); j++ {
+			if checkCollisionRecs(bi, t._buildingBounds[j]) {
+				t.InvalidBuildings[i] = true
+				t.InvalidBuildings[j] = true
+				t.IsValid = false
+			}
+		}
+	}
+}
+
+// rectUnion returns the smallest rectangle containing a and b
+func rectUnion(a, b rl.Rectangle) rl.Rectangle {
+	xmin, ymin := min(a.X, b.X), min(a.Y, b.Y)
+	xmax, ymax := max(a.X+a.Width, b.X+b.Width), max(a.Y+a.Height, b.Y+b.Height)
+	return rl.NewRectangle(xmin, ymin, xmax-xmin, ymax-ymin)
+}
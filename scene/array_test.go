@@ -0,0 +1,71 @@
+package scene
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestArrayPatternTransform(t *testing.T) {
+	pat := ArrayPattern{Cols: 3, Rows: 2, Spacing: vec2(10, 20), Rot: 90}
+	if got := pat.Count(); got != 5 {
+		t.Errorf("Count() = %d, want 5", got)
+	}
+	if got, want := pat.Transform(2, 1), (Transform{Rot: 90, Translate: vec2(20, 20)}); got != want {
+		t.Errorf("Transform(2, 1) = %v, want %v", got, want)
+	}
+	if got := pat.Transform(0, 0); !got.IsIdentity() {
+		t.Errorf("Transform(0, 0) = %v, want identity", got)
+	}
+}
+
+func TestComputeArray(t *testing.T) {
+	oc := testCollection()
+	// building 0 spans y in [-5, 5]
+	sel := ObjectSelection{BuildingIdxs: []int{0}}
+	sel.RecomputeBounds(oc)
+
+	var tr Transformed
+	tr.ComputeArray(oc, sel, ArrayPattern{Cols: 1, Rows: 4, Spacing: vec2(0, -10)})
+	if !tr.IsValid || len(tr.Buildings) != 3 {
+		t.Fatalf("column of 4: IsValid=%v Buildings=%v, want true and 3 copies", tr.IsValid, tr.Buildings)
+	}
+	for i, b := range tr.Buildings {
+		if want := vec2(0, float32(-10*(i+1))); b.Pos != want {
+			t.Errorf("copy %d at %v, want %v", i, b.Pos, want)
+		}
+	}
+	if want := vec2(-4, -35); tr.Bounds.TopLeft() != want || tr.Bounds.Height != 40 {
+		t.Errorf("Bounds = %v, want from %v with height 40", tr.Bounds, want)
+	}
+
+	// copies overlapping each other, and the original
+	tr.ComputeArray(oc, sel, ArrayPattern{Cols: 1, Rows: 3, Spacing: vec2(0, -8)})
+	if tr.IsValid || !slices.Equal(tr.InvalidBuildings, []bool{true, true}) {
+		t.Errorf("overlapping copies: IsValid=%v InvalidBuildings=%v, want false [true true]", tr.IsValid, tr.InvalidBuildings)
+	}
+
+	// copy onto building 1, at x = 20
+	tr.ComputeArray(oc, sel, ArrayPattern{Cols: 3, Rows: 1, Spacing: vec2(10, 0)})
+	if tr.IsValid || !slices.Equal(tr.InvalidBuildings, []bool{false, true}) {
+		t.Errorf("copy onto building: IsValid=%v InvalidBuildings=%v, want false [false true]", tr.IsValid, tr.InvalidBuildings)
+	}
+
+	// buildings only rotate by multiples of 90°
+	tr.ComputeArray(oc, sel, ArrayPattern{Cols: 1, Rows: 2, Spacing: vec2(0, -20), Rot: 15})
+	if tr.IsValid {
+		t.Errorf("free rotation: IsValid = true, want false")
+	}
+
+	// no copy
+	tr.ComputeArray(oc, sel, ArrayPattern{Cols: 1, Rows: 1})
+	if tr.IsValid || len(tr.Buildings) != 0 {
+		t.Errorf("no copy: IsValid=%v Buildings=%v, want false and none", tr.IsValid, tr.Buildings)
+	}
+
+	// paths and text boxes are copied too
+	sel = selectAll(ObjectCollection{Paths: oc.Paths[:1], TextBoxes: oc.TextBoxes[:1]})
+	tr.ComputeArray(oc, sel, ArrayPattern{Cols: 3, Rows: 1, Spacing: vec2(100, 0)})
+	if !tr.IsValid || len(tr.Paths) != 2 || len(tr.TextBoxes) != 2 {
+		t.Errorf("paths and text boxes: IsValid=%v Paths=%v TextBoxes=%v, want true and 2 copies each", tr.IsValid, tr.Paths, tr.TextBoxes)
+	}
+}
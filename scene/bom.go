@@ -0,0 +1,159 @@
+// bom - Construction cost (bill of materials) of objects, and its CSV / Markdown export
+
+package scene
+
+import (
+	"cmp"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"math"
+	"slices"
+	"strconv"
+)
+
+// PathTier is a tier of a path definition (e.g. a belt mark) and its cost
+type PathTier struct {
+	Name string
+	// Items needed per meter of path
+	Cost []ItemAmount
+}
+
+// BOM is a bill of materials: the items needed to build some objects
+type BOM struct {
+	// Items needed, whole amounts sorted by decreasing amount then item name
+	Items []ItemAmount
+	// Number of buildings by class, in definitions order
+	Buildings []BuildingCount
+	// Length of the paths by class and tier, in m, in definitions order
+	Paths []ItemAmount
+	// Classes of the objects without cost, in definitions order
+	Missing []string
+}
+
+// NewBOM returns the bill of materials of the objects, the paths of each definition costed with
+// the tier tiers[defIdx] (the first one if tiers is too short or the tier does not exist).
+//
+// Items amounts are rounded up once summed, text boxes cost nothing and power lines are left out
+// (their few cables are not worth listing).
+func NewBOM(oc ObjectCollection, tiers []int) BOM {
+	var bom BOM
+	amounts := make(map[string]float64)
+	addCost := func(cost []ItemAmount, factor float64) {
+		for _, ia := range cost {
+			amounts[ia.Item] += float64(ia.Amount) * factor
+		}
+	}
+
+	counts := make([]int, len(buildingDefs))
+	for _, b := range oc.Buildings {
+		counts[b.DefIdx]++
+	}
+	for defIdx, count := range counts {
+		if count == 0 {
+			continue
+		}
+		def := buildingDefs[defIdx]
+		bom.Buildings = append(bom.Buildings, BuildingCount{Class: def.Class, Count: count})
+		if len(def.Cost) == 0 {
+			bom.Missing = append(bom.Missing, def.Class)
+		}
+		addCost(def.Cost, float64(count))
+	}
+
+	lengths := make([]float64, len(pathDefs))
+	for _, p := range oc.Paths {
+		if !pathDefs[p.DefIdx].IsPowerLine {
+			lengths[p.DefIdx] += float64(p.Length())
+		}
+	}
+	for defIdx, length := range lengths {
+		if length == 0 {
+			continue
+		}
+		def := pathDefs[defIdx]
+		if len(def.Tiers) == 0 {
+			bom.Paths = append(bom.Paths, ItemAmount{Item: def.Class, Amount: float32(length)})
+			bom.Missing = append(bom.Missing, def.Class)
+			continue
+		}
+		tier := def.Tiers[0]
+		if defIdx < len(tiers) && tiers[defIdx] >= 0 && tiers[defIdx] < len(def.Tiers) {
+			tier = def.Tiers[tiers[defIdx]]
+		}
+		bom.Paths = append(bom.Paths, ItemAmount{Item: def.Class + " " + tier.Name, Amount: float32(length)})
+		addCost(tier.Cost, length)
+	}
+
+	for item, amount := range amounts {
+		// tolerance on the float sums of the path lengths
+		bom.Items = append(bom.Items, ItemAmount{Item: item, Amount: float32(math.Ceil(amount - 1e-4))})
+	}
+	slices.SortFunc(bom.Items, func(a, b ItemAmount) int {
+		return cmp.Or(cmp.Compare(b.Amount, a.Amount), cmp.Compare(a.Item, b.Item))
+	})
+	return bom
+}
+
+// formatAmount formats an item amount, without exponent
+func formatAmount(amount float32) string {
+	return strconv.FormatFloat(float64(amount), 'f', -1, 32)
+}
+
+// formatLength formats a length in m, rounded to 0.1 m
+func formatLength(length float32) string {
+	return strconv.FormatFloat(math.Round(float64(length)*10)/10, 'f', -1, 64)
+}
+
+// WriteCSV writes the bill of materials as CSV, with a Kind (item, building or path), Name and
+// Amount (the length in m for the paths) column
+func (bom BOM) WriteCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"Kind", "Name", "Amount"})
+	for _, ia := range bom.Items {
+		cw.Write([]string{"item", ia.Item, formatAmount(ia.Amount)})
+	}
+	for _, bc := range bom.Buildings {
+		cw.Write([]string{"building", bc.Class, strconv.Itoa(bc.Count)})
+	}
+	for _, ia := range bom.Paths {
+		cw.Write([]string{"path", ia.Item, formatLength(ia.Amount)})
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// WriteMarkdown writes the bill of materials as Markdown tables, under the given title
+func (bom BOM) WriteMarkdown(w io.Writer, title string) error {
+	var err error
+	printf := func(format string, args ...any) {
+		if err == nil {
+			_, err = fmt.Fprintf(w, format, args...)
+		}
+	}
+	printf("# %s\n\n## Items\n\n| Item | Amount |\n| --- | ---: |\n", title)
+	for _, ia := range bom.Items {
+		printf("| %s | %s |\n", ia.Item, formatAmount(ia.Amount))
+	}
+	printf("\n## Buildings\n\n| Building | Count |\n| --- | ---: |\n")
+	for _, bc := range bom.Buildings {
+		printf("| %s | %d |\n", bc.Class, bc.Count)
+	}
+	if len(bom.Paths) > 0 {
+		printf("\n## Belts and pipes\n\n| Path | Length (m) |\n| --- | ---: |\n")
+		for _, ia := range bom.Paths {
+			printf("| %s | %s |\n", ia.Item, formatLength(ia.Amount))
+		}
+	}
+	for i, class := range bom.Missing {
+		if i == 0 {
+			printf("\nNo cost defined for: %s", class)
+		} else {
+			printf(", %s", class)
+		}
+		if i == len(bom.Missing)-1 {
+			printf("\n")
+		}
+	}
+	return err
+}
@@ -0,0 +1,113 @@
+package scene
+
+import (
+	"bytes"
+	"slices"
+	"testing"
+)
+
+// setBOMDefs registers the test definitions with costs for the test
+func setBOMDefs(t *testing.T) {
+	t.Helper()
+	buildings := slices.Clone(testBuildingDefs)
+	buildings[defConstructor].Cost = []ItemAmount{{"Reinforced Iron Plate", 2}, {"Cable", 8}}
+	buildings[defSplitter].Cost = []ItemAmount{{"Iron Plate", 2}, {"Cable", 2}}
+	paths := slices.Clone(testPathDefs)
+	paths[defBelt].Tiers = []PathTier{
+		{Name: "Mk.1", Cost: []ItemAmount{{"Iron Plate", 1}}},
+		{Name: "Mk.2", Cost: []ItemAmount{{"Reinforced Iron Plate", 1}}},
+	}
+	paths = append(paths, PathDef{Class: "Power Line", Width: 0.5, IsPowerLine: true})
+	SetDefs(buildings, paths)
+	t.Cleanup(func() { SetDefs(testBuildingDefs, testPathDefs) })
+}
+
+func TestNewBOM(t *testing.T) {
+	setBOMDefs(t)
+	oc := ObjectCollection{
+		Buildings: []Building{
+			building(defConstructor, 0, 0, 0),
+			building(defConstructor, 20, 0, 90),
+			building(defSplitter, 40, 0, 0),
+			building(defFoundation, 60, 0, 0),
+		},
+		Paths: []Path{
+			path(defBelt, 0, 10, 0, 20.5),
+			path(defBelt, 0, 30, 10, 30),
+			path(defPipe, 0, 40, 5, 40),
+			// a power line, left out
+			{DefIdx: len(testPathDefs), Start: vec2(0, 0), End: vec2(20, 0)},
+		},
+		TextBoxes: []TextBox{textBox(0, 0, 10, 10, "free")},
+	}
+
+	bom := NewBOM(oc, nil)
+	// 2 + 10.5 + 10 iron plates, rounded up
+	wantItems := []ItemAmount{{"Iron Plate", 23}, {"Cable", 18}, {"Reinforced Iron Plate", 4}}
+	if !slices.Equal(bom.Items, wantItems) {
+		t.Errorf("Items = %v, want %v", bom.Items, wantItems)
+	}
+	wantBuildings := []BuildingCount{{"Constructor", 2}, {"Foundation", 1}, {"Splitter", 1}}
+	if !slices.Equal(bom.Buildings, wantBuildings) {
+		t.Errorf("Buildings = %v, want %v", bom.Buildings, wantBuildings)
+	}
+	wantPaths := []ItemAmount{{"Belt Mk.1", 20.5}, {"Pipe", 5}}
+	if !slices.Equal(bom.Paths, wantPaths) {
+		t.Errorf("Paths = %v, want %v", bom.Paths, wantPaths)
+	}
+	if wantMissing := []string{"Foundation", "Pipe"}; !slices.Equal(bom.Missing, wantMissing) {
+		t.Errorf("Missing = %v, want %v", bom.Missing, wantMissing)
+	}
+
+	// belts costed with their second tier, invalid tiers fall back to the first one
+	bom = NewBOM(oc, []int{1, 5})
+	wantItems = []ItemAmount{{"Reinforced Iron Plate", 25}, {"Cable", 18}, {"Iron Plate", 2}}
+	if !slices.Equal(bom.Items, wantItems) {
+		t.Errorf("Mk.2 Items = %v, want %v", bom.Items, wantItems)
+	}
+	if bom.Paths[0].Item != "Belt Mk.2" {
+		t.Errorf("Mk.2 Paths = %v, want Belt Mk.2 first", bom.Paths)
+	}
+	if bom := NewBOM(oc, []int{-1}); bom.Paths[0].Item != "Belt Mk.1" {
+		t.Errorf("invalid tier Paths = %v, want Belt Mk.1 first", bom.Paths)
+	}
+
+	if bom := NewBOM(ObjectCollection{}, nil); len(bom.Items) != 0 || len(bom.Buildings) != 0 || len(bom.Paths) != 0 {
+		t.Errorf("empty collection BOM = %v, want empty", bom)
+	}
+}
+
+func TestBOMExport(t *testing.T) {
+	bom := BOM{
+		// large amounts are written without exponent
+		Items:     []ItemAmount{{"Iron Plate", 25000000}, {"Cable, insulated", 18}},
+		Buildings: []BuildingCount{{"Constructor", 2}},
+		Paths:     []ItemAmount{{"Belt Mk.1", 20.54}},
+		Missing:   []string{"Foundation", "Pipe"},
+	}
+	var buf bytes.Buffer
+	if err := bom.WriteCSV(&buf); err != nil {
+		t.Fatal(err)
+	}
+	wantCSV := "Kind,Name,Amount\n" +
+		"item,Iron Plate,25000000\n" +
+		"item,\"Cable, insulated\",18\n" +
+		"building,Constructor,2\n" +
+		"path,Belt Mk.1,20.5\n"
+	if buf.String() != wantCSV {
+		t.Errorf("WriteCSV() = %q, want %q", buf.String(), wantCSV)
+	}
+
+	buf.Reset()
+	if err := bom.WriteMarkdown(&buf, "Factory"); err != nil {
+		t.Fatal(err)
+	}
+	wantMD := "# Factory\n\n" +
+		"## Items\n\n| Item | Amount |\n| --- | ---: |\n| Iron Plate | 25000000 |\n| Cable, insulated | 18 |\n\n" +
+		"## Buildings\n\n| Building | Count |\n| --- | ---: |\n| Constructor | 2 |\n\n" +
+		"## Belts and pipes\n\n| Path | Length (m) |\n| --- | ---: |\n| Belt Mk.1 | 20.5 |\n\n" +
+		"No cost defined for: Foundation, Pipe\n"
+	if buf.String() != wantMD {
+		t.Errorf("WriteMarkdown() = %q, want %q", buf.String(), wantMD)
+	}
+}
@@ -0,0 +1,256 @@
+package scene
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+
+	"github.com/bonoboris/satisfied/math32"
+	"github.com/bonoboris/satisfied/matrix"
+	rl "github.com/bonoboris/satisfied/rlmath"
+)
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
+// Building
+////////////////////////////////////////////////////////////////////////////////////////////////////
+
+type Building struct {
+	ID     ID
+	DefIdx int
+	Pos    rl.Vector2
+	Rot    int32
+	// Mirrored horizontally (in building local coordinates, before rotation)
+	Mirror bool
+	// Clock speed, power shards and somersloops, the zero value is the default
+	Mods Modifiers
+}
+
+func (b Building) String() string {
+	class := "<invalid>"
+	if b.DefIdx != -1 {
+		class = b.Def().Class
+	}
+	mods := ""
+	if !b.Mods.IsDefault() {
+		mods = " " + b.Mods.String()
+	}
+	if b.Mirror {
+		return fmt.Sprintf("%s{%v %v %d mirror%s}", class, b.Pos.X, b.Pos.Y, b.Rot, mods)
+	}
+	return fmt.Sprintf("%s{%v %v %d%s}", class, b.Pos.X, b.Pos.Y, b.Rot, mods)
+}
+
+func (b Building) Def() BuildingDef { return buildingDefs[b.DefIdx] }
+
+// Matrix returns the building local to world coordinates transformation matrix
+func (b Building) Matrix() matrix.Matrix {
+	// rotation center is snapped to the 1m building grid, regardless of the user snap step
+	dims := b.Def().Dims
+	mid := vec2(math32.Round(dims.X/2), math32.Round(dims.Y/2))
+	mat := matrix.NewTranslateV(b.Pos).Rotate(b.Rot)
+	if b.Mirror {
+		mat = mat.MirrorX()
+	}
+	return mat.TranslateV(mid.Negate())
+}
+
+func (b Building) Bounds() rl.Rectangle {
+	dims := b.Def().Dims
+	return b.Matrix().ApplyRec(0, 0, dims.X, dims.Y)
+}
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
+// (Belt, Pipe)  input / output
+////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// InputOutput is a building belt or pipe input / output, in building local coordinates
+type InputOutput struct {
+	Pos rl.Vector2
+	Rot int32
+}
+
+func (io InputOutput) String() string {
+	if io.Rot == 0 {
+		return fmt.Sprintf("(%v,%v)", io.Pos.X, io.Pos.Y)
+	} else {
+		return fmt.Sprintf("(%v,%v, r=%d°)", io.Pos.X, io.Pos.Y, io.Rot)
+	}
+}
+
+// Matrix returns the input / output local to building coordinates transformation matrix
+func (io InputOutput) Matrix() matrix.Matrix {
+	return matrix.NewTranslateV(io.Pos).Rotate(io.Rot)
+}
+
+// MAX_INOUT is the maximum number of inputs or outputs of a kind (e.g. belt inputs) of a building,
+// the Space Elevator has the most with 6 belt inputs
+const MAX_INOUT = 6
+
+// InputOutputs is a fixed capacity list of [InputOutput]
+type InputOutputs struct {
+	arr [MAX_INOUT]InputOutput
+	len int
+}
+
+// Len returns the number of inputs / outputs
+func (inouts InputOutputs) Len() int { return inouts.len }
+
+// Get returns the i-th input / output
+func (inouts InputOutputs) Get(i int) InputOutput { return inouts.arr[i] }
+
+func (inouts *InputOutputs) UnmarshalJSON(data []byte) error {
+	var s []InputOutput
+	err := json.Unmarshal(data, &s)
+	if err != nil {
+		return err
+	}
+	if len(s) > MAX_INOUT {
+		return errors.New("elements count exceeds MAX_INOUT")
+	}
+	copy(inouts.arr[:], s)
+	inouts.len = len(s)
+	return err
+}
+
+func (inouts InputOutputs) String() string {
+	return fmt.Sprintf("%v", inouts.arr[:inouts.len])
+}
+
+// Port is a building input / output in world coordinates
+type Port struct {
+	Pos rl.Vector2
+	// Unit vector pointing out of the building
+	Dir      rl.Vector2
+	IsOutput bool
+	IsPipe   bool
+}
+
+func (p Port) String() string {
+	kind := "Belt"
+	if p.IsPipe {
+		kind = "Pipe"
+	}
+	if p.IsOutput {
+		kind += "Out"
+	} else {
+		kind += "In"
+	}
+	return fmt.Sprintf("%s{%v %v dir=(%v,%v)}", kind, p.Pos.X, p.Pos.Y, p.Dir.X, p.Dir.Y)
+}
+
+// Ports appends the building inputs and outputs to dst and returns the extended slice
+func (b Building) Ports(dst []Port) []Port {
+	mat := b.Matrix()
+	def := b.Def()
+	appendPorts := func(inouts InputOutputs, isOutput, isPipe bool) {
+		for _, io := range inouts.arr[:inouts.len] {
+			ioMat := mat.Mult(io.Matrix())
+			pos := ioMat.Apply(0, 0)
+			// items flow toward local -Y: out of the building for an output, into it for an input
+			dir := ioMat.Apply(0, -1).Subtract(pos)
+			if !isOutput {
+				dir = dir.Negate()
+			}
+			dst = append(dst, Port{Pos: pos, Dir: dir, IsOutput: isOutput, IsPipe: isPipe})
+		}
+	}
+	appendPorts(def.BeltIn, false, false)
+	appendPorts(def.BeltOut, true, false)
+	appendPorts(def.PipeIn, false, true)
+	appendPorts(def.PipeOut, true, true)
+	return dst
+}
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
+// BuildingDef
+////////////////////////////////////////////////////////////////////////////////////////////////////
+
+type BuildingDef struct {
+	Class    string
+	Category string
+	Dims     rl.Vector2
+	BeltIn   InputOutputs
+	BeltOut  InputOutputs
+	PipeIn   InputOutputs
+	PipeOut  InputOutputs
+	// Power connectors, where power lines end (their rotation is ignored)
+	PowerConn InputOutputs
+	// Maximum number of power lines of each power connector, 1 if not set
+	MaxWires int
+	// Power consumed, in MW
+	PowerUse float32
+	// Power generated, in MW
+	PowerGen float32
+	// Whether the clock speed can be changed (see [Modifiers])
+	Clockable bool
+	// Number of somersloop slots
+	Somersloops int32
+	// Items needed to build it
+	Cost []ItemAmount
+	// Name of the pack defining it, empty for built-in definitions
+	Pack string `json:"-"`
+}
+
+func (b BuildingDef) String() string {
+	s := fmt.Sprintf("{%s(%s) W=%v H=%v", b.Class, b.Category, b.Dims.X, b.Dims.Y)
+	if b.BeltIn.len > 0 {
+		s += fmt.Sprintf(" BeltIn=%s", b.BeltIn)
+	}
+	if b.BeltOut.len > 0 {
+		s += fmt.Sprintf(" BeltOut=%s", b.BeltOut)
+	}
+	if b.PipeIn.len > 0 {
+		s += fmt.Sprintf(" PipeIn=%s", b.PipeIn)
+	}
+	if b.PipeOut.len > 0 {
+		s += fmt.Sprintf(" PipeOut=%s", b.PipeOut)
+	}
+	if b.PowerConn.len > 0 {
+		s += fmt.Sprintf(" PowerConn=%s", b.PowerConn)
+	}
+	if b.PowerUse > 0 {
+		s += fmt.Sprintf(" PowerUse=%vMW", b.PowerUse)
+	}
+	if b.PowerGen > 0 {
+		s += fmt.Sprintf(" PowerGen=%vMW", b.PowerGen)
+	}
+	if b.Clockable {
+		s += " Clockable"
+	}
+	if b.Somersloops > 0 {
+		s += fmt.Sprintf(" Somersloops=%d", b.Somersloops)
+	}
+	return fmt.Sprintf("%s}", s)
+}
+
+type BuildingDefs []BuildingDef
+
+func (defs BuildingDefs) Classes() []string {
+	classes := make([]string, len(defs))
+	for i, def := range defs {
+		classes[i] = def.Class
+	}
+	return classes
+}
+
+func (defs BuildingDefs) Categories() []string {
+	var categories []string
+	for _, def := range defs {
+		if !slices.Contains(categories, def.Category) {
+			categories = append(categories, def.Category)
+		}
+	}
+	sort.Strings(categories)
+	return categories
+}
+
+func (defs BuildingDefs) Index(class string) int {
+	for i, def := range defs {
+		if def.Class == class {
+			return i
+		}
+	}
+	return -1
+}
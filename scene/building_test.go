@@ -0,0 +1,64 @@
+package scene
+
+import (
+	"encoding/json"
+	"os"
+	"testing"
+
+	rl "github.com/bonoboris/satisfied/rlmath"
+)
+
+// TestBuildingDefsPorts checks every port of the assets building definitions lies on the building
+// edge, pointing out of it, every power connector inside it, and that it has a build cost
+func TestBuildingDefsPorts(t *testing.T) {
+	data, err := os.ReadFile("../assets/building_defs.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var defs BuildingDefs
+	if err := json.Unmarshal(data, &defs); err != nil {
+		t.Fatal(err)
+	}
+	SetDefs(defs, testPathDefs)
+	t.Cleanup(func() { SetDefs(testBuildingDefs, testPathDefs) })
+
+	for i, def := range defs {
+		if defs.Index(def.Class) != i {
+			t.Errorf("%s: duplicate class", def.Class)
+		}
+		// local coordinates are world coordinates, translated by the rotation center
+		b := Building{DefIdx: i}
+		origin := b.Matrix().Apply(0, 0)
+		for _, p := range b.Ports(nil) {
+			pos := p.Pos.Subtract(origin)
+			var out rl.Vector2
+			switch {
+			case pos.X == 0 && pos.Y >= 0 && pos.Y <= def.Dims.Y:
+				out = vec2(-1, 0)
+			case pos.X == def.Dims.X && pos.Y >= 0 && pos.Y <= def.Dims.Y:
+				out = vec2(1, 0)
+			case pos.Y == 0 && pos.X >= 0 && pos.X <= def.Dims.X:
+				out = vec2(0, -1)
+			case pos.Y == def.Dims.Y && pos.X >= 0 && pos.X <= def.Dims.X:
+				out = vec2(0, 1)
+			default:
+				t.Errorf("%s: %v not on the edge of %vx%v", def.Class, p, def.Dims.X, def.Dims.Y)
+				continue
+			}
+			if p.Dir != out {
+				t.Errorf("%s: %v direction, want (%v,%v)", def.Class, p, out.X, out.Y)
+			}
+		}
+		for _, conn := range b.PowerConnectors(nil) {
+			if pos := conn.Subtract(origin); pos.X < 0 || pos.X > def.Dims.X || pos.Y < 0 || pos.Y > def.Dims.Y {
+				t.Errorf("%s: power connector %v outside of %vx%v", def.Class, pos, def.Dims.X, def.Dims.Y)
+			}
+		}
+		if (def.PowerUse > 0 || def.PowerGen > 0) && def.PowerConn.Len() == 0 {
+			t.Errorf("%s: powered without power connector", def.Class)
+		}
+		if len(def.Cost) == 0 {
+			t.Errorf("%s: no build cost", def.Class)
+		}
+	}
+}
@@ -0,0 +1,145 @@
+// chain - Buildings and paths linked through their ports, upstream or downstream
+
+package scene
+
+import (
+	"slices"
+
+	rl "github.com/bonoboris/satisfied/rlmath"
+)
+
+// ChainDir is the direction of a [ObjectCollection.SelectChain] traversal
+type ChainDir int
+
+const (
+	// ChainConnected follows every link
+	ChainConnected ChainDir = iota
+	// ChainUpstream follows the links items come from
+	ChainUpstream
+	// ChainDownstream follows the links items go to
+	ChainDownstream
+)
+
+func (d ChainDir) String() string {
+	switch d {
+	case ChainConnected:
+		return "ChainConnected"
+	case ChainUpstream:
+		return "ChainUpstream"
+	case ChainDownstream:
+		return "ChainDownstream"
+	default:
+		return "ChainInvalid"
+	}
+}
+
+// chainEnd is a building port or a path end
+type chainEnd struct {
+	obj Object
+	// Whether items enter the object there: building input, belt start or any pipe end
+	in bool
+	// Whether items leave the object there: building output, belt end or any pipe end
+	out bool
+	// Whether it is a pipe end, only linked to other pipe ends
+	pipe bool
+}
+
+// chainEnds appends the ports of the building or the ends of the path obj to ends, and their
+// positions to dst, and returns the extended slices; power lines have none
+func (oc ObjectCollection) chainEnds(obj Object, dst []rl.Vector2, ends []chainEnd) ([]rl.Vector2, []chainEnd) {
+	switch obj.Type {
+	case TypeBuilding:
+		var buf [4 * MAX_INOUT]Port
+		for _, p := range oc.Buildings[obj.Idx].Ports(buf[:0]) {
+			dst = append(dst, p.Pos)
+			ends = append(ends, chainEnd{obj, !p.IsOutput, p.IsOutput, p.IsPipe})
+		}
+	case TypePath:
+		p := oc.Paths[obj.Idx]
+		if p.Def().IsPowerLine {
+			break
+		}
+		pipe := !p.Def().IsDirectional
+		dst = append(dst, p.Start, p.End)
+		ends = append(ends, chainEnd{obj, true, pipe, pipe}, chainEnd{obj, pipe, true, pipe})
+	}
+	return dst, ends
+}
+
+// SelectChain returns the buildings and paths linked to the selected ones (a path end selected is
+// enough) through the building ports and path ends at the same position, following the items flow
+// in the given direction, the selected objects included; text boxes are not selected.
+func (oc ObjectCollection) SelectChain(sel ObjectSelection, dir ChainDir) ObjectSelection {
+	var pos []rl.Vector2
+	var ends []chainEnd
+	byPos := make(map[portKey][]chainEnd)
+	for i := range oc.Buildings {
+		pos, ends = oc.chainEnds(Object{TypeBuilding, i}, pos[:0], ends[:0])
+		for k, end := range ends {
+			byPos[newPortKey(pos[k])] = append(byPos[newPortKey(pos[k])], end)
+		}
+	}
+	for i := range oc.Paths {
+		pos, ends = oc.chainEnds(Object{TypePath, i}, pos[:0], ends[:0])
+		for k, end := range ends {
+			byPos[newPortKey(pos[k])] = append(byPos[newPortKey(pos[k])], end)
+		}
+	}
+
+	// depth first traversal from the selected objects
+	buildings := make([]bool, len(oc.Buildings))
+	paths := make([]bool, len(oc.Paths))
+	visit := func(obj Object) bool {
+		seen := buildings
+		if obj.Type == TypePath {
+			seen = paths
+		}
+		if seen[obj.Idx] {
+			return false
+		}
+		seen[obj.Idx] = true
+		return true
+	}
+	var stack []Object
+	for _, idx := range sel.BuildingIdxs {
+		stack = append(stack, Object{TypeBuilding, idx})
+	}
+	for _, idx := range sel.AnyPathIdxs() {
+		stack = append(stack, Object{TypePath, idx})
+	}
+	stack = slices.DeleteFunc(stack, func(obj Object) bool { return !visit(obj) })
+	for len(stack) > 0 {
+		obj := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		pos, ends = oc.chainEnds(obj, pos[:0], ends[:0])
+		for k, from := range ends {
+			if dir == ChainDownstream && !from.out || dir == ChainUpstream && !from.in {
+				continue
+			}
+			for _, to := range byPos[newPortKey(pos[k])] {
+				if to.obj == obj || to.pipe != from.pipe || dir == ChainDownstream && !to.in || dir == ChainUpstream && !to.out {
+					continue
+				}
+				if visit(to.obj) {
+					stack = append(stack, to.obj)
+				}
+			}
+		}
+	}
+
+	var chain ObjectSelection
+	for i, ok := range buildings {
+		if ok {
+			chain.BuildingIdxs = append(chain.BuildingIdxs, i)
+		}
+	}
+	for i, ok := range paths {
+		if ok {
+			chain.PathIdxs = append(chain.PathIdxs, PathSel{Idx: i, Start: true, End: true})
+		}
+	}
+	if !chain.IsEmpty() {
+		chain.RecomputeBounds(oc)
+	}
+	return chain
+}
@@ -0,0 +1,55 @@
+package scene
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestSelectChain(t *testing.T) {
+	oc := ObjectCollection{
+		Buildings: []Building{
+			building(defConstructor, 4, 5, 0),   // input at (4,10), output at (4,0)
+			building(defConstructor, 4, -15, 0), // input at (4,-10), output at (4,-20)
+		},
+		Paths: []Path{
+			path(defBelt, 4, 20, 4, 10),
+			path(defBelt, 4, 0, 4, -10),
+			path(defBelt, 4, -20, 4, -30),
+			path(defBelt, 20, 0, 30, 0),
+			path(defPipe, 4, 20, 20, 20), // not linked to the belt at (4,20)
+			path(defPipe, 20, 20, 30, 20),
+			path(defPipe, 40, 20, 30, 20),
+		},
+	}
+	tests := []struct {
+		name          string
+		sel           ObjectSelection
+		dir           ChainDir
+		wantBuildings []int
+		wantPaths     []int
+	}{
+		{"downstream", ObjectSelection{BuildingIdxs: []int{0}}, ChainDownstream, []int{0, 1}, []int{1, 2}},
+		{"upstream", ObjectSelection{BuildingIdxs: []int{1}}, ChainUpstream, []int{0, 1}, []int{0, 1}},
+		{"connected", ObjectSelection{BuildingIdxs: []int{0}}, ChainConnected, []int{0, 1}, []int{0, 1, 2}},
+		{"path end", ObjectSelection{PathIdxs: []PathSel{{Idx: 2, End: true}}}, ChainUpstream, []int{0, 1}, []int{0, 1, 2}},
+		{"unlinked", ObjectSelection{PathIdxs: []PathSel{{Idx: 3, Start: true, End: true}}}, ChainConnected, nil, []int{3}},
+		{"pipes", ObjectSelection{PathIdxs: []PathSel{{Idx: 5, Start: true, End: true}}}, ChainDownstream, nil, []int{4, 5, 6}},
+		{"empty", ObjectSelection{}, ChainConnected, nil, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := oc.SelectChain(tt.sel, tt.dir)
+			if !slices.Equal(got.BuildingIdxs, tt.wantBuildings) {
+				t.Errorf("BuildingIdxs = %v, want %v", got.BuildingIdxs, tt.wantBuildings)
+			}
+			if paths := got.FullPathIdxs(); !slices.Equal(paths, tt.wantPaths) || len(got.PathIdxs) != len(paths) {
+				t.Errorf("PathIdxs = %v, want %v", got.PathIdxs, tt.wantPaths)
+			}
+			want := got.Clone()
+			want.RecomputeBounds(oc)
+			if !got.IsEmpty() && got.Bounds != want.Bounds {
+				t.Errorf("Bounds = %v, want %v", got.Bounds, want.Bounds)
+			}
+		})
+	}
+}
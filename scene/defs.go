@@ -0,0 +1,102 @@
+// defs - Registered buildings and paths definitions
+
+package scene
+
+import "slices"
+
+var (
+	// Building defs, indexed by [Building.DefIdx]
+	buildingDefs BuildingDefs
+	// Path defs, indexed by [Path.DefIdx]
+	pathDefs PathDefs
+)
+
+// SetDefs sets the buildings and paths definitions used to resolve [Building.Def] and [Path.Def]
+// and to decode saves.
+func SetDefs(buildings BuildingDefs, paths PathDefs) {
+	buildingDefs = buildings
+	pathDefs = paths
+}
+
+// DefsRemap maps the objects definitions indexes from the registered definitions to new ones, by
+// class name, to replace the definitions while keeping the objects classes (see [SetDefs]).
+//
+// A registered definition missing from the new ones but still used by a remapped object is appended
+// to them.
+type DefsRemap struct {
+	buildings BuildingDefs
+	paths     PathDefs
+	// Registered to new buildings defs indexes, -1 if not resolved yet
+	buildingIdxs []int
+	// Registered to new paths defs indexes, -1 if not resolved yet
+	pathIdxs []int
+	// Classes of the missing definitions appended
+	missing []string
+}
+
+// NewDefsRemap returns a remap from the registered definitions to the given ones
+func NewDefsRemap(buildings BuildingDefs, paths PathDefs) *DefsRemap {
+	return &DefsRemap{
+		buildings:    slices.Clip(buildings),
+		paths:        slices.Clip(paths),
+		buildingIdxs: Repeat(nil, -1, len(buildingDefs)),
+		pathIdxs:     Repeat(nil, -1, len(pathDefs)),
+	}
+}
+
+// building returns the new index of the registered building def at idx
+func (r *DefsRemap) building(idx int) int {
+	if idx < 0 {
+		return idx
+	}
+	if r.buildingIdxs[idx] == -1 {
+		def := buildingDefs[idx]
+		newIdx := r.buildings.Index(def.Class)
+		if newIdx < 0 {
+			r.buildings = append(r.buildings, def)
+			r.missing = append(r.missing, def.Class)
+			newIdx = len(r.buildings) - 1
+		}
+		r.buildingIdxs[idx] = newIdx
+	}
+	return r.buildingIdxs[idx]
+}
+
+// path returns the new index of the registered path def at idx
+func (r *DefsRemap) path(idx int) int {
+	if idx < 0 {
+		return idx
+	}
+	if r.pathIdxs[idx] == -1 {
+		def := pathDefs[idx]
+		newIdx := r.paths.Index(def.Class)
+		if newIdx < 0 {
+			r.paths = append(r.paths, def)
+			r.missing = append(r.missing, def.Class)
+			newIdx = len(r.paths) - 1
+		}
+		r.pathIdxs[idx] = newIdx
+	}
+	return r.pathIdxs[idx]
+}
+
+// Collection returns a copy of the collection with its buildings and paths remapped
+func (r *DefsRemap) Collection(oc ObjectCollection) ObjectCollection {
+	oc = oc.Clone()
+	for i := range oc.Buildings {
+		oc.Buildings[i].DefIdx = r.building(oc.Buildings[i].DefIdx)
+	}
+	for i := range oc.Paths {
+		oc.Paths[i].DefIdx = r.path(oc.Paths[i].DefIdx)
+	}
+	return oc
+}
+
+// Apply registers the new definitions (see [SetDefs]) and returns them, and the classes of the
+// missing definitions kept for the remapped objects.
+//
+// Objects not remapped before must not be used afterward.
+func (r *DefsRemap) Apply() (BuildingDefs, PathDefs, []string) {
+	SetDefs(r.buildings, r.paths)
+	return r.buildings, r.paths, r.missing
+}
@@ -0,0 +1,57 @@
+package scene
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestDefsRemap(t *testing.T) {
+	defer SetDefs(testBuildingDefs, testPathDefs)
+
+	s := NewScene(testCollection())
+	s.AddBuilding(building(defSplitter, 100, 100, 0))
+	s.AddPath(path(defPipe, 100, 0, 110, 0))
+	s.Undo()
+	want := s.ObjectCollection.Clone()
+	classes := func(oc ObjectCollection) (cls []string) {
+		for _, b := range oc.Buildings {
+			cls = append(cls, b.Def().Class)
+		}
+		for _, p := range oc.Paths {
+			cls = append(cls, p.Def().Class)
+		}
+		return cls
+	}
+	wantClasses := classes(want)
+
+	// reordered, Foundation dims changed, Splitter and Pipe removed
+	newBuildings := BuildingDefs{testBuildingDefs[defFoundation], testBuildingDefs[defConstructor]}
+	newBuildings[0].Dims = vec2(16, 16)
+	newPaths := PathDefs{testPathDefs[defBelt]}
+	r := NewDefsRemap(newBuildings, newPaths)
+	rev, modified := s.Revision(), s.IsModified()
+	s.RemapDefs(r)
+	buildings, paths, missing := r.Apply()
+
+	if got := classes(s.ObjectCollection); !slices.Equal(got, wantClasses) {
+		t.Errorf("RemapDefs() classes = %v, want %v", got, wantClasses)
+	}
+	if got := s.Buildings[1].Def().Dims; got != vec2(16, 16) {
+		t.Errorf("RemapDefs() Foundation dims = %v, want the new ones", got)
+	}
+	// Splitter is only used in the history
+	slices.Sort(missing)
+	if !slices.Equal(missing, []string{"Pipe", "Splitter"}) {
+		t.Errorf("Apply() missing = %v, want [Pipe Splitter]", missing)
+	}
+	if len(buildings) != 3 || len(paths) != 2 {
+		t.Errorf("Apply() defs = %d buildings, %d paths, want 3, 2", len(buildings), len(paths))
+	}
+	if s.Revision() == rev || s.IsModified() != modified {
+		t.Errorf("RemapDefs() revision = %d, modified = %v, want incremented, unchanged", s.Revision(), s.IsModified())
+	}
+	s.Redo()
+	if got := s.Buildings[len(s.Buildings)-1].Def().Class; got != "Splitter" {
+		t.Errorf("Redo() after RemapDefs() class = %q, want Splitter", got)
+	}
+}
@@ -0,0 +1,516 @@
+// diff - Object by object comparison and three-way merge of scenes
+
+package scene
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"slices"
+
+	rl "github.com/bonoboris/satisfied/rlmath"
+)
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
+// Diff
+////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// ChangeKind enumerates what happened to an object between an old and a new collection
+type ChangeKind int
+
+const (
+	// Unchanged object, identical in both collections
+	Unchanged ChangeKind = iota
+	// Changed object, modified in place (building rotation or modifiers, path end, text box size or
+	// content)
+	Changed
+	// Moved object, translated without any other modification
+	Moved
+	// Removed object, only in the old collection
+	Removed
+	// Added object, only in the new collection
+	Added
+)
+
+func (k ChangeKind) String() string {
+	switch k {
+	case Unchanged:
+		return "unchanged"
+	case Changed:
+		return "changed"
+	case Moved:
+		return "moved"
+	case Removed:
+		return "removed"
+	case Added:
+		return "added"
+	default:
+		return "invalid"
+	}
+}
+
+// Change pairs an object of the old collection with an object of the new collection
+type Change struct {
+	Kind ChangeKind
+	// Type is either [TypeBuilding], [TypePath] or [TypeTextBox]
+	Type ObjectType
+	// OldIdx is the object index in the old collection, -1 for [Added] objects
+	OldIdx int
+	// NewIdx is the object index in the new collection, -1 for [Removed] objects
+	NewIdx int
+}
+
+// Diff is the object by object difference between 2 collections
+type Diff struct {
+	Old, New ObjectCollection
+	// Changes has one entry per object of both collections, including [Unchanged] ones.
+	//
+	// Entries are ordered by type: buildings, paths then text boxes.
+	Changes []Change
+}
+
+// ComputeDiff matches the objects of old and new collections.
+//
+// Objects with the same ID and class are matched first, as [Unchanged], [Moved] or [Changed].
+// The other objects (eg: objects of version 0 saves, IDs are given in the file order) are matched on
+// their class and position, in 3 passes:
+//   - identical objects, regardless of their IDs, are [Unchanged]
+//   - objects of the same class at the same position are [Changed]
+//     (same building position, same path start or end, same text box position)
+//   - objects of the same class and shape are [Moved], the nearest pairs first
+//
+// Remaining objects are either [Removed] or [Added].
+func ComputeDiff(old, new ObjectCollection) Diff {
+	d := Diff{Old: old, New: new}
+	d.Changes = buildingMatcher.match(old.Buildings, new.Buildings, d.Changes)
+	d.Changes = pathMatcher.match(old.Paths, new.Paths, d.Changes)
+	d.Changes = textBoxMatcher.match(old.TextBoxes, new.TextBoxes, d.Changes)
+	return d
+}
+
+// IsEmpty returns true if all objects are [Unchanged]
+func (d Diff) IsEmpty() bool {
+	for _, c := range d.Changes {
+		if c.Kind != Unchanged {
+			return false
+		}
+	}
+	return true
+}
+
+// Count returns the number of changes of the given type and kind
+func (d Diff) Count(typ ObjectType, kind ChangeKind) int {
+	n := 0
+	for _, c := range d.Changes {
+		if c.Type == typ && c.Kind == kind {
+			n++
+		}
+	}
+	return n
+}
+
+// line returns the save line of the object of the old (isNew == false) or new collection
+func (d Diff) line(typ ObjectType, idx int, isNew bool) string {
+	oc := d.Old
+	if isNew {
+		oc = d.New
+	}
+	switch typ {
+	case TypeBuilding:
+		return buildingLine(oc.Buildings[idx])
+	case TypePath:
+		return pathLine(oc.Paths[idx])
+	case TypeTextBox:
+		return textBoxLine(oc.TextBoxes[idx])
+	default:
+		panic("invalid object type")
+	}
+}
+
+// WriteText writes a human readable report of the changes, nothing is written if the diff is empty.
+//
+// Each object type with changes has a summary line followed by one line per change, prefixed by
+// '-' (removed), '+' (added), '>' (moved) or '~' (changed); moved and changed lines show the old
+// and new save lines separated by '->'.
+//
+// All errors originate from the underlying [io.Writer].
+func (d Diff) WriteText(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	defer bw.Flush()
+	for _, section := range []struct {
+		typ  ObjectType
+		name string
+	}{{TypeBuilding, "buildings"}, {TypePath, "paths"}, {TypeTextBox, "text boxes"}} {
+		added, removed := d.Count(section.typ, Added), d.Count(section.typ, Removed)
+		moved, changed := d.Count(section.typ, Moved), d.Count(section.typ, Changed)
+		if added+removed+moved+changed == 0 {
+			continue
+		}
+		_, err := fmt.Fprintf(bw, "%s: %d added, %d removed, %d moved, %d changed\n",
+			section.name, added, removed, moved, changed)
+		if err != nil {
+			return err
+		}
+		for _, c := range d.Changes {
+			if c.Type != section.typ {
+				continue
+			}
+			switch c.Kind {
+			case Removed:
+				_, err = fmt.Fprintf(bw, "- %s\n", d.line(c.Type, c.OldIdx, false))
+			case Added:
+				_, err = fmt.Fprintf(bw, "+ %s\n", d.line(c.Type, c.NewIdx, true))
+			case Moved:
+				_, err = fmt.Fprintf(bw, "> %s -> %s\n", d.line(c.Type, c.OldIdx, false), d.line(c.Type, c.NewIdx, true))
+			case Changed:
+				_, err = fmt.Fprintf(bw, "~ %s -> %s\n", d.line(c.Type, c.OldIdx, false), d.line(c.Type, c.NewIdx, true))
+			}
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
+// Matching
+////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// matcher defines how objects of one type are paired by [ComputeDiff]
+type matcher[T comparable] struct {
+	typ ObjectType
+	// id returns the object ID
+	id func(a T) ID
+	// withoutID returns the object with a zero ID
+	withoutID func(a T) T
+	// sameClass returns true if a and b have the same class
+	sameClass func(a, b T) bool
+	// inPlace returns true if b is a in place modification of a
+	inPlace func(a, b T) bool
+	// inPlaceKey returns a key, equal for a and b if inPlace(a, b), indexing the pairing candidates
+	inPlaceKey func(a T) any
+	// moved returns true if b is a translated copy of a
+	moved func(a, b T) bool
+	// movedKey returns a key, equal for a and b if moved(a, b), indexing the pairing candidates
+	movedKey func(a T) any
+	// dist returns the distance between a and b, used to pair the nearest objects first
+	dist func(a, b T) float32
+}
+
+var buildingMatcher = matcher[Building]{
+	typ:       TypeBuilding,
+	id:        func(a Building) ID { return a.ID },
+	withoutID: func(a Building) Building { a.ID = 0; return a },
+	sameClass: func(a, b Building) bool { return a.DefIdx == b.DefIdx },
+	inPlace:   func(a, b Building) bool { return a.DefIdx == b.DefIdx && a.Pos == b.Pos },
+	inPlaceKey: func(a Building) any {
+		return struct {
+			defIdx int
+			pos    rl.Vector2
+		}{a.DefIdx, a.Pos}
+	},
+	moved: func(a, b Building) bool {
+		return a.DefIdx == b.DefIdx && a.Rot == b.Rot && a.Mirror == b.Mirror && a.Mods == b.Mods
+	},
+	movedKey: func(a Building) any { a.ID, a.Pos = 0, rl.Vector2{}; return a },
+	dist:     func(a, b Building) float32 { return rl.Vector2Distance(a.Pos, b.Pos) },
+}
+
+var pathMatcher = matcher[Path]{
+	typ:       TypePath,
+	id:        func(a Path) ID { return a.ID },
+	withoutID: func(a Path) Path { a.ID = 0; return a },
+	sameClass: func(a, b Path) bool { return a.DefIdx == b.DefIdx },
+	inPlace: func(a, b Path) bool {
+		return a.DefIdx == b.DefIdx && (a.Start == b.Start || a.End == b.End)
+	},
+	inPlaceKey: func(a Path) any { return a.DefIdx },
+	moved: func(a, b Path) bool {
+		a.ID, b.ID = 0, 0
+		return a.DefIdx == b.DefIdx && a.Translate(b.Start.Subtract(a.Start)) == b
+	},
+	movedKey: func(a Path) any {
+		return struct {
+			defIdx, vertices int
+			spline           bool
+		}{a.DefIdx, a.Vertices.Len(), a.Spline}
+	},
+	dist: func(a, b Path) float32 { return rl.Vector2Distance(a.Start, b.Start) },
+}
+
+var textBoxMatcher = matcher[TextBox]{
+	typ:       TypeTextBox,
+	id:        func(a TextBox) ID { return a.ID },
+	withoutID: func(a TextBox) TextBox { a.ID = 0; return a },
+	sameClass: func(a, b TextBox) bool { return true },
+	inPlace: func(a, b TextBox) bool {
+		return a.Bounds.X == b.Bounds.X && a.Bounds.Y == b.Bounds.Y
+	},
+	inPlaceKey: func(a TextBox) any { return vec2(a.Bounds.X, a.Bounds.Y) },
+	moved: func(a, b TextBox) bool {
+		return a.Content == b.Content && a.Bounds.Width == b.Bounds.Width && a.Bounds.Height == b.Bounds.Height &&
+			a.Rot == b.Rot
+	},
+	movedKey: func(a TextBox) any { a.ID, a.Bounds.X, a.Bounds.Y = 0, 0, 0; return a },
+	dist: func(a, b TextBox) float32 {
+		return rl.Vector2Distance(vec2(a.Bounds.X, a.Bounds.Y), vec2(b.Bounds.X, b.Bounds.Y))
+	},
+}
+
+// match pairs old and new objects and appends the resulting changes
+func (m matcher[T]) match(old, new []T, changes []Change) []Change {
+	oldDone := make([]bool, len(old))
+	newDone := make([]bool, len(new))
+	// same ID and class
+	byID := make(map[ID]int, len(new))
+	for j, b := range new {
+		if id := m.id(b); id != 0 {
+			byID[id] = j
+		}
+	}
+	for i, a := range old {
+		j, ok := byID[m.id(a)]
+		if m.id(a) == 0 || !ok || !m.sameClass(a, new[j]) {
+			continue
+		}
+		kind := Changed
+		if a == new[j] {
+			kind = Unchanged
+		} else if m.moved(a, new[j]) {
+			kind = Moved
+		}
+		oldDone[i], newDone[j] = true, true
+		changes = append(changes, Change{Kind: kind, Type: m.typ, OldIdx: i, NewIdx: j})
+	}
+	// identical objects regardless of IDs, in order for duplicated values
+	byValue := make(map[T][]int, len(new))
+	for j, b := range new {
+		if !newDone[j] {
+			byValue[m.withoutID(b)] = append(byValue[m.withoutID(b)], j)
+		}
+	}
+	for i, a := range old {
+		if oldDone[i] {
+			continue
+		}
+		if js := byValue[m.withoutID(a)]; len(js) > 0 {
+			byValue[m.withoutID(a)] = js[1:]
+			oldDone[i], newDone[js[0]] = true, true
+			changes = append(changes, Change{Kind: Unchanged, Type: m.typ, OldIdx: i, NewIdx: js[0]})
+		}
+	}
+	changes = m.pair(old, new, oldDone, newDone, m.inPlace, m.inPlaceKey, Changed, changes)
+	changes = m.pair(old, new, oldDone, newDone, m.moved, m.movedKey, Moved, changes)
+	for i := range old {
+		if !oldDone[i] {
+			changes = append(changes, Change{Kind: Removed, Type: m.typ, OldIdx: i, NewIdx: -1})
+		}
+	}
+	for j := range new {
+		if !newDone[j] {
+			changes = append(changes, Change{Kind: Added, Type: m.typ, OldIdx: -1, NewIdx: j})
+		}
+	}
+	return changes
+}
+
+// pair matches the remaining objects satisfying pred, the nearest pairs first, only the objects with
+// the same key are compared
+func (m matcher[T]) pair(old, new []T, oldDone, newDone []bool, pred func(a, b T) bool, key func(a T) any,
+	kind ChangeKind, changes []Change,
+) []Change {
+	type candidate struct {
+		i, j int
+		dist float32
+	}
+	byKey := make(map[any][]int)
+	for j, b := range new {
+		if !newDone[j] {
+			byKey[key(b)] = append(byKey[key(b)], j)
+		}
+	}
+	var candidates []candidate
+	for i, a := range old {
+		if oldDone[i] {
+			continue
+		}
+		for _, j := range byKey[key(a)] {
+			if b := new[j]; pred(a, b) {
+				candidates = append(candidates, candidate{i, j, m.dist(a, b)})
+			}
+		}
+	}
+	slices.SortStableFunc(candidates, func(a, b candidate) int {
+		switch {
+		case a.dist < b.dist:
+			return -1
+		case a.dist > b.dist:
+			return 1
+		default:
+			return 0
+		}
+	})
+	for _, c := range candidates {
+		if oldDone[c.i] || newDone[c.j] {
+			continue
+		}
+		oldDone[c.i], newDone[c.j] = true, true
+		changes = append(changes, Change{Kind: kind, Type: m.typ, OldIdx: c.i, NewIdx: c.j})
+	}
+	return changes
+}
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
+// Merge
+////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// MergeConflict is an object [Merge] could not reconcile automatically
+type MergeConflict struct {
+	Type   ObjectType
+	Reason string
+	// Save lines of the object in each collection, empty if absent (added or removed)
+	Base, Ours, Theirs string
+}
+
+func (c MergeConflict) String() string {
+	or := func(s string) string {
+		if s == "" {
+			return "<none>"
+		}
+		return s
+	}
+	return fmt.Sprintf("%s: base: %s, ours: %s, theirs: %s", c.Reason, or(c.Base), or(c.Ours), or(c.Theirs))
+}
+
+const (
+	conflictBothModified    = "modified on both sides"
+	conflictRemovedModified = "removed on one side, modified on the other"
+	conflictOverlap         = "building overlaps another building"
+)
+
+// Merge reconciles the changes made from base in ours and theirs.
+//
+// Objects are matched with [ComputeDiff], by ID first:
+//   - an object changed on one side only takes the change
+//   - an object removed on one side and unchanged on the other is removed
+//   - added objects from both sides are kept, identical additions only once
+//
+// Merged objects keep their IDs, except added objects whose ID is already used, which get new IDs.
+//
+// When an object is modified differently on both sides, ours is kept; when it is removed on one
+// side and modified on the other, the modified object is kept. Both cases are returned as conflicts,
+// as well as the merged buildings overlapping each other.
+func Merge(base, ours, theirs ObjectCollection) (ObjectCollection, []MergeConflict) {
+	dOurs, dTheirs := ComputeDiff(base, ours), ComputeDiff(base, theirs)
+	var merged ObjectCollection
+	var conflicts []MergeConflict
+	merged.Buildings, conflicts = mergeObjects(buildingMatcher, base.Buildings, ours.Buildings, theirs.Buildings,
+		dOurs, dTheirs, buildingLine, conflicts)
+	merged.Paths, conflicts = mergeObjects(pathMatcher, base.Paths, ours.Paths, theirs.Paths,
+		dOurs, dTheirs, pathLine, conflicts)
+	merged.TextBoxes, conflicts = mergeObjects(textBoxMatcher, base.TextBoxes, ours.TextBoxes, theirs.TextBoxes,
+		dOurs, dTheirs, textBoxLine, conflicts)
+
+	// give new IDs to the objects without ID or with an already used one
+	lastID := max(base.maxID(), ours.maxID(), theirs.maxID())
+	used := make(map[ID]bool)
+	fixID := func(id *ID) {
+		if *id == 0 || used[*id] {
+			lastID++
+			*id = lastID
+		}
+		used[*id] = true
+	}
+	for i := range merged.Buildings {
+		fixID(&merged.Buildings[i].ID)
+	}
+	for i := range merged.Paths {
+		fixID(&merged.Paths[i].ID)
+	}
+	for i := range merged.TextBoxes {
+		fixID(&merged.TextBoxes[i].ID)
+	}
+
+	s := Scene{ObjectCollection: merged}
+	for i, b := range merged.Buildings {
+		if !s.IsBuildingValid(b, i) {
+			conflicts = append(conflicts, MergeConflict{Type: TypeBuilding, Reason: conflictOverlap, Ours: buildingLine(b)})
+		}
+	}
+	return merged, conflicts
+}
+
+// mergeObjects merges the objects of one type, see [Merge]
+func mergeObjects[T comparable](m matcher[T], base, ours, theirs []T, dOurs, dTheirs Diff,
+	line func(T) string, conflicts []MergeConflict,
+) ([]T, []MergeConflict) {
+	typ := m.typ
+	// kind and index of each base object on each side
+	type side struct {
+		kind ChangeKind
+		idx  int
+	}
+	oursSide, theirsSide := make([]side, len(base)), make([]side, len(base))
+	var oursAdded, theirsAdded []T
+	for _, c := range dOurs.Changes {
+		switch {
+		case c.Type != typ:
+		case c.Kind == Added:
+			oursAdded = append(oursAdded, ours[c.NewIdx])
+		default:
+			oursSide[c.OldIdx] = side{c.Kind, c.NewIdx}
+		}
+	}
+	for _, c := range dTheirs.Changes {
+		switch {
+		case c.Type != typ:
+		case c.Kind == Added:
+			theirsAdded = append(theirsAdded, theirs[c.NewIdx])
+		default:
+			theirsSide[c.OldIdx] = side{c.Kind, c.NewIdx}
+		}
+	}
+
+	merged := make([]T, 0, len(base)+len(oursAdded)+len(theirsAdded))
+	for i, b := range base {
+		o, t := oursSide[i], theirsSide[i]
+		switch {
+		case o.kind == Removed && t.kind == Removed:
+		case o.kind == Removed && t.kind == Unchanged:
+		case t.kind == Removed && o.kind == Unchanged:
+		case o.kind == Removed:
+			merged = append(merged, theirs[t.idx])
+			conflicts = append(conflicts, MergeConflict{Type: typ, Reason: conflictRemovedModified,
+				Base: line(b), Theirs: line(theirs[t.idx])})
+		case t.kind == Removed:
+			merged = append(merged, ours[o.idx])
+			conflicts = append(conflicts, MergeConflict{Type: typ, Reason: conflictRemovedModified,
+				Base: line(b), Ours: line(ours[o.idx])})
+		case o.kind == Unchanged:
+			merged = append(merged, theirs[t.idx])
+		case t.kind == Unchanged:
+			merged = append(merged, ours[o.idx])
+		default:
+			merged = append(merged, ours[o.idx])
+			if m.withoutID(ours[o.idx]) != m.withoutID(theirs[t.idx]) {
+				conflicts = append(conflicts, MergeConflict{Type: typ, Reason: conflictBothModified,
+					Base: line(b), Ours: line(ours[o.idx]), Theirs: line(theirs[t.idx])})
+			}
+		}
+	}
+	// identical additions on both sides are kept once
+	counts := make(map[T]int, len(oursAdded))
+	for _, v := range oursAdded {
+		merged = append(merged, v)
+		counts[m.withoutID(v)]++
+	}
+	for _, v := range theirsAdded {
+		if counts[m.withoutID(v)] > 0 {
+			counts[m.withoutID(v)]--
+			continue
+		}
+		merged = append(merged, v)
+	}
+	return merged, conflicts
+}
@@ -0,0 +1,280 @@
+package scene
+
+import (
+	"bytes"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// changesOf returns the changes of the given type, unchanged ones excluded
+func changesOf(d Diff, typ ObjectType) []Change {
+	var res []Change
+	for _, c := range d.Changes {
+		if c.Type == typ && c.Kind != Unchanged {
+			res = append(res, c)
+		}
+	}
+	return res
+}
+
+func TestDiffIdentical(t *testing.T) {
+	oc := testCollection()
+	d := ComputeDiff(oc, oc.Clone())
+	if !d.IsEmpty() {
+		t.Errorf("IsEmpty() = false, changes: %v", d.Changes)
+	}
+	if n := len(oc.Buildings) + len(oc.Paths) + len(oc.TextBoxes); len(d.Changes) != n {
+		t.Errorf("len(Changes) = %d, want %d", len(d.Changes), n)
+	}
+	var buf bytes.Buffer
+	if err := d.WriteText(&buf); err != nil || buf.Len() != 0 {
+		t.Errorf("WriteText() = %q, %v, want empty", buf.String(), err)
+	}
+}
+
+func TestDiffReordered(t *testing.T) {
+	old := testCollection()
+	new := old.Clone()
+	slices.Reverse(new.Buildings)
+	slices.Reverse(new.Paths)
+	d := ComputeDiff(old, new)
+	if !d.IsEmpty() {
+		t.Errorf("IsEmpty() = false, changes: %v", d.Changes)
+	}
+}
+
+func TestDiffBuildings(t *testing.T) {
+	old := testCollection()
+	new := old.Clone()
+	new.Buildings[0].Pos = vec2(0, 100)                                     // moved
+	new.Buildings[1].Rot = 180                                              // changed
+	new.Buildings = SwapDelete(new.Buildings, 2)                            // removed
+	new.Buildings = append(new.Buildings, building(defSplitter, 60, 0, 90)) // added: rotation differs
+
+	d := ComputeDiff(old, new)
+	want := []Change{
+		{Kind: Changed, Type: TypeBuilding, OldIdx: 1, NewIdx: 1},
+		{Kind: Moved, Type: TypeBuilding, OldIdx: 0, NewIdx: 0},
+		{Kind: Removed, Type: TypeBuilding, OldIdx: 2, NewIdx: -1},
+		{Kind: Added, Type: TypeBuilding, OldIdx: -1, NewIdx: 2},
+	}
+	if got := changesOf(d, TypeBuilding); !slices.Equal(got, want) {
+		t.Errorf("changes = %v, want %v", got, want)
+	}
+	if d.Count(TypePath, Unchanged) != len(old.Paths) || d.Count(TypeTextBox, Unchanged) != len(old.TextBoxes) {
+		t.Errorf("paths and text boxes must be unchanged, changes: %v", d.Changes)
+	}
+}
+
+func TestDiffMovedNearestFirst(t *testing.T) {
+	old := ObjectCollection{Buildings: []Building{
+		building(defSplitter, 0, 0, 0),
+		building(defSplitter, 100, 0, 0),
+	}}
+	new := ObjectCollection{Buildings: []Building{
+		building(defSplitter, 10, 0, 0),
+		building(defSplitter, 110, 0, 0),
+	}}
+	d := ComputeDiff(old, new)
+	want := []Change{
+		{Kind: Moved, Type: TypeBuilding, OldIdx: 0, NewIdx: 0},
+		{Kind: Moved, Type: TypeBuilding, OldIdx: 1, NewIdx: 1},
+	}
+	if !slices.Equal(d.Changes, want) {
+		t.Errorf("changes = %v, want %v", d.Changes, want)
+	}
+}
+
+func TestDiffPaths(t *testing.T) {
+	old := testCollection()
+	new := old.Clone()
+	new.Paths[0].End = vec2(15, 20)                                 // changed: same start
+	new.Paths[1].Start, new.Paths[1].End = vec2(5, 30), vec2(5, 40) // moved
+	new.Paths[2].DefIdx = defPipe                                   // class differs: removed + added
+
+	d := ComputeDiff(old, new)
+	want := []Change{
+		{Kind: Changed, Type: TypePath, OldIdx: 0, NewIdx: 0},
+		{Kind: Moved, Type: TypePath, OldIdx: 1, NewIdx: 1},
+		{Kind: Removed, Type: TypePath, OldIdx: 2, NewIdx: -1},
+		{Kind: Added, Type: TypePath, OldIdx: -1, NewIdx: 2},
+	}
+	if got := changesOf(d, TypePath); !slices.Equal(got, want) {
+		t.Errorf("changes = %v, want %v", got, want)
+	}
+}
+
+func TestDiffTextBoxes(t *testing.T) {
+	old := testCollection()
+	new := old.Clone()
+	new.TextBoxes[0].Content = "edited" // changed
+	new.TextBoxes[1].Bounds.Y = 70      // moved
+	new.TextBoxes = append(new.TextBoxes, textBox(0, 80, 10, 5, "new"))
+
+	d := ComputeDiff(old, new)
+	want := []Change{
+		{Kind: Changed, Type: TypeTextBox, OldIdx: 0, NewIdx: 0},
+		{Kind: Moved, Type: TypeTextBox, OldIdx: 1, NewIdx: 1},
+		{Kind: Added, Type: TypeTextBox, OldIdx: -1, NewIdx: 2},
+	}
+	if got := changesOf(d, TypeTextBox); !slices.Equal(got, want) {
+		t.Errorf("changes = %v, want %v", got, want)
+	}
+}
+
+func TestDiffWriteText(t *testing.T) {
+	old := newTestScene().ObjectCollection
+	new := old.Clone()
+	new.Buildings[0].Pos = vec2(0, 100)
+	new.Paths = new.Paths[:2]
+	d := ComputeDiff(old, new)
+	var buf bytes.Buffer
+	if err := d.WriteText(&buf); err != nil {
+		t.Fatalf("WriteText() error: %v", err)
+	}
+	want := strings.Join([]string{
+		"buildings: 0 added, 0 removed, 1 moved, 0 changed",
+		"> 1 Constructor 0 0 0 0 100 0 0 -> 1 Constructor 0 100 0 0 100 0 0",
+		"paths: 0 added, 1 removed, 0 moved, 0 changed",
+		"- 6 Belt 20 20 30 30 0",
+		"",
+	}, "\n")
+	if buf.String() != want {
+		t.Errorf("WriteText() =\n%s\nwant\n%s", buf.String(), want)
+	}
+}
+
+func TestDiffModifiers(t *testing.T) {
+	old := newTestScene().ObjectCollection
+	new := old.Clone()
+	new.Buildings[0].Pos = vec2(0, 100)
+	new.Buildings[0].Mods = Modifiers{Clock: 50}
+	d := ComputeDiff(old, new)
+	want := []Change{{Kind: Changed, Type: TypeBuilding, OldIdx: 0, NewIdx: 0}}
+	if got := changesOf(d, TypeBuilding); !slices.Equal(got, want) {
+		t.Errorf("changes = %v, want %v", got, want)
+	}
+}
+
+func TestDiffIDs(t *testing.T) {
+	old := newTestScene().ObjectCollection
+	new := old.Clone()
+	// building 1 replaced by a splitter with the same ID, and re-added with ID 9: the constructors are
+	// matched on their position, the splitter is added
+	new.Buildings[0] = building(defSplitter, 100, 0, 0)
+	new.Buildings[0].ID = 1
+	new.Buildings = append(new.Buildings, building(defConstructor, 0, 0, 0))
+	new.Buildings[3].ID = 9
+	// building 3 moved next to building 2: matched by ID
+	new.Buildings[2].Pos = vec2(20, 20)
+	// path 4 replaced by an identical path with another ID: unchanged
+	new.Paths[0].ID = 10
+
+	d := ComputeDiff(old, new)
+	want := []Change{
+		{Kind: Unchanged, Type: TypeBuilding, OldIdx: 1, NewIdx: 1},
+		{Kind: Moved, Type: TypeBuilding, OldIdx: 2, NewIdx: 2},
+		{Kind: Unchanged, Type: TypeBuilding, OldIdx: 0, NewIdx: 3},
+		{Kind: Added, Type: TypeBuilding, OldIdx: -1, NewIdx: 0},
+	}
+	if !slices.Equal(d.Changes[:4], want) {
+		t.Errorf("building changes = %v, want %v", d.Changes[:4], want)
+	}
+	if got := changesOf(d, TypePath); len(got) != 0 {
+		t.Errorf("path changes = %v, want none", got)
+	}
+}
+
+func TestMerge(t *testing.T) {
+	base := testCollection()
+
+	ours := base.Clone()
+	ours.Buildings[0].Pos = vec2(0, 100)   // moved
+	ours.Paths = SwapDelete(ours.Paths, 1) // removed
+	ours.TextBoxes = append(ours.TextBoxes, textBox(0, 80, 5, 5, "both"))
+	ours.Buildings = append(ours.Buildings, building(defSplitter, 60, 0, 0))
+
+	theirs := base.Clone()
+	theirs.Buildings[1].Rot = 0 // changed
+	theirs.TextBoxes[0].Content = "edited"
+	theirs.TextBoxes = append(theirs.TextBoxes, textBox(0, 80, 5, 5, "both"))
+	theirs.Paths = append(theirs.Paths, path(defPipe, 60, 10, 60, 20))
+
+	merged, conflicts := Merge(base, ours, theirs)
+	if len(conflicts) != 0 {
+		t.Errorf("conflicts = %v, want none", conflicts)
+	}
+	want := ObjectCollection{
+		Buildings: []Building{
+			building(defConstructor, 0, 100, 0),
+			building(defFoundation, 20, 0, 0),
+			building(defSplitter, 40, 0, 0),
+			building(defSplitter, 60, 0, 0),
+		},
+		Paths: []Path{base.Paths[0], base.Paths[2], path(defPipe, 60, 10, 60, 20)},
+		TextBoxes: []TextBox{
+			textBox(0, 50, 10, 5, "edited"),
+			base.TextBoxes[1],
+			textBox(0, 80, 5, 5, "both"),
+		},
+	}
+	// objects without ID are given new IDs in order
+	assertCollection(t, &Scene{ObjectCollection: merged}, NewScene(want).ObjectCollection)
+}
+
+func TestMergeConflicts(t *testing.T) {
+	base := newTestScene().ObjectCollection
+
+	ours := base.Clone()
+	ours.Buildings[0].Pos = vec2(0, 100)
+	ours.Paths = SwapDelete(ours.Paths, 1)
+	ours.Buildings = append(ours.Buildings, building(defSplitter, 60, 0, 0))
+
+	theirs := base.Clone()
+	theirs.Buildings[0].Pos = vec2(0, 200)
+	theirs.Paths[1].End = vec2(0, 45)
+	theirs.Buildings = append(theirs.Buildings, building(defSplitter, 61, 0, 0))
+
+	merged, conflicts := Merge(base, ours, theirs)
+	wantConflicts := []MergeConflict{
+		{Type: TypeBuilding, Reason: conflictBothModified,
+			Base: "1 Constructor 0 0 0 0 100 0 0", Ours: "1 Constructor 0 100 0 0 100 0 0",
+			Theirs: "1 Constructor 0 200 0 0 100 0 0"},
+		{Type: TypePath, Reason: conflictRemovedModified, Base: "5 Pipe 0 30 0 40 0", Theirs: "5 Pipe 0 30 0 45 0"},
+		{Type: TypeBuilding, Reason: conflictOverlap, Ours: "9 Splitter 60 0 0 0 100 0 0"},
+		{Type: TypeBuilding, Reason: conflictOverlap, Ours: "10 Splitter 61 0 0 0 100 0 0"},
+	}
+	if !slices.Equal(conflicts, wantConflicts) {
+		t.Errorf("conflicts =\n%v\nwant\n%v", conflicts, wantConflicts)
+	}
+	// ours is kept on both sides modifications, the modified object on remove / modify
+	if merged.Buildings[0].Pos != vec2(0, 100) {
+		t.Errorf("merged building 0 = %v, want ours", merged.Buildings[0])
+	}
+	if i := slices.IndexFunc(merged.Paths, func(p Path) bool { return p.ID == 5 }); i < 0 || merged.Paths[i].End != vec2(0, 45) {
+		t.Errorf("merged paths = %v, want the modified path", merged.Paths)
+	}
+}
+
+// Objects added on both sides with the same ID are different objects, theirs get a new ID
+func TestMergeIDs(t *testing.T) {
+	base := newTestScene()
+	ours, theirs := NewScene(base.Clone()), NewScene(base.Clone())
+	ours.lastID, theirs.lastID = base.lastID, base.lastID
+	ours.AddBuilding(building(defSplitter, 60, 0, 0))
+	theirs.AddBuilding(building(defSplitter, 80, 0, 0))
+	theirs.AddBuilding(building(defSplitter, 100, 0, 0))
+
+	merged, conflicts := Merge(base.ObjectCollection, ours.ObjectCollection, theirs.ObjectCollection)
+	if len(conflicts) != 0 {
+		t.Errorf("conflicts = %v, want none", conflicts)
+	}
+	var ids []ID
+	for _, b := range merged.Buildings {
+		ids = append(ids, b.ID)
+	}
+	if want := []ID{1, 2, 3, 9, 11, 10}; !slices.Equal(ids, want) {
+		t.Errorf("merged building IDs = %v, want %v", ids, want)
+	}
+}
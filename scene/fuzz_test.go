@@ -0,0 +1,289 @@
+package scene
+
+import (
+	"bytes"
+	"math/rand"
+	"slices"
+	"strings"
+	"testing"
+)
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
+// Save format
+////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// saveToString saves the scene in text format, failing the test on error
+func saveToString(t *testing.T, s *Scene) string {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := s.SaveToText(&buf); err != nil {
+		t.Fatalf("SaveToText() error = %v", err)
+	}
+	return buf.String()
+}
+
+// LoadFromText must never panic, and a successfully loaded scene must be saved and loaded again
+// without changes.
+func FuzzLoadFromText(f *testing.F) {
+	seeds := []string{
+		"",
+		"#VERSION=0\n",
+		"#VERSION=1\n",
+		"#VERSION=-1\n",
+		"#VERSION=0\nConstructor 1 2 90\nBelt 0 0 10 0\nPipe 0 0 0 -5.5\nTextBox 0 0 1 1 \"a b\"\n",
+		"#VERSION=0\nFoundation 1e3 -2.25 180\n\n\nSplitter 0 0 0\n",
+		"#VERSION=0\nTextBox 0 0 1 1 \"multi\\nline \\\"quoted\\\"\"\n",
+		"#VERSION=0\nTextBox 0 0 1 1\n",
+		"#VERSION=0\nTextBox 0 0 1 1 unquoted\n",
+		"#VERSION=0\nBelt 0 0 1\n",
+		"#VERSION=0\nConstructor x 0 0\n",
+		"#VERSION=0\nSmelter 0 0 0\n",
+		"#VERSION=0\r\nConstructor 0 0 0\r\n",
+		"#VERSION=1\n#LASTID=2\n5 Constructor 1 2 90\n2 TextBox 0 0 1 1 \"a\"\n",
+		"#VERSION=1\n#LASTID=1\n1 Belt 0 0 1 0\n1 Pipe 0 0 0 1\n",
+		"#VERSION=1\nConstructor 1 2 90\n",
+		"#VERSION=2\n#LASTID=3\n1 Constructor 1 2 90 1\n2 Belt 0 0 1 0\n3 TextBox 0 0 1 1 15 \"a\"\n",
+		"#VERSION=2\n#LASTID=1\n1 TextBox 0 0 1 1 \"a\"\n",
+		"#VERSION=3\n#LASTID=2\n1 Belt 0 0 10 10 1 0 10 5 5\n2 Pipe 0 0 1 0 0\n",
+		"#VERSION=3\n#LASTID=1\n1 Belt 0 0 10 10 0 0\n",
+		"#VERSION=4\n#LASTID=2\n#PACKS=\n1 Constructor 1 2 90 0\n2 Belt 0 0 1 0 0\n",
+		"#VERSION=4\n#LASTID=1\n#PACKS=mod,other\n1 mod:Big Machine 0 0 0 0\n",
+		"#VERSION=5\n#LASTID=2\n#PACKS=\n1 Constructor 1 2 90 0 175.5 2 1\n2 Splitter 0 0 0 1 100 0 0\n",
+	}
+	for _, seed := range seeds {
+		f.Add(seed)
+	}
+	var buf bytes.Buffer
+	if err := newTestScene().SaveToText(&buf); err != nil {
+		f.Fatal(err)
+	}
+	f.Add(buf.String())
+
+	f.Fuzz(func(t *testing.T, text string) {
+		var s Scene
+		if err := s.LoadFromText(strings.NewReader(text)); err != nil {
+			return
+		}
+		if s.HasUndo() || s.IsModified() {
+			t.Fatalf("loaded scene: HasUndo=%v IsModified=%v, want false false", s.HasUndo(), s.IsModified())
+		}
+		saved := saveToString(t, &s)
+
+		var reloaded Scene
+		if err := reloaded.LoadFromText(strings.NewReader(saved)); err != nil {
+			t.Fatalf("cannot load a saved scene: %v\nsaved:\n%s", err, saved)
+		}
+		if resaved := saveToString(t, &reloaded); resaved != saved {
+			t.Fatalf("load/save/load is not stable\nfirst save:\n%s\nsecond save:\n%s", saved, resaved)
+		}
+		if len(reloaded.Buildings) != len(s.Buildings) || len(reloaded.Paths) != len(s.Paths) || len(reloaded.TextBoxes) != len(s.TextBoxes) {
+			t.Fatalf("reloaded counts = %d %d %d, want %d %d %d",
+				len(reloaded.Buildings), len(reloaded.Paths), len(reloaded.TextBoxes),
+				len(s.Buildings), len(s.Paths), len(s.TextBoxes))
+		}
+	})
+}
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
+// Undo / redo
+////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// randomOps generates random scene operations, and checks them against a stack of scene snapshots
+type randomOps struct {
+	t   *testing.T
+	rnd *rand.Rand
+	s   *Scene
+	// scene snapshots: states[i] is the scene after i operations
+	states []ObjectCollection
+	// current position in states
+	pos int
+	// position in states of the last save (-1 if the saved state is not in states anymore)
+	saved int
+}
+
+func (r *randomOps) coord() float32 { return float32(r.rnd.Intn(200) - 100) }
+
+func (r *randomOps) building() Building {
+	return building(r.rnd.Intn(len(testBuildingDefs)), r.coord(), r.coord(), int32(r.rnd.Intn(4)*90))
+}
+
+func (r *randomOps) path() Path {
+	return path(r.rnd.Intn(len(testPathDefs)), r.coord(), r.coord(), r.coord(), r.coord())
+}
+
+func (r *randomOps) textBox() TextBox {
+	return textBox(r.coord(), r.coord(), float32(1+r.rnd.Intn(10)), float32(1+r.rnd.Intn(10)), string(rune('a'+r.rnd.Intn(26))))
+}
+
+// idxs returns a random sorted subset of [0, n[
+func (r *randomOps) idxs(n int) []int {
+	var idxs []int
+	for i := range n {
+		if r.rnd.Intn(3) == 0 {
+			idxs = append(idxs, i)
+		}
+	}
+	return idxs
+}
+
+// selection returns a random selection of the scene objects
+func (r *randomOps) selection() ObjectSelection {
+	sel := ObjectSelection{
+		BuildingIdxs: r.idxs(len(r.s.Buildings)),
+		TextBoxIdxs:  r.idxs(len(r.s.TextBoxes)),
+	}
+	for _, idx := range r.idxs(len(r.s.Paths)) {
+		start, end := true, true
+		switch r.rnd.Intn(3) {
+		case 1:
+			start = false
+		case 2:
+			end = false
+		}
+		sel.PathIdxs = append(sel.PathIdxs, PathSel{Idx: idx, Start: start, End: end})
+	}
+	if !sel.IsEmpty() {
+		sel.RecomputeBounds(r.s.ObjectCollection)
+	}
+	return sel
+}
+
+// pushState records the scene state after a new operation
+func (r *randomOps) pushState() {
+	if r.saved > r.pos {
+		// the saved state is discarded with the undone operations
+		r.saved = -1
+	}
+	r.states = append(r.states[:r.pos+1], r.s.ObjectCollection.Clone())
+	r.pos++
+}
+
+func (r *randomOps) check(step int, op string) {
+	r.t.Helper()
+	want := r.states[r.pos]
+	if !slices.Equal(r.s.Buildings, want.Buildings) ||
+		!slices.Equal(r.s.Paths, want.Paths) ||
+		!slices.Equal(r.s.TextBoxes, want.TextBoxes) {
+		r.t.Fatalf("step %d (%s): scene = %+v, want %+v", step, op, r.s.ObjectCollection, want)
+	}
+	if r.s.HasUndo() != (r.pos > 0) || r.s.HasRedo() != (r.pos < len(r.states)-1) {
+		r.t.Fatalf("step %d (%s): HasUndo=%v HasRedo=%v, want %v %v",
+			step, op, r.s.HasUndo(), r.s.HasRedo(), r.pos > 0, r.pos < len(r.states)-1)
+	}
+	if r.s.IsModified() != (r.pos != r.saved) {
+		r.t.Fatalf("step %d (%s): IsModified=%v at position %d, saved at %d", step, op, r.s.IsModified(), r.pos, r.saved)
+	}
+}
+
+func (r *randomOps) checkSelection(step int, op string, sel ObjectSelection) {
+	r.t.Helper()
+	for _, idx := range sel.BuildingIdxs {
+		if idx >= len(r.s.Buildings) {
+			r.t.Fatalf("step %d (%s): selected building %d out of range", step, op, idx)
+		}
+	}
+	for _, elt := range sel.PathIdxs {
+		if elt.Idx >= len(r.s.Paths) {
+			r.t.Fatalf("step %d (%s): selected path %d out of range", step, op, elt.Idx)
+		}
+	}
+	for _, idx := range sel.TextBoxIdxs {
+		if idx >= len(r.s.TextBoxes) {
+			r.t.Fatalf("step %d (%s): selected text box %d out of range", step, op, idx)
+		}
+	}
+}
+
+// step performs a random operation and checks the scene state
+func (r *randomOps) step(i int) {
+	switch op := r.rnd.Intn(6); op {
+	case 0:
+		var add ObjectCollection
+		for range r.rnd.Intn(3) {
+			add.Buildings = append(add.Buildings, r.building())
+		}
+		for range r.rnd.Intn(3) {
+			add.Paths = append(add.Paths, r.path())
+		}
+		for range r.rnd.Intn(2) {
+			add.TextBoxes = append(add.TextBoxes, r.textBox())
+		}
+		r.s.AddObjects(add)
+		r.pushState()
+		r.check(i, "add")
+	case 1:
+		r.s.DeleteObjects(r.selection())
+		r.pushState()
+		r.check(i, "delete")
+	case 2:
+		sel := r.selection()
+		var new ObjectCollection
+		for range sel.BuildingIdxs {
+			new.Buildings = append(new.Buildings, r.building())
+		}
+		for range sel.PathIdxs {
+			new.Paths = append(new.Paths, r.path())
+		}
+		for range sel.TextBoxIdxs {
+			new.TextBoxes = append(new.TextBoxes, r.textBox())
+		}
+		r.s.ModifyObjects(sel, new)
+		r.pushState()
+		r.check(i, "modify")
+	case 3:
+		sel, ok := r.s.Undo()
+		if ok != (r.pos > 0) {
+			r.t.Fatalf("step %d (undo): Undo() = %v at position %d", i, ok, r.pos)
+		}
+		if ok {
+			r.pos--
+		}
+		r.check(i, "undo")
+		r.checkSelection(i, "undo", sel)
+	case 4:
+		sel, ok := r.s.Redo()
+		if ok != (r.pos < len(r.states)-1) {
+			r.t.Fatalf("step %d (redo): Redo() = %v at position %d / %d", i, ok, r.pos, len(r.states)-1)
+		}
+		if ok {
+			r.pos++
+		}
+		r.check(i, "redo")
+		r.checkSelection(i, "redo", sel)
+	case 5:
+		r.s.ResetModified()
+		r.saved = r.pos
+		r.check(i, "save")
+	}
+}
+
+// Random sequences of add, delete, modify, undo, redo and save must always match the scene
+// snapshots, and undoing / redoing everything must return to the initial / final state.
+func FuzzSceneOperations(f *testing.F) {
+	for seed := range int64(20) {
+		f.Add(seed, uint8(100))
+	}
+	f.Fuzz(func(t *testing.T, seed int64, n uint8) {
+		s := newTestScene()
+		r := randomOps{t: t, rnd: rand.New(rand.NewSource(seed)), s: s, saved: 0}
+		r.states = []ObjectCollection{s.ObjectCollection.Clone()}
+		for i := range int(n) {
+			r.step(i)
+		}
+
+		final := r.pos
+		for s.HasUndo() {
+			s.Undo()
+			r.pos--
+		}
+		r.check(int(n), "undo all")
+		if r.pos != 0 {
+			t.Fatalf("undo all: position = %d, want 0", r.pos)
+		}
+		for range final {
+			s.Redo()
+			r.pos++
+		}
+		r.check(int(n), "redo to final")
+	})
+}
@@ -0,0 +1,360 @@
+// layout - Draft factory layout of a production plan
+
+package scene
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+
+	"github.com/bonoboris/satisfied/log"
+	"github.com/bonoboris/satisfied/math32"
+	rl "github.com/bonoboris/satisfied/rlmath"
+)
+
+const (
+	// Free space between 2 machines of a row
+	layoutMachineGap = 2
+	// Distance between the machines and their first manifold
+	layoutManifoldGap = 2
+	// Distance between 2 manifolds of a row, center to center
+	layoutManifoldPitch = 6
+	// Free space between 2 rows, for the belts and pipes connecting them
+	layoutRowGap = 12
+	// Width of the text box labels, and distance between a label and the objects it describes
+	layoutLabelWidth  = 24
+	layoutLabelMargin = 2
+	// Height of the manifold labels
+	layoutLabelHeight = 5
+	// Maximum number of search steps of a connection route, failed searches would otherwise explore
+	// the whole routing grid
+	layoutRouteMaxSteps = 1 << 16
+)
+
+// Classes of the definitions used by the layout, besides the plan buildings
+const (
+	layoutSplitter = "Splitter"
+	layoutMerger   = "Merger"
+	layoutJunction = "Pipeline Junction Cross"
+	layoutBelt     = "Belt"
+	layoutPipe     = "Pipe"
+)
+
+var (
+	ErrLayoutUnknownDef = errors.New("unknown definition")
+	ErrLayoutPorts      = errors.New("recipe items do not fit the building ports")
+	ErrLayoutNoPort     = errors.New("building has no port facing the manifold")
+)
+
+// layoutManifold is a line of splitters / mergers (or pipeline junctions) along a row of machines,
+// carrying one item to or from the machines
+type layoutManifold struct {
+	item     string
+	rate     float32
+	isOutput bool
+	isPipe   bool
+	// Index in the layout buildings of the first and last building of the manifold
+	first, last int
+}
+
+// PlanLayout returns a draft layout of the production plan centered on center (snapped to the 1m
+// grid), to be placed with [Scene.AddObjects].
+//
+// Each plan step is a row of machines at the plan clock speed (the last one underclocked), fed from
+// the south by a manifold of splitters per input item and drained to the north by a manifold of
+// mergers per output item (pipeline junctions for fluids). Rows are stacked northward, producers
+// first, and an output manifold is routed to the input manifold of another row when that row
+// consumes all of its item. Text boxes label the rows and the manifolds open ends with their item and rate.
+//
+// Belts of a row may cross where lifts are needed in game, and connections that cannot be routed
+// are left open.
+func PlanLayout(book RecipeBook, plan Plan, center rl.Vector2) (ObjectCollection, error) {
+	var col ObjectCollection
+	defIdxs := make(map[string]int)
+	for _, class := range []string{layoutSplitter, layoutMerger, layoutJunction} {
+		if defIdxs[class] = buildingDefs.Index(class); defIdxs[class] < 0 {
+			return col, fmt.Errorf("%w: %s", ErrLayoutUnknownDef, class)
+		}
+	}
+	beltIdx, pipeIdx := pathDefs.Index(layoutBelt), pathDefs.Index(layoutPipe)
+	if beltIdx < 0 || pipeIdx < 0 {
+		return col, fmt.Errorf("%w: %s or %s", ErrLayoutUnknownDef, layoutBelt, layoutPipe)
+	}
+
+	var manifolds []layoutManifold
+	var bottom float32
+	clock := Modifiers{Clock: plan.Clock}.ClockSpeed()
+	for _, step := range layoutOrder(book, plan.Steps) {
+		defIdx := buildingDefs.Index(step.Building)
+		if defIdx < 0 {
+			return col, fmt.Errorf("%w: %s", ErrLayoutUnknownDef, step.Building)
+		}
+		def := buildingDefs[defIdx]
+		recipe := book.Recipes[step.RecipeIdx]
+		var beltIn, pipeIn, beltOut, pipeOut []ItemAmount
+		// rates of a single machine at 100% clock speed, scaled once the row machines are known
+		for _, ia := range recipe.In {
+			ia.Amount = -recipe.Net(ia.Item)
+			if book.IsFluid(ia.Item) {
+				pipeIn = append(pipeIn, ia)
+			} else {
+				beltIn = append(beltIn, ia)
+			}
+		}
+		for _, ia := range recipe.Out {
+			ia.Amount = recipe.Net(ia.Item)
+			if book.IsFluid(ia.Item) {
+				pipeOut = append(pipeOut, ia)
+			} else {
+				beltOut = append(beltOut, ia)
+			}
+		}
+		if len(beltIn) > def.BeltIn.Len() || len(pipeIn) > def.PipeIn.Len() ||
+			len(beltOut) > def.BeltOut.Len() || len(pipeOut) > def.PipeOut.Len() {
+			return ObjectCollection{}, fmt.Errorf("%w: %s in %s", ErrLayoutPorts, recipe.Class, def.Class)
+		}
+
+		// machines, top-left corners on the row top
+		ins, outs := len(beltIn)+len(pipeIn), len(beltOut)+len(pipeOut)
+		top := bottom - float32(ins*layoutManifoldPitch) - layoutManifoldGap - def.Dims.Y
+		count := int(math32.Ceil(step.Machines - planEps))
+		pitch := max(def.Dims.X, 4) + layoutMachineGap
+		mid := vec2(math32.Round(def.Dims.X/2), math32.Round(def.Dims.Y/2))
+		var mods Modifiers
+		if def.Clockable {
+			mods = clockModifiers(clock)
+		}
+		machines := make([]Building, count)
+		for i := range machines {
+			machines[i] = Building{DefIdx: defIdx, Pos: vec2(float32(i)*pitch, top).Add(mid), Mods: mods}
+		}
+		if frac := step.Machines - float32(count-1); def.Clockable && frac < 1-planEps {
+			machines[count-1].Mods = clockModifiers(max(MinClock, math32.Round(frac*clock*100)/100))
+		}
+		var rate float32
+		for _, m := range machines {
+			rate += m.ProductionRate()
+		}
+		for _, ias := range [][]ItemAmount{beltIn, pipeIn, beltOut, pipeOut} {
+			for i := range ias {
+				ias[i].Amount *= rate
+			}
+		}
+		col.Buildings = append(col.Buildings, machines...)
+		label := fmt.Sprintf("%s\n%s x %s", recipe.Class, formatLayoutRate(step.Machines), def.Class)
+		if def.Clockable && clock != DefaultClock {
+			label += " at " + formatLayoutRate(clock) + "%"
+		}
+		col.TextBoxes = append(col.TextBoxes, TextBox{
+			Bounds:  rl.NewRectangle(-layoutLabelMargin-layoutLabelWidth, top, layoutLabelWidth, def.Dims.Y),
+			Content: label,
+		})
+
+		// manifolds, lane 0 the nearest to the machines
+		addManifold := func(lane, portIdx int, ia ItemAmount, isOutput, isPipe bool) error {
+			m := layoutManifold{item: ia.Item, rate: ia.Amount, isOutput: isOutput, isPipe: isPipe}
+			var class string
+			var rot int32
+			var centerY float32
+			switch {
+			case isPipe:
+				// west input and north output, or south input and east output
+				class = layoutJunction
+			case isOutput:
+				// west and south inputs, east output
+				class, rot = layoutMerger, 90
+			default:
+				// west input, north and east outputs
+				class, rot = layoutSplitter, 90
+			}
+			half := buildingDefs[defIdxs[class]].Dims.Y / 2
+			if isOutput {
+				centerY = top - layoutManifoldGap - float32(lane*layoutManifoldPitch) - half
+			} else {
+				centerY = top + def.Dims.Y + layoutManifoldGap + float32(lane*layoutManifoldPitch) + half
+			}
+			pathIdx := beltIdx
+			if isPipe {
+				pathIdx = pipeIdx
+			}
+			m.first = len(col.Buildings)
+			for i, machine := range machines {
+				port := layoutPorts(machine, isOutput, isPipe)[portIdx]
+				b := Building{DefIdx: defIdxs[class], Pos: vec2(port.Pos.X, centerY), Rot: rot}
+				if isOutput {
+					end, err := layoutPort(b, vec2(0, 1))
+					if err != nil {
+						return err
+					}
+					col.Paths = append(col.Paths, Path{DefIdx: pathIdx, Start: port.Pos, End: end.Pos})
+				} else {
+					start, err := layoutPort(b, vec2(0, -1))
+					if err != nil {
+						return err
+					}
+					col.Paths = append(col.Paths, Path{DefIdx: pathIdx, Start: start.Pos, End: port.Pos})
+				}
+				if i > 0 {
+					start, err := layoutPort(col.Buildings[len(col.Buildings)-1], vec2(1, 0))
+					if err != nil {
+						return err
+					}
+					end, err := layoutPort(b, vec2(-1, 0))
+					if err != nil {
+						return err
+					}
+					col.Paths = append(col.Paths, Path{DefIdx: pathIdx, Start: start.Pos, End: end.Pos})
+				}
+				col.Buildings = append(col.Buildings, b)
+			}
+			m.last = len(col.Buildings) - 1
+			manifolds = append(manifolds, m)
+			return nil
+		}
+		for i, ia := range beltIn {
+			if err := addManifold(i, i, ia, false, false); err != nil {
+				return ObjectCollection{}, err
+			}
+		}
+		for i, ia := range pipeIn {
+			if err := addManifold(len(beltIn)+i, i, ia, false, true); err != nil {
+				return ObjectCollection{}, err
+			}
+		}
+		for i, ia := range beltOut {
+			if err := addManifold(i, i, ia, true, false); err != nil {
+				return ObjectCollection{}, err
+			}
+		}
+		for i, ia := range pipeOut {
+			if err := addManifold(len(beltOut)+i, i, ia, true, true); err != nil {
+				return ObjectCollection{}, err
+			}
+		}
+		bottom = top - layoutManifoldGap - float32(outs*layoutManifoldPitch) - layoutRowGap
+	}
+
+	// connections between the rows: an output manifold entirely consumed by a single input one,
+	// then labels of the manifolds left open
+	connected := make([]bool, len(manifolds))
+	for i, out := range manifolds {
+		if !out.isOutput {
+			continue
+		}
+		in, count := -1, 0
+		for j, m := range manifolds {
+			if m.item == out.item && j != i {
+				in, count = j, count+1
+			}
+		}
+		if count != 1 || manifolds[in].isOutput || math32.Abs(manifolds[in].rate-out.rate) > planEps*max(1, out.rate) {
+			continue
+		}
+		pathIdx := beltIdx
+		if out.isPipe {
+			pathIdx = pipeIdx
+		}
+		from, err := layoutPort(col.Buildings[out.last], vec2(1, 0))
+		if err != nil {
+			return ObjectCollection{}, err
+		}
+		to, err := layoutPort(col.Buildings[manifolds[in].first], vec2(-1, 0))
+		if err != nil {
+			return ObjectCollection{}, err
+		}
+		path, err := Scene{ObjectCollection: col}.route(pathIdx, from, to, layoutRouteMaxSteps)
+		if err != nil {
+			log.Debug("scene.PlanLayout", "item", out.item, "err", err)
+			continue
+		}
+		col.Paths = append(col.Paths, path)
+		connected[i], connected[in] = true, true
+	}
+	for i, m := range manifolds {
+		if connected[i] {
+			continue
+		}
+		var bounds rl.Rectangle
+		if m.isOutput {
+			b := col.Buildings[m.last].Bounds()
+			bounds = rl.NewRectangle(b.X+b.Width+layoutLabelMargin, b.Y+b.Height/2-layoutLabelHeight/2, layoutLabelWidth, layoutLabelHeight)
+		} else {
+			b := col.Buildings[m.first].Bounds()
+			bounds = rl.NewRectangle(b.X-layoutLabelMargin-layoutLabelWidth, b.Y+b.Height/2-layoutLabelHeight/2, layoutLabelWidth, layoutLabelHeight)
+		}
+		col.TextBoxes = append(col.TextBoxes, TextBox{Bounds: bounds, Content: fmt.Sprintf("%s\n%s/min", m.item, formatLayoutRate(m.rate))})
+	}
+
+	sel := ObjectSelection{
+		BuildingIdxs: Range(0, len(col.Buildings)),
+		TextBoxIdxs:  Range(0, len(col.TextBoxes)),
+	}
+	sel.RecomputeBounds(col)
+	delta := center.Subtract(sel.Bounds.Center())
+	delta = vec2(math32.Round(delta.X), math32.Round(delta.Y))
+	for i := range col.Buildings {
+		col.Buildings[i].Pos = col.Buildings[i].Pos.Add(delta)
+	}
+	for i := range col.Paths {
+		col.Paths[i] = col.Paths[i].Translate(delta)
+	}
+	for i := range col.TextBoxes {
+		col.TextBoxes[i].Bounds.X += delta.X
+		col.TextBoxes[i].Bounds.Y += delta.Y
+	}
+	return col, nil
+}
+
+// layoutOrder returns the plan steps with the producers of items before their consumers, loops
+// aside
+func layoutOrder(book RecipeBook, steps []PlanStep) []PlanStep {
+	remaining := slices.Clone(steps)
+	ordered := make([]PlanStep, 0, len(steps))
+	for len(remaining) > 0 {
+		// the first step whose inputs are not produced by another remaining step, or the first one
+		// in a loop
+		next := max(0, slices.IndexFunc(remaining, func(s PlanStep) bool {
+			for _, ia := range book.Recipes[s.RecipeIdx].In {
+				if slices.ContainsFunc(remaining, func(o PlanStep) bool {
+					return o.RecipeIdx != s.RecipeIdx && book.Recipes[o.RecipeIdx].Net(ia.Item) > 0
+				}) {
+					return false
+				}
+			}
+			return true
+		}))
+		ordered = append(ordered, remaining[next])
+		remaining = slices.Delete(remaining, next, next+1)
+	}
+	return ordered
+}
+
+// layoutPorts returns the building input or output belt or pipe ports, in definition order
+func layoutPorts(b Building, isOutput, isPipe bool) []Port {
+	var buf [4 * MAX_INOUT]Port
+	var ports []Port
+	for _, p := range b.Ports(buf[:0]) {
+		if p.IsOutput == isOutput && p.IsPipe == isPipe {
+			ports = append(ports, p)
+		}
+	}
+	return ports
+}
+
+// layoutPort returns the building port facing dir, or [ErrLayoutNoPort] if it has none (custom
+// definitions)
+func layoutPort(b Building, dir rl.Vector2) (Port, error) {
+	var buf [4 * MAX_INOUT]Port
+	for _, p := range b.Ports(buf[:0]) {
+		if p.Dir.Distance(dir) < routeEps {
+			return p, nil
+		}
+	}
+	return Port{}, fmt.Errorf("%w: %s facing %v", ErrLayoutNoPort, b.Def().Class, dir)
+}
+
+// formatLayoutRate formats a rate or machines count with at most 2 decimals
+func formatLayoutRate(v float32) string {
+	return strconv.FormatFloat(float64(math32.Round(v*100)/100), 'f', -1, 32)
+}
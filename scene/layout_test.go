@@ -0,0 +1,196 @@
+package scene
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"slices"
+	"testing"
+)
+
+// setAssetsBuildingDefs registers the assets building definitions for the test
+func setAssetsBuildingDefs(t *testing.T) {
+	t.Helper()
+	data, err := os.ReadFile("../assets/building_defs.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var defs BuildingDefs
+	if err := json.Unmarshal(data, &defs); err != nil {
+		t.Fatal(err)
+	}
+	SetDefs(defs, testPathDefs)
+	t.Cleanup(func() { SetDefs(testBuildingDefs, testPathDefs) })
+}
+
+// checkLayoutPaths checks every path of the layout links an output port to an input port of the
+// same kind
+func checkLayoutPaths(t *testing.T, col ObjectCollection) {
+	t.Helper()
+	s := Scene{ObjectCollection: col}
+	for _, p := range col.Paths {
+		from, ok1 := s.PortAt(p.Start)
+		to, ok2 := s.PortAt(p.End)
+		isPipe := p.DefIdx == defPipe
+		if !ok1 || !ok2 || from.Pos != p.Start || to.Pos != p.End || !from.IsOutput || to.IsOutput ||
+			from.IsPipe != isPipe || to.IsPipe != isPipe {
+			t.Errorf("path %v: want from an output to an input port, got %v and %v", p, from, to)
+		}
+	}
+}
+
+func TestPlanLayout(t *testing.T) {
+	setAssetsBuildingDefs(t)
+	plan, err := SolvePlan(testRecipeBook, []ItemAmount{{"Iron Plate", 50}}, nil, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	col, err := PlanLayout(testRecipeBook, plan, vec2(0, 0))
+	if err != nil {
+		t.Fatalf("PlanLayout() error = %v", err)
+	}
+	counts := make(map[string]int)
+	for _, b := range col.Buildings {
+		counts[b.Def().Class]++
+	}
+	// 2.5 smelters and constructors, each with a splitter and a merger
+	want := map[string]int{"Smelter": 3, "Constructor": 3, "Splitter": 6, "Merger": 6}
+	for class, n := range want {
+		if counts[class] != n {
+			t.Errorf("%d %s, want %d", counts[class], class, n)
+		}
+	}
+	var clocks []float32
+	for _, b := range col.Buildings {
+		if b.Def().Clockable {
+			clocks = append(clocks, b.Mods.ClockSpeed())
+		}
+	}
+	if wantClocks := []float32{100, 100, 50, 100, 100, 50}; !slices.Equal(clocks, wantClocks) {
+		t.Errorf("clocks = %v, want %v", clocks, wantClocks)
+	}
+	// feeders and manifolds: 2 belts per machine but the first of each manifold, and the ingots
+	// connection
+	if len(col.Paths) != 4*(3+2)+1 {
+		t.Errorf("%d paths, want %d", len(col.Paths), 4*(3+2)+1)
+	}
+	checkLayoutPaths(t, col)
+	// the rows, the iron ore input and the iron plate output
+	if len(col.TextBoxes) != 4 {
+		t.Errorf("text boxes = %v, want 4", col.TextBoxes)
+	}
+	// the ingots row is south of the plates row
+	if col.Buildings[0].Def().Class != "Smelter" {
+		t.Errorf("first building %v, want a smelter", col.Buildings[0])
+	}
+	// centered on the given position, on the grid
+	moved, err := PlanLayout(testRecipeBook, plan, vec2(100.4, -50))
+	if err != nil {
+		t.Fatalf("PlanLayout() error = %v", err)
+	}
+	if delta := moved.Buildings[0].Pos.Subtract(col.Buildings[0].Pos); delta != vec2(100, -50) {
+		t.Errorf("moved by %v, want (100, -50)", delta)
+	}
+
+	// overclocked machines, with the shards they need: 1.33 smelters and constructors
+	plan, err = SolvePlan(testRecipeBook, []ItemAmount{{"Iron Plate", 40}}, nil, 150)
+	if err != nil {
+		t.Fatal(err)
+	}
+	col, err = PlanLayout(testRecipeBook, plan, vec2(0, 0))
+	if err != nil {
+		t.Fatalf("PlanLayout(150%%) error = %v", err)
+	}
+	var mods []Modifiers
+	for _, b := range col.Buildings {
+		if b.Def().Clockable {
+			mods = append(mods, b.Mods)
+		}
+	}
+	wantMods := []Modifiers{{Clock: 150, Shards: 1}, {Clock: 50}, {Clock: 150, Shards: 1}, {Clock: 50}}
+	if !slices.EqualFunc(mods, wantMods, func(a, b Modifiers) bool {
+		return near(a.ClockSpeed(), b.ClockSpeed()) && a.Shards == b.Shards
+	}) {
+		t.Errorf("modifiers = %v, want %v", mods, wantMods)
+	}
+	// the ingots rates match, the rows are connected
+	if len(col.TextBoxes) != 4 {
+		t.Errorf("150%% text boxes = %v, want 4", col.TextBoxes)
+	}
+	checkLayoutPaths(t, col)
+
+	// fluids use pipeline junctions (1.5 refineries), the heavy oil residue byproduct is left open
+	plan, err = SolvePlan(testRecipeBook, []ItemAmount{{"Plastic", 30}}, nil, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	col, err = PlanLayout(testRecipeBook, plan, vec2(0, 0))
+	if err != nil {
+		t.Fatalf("PlanLayout() error = %v", err)
+	}
+	junctions := 0
+	for _, b := range col.Buildings {
+		if b.Def().Class == "Pipeline Junction Cross" {
+			junctions++
+		}
+	}
+	if junctions != 2*2 {
+		t.Errorf("%d pipeline junctions, want 4", junctions)
+	}
+	checkLayoutPaths(t, col)
+}
+
+// TestPlanLayoutAssets lays out a few plans of the assets recipes, and every recipe on its own
+func TestPlanLayoutAssets(t *testing.T) {
+	book := loadAssetsRecipeBook(t)
+	setAssetsBuildingDefs(t)
+	// belts only, with a fluid and a byproduct, with several fluid and belt outputs
+	for _, item := range []string{"Reinforced Iron Plate", "Plastic", "Cooling System"} {
+		plan, err := SolvePlan(book, []ItemAmount{{item, 10}}, nil, 0)
+		if err != nil {
+			t.Fatalf("SolvePlan(%s) error = %v", item, err)
+		}
+		col, err := PlanLayout(book, plan, vec2(0, 0))
+		if err != nil {
+			t.Errorf("PlanLayout(%s) error = %v", item, err)
+			continue
+		}
+		checkLayoutPaths(t, col)
+	}
+	// every recipe fits its building ports
+	for i, r := range book.Recipes {
+		plan := Plan{Steps: []PlanStep{{RecipeIdx: i, Recipe: r.Class, Building: r.Building, Machines: 1}}}
+		if _, err := PlanLayout(book, plan, vec2(0, 0)); err != nil {
+			t.Errorf("PlanLayout(%s) error = %v", r.Class, err)
+		}
+	}
+}
+
+func TestPlanLayoutErrors(t *testing.T) {
+	plan := Plan{Steps: []PlanStep{{RecipeIdx: 1, Recipe: "Iron Plate", Building: "Constructor", Machines: 1}}}
+	// the test definitions have no merger
+	if _, err := PlanLayout(testRecipeBook, plan, vec2(0, 0)); !errors.Is(err, ErrLayoutUnknownDef) {
+		t.Errorf("PlanLayout() error = %v, want %v", err, ErrLayoutUnknownDef)
+	}
+
+	setAssetsBuildingDefs(t)
+	plan.Steps[0].Building = "Teleporter"
+	if _, err := PlanLayout(testRecipeBook, plan, vec2(0, 0)); !errors.Is(err, ErrLayoutUnknownDef) {
+		t.Errorf("PlanLayout() error = %v, want %v", err, ErrLayoutUnknownDef)
+	}
+	// a refinery has a single belt input
+	book := RecipeBook{Recipes: []RecipeDef{{Class: "Mix", Building: "Refinery", Duration: 1,
+		In: []ItemAmount{{"A", 1}, {"B", 1}}, Out: []ItemAmount{{"C", 1}}}}}
+	plan = Plan{Steps: []PlanStep{{Recipe: "Mix", Building: "Refinery", Machines: 1}}}
+	if _, err := PlanLayout(book, plan, vec2(0, 0)); !errors.Is(err, ErrLayoutPorts) {
+		t.Errorf("PlanLayout() error = %v, want %v", err, ErrLayoutPorts)
+	}
+	// a custom merger without output
+	defs := slices.Clone(buildingDefs)
+	defs[defs.Index("Merger")].BeltOut = InputOutputs{}
+	SetDefs(defs, testPathDefs)
+	plan = Plan{Steps: []PlanStep{{RecipeIdx: 1, Recipe: "Iron Plate", Building: "Constructor", Machines: 2}}}
+	if _, err := PlanLayout(testRecipeBook, plan, vec2(0, 0)); !errors.Is(err, ErrLayoutNoPort) {
+		t.Errorf("PlanLayout() error = %v, want %v", err, ErrLayoutNoPort)
+	}
+}
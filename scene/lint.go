@@ -0,0 +1,323 @@
+// lint - Design rules checker: pluggable rules reporting objects issues
+
+package scene
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/bonoboris/satisfied/math32"
+	rl "github.com/bonoboris/satisfied/rlmath"
+)
+
+const (
+	// Size of a foundation, the building grid of [LintFoundationEdges]
+	FoundationSize = 8
+	// Tolerance on the lint positions and lengths comparisons
+	lintEps = 1e-3
+)
+
+// Built-in rules names
+const (
+	LintUnconnectedPort   = "Unconnected port"
+	LintReversedPath      = "Belt into an output"
+	LintPathEndInBuilding = "Path end in a building"
+	LintOverlappingPaths  = "Overlapping paths"
+	LintPathThroughBuild  = "Path through a building"
+	LintZeroLengthPath    = "Zero length path"
+	LintFoundationEdges   = "Building across foundations"
+)
+
+// LintIssue is an object breaking a design rule
+type LintIssue struct {
+	// Name of the rule
+	Rule string
+	// Object breaking the rule
+	Object Object
+	// Position of the issue, e.g. the unconnected port
+	Pos rl.Vector2
+	// Description of the issue
+	Msg string
+}
+
+func (i LintIssue) String() string {
+	return fmt.Sprintf("%s{%v %d (%v,%v) %q}", i.Rule, i.Object.Type, i.Object.Idx, i.Pos.X, i.Pos.Y, i.Msg)
+}
+
+// LintRule is a design rule
+type LintRule struct {
+	Name string
+	// Check appends the issues of the collection objects to dst and returns the extended slice
+	Check func(oc ObjectCollection, dst []LintIssue) []LintIssue
+}
+
+// Registered rules, see [RegisterLintRule]
+var lintRules = []LintRule{
+	{LintUnconnectedPort, lintUnconnectedPorts},
+	{LintReversedPath, lintReversedPaths},
+	{LintPathEndInBuilding, lintPathEndsInBuildings},
+	{LintOverlappingPaths, lintOverlappingPaths},
+	{LintPathThroughBuild, lintPathsThroughBuildings},
+	{LintZeroLengthPath, lintZeroLengthPaths},
+	{LintFoundationEdges, lintFoundationEdges},
+}
+
+// LintRules returns a copy of the registered rules, the built-in ones first
+func LintRules() []LintRule { return slices.Clone(lintRules) }
+
+// RegisterLintRule adds a rule checked by [Lint], replacing the registered rule of the same name
+func RegisterLintRule(rule LintRule) {
+	if i := slices.IndexFunc(lintRules, func(r LintRule) bool { return r.Name == rule.Name }); i >= 0 {
+		lintRules[i] = rule
+	} else {
+		lintRules = append(lintRules, rule)
+	}
+}
+
+// Lint returns the issues of the collection objects, by rule in registration order, skipping the
+// disabled rules (by name)
+func Lint(oc ObjectCollection, disabled []string) []LintIssue {
+	var issues []LintIssue
+	for _, rule := range lintRules {
+		if !slices.Contains(disabled, rule.Name) {
+			issues = rule.Check(oc, issues)
+		}
+	}
+	return issues
+}
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
+// Built-in rules
+////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// portKey is a port or path end position, rounded to [lintEps]
+type portKey [2]int32
+
+func newPortKey(pos rl.Vector2) portKey {
+	return portKey{int32(math32.Round(pos.X / lintEps)), int32(math32.Round(pos.Y / lintEps))}
+}
+
+// portName returns a description of the port kind, e.g. "belt input"
+func portName(p Port) string {
+	name := "belt"
+	if p.IsPipe {
+		name = "pipe"
+	}
+	if p.IsOutput {
+		return name + " output"
+	}
+	return name + " input"
+}
+
+// isManifold returns true if the building spreads or gathers items (splitters, mergers, pipeline
+// junctions): it is unpowered with several ports of a kind, of which only one needs a connection
+func isManifold(def BuildingDef) bool {
+	return def.PowerUse == 0 && (def.BeltIn.Len() > 1 || def.BeltOut.Len() > 1 || def.PipeIn.Len() > 1 || def.PipeOut.Len() > 1)
+}
+
+// lintUnconnectedPorts reports the building ports without a path end or another building port
+func lintUnconnectedPorts(oc ObjectCollection, dst []LintIssue) []LintIssue {
+	used := make(map[portKey]int)
+	for _, p := range oc.Paths {
+		if !p.Def().IsPowerLine {
+			used[newPortKey(p.Start)]++
+			used[newPortKey(p.End)]++
+		}
+	}
+	var buf [4 * MAX_INOUT]Port
+	for _, b := range oc.Buildings {
+		for _, p := range b.Ports(buf[:0]) {
+			used[newPortKey(p.Pos)]++
+		}
+	}
+	for i, b := range oc.Buildings {
+		ports := b.Ports(buf[:0])
+		if isManifold(b.Def()) {
+			// one issue per kind of ports without any connection
+			for k, p := range ports {
+				if slices.ContainsFunc(ports[:k], func(o Port) bool { return o.IsOutput == p.IsOutput && o.IsPipe == p.IsPipe }) {
+					continue
+				}
+				if !slices.ContainsFunc(ports, func(o Port) bool {
+					return o.IsOutput == p.IsOutput && o.IsPipe == p.IsPipe && used[newPortKey(o.Pos)] > 1
+				}) {
+					dst = append(dst, LintIssue{LintUnconnectedPort, Object{TypeBuilding, i}, p.Pos,
+						fmt.Sprintf("%s: no %s connected", b.Def().Class, portName(p))})
+				}
+			}
+			continue
+		}
+		for _, p := range ports {
+			if used[newPortKey(p.Pos)] <= 1 {
+				dst = append(dst, LintIssue{LintUnconnectedPort, Object{TypeBuilding, i}, p.Pos,
+					fmt.Sprintf("%s: %s not connected", b.Def().Class, portName(p))})
+			}
+		}
+	}
+	return dst
+}
+
+// lintReversedPaths reports the directional paths (belts) starting from an input port or ending
+// into an output port
+func lintReversedPaths(oc ObjectCollection, dst []LintIssue) []LintIssue {
+	ports := make(map[portKey]Port)
+	var buf [4 * MAX_INOUT]Port
+	for _, b := range oc.Buildings {
+		for _, p := range b.Ports(buf[:0]) {
+			ports[newPortKey(p.Pos)] = p
+		}
+	}
+	for i, p := range oc.Paths {
+		if !p.Def().IsDirectional {
+			continue
+		}
+		if port, ok := ports[newPortKey(p.Start)]; ok && !port.IsOutput {
+			dst = append(dst, LintIssue{LintReversedPath, Object{TypePath, i}, p.Start,
+				fmt.Sprintf("%s starts from a %s", p.Def().Class, portName(port))})
+		}
+		if port, ok := ports[newPortKey(p.End)]; ok && port.IsOutput {
+			dst = append(dst, LintIssue{LintReversedPath, Object{TypePath, i}, p.End,
+				fmt.Sprintf("%s ends into a %s", p.Def().Class, portName(port))})
+		}
+	}
+	return dst
+}
+
+// shrinkRec returns the rectangle shrunk by [lintEps] on each side, to ignore touching edges
+func shrinkRec(rec rl.Rectangle) rl.Rectangle {
+	return rl.NewRectangle(rec.X+lintEps, rec.Y+lintEps, rec.Width-2*lintEps, rec.Height-2*lintEps)
+}
+
+// lintPathEndsInBuildings reports the belts and pipes ends inside a building, rather than on its
+// edge ports
+func lintPathEndsInBuildings(oc ObjectCollection, dst []LintIssue) []LintIssue {
+	for i, p := range oc.Paths {
+		if p.Def().IsPowerLine {
+			continue
+		}
+		for _, b := range oc.Buildings {
+			inner := shrinkRec(b.Bounds())
+			for _, end := range [2]rl.Vector2{p.Start, p.End} {
+				if checkCollisionPointRec(end, inner) {
+					dst = append(dst, LintIssue{LintPathEndInBuilding, Object{TypePath, i}, end,
+						fmt.Sprintf("%s ends inside a %s", p.Def().Class, b.Def().Class)})
+				}
+			}
+		}
+	}
+	return dst
+}
+
+// lintPathsThroughBuildings reports the belts and pipes crossing a building, those ending inside it
+// excepted (see [lintPathEndsInBuildings])
+func lintPathsThroughBuildings(oc ObjectCollection, dst []LintIssue) []LintIssue {
+	var buf [MAX_POLYLINE_POINTS]rl.Vector2
+	for i, p := range oc.Paths {
+		if p.Def().IsPowerLine {
+			continue
+		}
+		bounds := p.Bounds()
+		points := p.Polyline(buf[:0])
+		for _, b := range oc.Buildings {
+			inner := shrinkRec(b.Bounds())
+			if !checkCollisionRecs(inner, bounds) || checkCollisionPointRec(p.Start, inner) || checkCollisionPointRec(p.End, inner) {
+				continue
+			}
+			for k := 1; k < len(points); k++ {
+				if CheckCollisionRecLine(inner, points[k-1], points[k]) {
+					dst = append(dst, LintIssue{LintPathThroughBuild, Object{TypePath, i}, inner.Center(),
+						fmt.Sprintf("%s crosses a %s", p.Def().Class, b.Def().Class)})
+					break
+				}
+			}
+		}
+	}
+	return dst
+}
+
+// collinearOverlap returns the middle of the overlap of segments ab and cd if they are collinear
+// and overlap over more than [lintEps]
+func collinearOverlap(a, b, c, d rl.Vector2) (rl.Vector2, bool) {
+	ab := b.Subtract(a)
+	length := ab.Length()
+	if length < lintEps {
+		return rl.Vector2{}, false
+	}
+	dir := ab.Scale(1 / length)
+	// distances of c and d to the ab line
+	if math32.Abs(dir.X*(c.Y-a.Y)-dir.Y*(c.X-a.X)) > lintEps || math32.Abs(dir.X*(d.Y-a.Y)-dir.Y*(d.X-a.X)) > lintEps {
+		return rl.Vector2{}, false
+	}
+	// positions of c and d along ab
+	tc, td := c.Subtract(a).DotProduct(dir), d.Subtract(a).DotProduct(dir)
+	lo, hi := max(0, min(tc, td)), min(length, max(tc, td))
+	if hi-lo <= lintEps {
+		return rl.Vector2{}, false
+	}
+	return a.Add(dir.Scale((lo + hi) / 2)), true
+}
+
+// lintOverlappingPaths reports the belts and pipes running over another one
+func lintOverlappingPaths(oc ObjectCollection, dst []LintIssue) []LintIssue {
+	var bufI, bufJ [MAX_POLYLINE_POINTS]rl.Vector2
+	for j, pj := range oc.Paths {
+		if pj.Def().IsPowerLine {
+			continue
+		}
+		boundsJ := pj.Bounds()
+		pointsJ := pj.Polyline(bufJ[:0])
+	others:
+		for _, pi := range oc.Paths[:j] {
+			if pi.Def().IsPowerLine || !checkCollisionRecs(pi.Bounds(), boundsJ) {
+				continue
+			}
+			pointsI := pi.Polyline(bufI[:0])
+			for ki := 1; ki < len(pointsI); ki++ {
+				for kj := 1; kj < len(pointsJ); kj++ {
+					if pos, ok := collinearOverlap(pointsI[ki-1], pointsI[ki], pointsJ[kj-1], pointsJ[kj]); ok {
+						dst = append(dst, LintIssue{LintOverlappingPaths, Object{TypePath, j}, pos,
+							fmt.Sprintf("%s runs over another %s", pj.Def().Class, pi.Def().Class)})
+						continue others
+					}
+				}
+			}
+		}
+	}
+	return dst
+}
+
+// lintZeroLengthPaths reports the paths with a null length segment
+func lintZeroLengthPaths(oc ObjectCollection, dst []LintIssue) []LintIssue {
+	var buf [MAX_PATH_VERTICES + 2]rl.Vector2
+	for i, p := range oc.Paths {
+		points := p.Points(buf[:0])
+		for k := 1; k < len(points); k++ {
+			if points[k].Distance(points[k-1]) < lintEps {
+				dst = append(dst, LintIssue{LintZeroLengthPath, Object{TypePath, i}, points[k],
+					fmt.Sprintf("%s has a zero length segment", p.Def().Class)})
+				break
+			}
+		}
+	}
+	return dst
+}
+
+// foundationCells returns the number of foundation cells between lo and hi on an axis
+func foundationCells(lo, hi float32) int {
+	return int(math32.Floor((hi-lintEps)/FoundationSize)-math32.Floor((lo+lintEps)/FoundationSize)) + 1
+}
+
+// lintFoundationEdges reports the buildings overlapping more foundations of the [FoundationSize]
+// grid than their size needs
+func lintFoundationEdges(oc ObjectCollection, dst []LintIssue) []LintIssue {
+	for i, b := range oc.Buildings {
+		bounds := b.Bounds()
+		cells := foundationCells(bounds.X, bounds.X+bounds.Width) * foundationCells(bounds.Y, bounds.Y+bounds.Height)
+		needed := int(math32.Ceil(bounds.Width/FoundationSize-lintEps)) * int(math32.Ceil(bounds.Height/FoundationSize-lintEps))
+		if cells > needed {
+			dst = append(dst, LintIssue{LintFoundationEdges, Object{TypeBuilding, i}, bounds.Center(),
+				fmt.Sprintf("%s overlaps %d foundations, %d needed", b.Def().Class, cells, needed)})
+		}
+	}
+	return dst
+}
@@ -0,0 +1,124 @@
+package scene
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestLint(t *testing.T) {
+	// constructor spanning (0,0) to (8,10), input at (4,10), output at (4,0)
+	constructor := building(defConstructor, 4, 5, 0)
+	feed := path(defBelt, 4, 20, 4, 10)
+	drain := path(defBelt, 4, 0, 4, -10)
+
+	tests := []struct {
+		name string
+		oc   ObjectCollection
+		want []LintIssue
+	}{
+		{"connected", ObjectCollection{Buildings: []Building{constructor}, Paths: []Path{feed, drain}}, nil},
+		{"unconnected ports", ObjectCollection{Buildings: []Building{constructor}, Paths: []Path{feed}}, []LintIssue{
+			{LintUnconnectedPort, Object{TypeBuilding, 0}, vec2(4, 0), "Constructor: belt output not connected"},
+		}},
+		{"ports facing", ObjectCollection{Buildings: []Building{constructor, building(defConstructor, 4, -5, 0)}, Paths: []Path{feed}}, []LintIssue{
+			{LintUnconnectedPort, Object{TypeBuilding, 1}, vec2(4, -10), "Constructor: belt output not connected"},
+		}},
+		{"reversed belts", ObjectCollection{Buildings: []Building{constructor}, Paths: []Path{path(defBelt, 4, 10, 4, 20), path(defBelt, 4, -10, 4, 0)}}, []LintIssue{
+			{LintReversedPath, Object{TypePath, 0}, vec2(4, 10), "Belt starts from a belt input"},
+			{LintReversedPath, Object{TypePath, 1}, vec2(4, 0), "Belt ends into a belt output"},
+		}},
+		{"reversed pipe", ObjectCollection{Buildings: []Building{constructor}, Paths: []Path{feed, path(defPipe, 4, -10, 4, 0)}}, nil},
+		{"end in building", ObjectCollection{Buildings: []Building{constructor}, Paths: []Path{feed, drain, path(defPipe, 20, 5, 6, 5)}}, []LintIssue{
+			{LintPathEndInBuilding, Object{TypePath, 2}, vec2(6, 5), "Pipe ends inside a Constructor"},
+		}},
+		{"through building", ObjectCollection{Buildings: []Building{constructor}, Paths: []Path{feed, drain, path(defPipe, -10, 5, 20, 5), path(defPipe, 8, 20, 8, -10)}}, []LintIssue{
+			{LintPathThroughBuild, Object{TypePath, 2}, vec2(4, 5), "Pipe crosses a Constructor"},
+		}},
+		{"overlapping", ObjectCollection{Paths: []Path{path(defBelt, 0, 0, 10, 0), path(defBelt, 10, 0, 20, 0), path(defPipe, 16, 0, 6, 0), path(defBelt, 10, 8, 10, 0)}}, []LintIssue{
+			{LintOverlappingPaths, Object{TypePath, 2}, vec2(8, 0), "Pipe runs over another Belt"},
+			{LintOverlappingPaths, Object{TypePath, 2}, vec2(13, 0), "Pipe runs over another Belt"},
+		}},
+		{"zero length", ObjectCollection{Paths: []Path{path(defBelt, 0, 0, 0, 0), path(defBelt, 0, 10, 10, 10)}}, []LintIssue{
+			{LintZeroLengthPath, Object{TypePath, 0}, vec2(0, 0), "Belt has a zero length segment"},
+		}},
+		{"across foundations", ObjectCollection{Buildings: []Building{building(defSplitter, 8, 2, 0), building(defSplitter, 2, 2, 0)}}, []LintIssue{
+			{LintFoundationEdges, Object{TypeBuilding, 0}, vec2(8, 2), "Splitter overlaps 2 foundations, 1 needed"},
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Lint(tt.oc, nil); !slices.Equal(got, tt.want) {
+				t.Errorf("Lint() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLintRules(t *testing.T) {
+	oc := ObjectCollection{
+		Buildings: []Building{building(defConstructor, 8, 5, 0)},
+		Paths:     []Path{path(defBelt, 0, 0, 0, 0)},
+	}
+	rules := func(issues []LintIssue) []string {
+		var names []string
+		for _, issue := range issues {
+			if !slices.Contains(names, issue.Rule) {
+				names = append(names, issue.Rule)
+			}
+		}
+		return names
+	}
+	if got, want := rules(Lint(oc, nil)), []string{LintUnconnectedPort, LintZeroLengthPath, LintFoundationEdges}; !slices.Equal(got, want) {
+		t.Errorf("Lint() rules = %v, want %v", got, want)
+	}
+	if got, want := rules(Lint(oc, []string{LintUnconnectedPort, LintFoundationEdges})), []string{LintZeroLengthPath}; !slices.Equal(got, want) {
+		t.Errorf("Lint(disabled) rules = %v, want %v", got, want)
+	}
+
+	// a custom rule, then replaced
+	saved := slices.Clone(lintRules)
+	t.Cleanup(func() { lintRules = saved })
+	RegisterLintRule(LintRule{"Custom", func(oc ObjectCollection, dst []LintIssue) []LintIssue {
+		return append(dst, LintIssue{Rule: "Custom"})
+	}})
+	if got, want := rules(Lint(oc, []string{LintUnconnectedPort})), []string{LintZeroLengthPath, LintFoundationEdges, "Custom"}; !slices.Equal(got, want) {
+		t.Errorf("Lint() rules = %v, want %v", got, want)
+	}
+	RegisterLintRule(LintRule{LintZeroLengthPath, func(oc ObjectCollection, dst []LintIssue) []LintIssue { return dst }})
+	if got := len(LintRules()); got != len(saved)+1 {
+		t.Errorf("%d rules, want %d", got, len(saved)+1)
+	}
+	// the returned rules are a copy
+	LintRules()[0].Name = "Changed"
+	if lintRules[0].Name != saved[0].Name {
+		t.Errorf("LintRules() modified the registered rule %s", lintRules[0].Name)
+	}
+	if got, want := rules(Lint(oc, []string{LintUnconnectedPort})), []string{LintFoundationEdges, "Custom"}; !slices.Equal(got, want) {
+		t.Errorf("Lint() rules = %v, want %v", got, want)
+	}
+}
+
+func TestLintManifolds(t *testing.T) {
+	setAssetsBuildingDefs(t)
+	plan, err := SolvePlan(testRecipeBook, []ItemAmount{{"Iron Plate", 50}}, nil, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	col, err := PlanLayout(testRecipeBook, plan, vec2(0, 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the generated layout is only open at the ends of the iron ore and iron plate manifolds, it is
+	// not aligned on foundations
+	var open []string
+	for _, issue := range Lint(col, []string{LintFoundationEdges}) {
+		if issue.Rule != LintUnconnectedPort {
+			t.Errorf("unexpected issue %v", issue)
+		} else {
+			open = append(open, issue.Msg)
+		}
+	}
+	if want := []string{"Splitter: no belt input connected", "Merger: no belt output connected"}; !slices.Equal(open, want) {
+		t.Errorf("unconnected ports = %v, want %v", open, want)
+	}
+}
@@ -0,0 +1,81 @@
+package scene
+
+import (
+	"os"
+	"testing"
+
+	"github.com/bonoboris/satisfied/log"
+	rl "github.com/bonoboris/satisfied/rlmath"
+)
+
+// Test definitions, indexed by the defXXX constants
+var (
+	testBuildingDefs = BuildingDefs{
+		{Class: "Constructor", Category: "Production", Dims: vec2(8, 10),
+			BeltIn:   InputOutputs{arr: [MAX_INOUT]InputOutput{{Pos: vec2(4, 10)}}, len: 1},
+			BeltOut:  InputOutputs{arr: [MAX_INOUT]InputOutput{{Pos: vec2(4, 0)}}, len: 1},
+			PowerUse: 4, Clockable: true, Somersloops: 1},
+		{Class: "Foundation", Category: "Structure", Dims: vec2(8, 8)},
+		{Class: "Splitter", Category: "Logistics", Dims: vec2(4, 4)},
+	}
+	testPathDefs = PathDefs{
+		{Class: "Belt", Width: 2, IsDirectional: true, BendRadius: 2},
+		{Class: "Pipe", Width: 1, BendRadius: 1},
+	}
+)
+
+const (
+	defConstructor = iota
+	defFoundation
+	defSplitter
+)
+
+const (
+	defBelt = iota
+	defPipe
+)
+
+func TestMain(m *testing.M) {
+	log.Init(log.Options{Level: log.ErrorLevel})
+	SetDefs(testBuildingDefs, testPathDefs)
+	os.Exit(m.Run())
+}
+
+func building(def int, x, y float32, rot int32) Building {
+	return Building{DefIdx: def, Pos: vec2(x, y), Rot: rot}
+}
+
+func path(def int, x1, y1, x2, y2 float32) Path {
+	return Path{DefIdx: def, Start: vec2(x1, y1), End: vec2(x2, y2)}
+}
+
+func textBox(x, y, w, h float32, content string) TextBox {
+	return TextBox{Bounds: rl.NewRectangle(x, y, w, h), Content: content}
+}
+
+// testCollection returns a small collection with non overlapping objects
+func testCollection() ObjectCollection {
+	return ObjectCollection{
+		Buildings: []Building{
+			building(defConstructor, 0, 0, 0),
+			building(defFoundation, 20, 0, 90),
+			building(defSplitter, 40, 0, 0),
+		},
+		Paths: []Path{
+			path(defBelt, 0, 20, 10, 20),
+			path(defPipe, 0, 30, 0, 40),
+			path(defBelt, 20, 20, 30, 30),
+		},
+		TextBoxes: []TextBox{
+			textBox(0, 50, 10, 5, "first"),
+			textBox(20, 50, 10, 5, "second"),
+		},
+	}
+}
+
+// newTestScene returns a scene containing [testCollection] without history.
+//
+// Objects IDs are given in order: buildings 1 to 3, paths 4 to 6 and text boxes 7 and 8.
+func newTestScene() *Scene {
+	return NewScene(testCollection())
+}
@@ -0,0 +1,134 @@
+// modifiers - Building clock speed, power shards and somersloops
+
+package scene
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/bonoboris/satisfied/math32"
+)
+
+const (
+	// Default clock speed, in percent
+	DefaultClock = 100
+	// Minimum clock speed, in percent
+	MinClock = 1
+	// Maximum clock speed, in percent
+	MaxClock = 250
+	// Maximum number of power shards of a building
+	MaxShards = 3
+	// Clock speed above [DefaultClock] unlocked by each power shard, in percent
+	ShardClock = 50
+	// Exponent of the clock speed in the power consumption formula
+	powerClockExponent = 1.321928
+)
+
+var (
+	ErrNotClockable   = errors.New("building cannot be overclocked")
+	ErrClockRange     = fmt.Errorf("clock speed must be between %d%% and %d%%", MinClock, MaxClock)
+	ErrShardsRange    = fmt.Errorf("power shards must be between 0 and %d", MaxShards)
+	ErrClockShards    = fmt.Errorf("clock speed above %d%% needs a power shard per %d%%", DefaultClock, ShardClock)
+	ErrSomersloopsMax = errors.New("more somersloops than the building slots")
+)
+
+// Modifiers are the clock speed, power shards and somersloops of a building.
+//
+// The zero value is the default: 100% clock speed, no power shards nor somersloops.
+type Modifiers struct {
+	// Clock speed in percent, 0 for [DefaultClock]
+	Clock float32
+	// Number of power shards
+	Shards int32
+	// Number of somersloops
+	Somersloops int32
+}
+
+func (m Modifiers) String() string {
+	return fmt.Sprintf("%v%% shards=%d sloops=%d", m.ClockSpeed(), m.Shards, m.Somersloops)
+}
+
+// ClockSpeed returns the clock speed, in percent
+func (m Modifiers) ClockSpeed() float32 {
+	if m.Clock == 0 {
+		return DefaultClock
+	}
+	return m.Clock
+}
+
+// Normalized returns m with a [DefaultClock] clock speed stored as 0, so that equal modifiers
+// compare equal
+func (m Modifiers) Normalized() Modifiers {
+	if m.Clock == DefaultClock {
+		m.Clock = 0
+	}
+	return m
+}
+
+// IsDefault returns true if m is the default: 100% clock speed, no power shards nor somersloops
+func (m Modifiers) IsDefault() bool { return m.Normalized() == Modifiers{} }
+
+// Validate returns why m cannot be applied to a building of definition def, if it cannot
+func (m Modifiers) Validate(def BuildingDef) error {
+	if m.IsDefault() {
+		return nil
+	}
+	clock := m.ClockSpeed()
+	// range checks are negated so that NaN is out of range
+	switch {
+	case !def.Clockable && (clock != DefaultClock || m.Shards != 0):
+		return ErrNotClockable
+	case !(clock >= MinClock && clock <= MaxClock):
+		return ErrClockRange
+	case !(m.Shards >= 0 && m.Shards <= MaxShards):
+		return ErrShardsRange
+	case clock > DefaultClock+ShardClock*float32(m.Shards):
+		return ErrClockShards
+	case m.Somersloops < 0 || m.Somersloops > def.Somersloops:
+		return ErrSomersloopsMax
+	}
+	return nil
+}
+
+// clockModifiers returns the modifiers of a building running at clock speed, with the power shards
+// it needs
+func clockModifiers(clock float32) Modifiers {
+	m := Modifiers{Clock: clock}
+	if clock > DefaultClock {
+		m.Shards = int32(math32.Ceil((clock - DefaultClock) / ShardClock))
+	}
+	return m.Normalized()
+}
+
+// amplification returns the production multiplier of the building somersloops
+func (b Building) amplification() float32 {
+	def := b.Def()
+	if def.Somersloops == 0 {
+		return 1
+	}
+	return 1 + float32(b.Mods.Somersloops)/float32(def.Somersloops)
+}
+
+// ProductionRate returns the multiplier of the building recipe rates: the clock speed times the
+// somersloops amplification
+func (b Building) ProductionRate() float32 {
+	return b.Mods.ClockSpeed() / DefaultClock * b.amplification()
+}
+
+// PowerUse returns the power consumed by the building, in MW.
+//
+// It grows with the clock speed to the power of ~1.32, and with the square of the somersloops
+// amplification.
+func (b Building) PowerUse() float32 {
+	def := b.Def()
+	if def.PowerUse == 0 {
+		return 0
+	}
+	amp := b.amplification()
+	return def.PowerUse * math32.Pow(b.Mods.ClockSpeed()/DefaultClock, powerClockExponent) * amp * amp
+}
+
+// PowerGen returns the power generated by the building, in MW, proportional to its clock speed
+func (b Building) PowerGen() float32 {
+	return b.Def().PowerGen * b.Mods.ClockSpeed() / DefaultClock
+}
@@ -0,0 +1,85 @@
+package scene
+
+import (
+	"errors"
+	"math"
+	"testing"
+
+	"github.com/bonoboris/satisfied/math32"
+)
+
+func TestModifiersValidate(t *testing.T) {
+	tests := []struct {
+		name   string
+		defIdx int
+		mods   Modifiers
+		want   error
+	}{
+		{"default", defConstructor, Modifiers{}, nil},
+		{"default explicit clock", defSplitter, Modifiers{Clock: 100}, nil},
+		{"underclock", defConstructor, Modifiers{Clock: 1}, nil},
+		{"overclock with shards", defConstructor, Modifiers{Clock: 200, Shards: 2}, nil},
+		{"max overclock", defConstructor, Modifiers{Clock: 250, Shards: 3, Somersloops: 1}, nil},
+		{"overclock without shards", defConstructor, Modifiers{Clock: 100.5}, ErrClockShards},
+		{"overclock with too few shards", defConstructor, Modifiers{Clock: 201, Shards: 2}, ErrClockShards},
+		{"clock too low", defConstructor, Modifiers{Clock: 0.5}, ErrClockRange},
+		{"clock too high", defConstructor, Modifiers{Clock: 300, Shards: 3}, ErrClockRange},
+		{"NaN clock", defConstructor, Modifiers{Clock: float32(math.NaN())}, ErrClockRange},
+		{"infinite clock", defConstructor, Modifiers{Clock: float32(math.Inf(1)), Shards: 3}, ErrClockRange},
+		{"negative infinite clock", defConstructor, Modifiers{Clock: float32(math.Inf(-1))}, ErrClockRange},
+		{"too many shards", defConstructor, Modifiers{Shards: 4}, ErrShardsRange},
+		{"negative shards", defConstructor, Modifiers{Shards: -1}, ErrShardsRange},
+		{"too many somersloops", defConstructor, Modifiers{Somersloops: 2}, ErrSomersloopsMax},
+		{"not clockable", defSplitter, Modifiers{Clock: 50}, ErrNotClockable},
+		{"no somersloop slots", defSplitter, Modifiers{Somersloops: 1}, ErrSomersloopsMax},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.mods.Validate(buildingDefs[tt.defIdx]); !errors.Is(got, tt.want) {
+				t.Errorf("Validate() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBuildingModifiersRates(t *testing.T) {
+	SetDefs(powerBuildingDefs, powerPathDefs)
+	t.Cleanup(func() { SetDefs(testBuildingDefs, testPathDefs) })
+
+	near := func(a, b float32) bool { return math32.Abs(a-b) < 1e-3 }
+	machine := building(powerDefMachine, 0, 0, 0)
+	generator := building(powerDefGenerator, 0, 0, 0)
+	tests := []struct {
+		name     string
+		mods     Modifiers
+		wantUse  float32
+		wantGen  float32
+		wantRate float32
+	}{
+		{"default", Modifiers{}, 60, 100, 1},
+		{"half clock", Modifiers{Clock: 50}, 60 * 0.4, 50, 0.5},
+		{"double clock", Modifiers{Clock: 200, Shards: 2}, 60 * 2.5, 200, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			machine.Mods, generator.Mods = tt.mods, tt.mods
+			if got := machine.PowerUse(); !near(got, tt.wantUse) {
+				t.Errorf("PowerUse() = %v, want %v", got, tt.wantUse)
+			}
+			if got := generator.PowerGen(); !near(got, tt.wantGen) {
+				t.Errorf("PowerGen() = %v, want %v", got, tt.wantGen)
+			}
+			if got := machine.ProductionRate(); !near(got, tt.wantRate) {
+				t.Errorf("ProductionRate() = %v, want %v", got, tt.wantRate)
+			}
+		})
+	}
+
+	// a full set of somersloops doubles the production and quadruples the power consumption
+	SetDefs(testBuildingDefs, testPathDefs)
+	b := building(defConstructor, 0, 0, 0)
+	b.Mods = Modifiers{Somersloops: 1}
+	if b.ProductionRate() != 2 || b.PowerUse() != 16 {
+		t.Errorf("with somersloops: ProductionRate() = %v, PowerUse() = %v, want 2, 16", b.ProductionRate(), b.PowerUse())
+	}
+}
@@ -1,13 +1,14 @@
 // objects - Contains struct and functions to represent object(s) and objects selection
 
-package app
+package scene
 
 import (
 	"fmt"
+	"math"
 	"slices"
 
 	"github.com/bonoboris/satisfied/math32"
-	rl "github.com/gen2brain/raylib-go/raylib"
+	rl "github.com/bonoboris/satisfied/rlmath"
 )
 
 ////////////////////////////////////////////////////////////////////////////////////////////////////
@@ -25,23 +26,15 @@ type Object struct {
 // IsEmpty returns true if [Object] is [TypeInvalid]
 func (o Object) IsEmpty() bool { return o.Type == TypeInvalid }
 
-// Draw draws the object in the given state
+// ID is a stable object identifier, unique in a scene, kept through edits, undo / redo and saves.
 //
-// Noop if [Object.Type] is [TypeInvalid]
-func (o Object) Draw(state DrawState) {
-	switch o.Type {
-	case TypeBuilding:
-		scene.Buildings[o.Idx].Draw(state)
-	case TypePath:
-		scene.Paths[o.Idx].Draw(state)
-	case TypePathStart:
-		scene.Paths[o.Idx].DrawStart(state)
-	case TypePathEnd:
-		scene.Paths[o.Idx].DrawEnd(state)
-	case TypeTextBox:
-		scene.TextBoxes[o.Idx].Draw(state, false)
-	}
-}
+// Unlike indices, IDs do not change when objects are deleted. The zero ID is not a valid
+// identifier, objects are given one when added to a [Scene].
+type ID uint64
+
+// MaxID is the highest ID accepted in a save, it leaves room for more new IDs than can ever be
+// created, so that they never wrap around to zero
+const MaxID ID = math.MaxUint32
 
 // ObjectType enumerates the different types of objects
 type ObjectType int
@@ -88,7 +81,8 @@ func (oc ObjectCollection) IsEmpty() bool {
 	return len(oc.Buildings) == 0 && len(oc.Paths) == 0 && len(oc.TextBoxes) == 0
 }
 
-func (oc ObjectCollection) clone() ObjectCollection {
+// Clone returns a deep copy of the collection
+func (oc ObjectCollection) Clone() ObjectCollection {
 	return ObjectCollection{
 		Buildings: slices.Clone(oc.Buildings),
 		Paths:     slices.Clone(oc.Paths),
@@ -96,29 +90,67 @@ func (oc ObjectCollection) clone() ObjectCollection {
 	}
 }
 
+// Find returns the building, path or text box (depending on typ) with the given ID, or an empty
+// object if there is none.
+func (oc ObjectCollection) Find(typ ObjectType, id ID) Object {
+	idx := -1
+	switch typ {
+	case TypeBuilding:
+		idx = slices.IndexFunc(oc.Buildings, func(b Building) bool { return b.ID == id })
+	case TypePath:
+		idx = slices.IndexFunc(oc.Paths, func(p Path) bool { return p.ID == id })
+	case TypeTextBox:
+		idx = slices.IndexFunc(oc.TextBoxes, func(tb TextBox) bool { return tb.ID == id })
+	}
+	if id == 0 || idx < 0 {
+		return Object{}
+	}
+	return Object{Type: typ, Idx: idx}
+}
+
+// maxID returns the highest object ID of the collection
+func (oc ObjectCollection) maxID() ID {
+	var res ID
+	for _, b := range oc.Buildings {
+		res = max(res, b.ID)
+	}
+	for _, p := range oc.Paths {
+		res = max(res, p.ID)
+	}
+	for _, tb := range oc.TextBoxes {
+		res = max(res, tb.ID)
+	}
+	return res
+}
+
 // SelectFromRect fills sel with the objects in the given rectangle and recomputes its bounding box
 //
 // sel must be empty, it is passed to avoid reallocating it
 func (oc ObjectCollection) SelectFromRect(sel *ObjectSelection, rect rl.Rectangle) {
 	xmin, ymin := math32.MaxFloat32, math32.MaxFloat32
 	xmax, ymax := -math32.MaxFloat32, -math32.MaxFloat32
-	for i, b := range scene.Buildings {
+	for i, b := range oc.Buildings {
 		bounds := b.Bounds()
 		tl := bounds.TopLeft()
 		br := bounds.BottomRight()
-		if rect.CheckCollisionPoint(tl) && rect.CheckCollisionPoint(br) {
+		if checkCollisionPointRec(tl, rect) && checkCollisionPointRec(br, rect) {
 			sel.BuildingIdxs = append(sel.BuildingIdxs, i)
 			xmin, ymin = min(xmin, tl.X), min(ymin, tl.Y)
 			xmax, ymax = max(xmax, br.X), max(ymax, br.Y)
 		}
 	}
-	for i, p := range scene.Paths {
-		start := rect.CheckCollisionPoint(p.Start)
-		end := rect.CheckCollisionPoint(p.End)
+	for i, p := range oc.Paths {
+		start := checkCollisionPointRec(p.Start, rect)
+		end := checkCollisionPointRec(p.End, rect)
 		if start && end {
 			sel.PathIdxs = append(sel.PathIdxs, PathSel{Idx: i, Start: true, End: true})
 			xmin, ymin = min(xmin, min(p.Start.X, p.End.X)), min(ymin, min(p.Start.Y, p.End.Y))
 			xmax, ymax = max(xmax, max(p.Start.X, p.End.X)), max(ymax, max(p.Start.Y, p.End.Y))
+			for j := range p.Vertices.Len() {
+				v := p.Vertices.Get(j)
+				xmin, ymin = min(xmin, v.X), min(ymin, v.Y)
+				xmax, ymax = max(xmax, v.X), max(ymax, v.Y)
+			}
 		} else if start {
 			sel.PathIdxs = append(sel.PathIdxs, PathSel{Idx: i, Start: true})
 			xmin, ymin = min(xmin, p.Start.X), min(ymin, p.Start.Y)
@@ -131,9 +163,10 @@ func (oc ObjectCollection) SelectFromRect(sel *ObjectSelection, rect rl.Rectangl
 	}
 
 	for i, tb := range oc.TextBoxes {
-		tl := tb.Bounds.TopLeft()
-		br := tb.Bounds.BottomRight()
-		if rect.CheckCollisionPoint(tl) && rect.CheckCollisionPoint(br) {
+		aabb := tb.AABB()
+		tl := aabb.TopLeft()
+		br := aabb.BottomRight()
+		if checkCollisionPointRec(tl, rect) && checkCollisionPointRec(br, rect) {
 			sel.TextBoxIdxs = append(sel.TextBoxIdxs, i)
 			xmin, ymin = min(xmin, tl.X), min(ymin, tl.Y)
 			xmax, ymax = max(xmax, br.X), max(ymax, br.Y)
@@ -173,8 +206,8 @@ func (os ObjectSelection) IsEmpty() bool {
 	return len(os.BuildingIdxs) == 0 && len(os.PathIdxs) == 0 && len(os.TextBoxIdxs) == 0
 }
 
-// Creates a clone of the selection
-func (os ObjectSelection) clone() ObjectSelection {
+// Clone returns a deep copy of the selection
+func (os ObjectSelection) Clone() ObjectSelection {
 	return ObjectSelection{
 		BuildingIdxs: slices.Clone(os.BuildingIdxs),
 		PathIdxs:     slices.Clone(os.PathIdxs),
@@ -183,16 +216,16 @@ func (os ObjectSelection) clone() ObjectSelection {
 	}
 }
 
-// copy the selection into the given [ObjectSelection] (clears the target)
-func (os ObjectSelection) copy(into *ObjectSelection) {
+// CopyTo copies the selection into the given [ObjectSelection] (clears the target)
+func (os ObjectSelection) CopyTo(into *ObjectSelection) {
 	into.BuildingIdxs = append(into.BuildingIdxs[:0], os.BuildingIdxs...)
 	into.PathIdxs = append(into.PathIdxs[:0], os.PathIdxs...)
 	into.TextBoxIdxs = append(into.TextBoxIdxs[:0], os.TextBoxIdxs...)
 	into.Bounds = os.Bounds
 }
 
-// reset the selection
-func (os *ObjectSelection) reset() {
+// Reset empties the selection
+func (os *ObjectSelection) Reset() {
 	os.BuildingIdxs = os.BuildingIdxs[:0]
 	os.PathIdxs = os.PathIdxs[:0]
 	os.TextBoxIdxs = os.TextBoxIdxs[:0]
@@ -241,8 +274,8 @@ func (os ObjectSelection) EndIdxs() []int {
 	return idxs
 }
 
-// recomputeBounds recomputes the selection bounding box
-func (os *ObjectSelection) recomputeBounds(oc ObjectCollection) {
+// RecomputeBounds recomputes the selection bounding box from the objects in oc
+func (os *ObjectSelection) RecomputeBounds(oc ObjectCollection) {
 	xmin := math32.MaxFloat32
 	ymin := math32.MaxFloat32
 	xmax := -math32.MaxFloat32
@@ -262,9 +295,16 @@ func (os *ObjectSelection) recomputeBounds(oc ObjectCollection) {
 			xmin, xmax = min(xmin, p.End.X), max(xmax, p.End.X)
 			ymin, ymax = min(ymin, p.End.Y), max(ymax, p.End.Y)
 		}
+		if pSel.Start && pSel.End {
+			for i := range p.Vertices.Len() {
+				v := p.Vertices.Get(i)
+				xmin, xmax = min(xmin, v.X), max(xmax, v.X)
+				ymin, ymax = min(ymin, v.Y), max(ymax, v.Y)
+			}
+		}
 	}
 	for _, idx := range os.TextBoxIdxs {
-		bounds := oc.TextBoxes[idx].Bounds
+		bounds := oc.TextBoxes[idx].AABB()
 		xmin, xmax = min(xmin, bounds.X), max(xmax, bounds.X+bounds.Width)
 		ymin, ymax = min(ymin, bounds.Y), max(ymax, bounds.Y+bounds.Height)
 	}
@@ -385,7 +425,7 @@ func NewPathSelMaskIterator(trueIdx []PathSel) PathSelMaskIterator {
 
 // Next returns the next pair of (start, end) from the mask.
 //
-// It always returns (false, flase) when all the true values have been iterated over
+// It always returns (false, false) when all the true values have been iterated over
 // [PathSelMaskIterator.Idx] counter.
 func (it *PathSelMaskIterator) Next() (bool, bool) {
 	if it.i == len(it.TrueIdxs) {
@@ -0,0 +1,175 @@
+package scene
+
+import (
+	"slices"
+	"testing"
+
+	rl "github.com/bonoboris/satisfied/rlmath"
+)
+
+func TestSelectFromRect(t *testing.T) {
+	oc := testCollection()
+	tests := []struct {
+		name       string
+		rect       rl.Rectangle
+		want       ObjectSelection
+		wantBounds rl.Rectangle
+	}{
+		{"nothing", rl.NewRectangle(100, 100, 10, 10), ObjectSelection{}, rl.Rectangle{}},
+		{
+			"building fully inside",
+			rl.NewRectangle(-5, -6, 10, 12),
+			ObjectSelection{BuildingIdxs: []int{0}},
+			rl.NewRectangle(-4, -5, 8, 10),
+		},
+		{
+			"building partially inside",
+			rl.NewRectangle(-3, -6, 10, 12),
+			ObjectSelection{},
+			rl.Rectangle{},
+		},
+		{
+			"path ends",
+			rl.NewRectangle(-1, 19, 2, 20),
+			ObjectSelection{PathIdxs: []PathSel{{Idx: 0, Start: true}, {Idx: 1, Start: true}}},
+			rl.NewRectangle(0, 20, 0, 10),
+		},
+		{
+			"everything",
+			rl.NewRectangle(-100, -100, 200, 200),
+			ObjectSelection{
+				BuildingIdxs: []int{0, 1, 2},
+				PathIdxs: []PathSel{
+					{Idx: 0, Start: true, End: true},
+					{Idx: 1, Start: true, End: true},
+					{Idx: 2, Start: true, End: true},
+				},
+				TextBoxIdxs: []int{0, 1},
+			},
+			rl.NewRectangle(-4, -5, 46, 60),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var sel ObjectSelection
+			oc.SelectFromRect(&sel, tt.rect)
+			if !slices.Equal(sel.BuildingIdxs, tt.want.BuildingIdxs) ||
+				!slices.Equal(sel.PathIdxs, tt.want.PathIdxs) ||
+				!slices.Equal(sel.TextBoxIdxs, tt.want.TextBoxIdxs) {
+				t.Errorf("SelectFromRect() = %v %v %v, want %v %v %v",
+					sel.BuildingIdxs, sel.PathIdxs, sel.TextBoxIdxs,
+					tt.want.BuildingIdxs, tt.want.PathIdxs, tt.want.TextBoxIdxs)
+			}
+			if sel.Bounds != tt.wantBounds {
+				t.Errorf("SelectFromRect() bounds = %v, want %v", sel.Bounds, tt.wantBounds)
+			}
+			if !sel.IsEmpty() {
+				// bounds must match the recomputed ones
+				recomputed := sel.Clone()
+				recomputed.RecomputeBounds(oc)
+				if recomputed.Bounds != sel.Bounds {
+					t.Errorf("RecomputeBounds() = %v, want %v", recomputed.Bounds, sel.Bounds)
+				}
+			}
+		})
+	}
+}
+
+func TestObjectSelectionPathIdxs(t *testing.T) {
+	sel := ObjectSelection{PathIdxs: []PathSel{
+		{Idx: 1, Start: true, End: true},
+		{Idx: 3, Start: true},
+		{Idx: 4, End: true},
+	}}
+	if got := sel.FullPathIdxs(); !slices.Equal(got, []int{1}) {
+		t.Errorf("FullPathIdxs() = %v, want [1]", got)
+	}
+	if got := sel.AnyPathIdxs(); !slices.Equal(got, []int{1, 3, 4}) {
+		t.Errorf("AnyPathIdxs() = %v, want [1 3 4]", got)
+	}
+	if got := sel.StartIdxs(); !slices.Equal(got, []int{1, 3}) {
+		t.Errorf("StartIdxs() = %v, want [1 3]", got)
+	}
+	if got := sel.EndIdxs(); !slices.Equal(got, []int{1, 4}) {
+		t.Errorf("EndIdxs() = %v, want [1 4]", got)
+	}
+}
+
+func TestObjectSelectionContains(t *testing.T) {
+	sel := ObjectSelection{
+		BuildingIdxs: []int{0, 2},
+		PathIdxs:     []PathSel{{Idx: 1, Start: true, End: true}, {Idx: 2, Start: true}},
+		TextBoxIdxs:  []int{1},
+	}
+	tests := []struct {
+		obj  Object
+		want bool
+	}{
+		{Object{}, false},
+		{Object{Type: TypeBuilding, Idx: 0}, true},
+		{Object{Type: TypeBuilding, Idx: 1}, false},
+		{Object{Type: TypePath, Idx: 1}, true},
+		{Object{Type: TypePath, Idx: 2}, false},
+		{Object{Type: TypePathStart, Idx: 2}, true},
+		{Object{Type: TypePathEnd, Idx: 2}, false},
+		{Object{Type: TypePathEnd, Idx: 1}, true},
+		{Object{Type: TypeTextBox, Idx: 1}, true},
+		{Object{Type: TypeTextBox, Idx: 0}, false},
+	}
+	for _, tt := range tests {
+		if got := sel.Contains(tt.obj); got != tt.want {
+			t.Errorf("Contains(%v %d) = %v, want %v", tt.obj.Type, tt.obj.Idx, got, tt.want)
+		}
+	}
+}
+
+func TestObjectSelectionCopyReset(t *testing.T) {
+	sel := ObjectSelection{
+		BuildingIdxs: []int{0, 2},
+		PathIdxs:     []PathSel{{Idx: 1, Start: true}},
+		TextBoxIdxs:  []int{1},
+		Bounds:       rl.NewRectangle(1, 2, 3, 4),
+	}
+	into := ObjectSelection{BuildingIdxs: []int{5, 6, 7}}
+	sel.CopyTo(&into)
+	if !slices.Equal(into.BuildingIdxs, sel.BuildingIdxs) || into.Bounds != sel.Bounds {
+		t.Errorf("CopyTo() = %v, want %v", into, sel)
+	}
+	into.BuildingIdxs[0] = 9
+	if sel.BuildingIdxs[0] != 0 {
+		t.Error("CopyTo() target aliases the source")
+	}
+
+	clone := sel.Clone()
+	clone.PathIdxs[0].End = true
+	if sel.PathIdxs[0].End {
+		t.Error("Clone() aliases the source")
+	}
+
+	sel.Reset()
+	if !sel.IsEmpty() || sel.Bounds != (rl.Rectangle{}) {
+		t.Errorf("Reset() = %v, want empty", sel)
+	}
+}
+
+func TestMaskIterators(t *testing.T) {
+	it := NewMaskIterator([]int{1, 2, 4})
+	var got []bool
+	for range 6 {
+		got = append(got, it.Next())
+	}
+	if want := []bool{false, true, true, false, true, false}; !slices.Equal(got, want) {
+		t.Errorf("MaskIterator = %v, want %v", got, want)
+	}
+
+	pit := NewPathSelMaskIterator([]PathSel{{Idx: 0, Start: true}, {Idx: 2, Start: true, End: true}, {Idx: 3, End: true}})
+	var gotPairs [][2]bool
+	for range 5 {
+		start, end := pit.Next()
+		gotPairs = append(gotPairs, [2]bool{start, end})
+	}
+	wantPairs := [][2]bool{{true, false}, {false, false}, {true, true}, {false, true}, {false, false}}
+	if !slices.Equal(gotPairs, wantPairs) {
+		t.Errorf("PathSelMaskIterator = %v, want %v", gotPairs, wantPairs)
+	}
+}
@@ -0,0 +1,157 @@
+// packs - User supplied building and path definitions packs
+
+package scene
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+const (
+	// PackFileSuffix is the suffix of the definitions pack files, the pack name is the file name
+	// without it
+	PackFileSuffix = "_defs.json"
+	// PackSeparator separates the pack name from the class in a pack definition class
+	PackSeparator = ":"
+)
+
+// Pack is a set of building and path definitions supplied by the user, their classes are namespaced
+// with the pack name: "[pack]:[class]"
+type Pack struct {
+	Name      string
+	Buildings BuildingDefs
+	Paths     PathDefs
+}
+
+// PackError is returned when a pack cannot be parsed or merged with the other definitions
+type PackError struct {
+	Pack string
+	Msg  string
+	Err  error
+}
+
+const (
+	msgInvalidPackName = "invalid pack name, expected no spaces, ':' nor ','"
+	msgInvalidPackJSON = "invalid pack file, expected '{\"Buildings\": [...], \"Paths\": [...]}'"
+	msgEmptyPackClass  = "empty class"
+	msgInvalidPackDims = "invalid building dimensions, expected positive numbers"
+	msgInvalidPackPath = "invalid path width, expected a positive number"
+	msgDuplicatePack   = "duplicate pack name"
+	msgConflictClass   = "class already defined"
+)
+
+func (e PackError) Error() string {
+	if e.Err != nil {
+		return fmt.Sprintf("pack %q: %s (%s)", e.Pack, e.Msg, e.Err.Error())
+	}
+	return fmt.Sprintf("pack %q: %s", e.Pack, e.Msg)
+}
+
+// isValidPackName returns whether name can namespace classes and be listed in a save
+func isValidPackName(name string) bool {
+	return name != "" && !strings.ContainsAny(name, " \t\r\n"+PackSeparator+",")
+}
+
+// ParsePack decodes the content of a pack file and namespaces its classes with the pack name
+func ParsePack(name string, data []byte) (Pack, error) {
+	if !isValidPackName(name) {
+		return Pack{}, PackError{Pack: name, Msg: msgInvalidPackName}
+	}
+	pack := Pack{Name: name}
+	if err := json.Unmarshal(data, &pack); err != nil {
+		return Pack{}, PackError{Pack: name, Msg: msgInvalidPackJSON, Err: err}
+	}
+	pack.Name = name
+	for i, def := range pack.Buildings {
+		if def.Class == "" {
+			return Pack{}, PackError{Pack: name, Msg: msgEmptyPackClass}
+		}
+		if def.Dims.X <= 0 || def.Dims.Y <= 0 {
+			return Pack{}, PackError{Pack: name, Msg: msgInvalidPackDims, Err: errors.New(def.Class)}
+		}
+		pack.Buildings[i].Class = name + PackSeparator + def.Class
+		pack.Buildings[i].Pack = name
+	}
+	for i, def := range pack.Paths {
+		if def.Class == "" {
+			return Pack{}, PackError{Pack: name, Msg: msgEmptyPackClass}
+		}
+		if def.Width <= 0 {
+			return Pack{}, PackError{Pack: name, Msg: msgInvalidPackPath, Err: errors.New(def.Class)}
+		}
+		pack.Paths[i].Class = name + PackSeparator + def.Class
+		pack.Paths[i].Pack = name
+	}
+	return pack, nil
+}
+
+// MergePacks returns the built-in definitions followed by the packs ones.
+//
+// A pack whose name is already used or defining an already defined class is left out, the returned
+// error joins a [PackError] for each.
+func MergePacks(buildings BuildingDefs, paths PathDefs, packs []Pack) (BuildingDefs, PathDefs, error) {
+	buildings, paths = slices.Clip(buildings), slices.Clip(paths)
+	var errs []error
+	var names []string
+	for _, pack := range packs {
+		if slices.Contains(names, pack.Name) {
+			errs = append(errs, PackError{Pack: pack.Name, Msg: msgDuplicatePack})
+			continue
+		}
+		if class, ok := conflictingClass(buildings, paths, pack); ok {
+			errs = append(errs, PackError{Pack: pack.Name, Msg: msgConflictClass, Err: errors.New(class)})
+			continue
+		}
+		names = append(names, pack.Name)
+		buildings = append(buildings, pack.Buildings...)
+		paths = append(paths, pack.Paths...)
+	}
+	return buildings, paths, errors.Join(errs...)
+}
+
+// conflictingClass returns the first class of pack already defined in buildings or paths, or in
+// pack itself
+func conflictingClass(buildings BuildingDefs, paths PathDefs, pack Pack) (string, bool) {
+	var classes []string
+	classes = append(classes, buildings.Classes()...)
+	classes = append(classes, paths.Classes()...)
+	for _, def := range pack.Buildings {
+		if slices.Contains(classes, def.Class) {
+			return def.Class, true
+		}
+		classes = append(classes, def.Class)
+	}
+	for _, def := range pack.Paths {
+		if slices.Contains(classes, def.Class) {
+			return def.Class, true
+		}
+		classes = append(classes, def.Class)
+	}
+	return "", false
+}
+
+// isPackLoaded returns whether a definition of the pack is registered (see [SetDefs])
+func isPackLoaded(name string) bool {
+	return slices.ContainsFunc(buildingDefs, func(def BuildingDef) bool { return def.Pack == name }) ||
+		slices.ContainsFunc(pathDefs, func(def PathDef) bool { return def.Pack == name })
+}
+
+// Packs returns the sorted names of the packs defining the collection buildings and paths
+func (oc ObjectCollection) Packs() []string {
+	var packs []string
+	for _, b := range oc.Buildings {
+		if pack := b.Def().Pack; pack != "" && !slices.Contains(packs, pack) {
+			packs = append(packs, pack)
+		}
+	}
+	for _, p := range oc.Paths {
+		if pack := p.Def().Pack; pack != "" && !slices.Contains(packs, pack) {
+			packs = append(packs, pack)
+		}
+	}
+	slices.Sort(packs)
+	return packs
+}
@@ -0,0 +1,128 @@
+package scene
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+)
+
+const testPackJSON = `{
+	"Buildings": [{"Class": "Big Machine", "Category": "Production", "Dims": {"X": 10, "Y": 10},
+		"Cost": [{"Item": "Iron Plate", "Amount": 10}]}],
+	"Paths": [{"Class": "Belt", "Width": 2, "Color": "#000000",
+		"Tiers": [{"Name": "Mk.1", "Cost": [{"Item": "Iron Plate", "Amount": 1}]}]}]
+}`
+
+func TestParsePack(t *testing.T) {
+	pack, err := ParsePack("mod", []byte(testPackJSON))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pack.Buildings) != 1 || pack.Buildings[0].Class != "mod:Big Machine" || pack.Buildings[0].Pack != "mod" {
+		t.Errorf("ParsePack() Buildings = %v, want namespaced mod:Big Machine", pack.Buildings)
+	}
+	if len(pack.Paths) != 1 || pack.Paths[0].Class != "mod:Belt" || pack.Paths[0].BendRadius != 2 {
+		t.Errorf("ParsePack() Paths = %v, want namespaced mod:Belt", pack.Paths)
+	}
+	if cost := pack.Buildings[0].Cost; len(cost) != 1 || cost[0] != (ItemAmount{"Iron Plate", 10}) {
+		t.Errorf("ParsePack() building Cost = %v, want [10 Iron Plate]", cost)
+	}
+	if tiers := pack.Paths[0].Tiers; len(tiers) != 1 || tiers[0].Name != "Mk.1" || len(tiers[0].Cost) != 1 {
+		t.Errorf("ParsePack() path Tiers = %v, want [Mk.1]", tiers)
+	}
+
+	tests := []struct {
+		name, pack, data, wantMsg string
+	}{
+		{"invalid name", "my mod", testPackJSON, msgInvalidPackName},
+		{"separator in name", "a:b", testPackJSON, msgInvalidPackName},
+		{"invalid json", "mod", `[]`, msgInvalidPackJSON},
+		{"empty class", "mod", `{"Buildings": [{"Dims": {"X": 1, "Y": 1}}]}`, msgEmptyPackClass},
+		{"no dims", "mod", `{"Buildings": [{"Class": "A"}]}`, msgInvalidPackDims},
+		{"no width", "mod", `{"Paths": [{"Class": "A", "Color": "#000000"}]}`, msgInvalidPackPath},
+		{"invalid color", "mod", `{"Paths": [{"Class": "A", "Width": 1, "Color": "black"}]}`, msgInvalidPackJSON},
+	}
+	for _, tt := range tests {
+		_, err := ParsePack(tt.pack, []byte(tt.data))
+		var packErr PackError
+		if !errors.As(err, &packErr) || packErr.Msg != tt.wantMsg {
+			t.Errorf("%s: ParsePack() error = %v, want %q", tt.name, err, tt.wantMsg)
+		}
+	}
+}
+
+func TestMergePacks(t *testing.T) {
+	pack, err := ParsePack("mod", []byte(testPackJSON))
+	if err != nil {
+		t.Fatal(err)
+	}
+	conflict, err := ParsePack("other", []byte(`{"Paths": [
+		{"Class": "Pipe", "Width": 1, "Color": "#000000"},
+		{"Class": "Pipe", "Width": 1, "Color": "#000000"}
+	]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	buildings, paths, err := MergePacks(testBuildingDefs, testPathDefs, []Pack{pack, conflict, pack})
+	if len(buildings) != len(testBuildingDefs)+1 || len(paths) != len(testPathDefs)+1 {
+		t.Errorf("MergePacks() = %v %v, want the built-in definitions and mod ones", buildings, paths)
+	}
+	if len(testBuildingDefs) != 3 {
+		t.Errorf("MergePacks() modified the built-in definitions")
+	}
+	for _, want := range []string{`pack "other": ` + msgConflictClass + " (other:Pipe)", `pack "mod": ` + msgDuplicatePack} {
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("MergePacks() error = %v, want %q", err, want)
+		}
+	}
+}
+
+func TestScenePacksSaveLoad(t *testing.T) {
+	pack, err := ParsePack("mod", []byte(testPackJSON))
+	if err != nil {
+		t.Fatal(err)
+	}
+	buildings, paths, err := MergePacks(testBuildingDefs, testPathDefs, []Pack{pack})
+	if err != nil {
+		t.Fatal(err)
+	}
+	SetDefs(buildings, paths)
+	t.Cleanup(func() { SetDefs(testBuildingDefs, testPathDefs) })
+
+	s := newTestScene()
+	s.AddBuilding(building(len(testBuildingDefs), 100, 100, 0))
+	var buf bytes.Buffer
+	if err := s.SaveToText(&buf); err != nil {
+		t.Fatal(err)
+	}
+	save := buf.String()
+	if !strings.Contains(save, "\n#PACKS=mod\n") || !strings.Contains(save, " mod:Big Machine 100 100 0 0 100 0 0\n") {
+		t.Errorf("SaveToText() = %q, want mod pack and building", save)
+	}
+	var loaded Scene
+	if err := loaded.LoadFromText(strings.NewReader(save)); err != nil {
+		t.Fatal(err)
+	}
+	assertCollection(t, &loaded, s.ObjectCollection)
+
+	// without the pack
+	SetDefs(testBuildingDefs, testPathDefs)
+	tests := []struct {
+		name, text string
+		wantLine   int
+	}{
+		{"listed", save, 3},
+		{"version 3", "#VERSION=3\n#LASTID=1\n1 mod:Big Machine 0 0 0 0\n", 3},
+	}
+	for _, tt := range tests {
+		var decodeErr DecodeTextError
+		err := new(Scene).LoadFromText(strings.NewReader(tt.text))
+		if !errors.As(err, &decodeErr) || decodeErr.Msg != msgMissingPacks || decodeErr.Line != tt.wantLine {
+			t.Errorf("%s: LoadFromText() error = %v, want %q line %d", tt.name, err, msgMissingPacks, tt.wantLine)
+		} else if decodeErr.Err == nil || decodeErr.Err.Error() != "mod" {
+			t.Errorf("%s: LoadFromText() error = %v, want the mod pack", tt.name, err)
+		}
+	}
+}
@@ -0,0 +1,351 @@
+package scene
+
+import (
+	"encoding/json"
+	"fmt"
+	"image/color"
+	"slices"
+	"strconv"
+
+	"github.com/bonoboris/satisfied/colors"
+	"github.com/bonoboris/satisfied/matrix"
+	rl "github.com/bonoboris/satisfied/rlmath"
+)
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
+// Path
+////////////////////////////////////////////////////////////////////////////////////////////////////
+
+type Path struct {
+	ID         ID
+	DefIdx     int
+	Start, End rl.Vector2
+	// Intermediate vertices, from start to end
+	Vertices PathVertices
+	// Drawn as a curve through its vertices (like in-game curved belts) instead of straight segments
+	Spline bool
+}
+
+func (p Path) String() string {
+	class := "<invalid>"
+	if p.DefIdx != -1 {
+		class = p.Def().Class
+	}
+	s := fmt.Sprintf("%s{%v %v %v %v", class, p.Start.X, p.Start.Y, p.End.X, p.End.Y)
+	if p.Vertices.Len() > 0 {
+		s += fmt.Sprintf(" vertices=%v", p.Vertices)
+	}
+	if p.Spline {
+		s += " spline"
+	}
+	return s + "}"
+}
+
+func (p Path) Def() PathDef { return pathDefs[p.DefIdx] }
+
+// IsValid returns true if the path has no zero length segment, and is not longer than its
+// definition [PathDef.MaxLength]
+func (p Path) IsValid() bool {
+	var buf [MAX_PATH_VERTICES + 2]rl.Vector2
+	points := p.Points(buf[:0])
+	for i := 1; i < len(points); i++ {
+		if points[i] == points[i-1] {
+			return false
+		}
+	}
+	if maxLength := p.Def().MaxLength; maxLength > 0 && p.Length() > maxLength {
+		return false
+	}
+	return true
+}
+
+// Length returns the length of the path as drawn
+func (p Path) Length() float32 {
+	var buf [MAX_POLYLINE_POINTS]rl.Vector2
+	points := p.Polyline(buf[:0])
+	var length float32
+	for i := 1; i < len(points); i++ {
+		length += points[i-1].Distance(points[i])
+	}
+	return length
+}
+
+// Points appends the path start, vertices and end to dst and returns the extended slice
+func (p Path) Points(dst []rl.Vector2) []rl.Vector2 {
+	dst = append(dst, p.Start)
+	dst = append(dst, p.Vertices.arr[:p.Vertices.len]...)
+	return append(dst, p.End)
+}
+
+// Polyline appends the points of the path as drawn to dst and returns the extended slice: its
+// points, or the sampled curve for a spline path
+func (p Path) Polyline(dst []rl.Vector2) []rl.Vector2 {
+	if !p.Spline || p.Vertices.Len() == 0 {
+		return p.Points(dst)
+	}
+	var buf [MAX_PATH_VERTICES + 2]rl.Vector2
+	return sampleCatmullRom(dst, p.Points(buf[:0]), splineSamples)
+}
+
+// Bounds returns the bounding box of the path body, with its width
+func (p Path) Bounds() rl.Rectangle {
+	var buf [MAX_POLYLINE_POINTS]rl.Vector2
+	points := p.Polyline(buf[:0])
+	min, max := points[0], points[0]
+	for _, pt := range points[1:] {
+		min, max = min.Min(pt), max.Max(pt)
+	}
+	hw := p.Def().Width / 2
+	return rl.NewRectangle(min.X-hw, min.Y-hw, max.X-min.X+2*hw, max.Y-min.Y+2*hw)
+}
+
+// Translate returns the path translated by delta
+func (p Path) Translate(delta rl.Vector2) Path {
+	return p.Transform(matrix.NewTranslateV(delta))
+}
+
+// Transform returns the path with its start, end and vertices transformed by mat
+func (p Path) Transform(mat matrix.Matrix) Path {
+	p.Start = mat.ApplyV(p.Start)
+	p.End = mat.ApplyV(p.End)
+	for i := range p.Vertices.len {
+		p.Vertices.arr[i] = mat.ApplyV(p.Vertices.arr[i])
+	}
+	return p
+}
+
+// Reverse returns the path with its start and end, and its vertices order, reversed
+func (p Path) Reverse() Path {
+	p.Start, p.End = p.End, p.Start
+	slices.Reverse(p.Vertices.arr[:p.Vertices.len])
+	return p
+}
+
+// SegmentMiddle returns the middle of the i-th segment, between the i-th and (i+1)-th points
+func (p Path) SegmentMiddle(i int) rl.Vector2 {
+	var buf [MAX_PATH_VERTICES + 2]rl.Vector2
+	points := p.Points(buf[:0])
+	return points[i].Add(points[i+1]).Scale(0.5)
+}
+
+// MoveVertex returns the path with its i-th vertex moved to pos, the vertex is removed when moved
+// onto one of its neighbour points
+func (p Path) MoveVertex(i int, pos rl.Vector2) Path {
+	var buf [MAX_PATH_VERTICES + 2]rl.Vector2
+	points := p.Points(buf[:0])
+	// vertex i is the point i+1
+	if pos == points[i] || pos == points[i+2] {
+		p.Vertices.Remove(i)
+	} else {
+		p.Vertices.Set(i, pos)
+	}
+	return p
+}
+
+// Returns true if the given position is inside the path start.
+func (p Path) CheckStartCollisionPoint(pos rl.Vector2) bool {
+	return checkCollisionPointCircle(pos, p.Start, p.Def().Width/2)
+}
+
+// Returns true if the given position is inside the path end.
+func (p Path) CheckEndCollisionPoint(pos rl.Vector2) bool {
+	return checkCollisionPointCircle(pos, p.End, p.Def().Width/2)
+}
+
+// Returns true if the given position is inside the path body.
+func (p Path) CheckCollisionPoint(pos rl.Vector2) bool {
+	width := p.Def().Width
+	var buf [MAX_POLYLINE_POINTS]rl.Vector2
+	points := p.Polyline(buf[:0])
+	for i := 1; i < len(points); i++ {
+		if checkCollisionSegmentPoint(points[i-1], points[i], width, pos) {
+			return true
+		}
+		// corner joins
+		if i > 1 && checkCollisionPointCircle(pos, points[i-1], width/2) {
+			return true
+		}
+	}
+	return false
+}
+
+// checkCollisionSegmentPoint returns true if pos is inside the segment [start, end] of the given width
+func checkCollisionSegmentPoint(start, end rl.Vector2, width float32, pos rl.Vector2) bool {
+	lengthSqr := start.DistanceSqr(end)
+	angle := start.LineAngle(end)
+	transform := matrix.NewRotateRad(angle).TranslateV(start.Negate())
+	tpos := transform.ApplyV(pos)
+	return tpos.X >= 0 && tpos.X*tpos.X <= lengthSqr && tpos.Y >= -width/2 && tpos.Y <= width/2
+}
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
+// Path vertices
+////////////////////////////////////////////////////////////////////////////////////////////////////
+
+const MAX_PATH_VERTICES = 16
+
+// MAX_POLYLINE_POINTS is the maximum number of points of a path polyline (see [Path.Polyline])
+const MAX_POLYLINE_POINTS = (MAX_PATH_VERTICES+1)*splineSamples + 1
+
+// number of sampled segments between 2 vertices of a spline path
+const splineSamples = 8
+
+// PathVertices is a fixed capacity list of path intermediate vertices (keeps [Path] comparable)
+type PathVertices struct {
+	arr [MAX_PATH_VERTICES]rl.Vector2
+	len int
+}
+
+// NewPathVertices returns a list of the given vertices, extra vertices are ignored
+func NewPathVertices(vertices ...rl.Vector2) PathVertices {
+	var res PathVertices
+	res.len = copy(res.arr[:], vertices)
+	return res
+}
+
+// Len returns the number of vertices
+func (v PathVertices) Len() int { return v.len }
+
+// Get returns the i-th vertex
+func (v PathVertices) Get(i int) rl.Vector2 { return v.arr[i] }
+
+// Set sets the i-th vertex
+func (v *PathVertices) Set(i int, pos rl.Vector2) { v.arr[i] = pos }
+
+// IsFull returns true if no vertex can be added
+func (v PathVertices) IsFull() bool { return v.len == MAX_PATH_VERTICES }
+
+// Insert inserts a vertex at index i, it returns false if the list is full
+func (v *PathVertices) Insert(i int, pos rl.Vector2) bool {
+	if v.IsFull() {
+		return false
+	}
+	copy(v.arr[i+1:v.len+1], v.arr[i:v.len])
+	v.arr[i] = pos
+	v.len++
+	return true
+}
+
+// Append appends a vertex, it returns false if the list is full
+func (v *PathVertices) Append(pos rl.Vector2) bool { return v.Insert(v.len, pos) }
+
+// Remove removes the i-th vertex
+func (v *PathVertices) Remove(i int) {
+	copy(v.arr[i:v.len-1], v.arr[i+1:v.len])
+	v.arr[v.len-1] = rl.Vector2{}
+	v.len--
+}
+
+func (v PathVertices) String() string {
+	return fmt.Sprintf("%v", v.arr[:v.len])
+}
+
+// sampleCatmullRom appends to dst the points of the Catmull-Rom spline going through points, with
+// n segments between 2 consecutive points, and returns the extended slice
+func sampleCatmullRom(dst []rl.Vector2, points []rl.Vector2, n int) []rl.Vector2 {
+	last := len(points) - 1
+	dst = append(dst, points[0])
+	for i := range last {
+		p0, p1, p2, p3 := points[max(i-1, 0)], points[i], points[i+1], points[min(i+2, last)]
+		for j := 1; j < n; j++ {
+			t := float32(j) / float32(n)
+			t2, t3 := t*t, t*t*t
+			pt := p1.Scale(2).
+				Add(p2.Subtract(p0).Scale(t)).
+				Add(p0.Scale(2).Subtract(p1.Scale(5)).Add(p2.Scale(4)).Subtract(p3).Scale(t2)).
+				Add(p1.Scale(3).Subtract(p0).Subtract(p2.Scale(3)).Add(p3).Scale(t3)).
+				Scale(0.5)
+			dst = append(dst, pt)
+		}
+		dst = append(dst, p2)
+	}
+	return dst
+}
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
+// PathDef
+////////////////////////////////////////////////////////////////////////////////////////////////////
+
+type PathDef struct {
+	Class         string
+	Width         float32
+	Color         color.RGBA
+	IsDirectional bool
+	// Minimum distance from a bend to a port or another bend, used by [Scene.Route]
+	BendRadius float32
+	// Power line: a single segment between 2 building power connectors (see [PowerNetwork])
+	IsPowerLine bool
+	// Maximum length of a power line, unlimited if not set
+	MaxLength float32
+	// Tiers (e.g. belt marks) and their cost, the paths are costed with a tier chosen for the
+	// bill of materials (see [NewBOM])
+	Tiers []PathTier
+	// Name of the pack defining it, empty for built-in definitions
+	Pack string `json:"-"`
+}
+
+func (def PathDef) String() string {
+	return fmt.Sprintf("{%s W=%v, directional=%v}", def.Class, def.Width, def.IsDirectional)
+}
+
+func (def *PathDef) UnmarshalJSON(data []byte) error {
+	type JsonPathDef struct {
+		Class         string
+		Width         float32
+		Color         string
+		IsDirectional bool
+		BendRadius    *float32
+		IsPowerLine   bool
+		MaxLength     float32
+		Tiers         []PathTier
+	}
+	var jsonDef JsonPathDef
+	err := json.Unmarshal(data, &jsonDef)
+	if err != nil {
+		return err
+	}
+	if !isHexColor(jsonDef.Color) {
+		return fmt.Errorf("invalid color %q, expected '#rrggbb'", jsonDef.Color)
+	}
+	def.Class = jsonDef.Class
+	def.Width = jsonDef.Width
+	def.Color = colors.NewColorFromHex(jsonDef.Color)
+	def.IsDirectional = jsonDef.IsDirectional
+	def.IsPowerLine = jsonDef.IsPowerLine
+	def.MaxLength = jsonDef.MaxLength
+	def.Tiers = jsonDef.Tiers
+	if jsonDef.BendRadius != nil {
+		def.BendRadius = *jsonDef.BendRadius
+	} else {
+		def.BendRadius = jsonDef.Width
+	}
+	return nil
+}
+
+// isHexColor returns whether s is a '#rrggbb' color
+func isHexColor(s string) bool {
+	if len(s) != 7 || s[0] != '#' {
+		return false
+	}
+	_, err := strconv.ParseUint(s[1:], 16, 32)
+	return err == nil
+}
+
+type PathDefs []PathDef
+
+func (defs PathDefs) Classes() []string {
+	classes := make([]string, len(defs))
+	for i, def := range defs {
+		classes[i] = def.Class
+	}
+	return classes
+}
+
+func (defs PathDefs) Index(class string) int {
+	for i, def := range defs {
+		if def.Class == class {
+			return i
+		}
+	}
+	return -1
+}
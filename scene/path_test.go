@@ -0,0 +1,160 @@
+package scene
+
+import (
+	"encoding/json"
+	"image/color"
+	"slices"
+	"testing"
+
+	"github.com/bonoboris/satisfied/matrix"
+	rl "github.com/bonoboris/satisfied/rlmath"
+)
+
+func TestPathDefUnmarshalJSON(t *testing.T) {
+	var def PathDef
+	if err := json.Unmarshal([]byte(`{"Class": "Belt", "Width": 2, "Color": "#ff8000"}`), &def); err != nil {
+		t.Fatal(err)
+	}
+	if def.Color != (color.RGBA{255, 128, 0, 255}) || def.BendRadius != 2 {
+		t.Errorf("PathDef = %v, want color #ff8000 and bend radius 2", def)
+	}
+	for _, color := range []string{"", "black", "#fff", "#gg0000", "ff8000", "#ff80001"} {
+		data := `{"Class": "Belt", "Width": 2, "Color": "` + color + `"}`
+		if err := json.Unmarshal([]byte(data), &def); err == nil {
+			t.Errorf("Unmarshal(color %q) error = nil, want an invalid color error", color)
+		}
+	}
+}
+
+func TestPathVertices(t *testing.T) {
+	v := NewPathVertices(vec2(1, 0), vec2(3, 0))
+	v.Insert(1, vec2(2, 0))
+	v.Append(vec2(4, 0))
+	v.Insert(0, vec2(0, 0))
+	want := []rl.Vector2{vec2(0, 0), vec2(1, 0), vec2(2, 0), vec2(3, 0), vec2(4, 0)}
+	if got := v.arr[:v.len]; !slices.Equal(got, want) {
+		t.Fatalf("vertices = %v, want %v", got, want)
+	}
+	v.Remove(0)
+	v.Remove(3)
+	if want := NewPathVertices(vec2(1, 0), vec2(2, 0), vec2(3, 0)); v != want {
+		t.Errorf("vertices = %v, want %v", v, want)
+	}
+
+	for i := v.Len(); i < MAX_PATH_VERTICES; i++ {
+		if !v.Append(vec2(float32(i), 1)) {
+			t.Fatalf("Append() = false with %d vertices", v.Len())
+		}
+	}
+	if !v.IsFull() || v.Append(vec2(0, 0)) || v.Len() != MAX_PATH_VERTICES {
+		t.Errorf("full vertices: IsFull=%v Len=%d, want true %d", v.IsFull(), v.Len(), MAX_PATH_VERTICES)
+	}
+}
+
+func TestPathPolyline(t *testing.T) {
+	p := path(defBelt, 0, 0, 10, 10)
+	p.Vertices = NewPathVertices(vec2(10, 0))
+	if got, want := p.Points(nil), []rl.Vector2{vec2(0, 0), vec2(10, 0), vec2(10, 10)}; !slices.Equal(got, want) {
+		t.Errorf("Points() = %v, want %v", got, want)
+	}
+	if got := p.Polyline(nil); !slices.Equal(got, p.Points(nil)) {
+		t.Errorf("Polyline() = %v, want the points", got)
+	}
+	if got, want := p.Bounds(), rl.NewRectangle(-1, -1, 12, 12); got != want {
+		t.Errorf("Bounds() = %v, want %v", got, want)
+	}
+
+	// the spline goes through every point
+	p.Spline = true
+	curve := p.Polyline(nil)
+	if len(curve) != 2*splineSamples+1 {
+		t.Fatalf("spline: %d points, want %d", len(curve), 2*splineSamples+1)
+	}
+	for i, pt := range p.Points(nil) {
+		if curve[i*splineSamples] != pt {
+			t.Errorf("spline point %d = %v, want %v", i*splineSamples, curve[i*splineSamples], pt)
+		}
+	}
+	// and rounds the corner
+	if mid := curve[splineSamples/2]; mid.Y >= 0 || mid.Y < -1 {
+		t.Errorf("spline first segment middle = %v, want slightly above the segment", mid)
+	}
+}
+
+func TestPathIsValid(t *testing.T) {
+	p := path(defBelt, 0, 0, 10, 10)
+	p.Vertices = NewPathVertices(vec2(10, 0))
+	if !p.IsValid() {
+		t.Errorf("%v: IsValid() = false, want true", p)
+	}
+	p.Vertices.Append(vec2(10, 10))
+	if p.IsValid() {
+		t.Errorf("%v: IsValid() = true, want false (last segment is empty)", p)
+	}
+}
+
+func TestPathCheckCollisionPoint(t *testing.T) {
+	p := path(defBelt, 0, 0, 10, 10)
+	p.Vertices = NewPathVertices(vec2(10, 0))
+	for _, pos := range []rl.Vector2{vec2(5, 0.5), vec2(10.5, 5), vec2(10.7, -0.7)} {
+		if !p.CheckCollisionPoint(pos) {
+			t.Errorf("CheckCollisionPoint(%v) = false, want true", pos)
+		}
+	}
+	// the diagonal of a straight path is outside
+	if pos := vec2(5, 5); p.CheckCollisionPoint(pos) {
+		t.Errorf("CheckCollisionPoint(%v) = true, want false", pos)
+	}
+}
+
+func TestPathTransform(t *testing.T) {
+	p := path(defBelt, 0, 0, 10, 10)
+	p.Vertices = NewPathVertices(vec2(10, 0))
+
+	if got, want := p.Translate(vec2(1, 2)).Points(nil), []rl.Vector2{vec2(1, 2), vec2(11, 2), vec2(11, 12)}; !slices.Equal(got, want) {
+		t.Errorf("Translate() = %v, want %v", got, want)
+	}
+	if got, want := p.Transform(matrix.NewMirrorX()).Points(nil), []rl.Vector2{vec2(0, 0), vec2(-10, 0), vec2(-10, 10)}; !slices.Equal(got, want) {
+		t.Errorf("Transform(mirror) = %v, want %v", got, want)
+	}
+	if got, want := p.Reverse().Points(nil), []rl.Vector2{vec2(10, 10), vec2(10, 0), vec2(0, 0)}; !slices.Equal(got, want) {
+		t.Errorf("Reverse() = %v, want %v", got, want)
+	}
+}
+
+func TestPathMoveVertex(t *testing.T) {
+	p := path(defBelt, 0, 0, 10, 10)
+	p.Vertices = NewPathVertices(vec2(10, 0))
+	if got, want := p.SegmentMiddle(1), vec2(10, 5); got != want {
+		t.Errorf("SegmentMiddle(1) = %v, want %v", got, want)
+	}
+	if got, want := p.MoveVertex(0, vec2(5, 5)).Vertices, NewPathVertices(vec2(5, 5)); got != want {
+		t.Errorf("MoveVertex() = %v, want %v", got, want)
+	}
+	// moved onto a neighbour: removed
+	if got := p.MoveVertex(0, vec2(10, 10)).Vertices; got.Len() != 0 {
+		t.Errorf("MoveVertex() onto end = %v, want no vertex", got)
+	}
+}
+
+// Fully selected paths are transformed with their vertices, only the selected end of the other ones
+func TestTransformPathVertices(t *testing.T) {
+	oc := ObjectCollection{Paths: []Path{path(defBelt, 0, 0, 10, 10), path(defBelt, 20, 0, 30, 10)}}
+	for i := range oc.Paths {
+		oc.Paths[i].Vertices = NewPathVertices(oc.Paths[i].Start.Add(vec2(10, 0)))
+	}
+	sel := ObjectSelection{PathIdxs: []PathSel{{Idx: 0, Start: true, End: true}, {Idx: 1, End: true}}}
+	sel.RecomputeBounds(oc)
+	if want := rl.NewRectangle(0, 0, 30, 10); sel.Bounds != want {
+		t.Errorf("selection bounds = %v, want %v", sel.Bounds, want)
+	}
+
+	var tr Transformed
+	tr.Compute(oc, sel, Transform{Translate: vec2(0, 5)}, false)
+	if got, want := tr.Paths[0].Vertices.Get(0), vec2(10, 5); got != want {
+		t.Errorf("full path vertex = %v, want %v", got, want)
+	}
+	if got, want := tr.Paths[1].Vertices.Get(0), vec2(30, 0); got != want {
+		t.Errorf("partial path vertex = %v, want %v (not moved)", got, want)
+	}
+}
@@ -0,0 +1,305 @@
+// planner - Production planner: recipes and machines needed for target production rates
+
+package scene
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+)
+
+const (
+	// Cost of a machine relative to a raw resource item per minute, so that among the plans using
+	// the fewest raw resources the one with the fewest machines is chosen
+	planMachineCost = 1e-3
+	// Rates below this are considered null, in items per minute
+	planEps = 1e-4
+)
+
+var (
+	ErrPlanNoTarget      = errors.New("no target production rate")
+	ErrPlanUnknownItem   = errors.New("unknown item")
+	ErrPlanInfeasible    = errors.New("targets cannot be produced with the allowed recipes")
+	ErrPlanNotConverging = errors.New("no plan found")
+)
+
+// PlanStep is a recipe of a production plan
+type PlanStep struct {
+	// Index of the recipe in the recipe book
+	RecipeIdx int
+	Recipe    string
+	Building  string
+	// Number of buildings running the recipe at the plan clock speed, the last one may be underclocked
+	Machines float32
+}
+
+// BuildingCount is a number of buildings of a class
+type BuildingCount struct {
+	Class string
+	Count int
+}
+
+// Plan is a production plan, the result of [SolvePlan]
+type Plan struct {
+	// Clock speed of the machines, in percent, 0 for [DefaultClock]
+	Clock float32
+	// Recipes used, in the recipe book order
+	Steps []PlanStep
+	// Whole number of buildings needed by the steps, by building class in the steps order
+	Buildings []BuildingCount
+	// Raw resources consumed, in items per minute
+	Resources []ItemAmount
+	// Items produced but not consumed beyond the targets, in items per minute
+	Byproducts []ItemAmount
+}
+
+// SolvePlan returns the production plan of the target rates (in items per minute) using the fewest
+// raw resources, then the fewest machines, running at the given clock speed (in percent, 0 for
+// [DefaultClock]).
+//
+// Standard recipes are always allowed, alternate ones only if their class is in alternates. The
+// recipes graph, loops included, is solved as a linear program.
+func SolvePlan(book RecipeBook, targets []ItemAmount, alternates []string, clock float32) (Plan, error) {
+	if !slices.ContainsFunc(targets, func(t ItemAmount) bool { return t.Amount > 0 }) {
+		return Plan{}, ErrPlanNoTarget
+	}
+	speed := Modifiers{Clock: clock}.ClockSpeed()
+	// negated so that NaN is out of range
+	if !(speed >= MinClock && speed <= MaxClock) {
+		return Plan{}, ErrClockRange
+	}
+	var recipeIdxs []int
+	for i, r := range book.Recipes {
+		if !r.Alternate || slices.Contains(alternates, r.Class) {
+			recipeIdxs = append(recipeIdxs, i)
+		}
+	}
+	items := slices.Clone(book.Resources)
+	for _, r := range book.Recipes {
+		for _, ia := range slices.Concat(r.In, r.Out) {
+			if !slices.Contains(items, ia.Item) {
+				items = append(items, ia.Item)
+			}
+		}
+	}
+	for _, t := range targets {
+		if !slices.Contains(items, t.Item) {
+			return Plan{}, fmt.Errorf("%w: %s", ErrPlanUnknownItem, t.Item)
+		}
+	}
+
+	// variables: machines of each allowed recipe, then rate of each resource
+	// constraints: net rate of each item >= its target rate
+	n := len(recipeIdxs) + len(book.Resources)
+	cost := make([]float64, n)
+	a := make([][]float64, len(items))
+	b := make([]float64, len(items))
+	for j := range recipeIdxs {
+		cost[j] = planMachineCost
+	}
+	for k := range book.Resources {
+		cost[len(recipeIdxs)+k] = 1
+	}
+	for i, item := range items {
+		a[i] = make([]float64, n)
+		for j, idx := range recipeIdxs {
+			a[i][j] = float64(book.Recipes[idx].Net(item) * speed / DefaultClock)
+		}
+		if k := slices.Index(book.Resources, item); k >= 0 {
+			a[i][len(recipeIdxs)+k] = 1
+		}
+		for _, t := range targets {
+			if t.Item == item {
+				b[i] += float64(t.Amount)
+			}
+		}
+	}
+	x, err := simplex(cost, a, b)
+	if err != nil {
+		return Plan{}, err
+	}
+
+	plan := Plan{Clock: Modifiers{Clock: clock}.Normalized().Clock}
+	for j, idx := range recipeIdxs {
+		if x[j] < planEps {
+			continue
+		}
+		r := book.Recipes[idx]
+		plan.Steps = append(plan.Steps, PlanStep{RecipeIdx: idx, Recipe: r.Class, Building: r.Building, Machines: float32(x[j])})
+		count := int(math.Ceil(x[j] - planEps))
+		if k := slices.IndexFunc(plan.Buildings, func(bc BuildingCount) bool { return bc.Class == r.Building }); k >= 0 {
+			plan.Buildings[k].Count += count
+		} else {
+			plan.Buildings = append(plan.Buildings, BuildingCount{Class: r.Building, Count: count})
+		}
+	}
+	for k, item := range book.Resources {
+		if rate := x[len(recipeIdxs)+k]; rate > planEps {
+			plan.Resources = append(plan.Resources, ItemAmount{Item: item, Amount: float32(rate)})
+		}
+	}
+	for i, item := range items {
+		var surplus float64
+		for j := range recipeIdxs {
+			surplus += a[i][j] * x[j]
+		}
+		if surplus -= b[i]; surplus > planEps {
+			plan.Byproducts = append(plan.Byproducts, ItemAmount{Item: item, Amount: float32(surplus)})
+		}
+	}
+	return plan, nil
+}
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
+// Simplex
+////////////////////////////////////////////////////////////////////////////////////////////////////
+
+const (
+	// Tolerance of the simplex computations
+	simplexEps = 1e-9
+	// Maximum number of pivots of each simplex phase
+	simplexMaxPivots = 1 << 14
+)
+
+// simplex returns x minimizing cost·x subject to a·x >= b and x >= 0, with b >= 0.
+//
+// It uses the two-phase simplex method on a dense tableau, with Bland's rule against cycling.
+func simplex(cost []float64, a [][]float64, b []float64) ([]float64, error) {
+	m, n := len(a), len(cost)
+	// columns: n variables, a surplus or slack variable per row, an artificial variable per row
+	// with a positive b, then the right hand side
+	artificials := 0
+	for _, v := range b {
+		if v > simplexEps {
+			artificials++
+		}
+	}
+	cols := n + m + artificials
+	t := make([][]float64, m)
+	basis := make([]int, m)
+	art := n + m
+	for i := range m {
+		t[i] = make([]float64, cols+1)
+		if b[i] > simplexEps {
+			// a·x - surplus + artificial = b
+			copy(t[i], a[i])
+			t[i][n+i] = -1
+			t[i][art] = 1
+			t[i][cols] = b[i]
+			basis[i] = art
+			art++
+		} else {
+			// -a·x + slack = 0
+			for j, v := range a[i] {
+				t[i][j] = -v
+			}
+			t[i][n+i] = 1
+			basis[i] = n + i
+		}
+	}
+
+	// phase 1: minimize the artificial variables to find a feasible basis
+	phase1 := make([]float64, cols)
+	for j := n + m; j < cols; j++ {
+		phase1[j] = 1
+	}
+	if err := simplexMinimize(t, basis, phase1, cols); err != nil {
+		return nil, err
+	}
+	var infeasibility float64
+	for i, col := range basis {
+		infeasibility += phase1[col] * t[i][cols]
+	}
+	if infeasibility > 1e-6 {
+		return nil, ErrPlanInfeasible
+	}
+	// drive the remaining (null) artificial variables out of the basis
+	for i, col := range basis {
+		if col < n+m {
+			continue
+		}
+		for j := range n + m {
+			if math.Abs(t[i][j]) > simplexEps {
+				simplexPivot(t, basis, i, j)
+				break
+			}
+		}
+	}
+
+	// phase 2: minimize the cost, artificial variables cannot enter the basis
+	phase2 := make([]float64, cols)
+	copy(phase2, cost)
+	if err := simplexMinimize(t, basis, phase2, n+m); err != nil {
+		return nil, err
+	}
+	x := make([]float64, n)
+	for i, col := range basis {
+		if col < n {
+			x[col] = t[i][cols]
+		}
+	}
+	return x, nil
+}
+
+// simplexMinimize pivots the tableau until cost is minimal, only the first enter columns may enter
+// the basis
+func simplexMinimize(t [][]float64, basis []int, cost []float64, enter int) error {
+	if len(t) == 0 {
+		return nil
+	}
+	rhs := len(t[0]) - 1
+	for range simplexMaxPivots {
+		// entering column: the first one with a negative reduced cost
+		col := -1
+		for j := range enter {
+			reduced := cost[j]
+			for i, bc := range basis {
+				reduced -= cost[bc] * t[i][j]
+			}
+			if reduced < -simplexEps {
+				col = j
+				break
+			}
+		}
+		if col < 0 {
+			return nil
+		}
+		// leaving row: the minimum ratio, ties broken by the lowest basic column
+		row := -1
+		var best float64
+		for i := range t {
+			if t[i][col] <= simplexEps {
+				continue
+			}
+			ratio := t[i][rhs] / t[i][col]
+			if row < 0 || ratio < best-simplexEps || ratio <= best+simplexEps && basis[i] < basis[row] {
+				row, best = i, ratio
+			}
+		}
+		if row < 0 {
+			// unbounded, not possible with non-negative costs
+			return ErrPlanNotConverging
+		}
+		simplexPivot(t, basis, row, col)
+	}
+	return ErrPlanNotConverging
+}
+
+// simplexPivot makes col a basic column of row
+func simplexPivot(t [][]float64, basis []int, row, col int) {
+	p := t[row][col]
+	for j := range t[row] {
+		t[row][j] /= p
+	}
+	for i := range t {
+		if i == row || t[i][col] == 0 {
+			continue
+		}
+		f := t[i][col]
+		for j := range t[i] {
+			t[i][j] -= f * t[row][j]
+		}
+	}
+	basis[row] = col
+}
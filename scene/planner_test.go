@@ -0,0 +1,187 @@
+package scene
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"os"
+	"slices"
+	"testing"
+)
+
+var testRecipeBook = RecipeBook{
+	Resources: []string{"Iron Ore", "Crude Oil", "Water"},
+	Fluids:    []string{"Crude Oil", "Water", "Heavy Oil Residue", "Fuel"},
+	Recipes: []RecipeDef{
+		{Class: "Iron Ingot", Building: "Smelter", Duration: 2,
+			In: []ItemAmount{{"Iron Ore", 1}}, Out: []ItemAmount{{"Iron Ingot", 1}}},
+		{Class: "Iron Plate", Building: "Constructor", Duration: 6,
+			In: []ItemAmount{{"Iron Ingot", 3}}, Out: []ItemAmount{{"Iron Plate", 2}}},
+		{Class: "Pure Iron Ingot", Building: "Refinery", Alternate: true, Duration: 12,
+			In: []ItemAmount{{"Iron Ore", 7}, {"Water", 4}}, Out: []ItemAmount{{"Iron Ingot", 13}}},
+		{Class: "Plastic", Building: "Refinery", Duration: 6,
+			In: []ItemAmount{{"Crude Oil", 3}}, Out: []ItemAmount{{"Plastic", 2}, {"Heavy Oil Residue", 1}}},
+		{Class: "Fuel", Building: "Refinery", Duration: 6,
+			In: []ItemAmount{{"Heavy Oil Residue", 6}}, Out: []ItemAmount{{"Fuel", 4}}},
+		{Class: "Recycled Plastic", Building: "Refinery", Alternate: true, Duration: 12,
+			In: []ItemAmount{{"Plastic", 6}, {"Fuel", 6}}, Out: []ItemAmount{{"Plastic", 12}}},
+	},
+}
+
+// near returns whether a and b are equal to 1e-3
+func near(a, b float32) bool { return math.Abs(float64(a-b)) < 1e-3 }
+
+func TestSolvePlan(t *testing.T) {
+	plan, err := SolvePlan(testRecipeBook, []ItemAmount{{"Iron Plate", 60}}, nil, 0)
+	if err != nil {
+		t.Fatalf("SolvePlan() error = %v", err)
+	}
+	wantSteps := []PlanStep{
+		{RecipeIdx: 0, Recipe: "Iron Ingot", Building: "Smelter", Machines: 3},
+		{RecipeIdx: 1, Recipe: "Iron Plate", Building: "Constructor", Machines: 3},
+	}
+	if len(plan.Steps) != len(wantSteps) {
+		t.Fatalf("Steps = %v, want %v", plan.Steps, wantSteps)
+	}
+	for i, s := range plan.Steps {
+		w := wantSteps[i]
+		if s.RecipeIdx != w.RecipeIdx || s.Recipe != w.Recipe || s.Building != w.Building || !near(s.Machines, w.Machines) {
+			t.Errorf("Steps[%d] = %v, want %v", i, s, w)
+		}
+	}
+	wantBuildings := []BuildingCount{{"Smelter", 3}, {"Constructor", 3}}
+	if !slices.Equal(plan.Buildings, wantBuildings) {
+		t.Errorf("Buildings = %v, want %v", plan.Buildings, wantBuildings)
+	}
+	if len(plan.Resources) != 1 || plan.Resources[0].Item != "Iron Ore" || !near(plan.Resources[0].Amount, 90) {
+		t.Errorf("Resources = %v, want [90 Iron Ore]", plan.Resources)
+	}
+	if len(plan.Byproducts) != 0 {
+		t.Errorf("Byproducts = %v, want none", plan.Byproducts)
+	}
+
+	// overclocked machines
+	plan, err = SolvePlan(testRecipeBook, []ItemAmount{{"Iron Plate", 60}}, nil, 150)
+	if err != nil {
+		t.Fatalf("SolvePlan(150%%) error = %v", err)
+	}
+	if plan.Clock != 150 || len(plan.Steps) != 2 || !near(plan.Steps[0].Machines, 2) || !near(plan.Steps[1].Machines, 2) {
+		t.Errorf("SolvePlan(150%%) = %v, want 2 machines per step", plan)
+	}
+}
+
+func TestSolvePlanAlternates(t *testing.T) {
+	// the alternate uses less iron ore per ingot
+	plan, err := SolvePlan(testRecipeBook, []ItemAmount{{"Iron Ingot", 65}}, []string{"Pure Iron Ingot"}, 0)
+	if err != nil {
+		t.Fatalf("SolvePlan() error = %v", err)
+	}
+	if len(plan.Steps) != 1 || plan.Steps[0].Recipe != "Pure Iron Ingot" || !near(plan.Steps[0].Machines, 1) {
+		t.Errorf("Steps = %v, want 1 Pure Iron Ingot", plan.Steps)
+	}
+	if len(plan.Buildings) != 1 || plan.Buildings[0] != (BuildingCount{"Refinery", 1}) {
+		t.Errorf("Buildings = %v, want [{Refinery 1}]", plan.Buildings)
+	}
+
+	// the recycling loop turns the heavy oil residue byproduct into more plastic
+	plan, err = SolvePlan(testRecipeBook, []ItemAmount{{"Plastic", 20}}, nil, 0)
+	if err != nil {
+		t.Fatalf("SolvePlan() error = %v", err)
+	}
+	if len(plan.Byproducts) != 1 || plan.Byproducts[0].Item != "Heavy Oil Residue" || !near(plan.Byproducts[0].Amount, 10) {
+		t.Errorf("without recycling: Byproducts = %v, want [10 Heavy Oil Residue]", plan.Byproducts)
+	}
+	recycled, err := SolvePlan(testRecipeBook, []ItemAmount{{"Plastic", 20}}, []string{"Recycled Plastic"}, 0)
+	if err != nil {
+		t.Fatalf("SolvePlan() error = %v", err)
+	}
+	if len(recycled.Resources) != 1 || recycled.Resources[0].Amount >= plan.Resources[0].Amount {
+		t.Errorf("with recycling: Resources = %v, want less than %v", recycled.Resources, plan.Resources)
+	}
+}
+
+func TestSolvePlanErrors(t *testing.T) {
+	tests := []struct {
+		name       string
+		targets    []ItemAmount
+		alternates []string
+		want       error
+	}{
+		{"no target", nil, nil, ErrPlanNoTarget},
+		{"null target", []ItemAmount{{"Iron Plate", 0}}, nil, ErrPlanNoTarget},
+		{"unknown item", []ItemAmount{{"Iron Gear", 10}}, nil, ErrPlanUnknownItem},
+		{"byproduct target", []ItemAmount{{"Fuel", 10}, {"Plastic", 10}}, []string{"Recycled Plastic"}, nil},
+		{"no recipe", []ItemAmount{{"Heavy Oil Residue", 10}, {"Nothing", 1}}, nil, ErrPlanUnknownItem},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := SolvePlan(testRecipeBook, tt.targets, tt.alternates, 0); !errors.Is(err, tt.want) {
+				t.Errorf("SolvePlan() error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+
+	if _, err := SolvePlan(testRecipeBook, []ItemAmount{{"Iron Plate", 60}}, nil, MaxClock+1); !errors.Is(err, ErrClockRange) {
+		t.Errorf("SolvePlan(%d%%) error = %v, want %v", MaxClock+1, err, ErrClockRange)
+	}
+
+	// an item only produced by a disallowed alternate recipe
+	book := RecipeBook{Resources: []string{"Ore"}, Recipes: []RecipeDef{
+		{Class: "Alt Gear", Building: "Constructor", Alternate: true, Duration: 1,
+			In: []ItemAmount{{"Ore", 1}}, Out: []ItemAmount{{"Gear", 1}}},
+	}}
+	if _, err := SolvePlan(book, []ItemAmount{{"Gear", 10}}, nil, 0); !errors.Is(err, ErrPlanInfeasible) {
+		t.Errorf("SolvePlan() error = %v, want %v", err, ErrPlanInfeasible)
+	}
+}
+
+// loadAssetsRecipeBook returns the assets recipes
+func loadAssetsRecipeBook(t *testing.T) RecipeBook {
+	t.Helper()
+	data, err := os.ReadFile("../assets/recipe_defs.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var book RecipeBook
+	if err := json.Unmarshal(data, &book); err != nil {
+		t.Fatal(err)
+	}
+	return book
+}
+
+// TestRecipeDefsAssets checks the assets recipes run in defined buildings, and that every item
+// they consume is a resource or produced by a standard recipe
+func TestRecipeDefsAssets(t *testing.T) {
+	book := loadAssetsRecipeBook(t)
+	setAssetsBuildingDefs(t)
+	var standard []string
+	for _, r := range book.Recipes {
+		if !r.Alternate {
+			for _, ia := range r.Out {
+				standard = append(standard, ia.Item)
+			}
+		}
+	}
+	for i, r := range book.Recipes {
+		if book.Index(r.Class) != i {
+			t.Errorf("recipe %s: duplicate class", r.Class)
+		}
+		if buildingDefs.Index(r.Building) < 0 {
+			t.Errorf("recipe %s: unknown building %q", r.Class, r.Building)
+		}
+		if r.Duration <= 0 || len(r.Out) == 0 {
+			t.Errorf("recipe %s: want a positive duration and outputs", r.Class)
+		}
+		for _, ia := range r.In {
+			if !book.IsResource(ia.Item) && !slices.Contains(standard, ia.Item) {
+				t.Errorf("recipe %s: input %q is not a resource nor produced by a standard recipe", r.Class, ia.Item)
+			}
+		}
+	}
+	// every item can be planned with the standard recipes
+	for _, item := range book.Items() {
+		if _, err := SolvePlan(book, []ItemAmount{{item, 10}}, nil, 0); err != nil {
+			t.Errorf("SolvePlan(%s) error = %v", item, err)
+		}
+	}
+}
@@ -0,0 +1,248 @@
+// power - Power network: power connectors, power lines and electrical circuits
+
+package scene
+
+import (
+	"fmt"
+
+	"github.com/bonoboris/satisfied/math32"
+	rl "github.com/bonoboris/satisfied/rlmath"
+)
+
+const (
+	// Distance from a power connector within which it is picked (see [Scene.PowerConnectorAt])
+	powerPickRadius = 1
+	// Power lines ends and power connectors positions are matched at this precision, in 1/m
+	powerPosPrecision = 100
+)
+
+// PowerConnectors appends the building power connectors, in world coordinates, to dst and returns
+// the extended slice
+func (b Building) PowerConnectors(dst []rl.Vector2) []rl.Vector2 {
+	def := b.Def()
+	if def.PowerConn.len == 0 {
+		return dst
+	}
+	mat := b.Matrix()
+	for _, conn := range def.PowerConn.arr[:def.PowerConn.len] {
+		dst = append(dst, mat.ApplyV(conn.Pos))
+	}
+	return dst
+}
+
+// MaxWiresPerConnector returns the maximum number of power lines of each power connector
+func (def BuildingDef) MaxWiresPerConnector() int {
+	if def.MaxWires > 0 {
+		return def.MaxWires
+	}
+	return 1
+}
+
+// PowerConnectorAt returns the building power connector closest to pos, if any is within reach
+func (s Scene) PowerConnectorAt(pos rl.Vector2) (rl.Vector2, bool) {
+	var buf [MAX_INOUT]rl.Vector2
+	var best rl.Vector2
+	bestDist := float32(powerPickRadius * powerPickRadius)
+	found := false
+	for _, b := range s.Buildings {
+		if !isNearRec(b.Bounds(), pos, powerPickRadius) {
+			continue
+		}
+		for _, conn := range b.PowerConnectors(buf[:0]) {
+			if d := conn.DistanceSqr(pos); d <= bestDist {
+				best, bestDist, found = conn, d, true
+			}
+		}
+	}
+	return best, found
+}
+
+// powerKey is a position rounded to [powerPosPrecision]
+type powerKey [2]int32
+
+func newPowerKey(pos rl.Vector2) powerKey {
+	return powerKey{int32(math32.Round(pos.X * powerPosPrecision)), int32(math32.Round(pos.Y * powerPosPrecision))}
+}
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
+// Power network
+////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// PowerIssueKind is the kind of a [PowerIssue]
+type PowerIssueKind int
+
+const (
+	// A power line end is not on a power connector
+	PowerLineUnconnected PowerIssueKind = iota
+	// A power line is longer than its definition [PathDef.MaxLength]
+	PowerLineTooLong
+	// A power connector has more power lines than its building definition [BuildingDef.MaxWires]
+	PowerConnectorOverused
+)
+
+func (k PowerIssueKind) String() string {
+	switch k {
+	case PowerLineUnconnected:
+		return "PowerLineUnconnected"
+	case PowerLineTooLong:
+		return "PowerLineTooLong"
+	case PowerConnectorOverused:
+		return "PowerConnectorOverused"
+	default:
+		return "Invalid"
+	}
+}
+
+// PowerIssue is a power line or power connector breaking a power network rule
+type PowerIssue struct {
+	Kind PowerIssueKind
+	// Power line path index, or building index for [PowerConnectorOverused]
+	Idx int
+	// Unconnected power line end, power line middle or overused power connector
+	Pos rl.Vector2
+}
+
+func (i PowerIssue) String() string {
+	return fmt.Sprintf("%v{%d (%v,%v)}", i.Kind, i.Idx, i.Pos.X, i.Pos.Y)
+}
+
+// Circuit is a set of buildings connected by power lines
+type Circuit struct {
+	// Indices of the circuit buildings, in increasing order
+	BuildingIdxs []int
+	// Indices of the circuit power lines (connected to a circuit building), in increasing order
+	PathIdxs []int
+	// Power generated by the circuit buildings, in MW
+	Generation float32
+	// Power consumed by the circuit buildings, in MW
+	Consumption float32
+}
+
+// IsOverloaded returns true if the circuit consumes more power than it generates
+func (c Circuit) IsOverloaded() bool { return c.Consumption > c.Generation }
+
+// PowerNetwork is the electrical circuits of a collection and their issues
+type PowerNetwork struct {
+	// Circuits, ordered by their first building index
+	Circuits []Circuit
+	// Indices of the buildings consuming power but not connected to any power line
+	Unpowered []int
+	// Power lines and power connectors issues
+	Issues []PowerIssue
+}
+
+// ComputePowerNetwork returns the power network of the collection.
+//
+// A power line connects the buildings whose power connectors are at its ends, power switches are
+// considered closed. Buildings power depends on their modifiers (see [Building.PowerUse]).
+func ComputePowerNetwork(oc ObjectCollection) PowerNetwork {
+	var net PowerNetwork
+
+	// power connectors by position
+	type connector struct {
+		building int
+		pos      rl.Vector2
+		wires    int
+	}
+	var connectors []connector
+	connIdxs := make(map[powerKey]int)
+	var buf [MAX_INOUT]rl.Vector2
+	for i, b := range oc.Buildings {
+		for _, pos := range b.PowerConnectors(buf[:0]) {
+			if _, ok := connIdxs[newPowerKey(pos)]; !ok {
+				connIdxs[newPowerKey(pos)] = len(connectors)
+				connectors = append(connectors, connector{building: i, pos: pos})
+			}
+		}
+	}
+
+	// union-find of the buildings connected by power lines
+	parents := Range(0, len(oc.Buildings))
+	find := func(i int) int {
+		for parents[i] != i {
+			parents[i] = parents[parents[i]]
+			i = parents[i]
+		}
+		return i
+	}
+	wired := make([]bool, len(oc.Buildings))
+	// first connected building of each power line, -1 if none
+	lineBuildings := Repeat(nil, -1, len(oc.Paths))
+	for i, p := range oc.Paths {
+		def := p.Def()
+		if !def.IsPowerLine {
+			continue
+		}
+		ends := [2]int{-1, -1}
+		for j, pos := range [2]rl.Vector2{p.Start, p.End} {
+			c, ok := connIdxs[newPowerKey(pos)]
+			if !ok {
+				net.Issues = append(net.Issues, PowerIssue{Kind: PowerLineUnconnected, Idx: i, Pos: pos})
+				continue
+			}
+			connectors[c].wires++
+			ends[j] = connectors[c].building
+			wired[ends[j]] = true
+		}
+		if def.MaxLength > 0 && p.Length() > def.MaxLength {
+			net.Issues = append(net.Issues, PowerIssue{Kind: PowerLineTooLong, Idx: i, Pos: p.Start.Add(p.End).Scale(0.5)})
+		}
+		switch {
+		case ends[0] >= 0 && ends[1] >= 0:
+			parents[find(ends[0])] = find(ends[1])
+			lineBuildings[i] = ends[0]
+		case ends[0] >= 0:
+			lineBuildings[i] = ends[0]
+		case ends[1] >= 0:
+			lineBuildings[i] = ends[1]
+		}
+	}
+	for _, c := range connectors {
+		if c.wires > oc.Buildings[c.building].Def().MaxWiresPerConnector() {
+			net.Issues = append(net.Issues, PowerIssue{Kind: PowerConnectorOverused, Idx: c.building, Pos: c.pos})
+		}
+	}
+
+	// circuit index of each union-find root
+	circuitIdxs := Repeat(nil, -1, len(oc.Buildings))
+	for i, b := range oc.Buildings {
+		if !wired[i] {
+			if b.Def().PowerUse > 0 {
+				net.Unpowered = append(net.Unpowered, i)
+			}
+			continue
+		}
+		root := find(i)
+		if circuitIdxs[root] == -1 {
+			circuitIdxs[root] = len(net.Circuits)
+			net.Circuits = append(net.Circuits, Circuit{})
+		}
+		c := &net.Circuits[circuitIdxs[root]]
+		c.BuildingIdxs = append(c.BuildingIdxs, i)
+		c.Generation += b.PowerGen()
+		c.Consumption += b.PowerUse()
+	}
+	for i, b := range lineBuildings {
+		if b >= 0 {
+			c := &net.Circuits[circuitIdxs[find(b)]]
+			c.PathIdxs = append(c.PathIdxs, i)
+		}
+	}
+	return net
+}
+
+// Generation returns the power generated by all the circuits, in MW
+func (net PowerNetwork) Generation() (mw float32) {
+	for _, c := range net.Circuits {
+		mw += c.Generation
+	}
+	return mw
+}
+
+// Consumption returns the power consumed by all the circuits, in MW
+func (net PowerNetwork) Consumption() (mw float32) {
+	for _, c := range net.Circuits {
+		mw += c.Consumption
+	}
+	return mw
+}
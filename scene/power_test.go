@@ -0,0 +1,150 @@
+package scene
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+
+	rl "github.com/bonoboris/satisfied/rlmath"
+)
+
+// Power test definitions, indexed by the powerDefXXX constants
+var (
+	powerBuildingDefs = BuildingDefs{
+		{Class: "Pole", Dims: vec2(1, 1), MaxWires: 2,
+			PowerConn: InputOutputs{arr: [MAX_INOUT]InputOutput{{Pos: vec2(0.5, 0.5)}}, len: 1}},
+		{Class: "Generator", Dims: vec2(4, 4), PowerGen: 100, Clockable: true,
+			PowerConn: InputOutputs{arr: [MAX_INOUT]InputOutput{{Pos: vec2(2, 2)}}, len: 1}},
+		{Class: "Machine", Dims: vec2(4, 4), PowerUse: 60, Clockable: true,
+			PowerConn: InputOutputs{arr: [MAX_INOUT]InputOutput{{Pos: vec2(2, 2)}}, len: 1}},
+	}
+	powerPathDefs = PathDefs{
+		{Class: "Belt", Width: 2, IsDirectional: true, BendRadius: 2},
+		{Class: "Wire", Width: 0.5, IsPowerLine: true, MaxLength: 20},
+	}
+)
+
+const (
+	powerDefPole = iota
+	powerDefGenerator
+	powerDefMachine
+)
+
+const (
+	powerDefBelt = iota
+	powerDefWire
+)
+
+func TestComputePowerNetwork(t *testing.T) {
+	SetDefs(powerBuildingDefs, powerPathDefs)
+	t.Cleanup(func() { SetDefs(testBuildingDefs, testPathDefs) })
+
+	oc := ObjectCollection{
+		Buildings: []Building{
+			building(powerDefGenerator, 0, 0, 0),
+			building(powerDefPole, 10, 0, 0),
+			building(powerDefMachine, 20, 0, 0),
+			building(powerDefMachine, 30, 0, 0),
+			building(powerDefMachine, 0, 40, 0),
+			building(powerDefPole, 10, 40, 0),
+			building(powerDefMachine, 0, 80, 0), // unpowered
+			building(powerDefGenerator, 40, 80, 0),
+		},
+	}
+	conn := func(i int) rl.Vector2 { return oc.Buildings[i].PowerConnectors(nil)[0] }
+	wire := func(start, end rl.Vector2) Path { return Path{DefIdx: powerDefWire, Start: start, End: end} }
+	oc.Paths = []Path{
+		// circuit A: buildings 0 to 3
+		wire(conn(0), conn(1)),
+		wire(conn(1), conn(2)),
+		wire(conn(1), conn(3)), // pole overused, too long
+		// circuit B: buildings 4 and 5
+		wire(conn(4), conn(5)),
+		wire(conn(5), vec2(15, 45)), // unconnected end
+		// belts do not carry power
+		{DefIdx: powerDefBelt, Start: conn(0), End: conn(4)},
+		// unconnected ends
+		wire(vec2(50, 50), vec2(55, 50)),
+	}
+	net := ComputePowerNetwork(oc)
+
+	if len(net.Circuits) != 2 {
+		t.Fatalf("ComputePowerNetwork() circuits = %v, want 2", net.Circuits)
+	}
+	a, b := net.Circuits[0], net.Circuits[1]
+	if !slices.Equal(a.BuildingIdxs, []int{0, 1, 2, 3}) || !slices.Equal(a.PathIdxs, []int{0, 1, 2}) {
+		t.Errorf("circuit A = %v, want buildings [0 1 2 3], paths [0 1 2]", a)
+	}
+	if a.Generation != 100 || a.Consumption != 120 || !a.IsOverloaded() {
+		t.Errorf("circuit A power = %v / %v MW, want overloaded 100 / 120 MW", a.Generation, a.Consumption)
+	}
+	if !slices.Equal(b.BuildingIdxs, []int{4, 5}) || !slices.Equal(b.PathIdxs, []int{3, 4}) {
+		t.Errorf("circuit B = %v, want buildings [4 5], paths [3 4]", b)
+	}
+	if b.Generation != 0 || b.Consumption != 60 {
+		t.Errorf("circuit B power = %v / %v MW, want 0 / 60 MW", b.Generation, b.Consumption)
+	}
+	if !slices.Equal(net.Unpowered, []int{6}) {
+		t.Errorf("Unpowered = %v, want [6]", net.Unpowered)
+	}
+	if net.Generation() != 100 || net.Consumption() != 180 {
+		t.Errorf("network power = %v / %v MW, want 100 / 180 MW", net.Generation(), net.Consumption())
+	}
+
+	wantIssues := []PowerIssue{
+		{Kind: PowerLineTooLong, Idx: 2, Pos: conn(1).Add(conn(3)).Scale(0.5)},
+		{Kind: PowerLineUnconnected, Idx: 4, Pos: vec2(15, 45)},
+		{Kind: PowerLineUnconnected, Idx: 6, Pos: vec2(50, 50)},
+		{Kind: PowerLineUnconnected, Idx: 6, Pos: vec2(55, 50)},
+		{Kind: PowerConnectorOverused, Idx: 1, Pos: conn(1)},
+	}
+	if !slices.Equal(net.Issues, wantIssues) {
+		t.Errorf("Issues = %v, want %v", net.Issues, wantIssues)
+	}
+}
+
+func TestPowerConnectorAt(t *testing.T) {
+	SetDefs(powerBuildingDefs, powerPathDefs)
+	t.Cleanup(func() { SetDefs(testBuildingDefs, testPathDefs) })
+
+	s := NewScene(ObjectCollection{Buildings: []Building{
+		building(powerDefPole, 0, 0, 0),
+		building(powerDefMachine, 10, 0, 90),
+	}})
+	// building positions are their rotation center
+	tests := []struct {
+		pos    rl.Vector2
+		want   rl.Vector2
+		wantOk bool
+	}{
+		{vec2(0, 0), vec2(-0.5, -0.5), true},
+		{vec2(10.5, 0.5), vec2(10, 0), true},
+		{vec2(5, 5), rl.Vector2{}, false},
+	}
+	for _, tt := range tests {
+		got, ok := s.PowerConnectorAt(tt.pos)
+		if got != tt.want || ok != tt.wantOk {
+			t.Errorf("PowerConnectorAt(%v) = %v, %v, want %v, %v", tt.pos, got, ok, tt.want, tt.wantOk)
+		}
+	}
+}
+
+// Power lines are a single segment, files with power line vertices are invalid
+func TestSceneLoadPowerLine(t *testing.T) {
+	SetDefs(powerBuildingDefs, powerPathDefs)
+	t.Cleanup(func() { SetDefs(testBuildingDefs, testPathDefs) })
+
+	for _, line := range []string{"1 Wire 0 0 10 0 0 5 5", "1 Wire 0 0 10 0 1"} {
+		var s Scene
+		err := s.LoadFromText(strings.NewReader("#VERSION=5\n#LASTID=1\n#PACKS=\n" + line + "\n"))
+		var decodeErr DecodeTextError
+		if !errors.As(err, &decodeErr) || decodeErr.Msg != msgInvalidPath {
+			t.Errorf("LoadFromText(%q) error = %v, want %q", line, err, msgInvalidPath)
+		}
+	}
+	var s Scene
+	if err := s.LoadFromText(strings.NewReader("#VERSION=5\n#LASTID=1\n#PACKS=\n1 Wire 0 0 10 0 0\n")); err != nil {
+		t.Errorf("LoadFromText(straight power line) error = %v", err)
+	}
+}
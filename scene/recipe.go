@@ -0,0 +1,97 @@
+// recipe - Items, recipes and the raw resources they start from
+
+package scene
+
+import (
+	"fmt"
+	"slices"
+)
+
+// ItemAmount is an amount of an item, per recipe cycle or per minute
+type ItemAmount struct {
+	Item   string
+	Amount float32
+}
+
+func (ia ItemAmount) String() string { return fmt.Sprintf("%v %s", ia.Amount, ia.Item) }
+
+// RecipeDef is a recipe: the items a building consumes and produces in each cycle
+type RecipeDef struct {
+	Class string
+	// Class of the building running the recipe
+	Building string
+	// Whether the recipe is an alternate recipe, unlocked with hard drives
+	Alternate bool
+	// Duration of a cycle, in seconds
+	Duration float32
+	// Items consumed in each cycle
+	In []ItemAmount
+	// Items produced in each cycle
+	Out []ItemAmount
+}
+
+func (r RecipeDef) String() string {
+	return fmt.Sprintf("{%s(%s) %vs In=%v Out=%v}", r.Class, r.Building, r.Duration, r.In, r.Out)
+}
+
+// Net returns the net rate of item of a building running the recipe at 100% clock speed, in items
+// per minute, positive if it produces it and negative if it consumes it
+func (r RecipeDef) Net(item string) float32 {
+	var rate float32
+	for _, ia := range r.Out {
+		if ia.Item == item {
+			rate += ia.Amount
+		}
+	}
+	for _, ia := range r.In {
+		if ia.Item == item {
+			rate -= ia.Amount
+		}
+	}
+	return rate * 60 / r.Duration
+}
+
+// RecipeBook is the recipes definitions and the raw resources they start from
+type RecipeBook struct {
+	// Raw resources, extracted rather than produced by recipes
+	Resources []string
+	// Fluid items, carried by pipes rather than belts
+	Fluids  []string
+	Recipes []RecipeDef
+}
+
+// Index returns the index of the recipe of the given class, -1 if not found
+func (rb RecipeBook) Index(class string) int {
+	return slices.IndexFunc(rb.Recipes, func(r RecipeDef) bool { return r.Class == class })
+}
+
+// IsResource returns whether item is a raw resource
+func (rb RecipeBook) IsResource(item string) bool { return slices.Contains(rb.Resources, item) }
+
+// IsFluid returns whether item is a fluid
+func (rb RecipeBook) IsFluid(item string) bool { return slices.Contains(rb.Fluids, item) }
+
+// Items returns the sorted items produced by a recipe, resources excluded
+func (rb RecipeBook) Items() []string {
+	var items []string
+	for _, r := range rb.Recipes {
+		for _, ia := range r.Out {
+			if !rb.IsResource(ia.Item) && !slices.Contains(items, ia.Item) {
+				items = append(items, ia.Item)
+			}
+		}
+	}
+	slices.Sort(items)
+	return items
+}
+
+// Alternates returns the classes of the alternate recipes, in the book order
+func (rb RecipeBook) Alternates() []string {
+	var classes []string
+	for _, r := range rb.Recipes {
+		if r.Alternate {
+			classes = append(classes, r.Class)
+		}
+	}
+	return classes
+}
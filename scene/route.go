@@ -0,0 +1,345 @@
+// route - Automatic path routing between two building ports
+
+package scene
+
+import (
+	"container/heap"
+	"errors"
+	"slices"
+
+	"github.com/bonoboris/satisfied/log"
+	"github.com/bonoboris/satisfied/math32"
+	rl "github.com/bonoboris/satisfied/rlmath"
+)
+
+const (
+	// Distance from a port within which it is picked (see [Scene.PortAt])
+	portPickRadius = 1
+	// Free space around the ports bounding box explored by the routing, in grid steps
+	routeMargin = 32
+	// Maximum number of grid nodes explored by the routing
+	routeMaxNodes = 1 << 16
+	// Extra cost of a bend, in grid steps, to favor routes with few bends
+	routeBendCost = 4
+	// Tolerance on obstacles overlaps, so that touching objects do not block the route
+	routeEps = 1e-3
+)
+
+var (
+	ErrRouteSamePort     = errors.New("source and target ports are the same")
+	ErrRouteSameKind     = errors.New("ports are both inputs or both outputs")
+	ErrRouteUnaligned    = errors.New("ports are not aligned on the grid")
+	ErrRouteTooFar       = errors.New("ports are too far apart")
+	ErrRouteTooManyBends = errors.New("route has too many bends")
+	ErrRouteNotFound     = errors.New("no route found")
+)
+
+// PortAt returns the building port closest to pos, if any is within reach
+func (s Scene) PortAt(pos rl.Vector2) (Port, bool) {
+	var buf [4 * MAX_INOUT]Port
+	var best Port
+	bestDist := float32(portPickRadius * portPickRadius)
+	found := false
+	for _, b := range s.Buildings {
+		if !isNearRec(b.Bounds(), pos, portPickRadius) {
+			continue
+		}
+		for _, p := range b.Ports(buf[:0]) {
+			if d := p.Pos.DistanceSqr(pos); d <= bestDist {
+				best, bestDist, found = p, d, true
+			}
+		}
+	}
+	return best, found
+}
+
+// isNearRec returns true if pos is at most dist away from the rectangle
+func isNearRec(rec rl.Rectangle, pos rl.Vector2, dist float32) bool {
+	return pos.X >= rec.X-dist && pos.X <= rec.X+rec.Width+dist &&
+		pos.Y >= rec.Y-dist && pos.Y <= rec.Y+rec.Height+dist
+}
+
+// Route returns a path of the given definition linking 2 ports, made of horizontal and vertical
+// segments on the 1m grid, and avoiding the scene buildings, belts and pipes.
+//
+// The path leaves and enters the ports perpendicularly to the building edge, with at least the
+// path [PathDef.BendRadius] before the first bend and after the last one, and twice that between
+// 2 bends. It goes from the output to the input port, directional paths (belts) cannot link 2 inputs
+// or 2 outputs.
+//
+// The route is found with an A* search over the grid nodes, favoring routes with few bends.
+func (s Scene) Route(defIdx int, from, to Port) (Path, error) {
+	return s.route(defIdx, from, to, 0)
+}
+
+// route is [Scene.Route] with at most maxSteps search steps (0 for no limit), to bound the time
+// spent on ports that cannot be linked
+func (s Scene) route(defIdx int, from, to Port, maxSteps int) (Path, error) {
+	if from.Pos == to.Pos {
+		return Path{}, ErrRouteSamePort
+	}
+	if pathDefs[defIdx].IsDirectional && from.IsOutput == to.IsOutput {
+		return Path{}, ErrRouteSameKind
+	}
+	if !from.IsOutput && to.IsOutput {
+		return s.route(defIdx, to, from, maxSteps)
+	}
+	r, err := newRouter(s, defIdx, from, to)
+	if err != nil {
+		return Path{}, err
+	}
+	r.maxSteps = maxSteps
+	nodes, ok := r.search()
+	if !ok {
+		log.Debug("scene.route", "from", from, "to", to, "err", ErrRouteNotFound)
+		return Path{}, ErrRouteNotFound
+	}
+
+	path := Path{DefIdx: defIdx, Start: from.Pos, End: to.Pos}
+	for i := 1; i < len(nodes)-1; i++ {
+		if nodes[i].dir == nodes[i+1].dir {
+			continue
+		}
+		if !path.Vertices.Append(r.worldPos(nodes[i].idx)) {
+			return Path{}, ErrRouteTooManyBends
+		}
+	}
+	log.Debug("scene.route", "from", from, "to", to, "path", path)
+	return path, nil
+}
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
+// Router
+////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// Grid directions: right, down, left, up
+var routeDirs = [4][2]int{{1, 0}, {0, 1}, {-1, 0}, {0, -1}}
+
+// routeDir returns the grid direction of an axis aligned unit vector
+func routeDir(v rl.Vector2) (int, bool) {
+	for d, dv := range routeDirs {
+		if math32.Abs(v.X-float32(dv[0])) < routeEps && math32.Abs(v.Y-float32(dv[1])) < routeEps {
+			return d, true
+		}
+	}
+	return 0, false
+}
+
+// routeState is a search state: a grid node, the direction it was entered from, and the straight
+// length since the last bend (capped)
+type routeState struct {
+	idx int
+	dir int
+	run int
+}
+
+// router holds the routing grid and search state
+type router struct {
+	// grid origin (world coordinates of node 0) and size
+	origin rl.Vector2
+	nx, ny int
+	// blocked nodes, indexed by iy*nx+ix
+	blocked []bool
+	// start and goal nodes, start and goal (entering) directions
+	start, goal       int
+	startDir, goalDir int
+	// minimum straight length after the start and before the goal, in grid steps
+	bend int
+	// maximum number of states expanded by the search, 0 for no limit
+	maxSteps int
+}
+
+func newRouter(s Scene, defIdx int, from, to Port) (*router, error) {
+	startDir, ok1 := routeDir(from.Dir)
+	outDir, ok2 := routeDir(to.Dir)
+	delta := to.Pos.Subtract(from.Pos)
+	if !ok1 || !ok2 ||
+		math32.Abs(delta.X-math32.Round(delta.X)) > routeEps || math32.Abs(delta.Y-math32.Round(delta.Y)) > routeEps {
+		return nil, ErrRouteUnaligned
+	}
+
+	// grid anchored on the source port
+	lo := vec2(min(0, math32.Round(delta.X)), min(0, math32.Round(delta.Y)))
+	hi := vec2(max(0, math32.Round(delta.X)), max(0, math32.Round(delta.Y)))
+	r := &router{
+		origin:   from.Pos.Add(lo).Subtract(vec2(routeMargin, routeMargin)),
+		nx:       int(hi.X-lo.X) + 2*routeMargin + 1,
+		ny:       int(hi.Y-lo.Y) + 2*routeMargin + 1,
+		startDir: startDir,
+		goalDir:  (outDir + 2) % 4,
+		bend:     int(math32.Ceil(pathDefs[defIdx].BendRadius)),
+	}
+	if r.nx*r.ny > routeMaxNodes {
+		return nil, ErrRouteTooFar
+	}
+	r.start = r.nodeIdx(from.Pos)
+	r.goal = r.nodeIdx(to.Pos)
+	r.blocked = make([]bool, r.nx*r.ny)
+
+	// nodes where the path body would overlap a building or a belt or pipe body
+	hw := pathDefs[defIdx].Width / 2
+	for _, b := range s.Buildings {
+		bounds := b.Bounds()
+		inflated := rl.NewRectangle(bounds.X-hw+routeEps, bounds.Y-hw+routeEps, bounds.Width+2*hw-2*routeEps, bounds.Height+2*hw-2*routeEps)
+		r.blockRec(inflated, func(rl.Vector2) bool { return true })
+	}
+	var buf [MAX_POLYLINE_POINTS]rl.Vector2
+	for _, p := range s.Paths {
+		if p.Def().IsPowerLine {
+			// power lines hang above the belts and pipes
+			continue
+		}
+		m := hw + p.Def().Width/2 - routeEps
+		points := p.Polyline(buf[:0])
+		for i := 1; i < len(points); i++ {
+			a, b := points[i-1], points[i]
+			aabb := rl.NewRectangle(min(a.X, b.X)-m, min(a.Y, b.Y)-m, math32.Abs(a.X-b.X)+2*m, math32.Abs(a.Y-b.Y)+2*m)
+			r.blockRec(aabb, func(pos rl.Vector2) bool {
+				return CheckCollisionRecLine(rl.NewRectangle(pos.X-m, pos.Y-m, 2*m, 2*m), a, b)
+			})
+		}
+	}
+	log.Debug("scene.newRouter", "origin", r.origin, "nx", r.nx, "ny", r.ny, "bend", r.bend)
+	return r, nil
+}
+
+// nodeIdx returns the index of the grid node at pos (which must be on the grid)
+func (r *router) nodeIdx(pos rl.Vector2) int {
+	ix := int(math32.Round(pos.X - r.origin.X))
+	iy := int(math32.Round(pos.Y - r.origin.Y))
+	return iy*r.nx + ix
+}
+
+// worldPos returns the world coordinates of a grid node
+func (r *router) worldPos(idx int) rl.Vector2 {
+	return r.origin.Add(vec2(float32(idx%r.nx), float32(idx/r.nx)))
+}
+
+// blockRec blocks the grid nodes strictly inside rec for which isBlocked returns true
+func (r *router) blockRec(rec rl.Rectangle, isBlocked func(pos rl.Vector2) bool) {
+	x0 := max(0, int(math32.Floor(rec.X-r.origin.X))+1)
+	y0 := max(0, int(math32.Floor(rec.Y-r.origin.Y))+1)
+	x1 := min(r.nx-1, int(math32.Ceil(rec.X+rec.Width-r.origin.X))-1)
+	y1 := min(r.ny-1, int(math32.Ceil(rec.Y+rec.Height-r.origin.Y))-1)
+	for iy := y0; iy <= y1; iy++ {
+		for ix := x0; ix <= x1; ix++ {
+			idx := iy*r.nx + ix
+			if !r.blocked[idx] && isBlocked(r.worldPos(idx)) {
+				r.blocked[idx] = true
+			}
+		}
+	}
+}
+
+// next returns the node next to idx in direction dir, if inside the grid
+func (r *router) next(idx, dir int) (int, bool) {
+	ix, iy := idx%r.nx+routeDirs[dir][0], idx/r.nx+routeDirs[dir][1]
+	if ix < 0 || ix >= r.nx || iy < 0 || iy >= r.ny {
+		return 0, false
+	}
+	return iy*r.nx + ix, true
+}
+
+// heuristic returns the manhattan distance from idx to the goal
+func (r *router) heuristic(idx int) int {
+	dx, dy := idx%r.nx-r.goal%r.nx, idx/r.nx-r.goal/r.nx
+	return max(dx, -dx) + max(dy, -dy)
+}
+
+// key returns the index of a search state in the search arrays
+func (r *router) key(s routeState) int { return (s.idx*4+s.dir)*(2*r.bend+1) + s.run }
+
+// state returns the search state of a key (reverse of [router.key])
+func (r *router) state(key int) routeState {
+	runs := 2*r.bend + 1
+	return routeState{idx: key / runs / 4, dir: key / runs % 4, run: key % runs}
+}
+
+// search returns the states from the start to the goal, with the lowest cost
+func (r *router) search() ([]routeState, bool) {
+	n := r.nx * r.ny * 4 * (2*r.bend + 1)
+	// cost from the start and previous state key, -1 if not reached yet
+	cost := make([]int32, n)
+	parent := make([]int32, n)
+	for i := range cost {
+		cost[i] = -1
+	}
+	// the start run counts as half a bend: the first bend only needs bend steps after the start
+	startState := routeState{idx: r.start, dir: r.startDir, run: r.bend}
+	cost[r.key(startState)] = 0
+	open := &routeQueue{{state: startState, priority: r.heuristic(r.start)}}
+
+	for steps := 0; open.Len() > 0; {
+		item := heap.Pop(open).(routeItem)
+		cur := item.state
+		curCost := int(cost[r.key(cur)])
+		if item.priority-r.heuristic(cur.idx) > curCost {
+			continue // outdated queue item
+		}
+		if steps++; r.maxSteps > 0 && steps > r.maxSteps {
+			return nil, false
+		}
+		if cur.idx == r.goal {
+			states := []routeState{cur}
+			for cur != startState {
+				cur = r.state(int(parent[r.key(cur)]))
+				states = append(states, cur)
+			}
+			slices.Reverse(states)
+			return states, true
+		}
+
+		for _, turn := range [3]int{0, 1, 3} {
+			dir := (cur.dir + turn) % 4
+			step := 1
+			run := min(cur.run+1, 2*r.bend)
+			if turn != 0 {
+				if cur.run < 2*r.bend {
+					continue
+				}
+				step += routeBendCost
+				run = min(1, 2*r.bend)
+			}
+			idx, ok := r.next(cur.idx, dir)
+			if !ok {
+				continue
+			}
+			if idx == r.goal {
+				if dir != r.goalDir || run < r.bend {
+					continue
+				}
+			} else if r.blocked[idx] || idx == r.start {
+				continue
+			}
+			next := routeState{idx: idx, dir: dir, run: run}
+			k := r.key(next)
+			if c := cost[k]; c >= 0 && int(c) <= curCost+step {
+				continue
+			}
+			cost[k] = int32(curCost + step)
+			parent[k] = int32(r.key(cur))
+			heap.Push(open, routeItem{state: next, priority: curCost + step + r.heuristic(idx)})
+		}
+	}
+	return nil, false
+}
+
+// routeItem is a [routeQueue] item
+type routeItem struct {
+	state    routeState
+	priority int
+}
+
+// routeQueue is the A* open set, a min-heap on the items priority (see [heap.Interface])
+type routeQueue []routeItem
+
+func (q routeQueue) Len() int           { return len(q) }
+func (q routeQueue) Less(i, j int) bool { return q[i].priority < q[j].priority }
+func (q routeQueue) Swap(i, j int)      { q[i], q[j] = q[j], q[i] }
+func (q *routeQueue) Push(x any)        { *q = append(*q, x.(routeItem)) }
+func (q *routeQueue) Pop() any {
+	old := *q
+	item := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return item
+}
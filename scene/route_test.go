@@ -0,0 +1,149 @@
+package scene
+
+import (
+	"slices"
+	"testing"
+
+	rl "github.com/bonoboris/satisfied/rlmath"
+)
+
+func TestBuildingPorts(t *testing.T) {
+	tests := []struct {
+		b    Building
+		want []Port
+	}{
+		{building(defConstructor, 0, 0, 0), []Port{
+			{Pos: vec2(0, 5), Dir: vec2(0, 1)},
+			{Pos: vec2(0, -5), Dir: vec2(0, -1), IsOutput: true},
+		}},
+		{building(defConstructor, 0, 0, 90), []Port{
+			{Pos: vec2(-5, 0), Dir: vec2(-1, 0)},
+			{Pos: vec2(5, 0), Dir: vec2(1, 0), IsOutput: true},
+		}},
+		{building(defFoundation, 0, 0, 0), nil},
+	}
+	for _, tt := range tests {
+		if got := tt.b.Ports(nil); !slices.Equal(got, tt.want) {
+			t.Errorf("%v.Ports() = %v, want %v", tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestScenePortAt(t *testing.T) {
+	s := NewScene(ObjectCollection{Buildings: []Building{building(defConstructor, 0, 0, 0)}})
+	if p, ok := s.PortAt(vec2(0.5, -5.5)); !ok || !p.IsOutput {
+		t.Errorf("PortAt() = %v, %v, want the output", p, ok)
+	}
+	if p, ok := s.PortAt(vec2(0, 0)); ok {
+		t.Errorf("PortAt() = %v, want no port", p)
+	}
+}
+
+// checkRoute checks the path is made of horizontal and vertical segments avoiding the buildings
+// and (straight) paths, respecting the bend radius
+func checkRoute(t *testing.T, s *Scene, p Path) {
+	t.Helper()
+	points := p.Points(nil)
+	bend := p.Def().BendRadius
+	hw := p.Def().Width / 2
+	var obstacles []rl.Rectangle
+	for _, b := range s.Buildings {
+		obstacles = append(obstacles, b.Bounds())
+	}
+	for _, other := range s.Paths {
+		obstacles = append(obstacles, other.Bounds())
+	}
+	for i := 1; i < len(points); i++ {
+		a, b := points[i-1], points[i]
+		if a.X != b.X && a.Y != b.Y {
+			t.Errorf("%v: segment %d is not horizontal or vertical", p, i-1)
+		}
+		minLength := 2 * bend
+		if i == 1 || i == len(points)-1 {
+			minLength = bend
+		}
+		if l := a.Distance(b); l < minLength {
+			t.Errorf("%v: segment %d length = %v, want >= %v", p, i-1, l, minLength)
+		}
+		// the ends on the ports are against their building
+		u := b.Subtract(a).Normalize().Scale(hw + 0.01)
+		if i == 1 {
+			a = a.Add(u)
+		}
+		if i == len(points)-1 {
+			b = b.Subtract(u)
+		}
+		for _, r := range obstacles {
+			inflated := rl.NewRectangle(r.X-hw+0.01, r.Y-hw+0.01, r.Width+2*hw-0.02, r.Height+2*hw-0.02)
+			if CheckCollisionRecLine(inflated, a, b) {
+				t.Errorf("%v: segment %d crosses %v", p, i-1, r)
+			}
+		}
+	}
+}
+
+func TestSceneRoute(t *testing.T) {
+	s := NewScene(ObjectCollection{Buildings: []Building{
+		building(defConstructor, 0, 0, 0),
+		building(defConstructor, 0, -30, 0),
+	}})
+	out := Port{Pos: vec2(0, -5), Dir: vec2(0, -1), IsOutput: true}
+	in := Port{Pos: vec2(0, -25), Dir: vec2(0, 1)}
+
+	p, err := s.Route(defBelt, out, in)
+	if want := path(defBelt, 0, -5, 0, -25); err != nil || p != want {
+		t.Errorf("Route() = %v, %v, want %v", p, err, want)
+	}
+	// from the output to the input
+	if p, err := s.Route(defBelt, in, out); err != nil || p.Start != out.Pos {
+		t.Errorf("Route(in, out) = %v, %v, want starting at the output", p, err)
+	}
+	// with too few search steps
+	if p, err := s.route(defBelt, out, in, 10); err != ErrRouteNotFound {
+		t.Errorf("route(maxSteps=10) = %v, %v, want %v", p, err, ErrRouteNotFound)
+	}
+
+	// around a foundation
+	s.AddBuilding(building(defFoundation, 0, -15, 0))
+	p, err = s.Route(defBelt, out, in)
+	if err != nil {
+		t.Fatalf("Route() around foundation error: %v", err)
+	}
+	if p.Vertices.Len() != 4 {
+		t.Errorf("Route() around foundation = %v, want 4 bends", p)
+	}
+	checkRoute(t, s, p)
+
+	// and a path
+	s.AddPath(path(defBelt, -20, -15, 20, -15))
+	if p, err := s.Route(defBelt, out, in); err == nil {
+		checkRoute(t, s, p)
+	} else {
+		t.Errorf("Route() around path error: %v", err)
+	}
+}
+
+func TestSceneRouteErrors(t *testing.T) {
+	s := NewScene(ObjectCollection{Buildings: []Building{
+		building(defConstructor, 0, 0, 0),
+		building(defConstructor, 20, -30, 0),
+		building(defFoundation, 20, -21, 0), // against the input
+	}})
+	out := Port{Pos: vec2(0, -5), Dir: vec2(0, -1), IsOutput: true}
+	tests := []struct {
+		name string
+		to   Port
+		want error
+	}{
+		{"same port", out, ErrRouteSamePort},
+		{"output to output", Port{Pos: vec2(20, -35), Dir: vec2(0, -1), IsOutput: true}, ErrRouteSameKind},
+		{"unaligned", Port{Pos: vec2(0.5, -25), Dir: vec2(0, 1)}, ErrRouteUnaligned},
+		{"too far", Port{Pos: vec2(0, -5000), Dir: vec2(0, 1)}, ErrRouteTooFar},
+		{"blocked", Port{Pos: vec2(20, -25), Dir: vec2(0, 1)}, ErrRouteNotFound},
+	}
+	for _, tt := range tests {
+		if p, err := s.Route(defBelt, out, tt.to); err != tt.want {
+			t.Errorf("%s: Route() = %v, %v, want error %v", tt.name, p, err, tt.want)
+		}
+	}
+}
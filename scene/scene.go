@@ -0,0 +1,957 @@
+// scene - Scene objects, operations history (undo / redo) and save / load
+
+package scene
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/bonoboris/satisfied/log"
+	rl "github.com/bonoboris/satisfied/rlmath"
+)
+
+// Scene holds the scene objects (buildings and paths)
+type Scene struct {
+	ObjectCollection
+	// History of scene operations (undo / redo)
+	history []sceneOp
+	// Current history position:
+	//   - history[:historyPos] all have been done
+	//   - history[historyPos:] all have been undone (if existing)
+	historyPos int
+
+	// History position the last time the scene was saved (-1 if never saved in its current state)
+	savedHistoryPos int
+	// Number of operations done / undone / redone, used to detect changes (see [Scene.Revision])
+	revision int
+	// Last ID given to an object, IDs are never reused even if the object is deleted
+	lastID ID
+}
+
+// NewScene returns a scene containing the given objects, without history.
+//
+// Objects without ID are given one.
+func NewScene(oc ObjectCollection) *Scene {
+	s := &Scene{ObjectCollection: oc, lastID: oc.maxID()}
+	for i := range s.Buildings {
+		if s.Buildings[i].ID == 0 {
+			s.Buildings[i].ID = s.newID()
+		}
+	}
+	for i := range s.Paths {
+		if s.Paths[i].ID == 0 {
+			s.Paths[i].ID = s.newID()
+		}
+	}
+	for i := range s.TextBoxes {
+		if s.TextBoxes[i].ID == 0 {
+			s.TextBoxes[i].ID = s.newID()
+		}
+	}
+	return s
+}
+
+// newID returns a new object ID
+func (s *Scene) newID() ID {
+	s.lastID++
+	return s.lastID
+}
+
+func (s Scene) traceState(key, val string) {
+	if log.WillTrace() {
+		if key != "" && val != "" {
+			log.Trace("scene", key, val)
+		}
+
+		for i, b := range s.Buildings {
+			log.Trace("scene.buildings", "i", i, "value", b)
+		}
+		for i, p := range s.Paths {
+			log.Trace("scene.paths", "i", i, "value", p)
+		}
+		for i, tb := range s.TextBoxes {
+			log.Trace("scene.textboxes", "i", i, "value", tb)
+		}
+		log.Trace("scene", "historyPos", s.historyPos, "savedHistoryPos", s.savedHistoryPos, "lastID", s.lastID)
+		for i, op := range s.history {
+			log.Trace("scene.history", "i", i, "op", op)
+		}
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
+// sceneOp (undo / redo)
+////////////////////////////////////////////////////////////////////////////////////////////////////
+
+type sceneOpType string
+
+const (
+	SceneOpAdd    sceneOpType = "add"
+	SceneOpDelete sceneOpType = "delete"
+	SceneOpModify sceneOpType = "modify"
+)
+
+// sceneOp represents a scene operation
+type sceneOp struct {
+	// Type is the type of the operation
+	Type sceneOpType
+	// Sel is the selection the operation acts on (empty for [SceneOpAdd])
+	Sel ObjectSelection
+	// Old is the objects before the operation (empty for [SceneOpAdd])
+	//
+	// - in [SceneOpDelete] Old.Paths contains only the deleted paths ([ObjectSelection.FullPathIdxs])
+	// - in [SceneOpModify] Old.Paths contains all the paths ([ObjectSelection.AnyPathIdxs])
+	Old ObjectCollection
+	// New is the objects after the operation (empty for [SceneOpDelete])
+	New ObjectCollection
+}
+
+func (op sceneOp) traceState() {
+	switch op.Type {
+	case SceneOpAdd:
+		log.Trace("scene.operation", "type", "add", "New", op.New)
+	case SceneOpDelete:
+		log.Trace("scene.operation", "type", "delete", "Sel", op.Sel, "Old", op.Old)
+	case SceneOpModify:
+		log.Trace("scene.operation", "type", "modify", "Sel", op.Sel, "Old", op.Old, "New", op.New)
+	default:
+		panic("invalid scene operation type")
+	}
+}
+
+// do performs the operation
+func (op sceneOp) do(s *Scene) {
+	s.traceState("before", "sceneOp.do")
+	op.traceState()
+	log.Info("scene.operation", "do", string(op.Type))
+	switch op.Type {
+
+	case SceneOpAdd:
+		log.Debug("scene.operation.add", "action", "do",
+			"num_paths", len(op.New.Paths),
+			"num_buildings", len(op.New.Buildings),
+			"num_textboxes", len(op.New.TextBoxes))
+
+		s.Paths = append(s.Paths, op.New.Paths...)
+		s.Buildings = append(s.Buildings, op.New.Buildings...)
+		s.TextBoxes = append(s.TextBoxes, op.New.TextBoxes...)
+
+	case SceneOpDelete:
+		pathIdxs := op.Sel.FullPathIdxs()
+		log.Debug("scene.operation.delete", "action", "do",
+			"paths", pathIdxs, "buildings", op.Sel.BuildingIdxs, "textboxes", op.Sel.TextBoxIdxs)
+		s.Paths = SwapDeleteMany(s.Paths, op.Sel.FullPathIdxs())
+		s.Buildings = SwapDeleteMany(s.Buildings, op.Sel.BuildingIdxs)
+		s.TextBoxes = SwapDeleteMany(s.TextBoxes, op.Sel.TextBoxIdxs)
+
+	case SceneOpModify:
+		pathIdxs := op.Sel.AnyPathIdxs()
+		log.Debug("scene.operation.modify", "action", "do",
+			"paths", pathIdxs, "buildings", op.Sel.BuildingIdxs, "textboxes", op.Sel.TextBoxIdxs)
+		for i, idx := range pathIdxs {
+			s.Paths[idx] = op.New.Paths[i]
+		}
+		for i, idx := range op.Sel.BuildingIdxs {
+			s.Buildings[idx] = op.New.Buildings[i]
+		}
+		for i, idx := range op.Sel.TextBoxIdxs {
+			s.TextBoxes[idx] = op.New.TextBoxes[i]
+		}
+
+	default:
+		panic("invalid scene operation type")
+	}
+	s.traceState("after", "sceneOp.do")
+}
+
+// redo performs the operation and returns the new selection, if any
+func (op sceneOp) redo(s *Scene) ObjectSelection {
+	s.traceState("before", "sceneOp.redo")
+	op.traceState()
+	log.Info("scene.operation", "redo", string(op.Type))
+
+	var newSel ObjectSelection
+
+	switch op.Type {
+
+	case SceneOpAdd:
+		log.Debug("scene.operation.add", "action", "redo",
+			"num_paths", len(op.New.Paths),
+			"num_buildings", len(op.New.Buildings),
+			"num_textboxes", len(op.New.TextBoxes))
+		s.Paths = append(s.Paths, op.New.Paths...)
+		s.Buildings = append(s.Buildings, op.New.Buildings...)
+		s.TextBoxes = append(s.TextBoxes, op.New.TextBoxes...)
+
+		newSel = ObjectSelection{
+			BuildingIdxs: Range(len(s.Buildings)-len(op.New.Buildings), len(s.Buildings)),
+			TextBoxIdxs:  Range(len(s.TextBoxes)-len(op.New.TextBoxes), len(s.TextBoxes)),
+		}
+		n := len(s.Paths) - len(op.New.Paths)
+		for i := range len(op.New.Paths) {
+			newSel.PathIdxs = append(newSel.PathIdxs, PathSel{Idx: n + i, Start: true, End: true})
+		}
+		newSel.RecomputeBounds(s.ObjectCollection)
+
+	case SceneOpDelete:
+		pathIdxs := op.Sel.FullPathIdxs()
+		log.Debug("scene.operation.delete", "action", "redo",
+			"paths", pathIdxs, "buildings", op.Sel.BuildingIdxs, "textboxes", op.Sel.TextBoxIdxs)
+		s.Paths = SwapDeleteMany(s.Paths, pathIdxs)
+		s.Buildings = SwapDeleteMany(s.Buildings, op.Sel.BuildingIdxs)
+		s.TextBoxes = SwapDeleteMany(s.TextBoxes, op.Sel.TextBoxIdxs)
+
+	case SceneOpModify:
+		pathIdxs := op.Sel.AnyPathIdxs()
+		log.Debug("scene.operation.modify", "action", "redo",
+			"paths", pathIdxs, "buildings", op.Sel.BuildingIdxs, "textboxes", op.Sel.TextBoxIdxs)
+		for i, idx := range pathIdxs {
+			s.Paths[idx] = op.New.Paths[i]
+		}
+		for i, idx := range op.Sel.BuildingIdxs {
+			s.Buildings[idx] = op.New.Buildings[i]
+		}
+		for i, idx := range op.Sel.TextBoxIdxs {
+			s.TextBoxes[idx] = op.New.TextBoxes[i]
+		}
+		newSel = op.Sel
+		newSel.RecomputeBounds(s.ObjectCollection)
+
+	default:
+		panic("invalid scene operation type")
+	}
+	s.traceState("after", "sceneOp.redo")
+	return newSel
+}
+
+// undo performs the operation and returns the new selection, if any
+func (op sceneOp) undo(s *Scene) ObjectSelection {
+	s.traceState("before", "sceneOp.undo")
+	op.traceState()
+	log.Info("scene.operation", "undo", string(op.Type))
+
+	var newSel ObjectSelection
+
+	switch op.Type {
+	case SceneOpAdd:
+		log.Debug("scene.operation.add", "action", "undo",
+			"num_paths", len(op.New.Paths),
+			"num_buildings", len(op.New.Buildings),
+			"num_textboxes", len(op.New.TextBoxes))
+		s.Paths = s.Paths[:len(s.Paths)-len(op.New.Paths)]
+		s.Buildings = s.Buildings[:len(s.Buildings)-len(op.New.Buildings)]
+		s.TextBoxes = s.TextBoxes[:len(s.TextBoxes)-len(op.New.TextBoxes)]
+
+	case SceneOpDelete:
+		pathIdxs := op.Sel.FullPathIdxs()
+		log.Debug("scene.operation.delete", "action", "undo",
+			"paths", pathIdxs, "buildinds", op.Sel.BuildingIdxs, "textboxes", op.Sel.TextBoxIdxs)
+		s.Paths = SwapInsertMany(s.Paths, pathIdxs, op.Old.Paths)
+		s.Buildings = SwapInsertMany(s.Buildings, op.Sel.BuildingIdxs, op.Old.Buildings)
+		s.TextBoxes = SwapInsertMany(s.TextBoxes, op.Sel.TextBoxIdxs, op.Old.TextBoxes)
+
+		newSel = op.Sel
+
+	case SceneOpModify:
+		pathIdxs := op.Sel.AnyPathIdxs()
+		log.Debug("scene.operation.modify", "action", "redo",
+			"paths", pathIdxs, "buildings", op.Sel.BuildingIdxs, "textboxes", op.Sel.TextBoxIdxs)
+		for i, idx := range pathIdxs {
+			s.Paths[idx] = op.Old.Paths[i]
+		}
+		for i, idx := range op.Sel.BuildingIdxs {
+			s.Buildings[idx] = op.Old.Buildings[i]
+		}
+		for i, idx := range op.Sel.TextBoxIdxs {
+			s.TextBoxes[idx] = op.Old.TextBoxes[i]
+		}
+
+		newSel = op.Sel
+		newSel.RecomputeBounds(s.ObjectCollection)
+
+	default:
+		panic("invalid scene operation type")
+	}
+	s.traceState("after", "sceneOp.undo")
+	return newSel
+}
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
+// Scene Modifiers methods
+////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// doSceneOp adds the given operation to the scene history and performs it
+func (s *Scene) doSceneOp(op sceneOp) {
+	if s.savedHistoryPos > s.historyPos {
+		// the saved state is in the undone operations, it cannot be reached anymore
+		s.savedHistoryPos = -1
+	}
+	s.history = s.history[:s.historyPos] // trim any undone operations
+	op.do(s)                             // actually perform the operation
+	s.history = append(s.history, op)    // append the operation to the history
+	s.historyPos++                       // increment history position
+	s.revision++                         // increment revision
+}
+
+// AddPath adds the given path to the scene, with a new ID.
+//
+// No validity check is performed.
+func (s *Scene) AddPath(path Path) {
+	path.ID = s.newID()
+	s.doSceneOp(sceneOp{Type: SceneOpAdd, New: ObjectCollection{Paths: []Path{path}}})
+}
+
+// AddBuilding adds the given building to the scene, with a new ID.
+//
+// No validity check is performed.
+func (s *Scene) AddBuilding(building Building) {
+	building.ID = s.newID()
+	s.doSceneOp(sceneOp{Type: SceneOpAdd, New: ObjectCollection{Buildings: []Building{building}}})
+}
+
+// AddTextBox adds the given text box to the scene, with a new ID.
+func (s *Scene) AddTextBox(tb TextBox) {
+	tb.ID = s.newID()
+	s.doSceneOp(sceneOp{Type: SceneOpAdd, New: ObjectCollection{TextBoxes: []TextBox{tb}}})
+}
+
+// AddObjects adds the given paths and buildings to the scene, with new IDs.
+//
+// No validity checks is performed.
+func (s *Scene) AddObjects(col ObjectCollection) {
+	col = col.Clone()
+	for i := range col.Buildings {
+		col.Buildings[i].ID = s.newID()
+	}
+	for i := range col.Paths {
+		col.Paths[i].ID = s.newID()
+	}
+	for i := range col.TextBoxes {
+		col.TextBoxes[i].ID = s.newID()
+	}
+	s.doSceneOp(sceneOp{Type: SceneOpAdd, New: col})
+}
+
+// DeleteObjects deletes the given paths and buildings from the scene.
+func (s *Scene) DeleteObjects(sel ObjectSelection) {
+	sel = sel.Clone()
+	op := sceneOp{Type: SceneOpDelete, Sel: sel}
+	op.Old.Buildings = CopyIdxs(op.Old.Buildings, s.Buildings, sel.BuildingIdxs)
+	op.Old.Paths = CopyIdxs(op.Old.Paths, s.Paths, sel.FullPathIdxs())
+	op.Old.TextBoxes = CopyIdxs(op.Old.TextBoxes, s.TextBoxes, sel.TextBoxIdxs)
+	s.doSceneOp(op)
+}
+
+// ModifyObjects updates the given paths and buildings in the scene, they keep their IDs.
+//
+// No validity checks is performed.
+func (s *Scene) ModifyObjects(sel ObjectSelection, new ObjectCollection) {
+	sel = sel.Clone()
+	op := sceneOp{Type: SceneOpModify, Sel: sel, New: new.Clone()}
+	op.Old.Buildings = CopyIdxs(op.Old.Buildings, s.Buildings, sel.BuildingIdxs)
+	op.Old.Paths = CopyIdxs(op.Old.Paths, s.Paths, sel.AnyPathIdxs())
+	op.Old.TextBoxes = CopyIdxs(op.Old.TextBoxes, s.TextBoxes, sel.TextBoxIdxs)
+	for i := range op.New.Buildings {
+		op.New.Buildings[i].ID = op.Old.Buildings[i].ID
+	}
+	for i := range op.New.Paths {
+		op.New.Paths[i].ID = op.Old.Paths[i].ID
+	}
+	for i := range op.New.TextBoxes {
+		op.New.TextBoxes[i].ID = op.Old.TextBoxes[i].ID
+	}
+	s.doSceneOp(op)
+}
+
+// Undo tries to undo the last operation, and returns the new selection and whether it has.
+func (s *Scene) Undo() (ObjectSelection, bool) {
+	if s.historyPos > 0 {
+		s.historyPos-- // decrement history position
+		s.revision++   // increment revision
+		op := s.history[s.historyPos]
+		return op.undo(s), true
+	}
+	log.Warn("cannot undo operation", "reason", "no more operations to undo")
+	return ObjectSelection{}, false
+}
+
+// Redo tries to redo the last undone operation, and returns the new selection and whether it has.
+func (s *Scene) Redo() (ObjectSelection, bool) {
+	if s.historyPos < len(s.history) {
+		op := s.history[s.historyPos]
+		s.historyPos++ // increment history position
+		s.revision++   // increment revision
+		return op.redo(s), true
+	}
+	log.Warn("cannot redo operation", "reason", "no more operations to redo")
+	return ObjectSelection{}, false
+}
+
+// HasUndo returns true if there are more undo operations to perform
+func (s *Scene) HasUndo() bool { return s.historyPos > 0 }
+
+// HasRedo returns true if there are more redo operations to perform
+func (s *Scene) HasRedo() bool { return s.historyPos < len(s.history) }
+
+// IsModified returns true if the scene has been modified since last save
+func (s *Scene) IsModified() bool {
+	return s.historyPos != s.savedHistoryPos
+}
+
+// RemapDefs remaps the objects of the scene and its history to new definitions, the revision is
+// incremented but the scene is not marked as modified (see [DefsRemap])
+func (s *Scene) RemapDefs(r *DefsRemap) {
+	s.ObjectCollection = r.Collection(s.ObjectCollection)
+	for i := range s.history {
+		s.history[i].Old = r.Collection(s.history[i].Old)
+		s.history[i].New = r.Collection(s.history[i].New)
+	}
+	s.revision++
+}
+
+// Revision returns a counter incremented on every change (operation, undo or redo)
+func (s *Scene) Revision() int { return s.revision }
+
+// SetModified sets the scene modified flag until next [Scene.ResetModified]
+//
+// Used when the scene is loaded from a file which is not the project file (eg: autosave).
+func (s *Scene) SetModified() {
+	s.savedHistoryPos = -1
+	log.Debug("scene.setModified", "savedHistoryPos", s.savedHistoryPos)
+}
+
+// ResetModified resets the scene modified flag
+func (s *Scene) ResetModified() {
+	s.traceState("before", "ResetModified")
+	s.savedHistoryPos = s.historyPos
+	log.Debug("scene.resetModified", "savedHistoryPos", s.savedHistoryPos)
+	s.traceState("after", "ResetModified")
+}
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
+// Scene other methods
+////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// GetObjectAt returns the object at the given position (world coordinates), objects in sel first
+//
+// If multiple objects are at the position returns first one on this list:
+//   - selected path with highest index: start / end over body
+//   - selected building with highest index
+//   - selected text box with highest index
+//   - normal path with highest index: start / end over body
+//   - normal building with highest index
+//   - normal text box with highest index
+//
+// This is (mostly) the reverse of the scene draw order to make viewing/selecting masked objects easier.
+//
+// If no object is found, returns an zero-valued [Object]
+func (s Scene) GetObjectAt(pos rl.Vector2, sel ObjectSelection) Object {
+	for i := len(sel.PathIdxs) - 1; i >= 0; i-- {
+		elt := sel.PathIdxs[i]
+		p := s.Paths[elt.Idx]
+		if elt.Start && p.CheckStartCollisionPoint(pos) {
+			return Object{Type: TypePathStart, Idx: elt.Idx}
+		}
+		if elt.End && p.CheckEndCollisionPoint(pos) {
+			return Object{Type: TypePathEnd, Idx: elt.Idx}
+		}
+		if p.CheckCollisionPoint(pos) {
+			return Object{Type: TypePath, Idx: elt.Idx}
+		}
+	}
+
+	for i := len(sel.BuildingIdxs) - 1; i >= 0; i-- {
+		if checkCollisionPointRec(pos, s.Buildings[sel.BuildingIdxs[i]].Bounds()) {
+			return Object{Type: TypeBuilding, Idx: sel.BuildingIdxs[i]}
+		}
+	}
+	for i := len(sel.TextBoxIdxs) - 1; i >= 0; i-- {
+		if s.TextBoxes[sel.TextBoxIdxs[i]].CheckCollisionPoint(pos) {
+			return Object{Type: TypeTextBox, Idx: sel.TextBoxIdxs[i]}
+		}
+	}
+
+	// TODO: do not check selected paths / buildings again ?
+	for i := len(s.Paths) - 1; i >= 0; i-- {
+		p := s.Paths[i]
+		if p.CheckStartCollisionPoint(pos) {
+			return Object{Type: TypePathStart, Idx: i}
+		}
+		if p.CheckEndCollisionPoint(pos) {
+			return Object{Type: TypePathEnd, Idx: i}
+		}
+		if p.CheckCollisionPoint(pos) {
+			return Object{Type: TypePath, Idx: i}
+		}
+	}
+
+	for i := len(s.Buildings) - 1; i >= 0; i-- {
+		if checkCollisionPointRec(pos, s.Buildings[i].Bounds()) {
+			return Object{Type: TypeBuilding, Idx: i}
+		}
+	}
+
+	for i := len(s.TextBoxes) - 1; i >= 0; i-- {
+		if s.TextBoxes[i].CheckCollisionPoint(pos) {
+			return Object{Type: TypeTextBox, Idx: i}
+		}
+	}
+
+	return Object{}
+}
+
+// IsBuildingValid returns true if the building does not overlap any scene building (except the one
+// at index ignore, -1 to check all)
+func (s Scene) IsBuildingValid(building Building, ignore int) bool {
+	bounds := building.Bounds()
+	for i, b := range s.Buildings {
+		if i == ignore {
+			continue
+		}
+		if checkCollisionRecs(b.Bounds(), bounds) {
+			return false
+		}
+	}
+	return true
+}
+
+// IsPathValid returns true if the path is valid (see [Path.IsValid])
+func (s Scene) IsPathValid(path Path) bool {
+	return path.IsValid()
+}
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
+// Save / Load
+////////////////////////////////////////////////////////////////////////////////////////////////////
+
+const (
+	// Version of the save file format
+	//
+	//   - 0: one object per line
+	//   - 1: '#LASTID' line, and one object per line prefixed with its ID
+	//   - 2: building mirror flag (0 or 1) after its rotation, text box rotation before its content
+	//   - 3: path spline flag (0 or 1) after its end, followed by its vertices coordinates
+	//   - 4: '#PACKS' line, listing the definitions packs needed by the objects
+	//   - 5: building clock speed, power shards and somersloops after its mirror flag
+	//
+	// The invalid object line messages (msgInvalidXXX) describe the lines of the current version,
+	// older versions have a subset of their fields.
+	Version = 5
+
+	tagVersion   = "#VERSION"
+	tagLastID    = "#LASTID"
+	tagPacks     = "#PACKS"
+	textboxClass = "TextBox"
+)
+
+// SaveToText saves the scene into text format.
+//
+// All errors originate from the underlying [io.Writer].
+func (s *Scene) SaveToText(w io.Writer) error {
+	// // bufSize is kind of low estimation of actual size of the save
+	// //   - version line is minimum 10 chars + '\n'
+	// //   - the minimum building line is 7 chars + '\n'
+	// //   - the minimum path line is 10 chars + '\n'
+	// //
+	// // Most of the actual lines will be longer as classes are more than 1 char long
+	// // and numbers will have multiple digits.
+	// bufSize := 10 * (len(s.Paths) + len(s.Buildings) + 1)
+	// br := bufio.NewWriterSize(w, bufSize)
+	br := bufio.NewWriter(w)
+	defer br.Flush()
+	// version
+	_, err := br.WriteString(fmt.Sprintf("%s=%d\n", tagVersion, Version))
+	if err != nil {
+		return err
+	}
+	// last ID, objects may have higher IDs if the scene was not created by [NewScene]
+	_, err = br.WriteString(fmt.Sprintf("%s=%d\n", tagLastID, max(s.lastID, s.maxID())))
+	if err != nil {
+		return err
+	}
+	// needed packs
+	_, err = br.WriteString(fmt.Sprintf("%s=%s\n", tagPacks, strings.Join(s.Packs(), ",")))
+	if err != nil {
+		return err
+	}
+	// buildings
+	for _, b := range s.Buildings {
+		_, err := br.WriteString(buildingLine(b) + "\n")
+		if err != nil {
+			return err
+		}
+	}
+	// paths
+	for _, p := range s.Paths {
+		_, err := br.WriteString(pathLine(p) + "\n")
+		if err != nil {
+			return err
+		}
+	}
+	// textboxes
+	for _, tb := range s.TextBoxes {
+		_, err := br.WriteString(textBoxLine(tb) + "\n")
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// buildingLine returns the save line of a building, without the trailing newline
+func buildingLine(b Building) string {
+	mirror := 0
+	if b.Mirror {
+		mirror = 1
+	}
+	return fmt.Sprintf("%d %s %v %v %d %d %v %d %d", b.ID, b.Def().Class, b.Pos.X, b.Pos.Y, b.Rot, mirror,
+		b.Mods.ClockSpeed(), b.Mods.Shards, b.Mods.Somersloops)
+}
+
+// pathLine returns the save line of a path, without the trailing newline
+func pathLine(p Path) string {
+	spline := 0
+	if p.Spline {
+		spline = 1
+	}
+	line := fmt.Sprintf("%d %s %v %v %v %v %d", p.ID, p.Def().Class, p.Start.X, p.Start.Y, p.End.X, p.End.Y, spline)
+	for i := range p.Vertices.Len() {
+		v := p.Vertices.Get(i)
+		line += fmt.Sprintf(" %v %v", v.X, v.Y)
+	}
+	return line
+}
+
+// textBoxLine returns the save line of a text box, without the trailing newline
+func textBoxLine(tb TextBox) string {
+	return fmt.Sprintf("%d %s %v %v %v %v %d %v", tb.ID, textboxClass,
+		tb.Bounds.X, tb.Bounds.Y, tb.Bounds.Width, tb.Bounds.Height, tb.Rot, strconv.Quote(tb.Content))
+}
+
+// DecodeTextError is returned by [Scene.LoadFromText] when the save is invalid
+type DecodeTextError struct {
+	Msg     string
+	Err     error
+	Line    int
+	Version int
+}
+
+const (
+	msgEmpty                = "empty file"
+	msgInvalidVersionLine   = "invalid first line, expected '#VERSION=x'"
+	msgInvalidVersionNumber = "invalid version, expected a positive integer"
+	msgVersionTooHigh       = "version is too high"
+	msgInvalidPath          = "invalid path line, expected '[id] [class] [startX] [startY] [endX] [endY] [spline] [vertexX vertexY]...'"
+	msgInvalidBuilding      = "invalid building line, expected '[id] [class] [posX] [posY] [rotation] [mirror] [clock] [shards] [somersloops]'"
+	msgInvalidModifiers     = "invalid building modifiers"
+	msgInvalidTextBox       = "invalid textbox line, expected '[id] TextBox [posX] [posY] [width] [height] [rotation] [content]'"
+	msgInvalidClass         = "unknown class"
+	msgInvalidLastIDLine    = "invalid second line, expected '#LASTID=x'"
+	msgInvalidID            = "invalid object ID, expected an integer from 1 to 4294967295"
+	msgLastIDTooHigh        = "last object ID is too high, expected at most 4294967295"
+	msgDuplicateID          = "duplicate object ID"
+	msgInvalidPacksLine     = "invalid third line, expected '#PACKS=[pack],[pack]...'"
+	msgMissingPacks         = "missing definitions packs"
+)
+
+func (e DecodeTextError) Error() string {
+	if e.Err != nil {
+		return fmt.Sprintf("line %d: %s (%s)", e.Line, e.Msg, e.Err.Error())
+	}
+	return fmt.Sprintf("line %d: %s", e.Line, e.Msg)
+}
+
+// LoadFromText appends the objects of a save in text format to the scene.
+//
+// Objects of version 0 saves, which have no ID, are given new IDs in the file order.
+func (s *Scene) LoadFromText(r io.Reader) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Scan()
+	line := scanner.Text()
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	if len(line) == 0 {
+		return DecodeTextError{Msg: msgEmpty}
+	}
+	// parse version
+	var ver int
+	if _, err := fmt.Sscanf(string(line), tagVersion+"=%d", &ver); err != nil {
+		return DecodeTextError{Msg: msgInvalidVersionLine, Line: 1, Err: err}
+	}
+	if ver < 0 {
+		return DecodeTextError{Msg: msgInvalidVersionNumber, Line: 1}
+	}
+	// call version specific function
+	switch ver {
+	case 0, 1, 2, 3, 4, 5:
+		return s.decodeText(scanner, ver)
+	default:
+		return DecodeTextError{Msg: msgVersionTooHigh, Version: ver, Line: 1}
+	}
+}
+
+// LoadFile reads the objects of a save file
+func LoadFile(name string) (ObjectCollection, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return ObjectCollection{}, err
+	}
+	defer f.Close()
+	var s Scene
+	if err := s.LoadFromText(f); err != nil {
+		return ObjectCollection{}, err
+	}
+	return s.ObjectCollection, nil
+}
+
+func (s *Scene) decodeText(scanner *bufio.Scanner, ver int) error {
+	no := 2
+	var (
+		p Path
+		b Building
+	)
+	// IDs already in the scene and loaded ones, to detect duplicates
+	ids := make(map[ID]bool)
+	if ver >= 1 {
+		for _, b := range s.Buildings {
+			ids[b.ID] = true
+		}
+		for _, p := range s.Paths {
+			ids[p.ID] = true
+		}
+		for _, tb := range s.TextBoxes {
+			ids[tb.ID] = true
+		}
+		scanner.Scan()
+		var lastID ID
+		if _, err := fmt.Sscanf(scanner.Text(), tagLastID+"=%d", &lastID); err != nil {
+			return DecodeTextError{Msg: msgInvalidLastIDLine, Line: no, Err: err, Version: ver}
+		}
+		if lastID > MaxID {
+			return DecodeTextError{Msg: msgLastIDTooHigh, Line: no, Version: ver}
+		}
+		s.lastID = max(s.lastID, lastID)
+		no++
+	}
+	if ver >= 4 {
+		scanner.Scan()
+		packs, ok := strings.CutPrefix(scanner.Text(), tagPacks+"=")
+		if !ok {
+			return DecodeTextError{Msg: msgInvalidPacksLine, Line: no, Version: ver}
+		}
+		var missing []string
+		for _, pack := range strings.Split(packs, ",") {
+			if pack != "" && !isPackLoaded(pack) {
+				missing = append(missing, pack)
+			}
+		}
+		if len(missing) > 0 {
+			return DecodeTextError{Msg: msgMissingPacks, Err: errors.New(strings.Join(missing, ", ")), Line: no, Version: ver}
+		}
+		no++
+	}
+	for scanner.Scan() {
+		line := scanner.Text()
+		if len(line) == 0 {
+			continue
+		}
+		var id ID
+		if ver >= 1 {
+			idStr, rest, _ := strings.Cut(line, " ")
+			v, err := strconv.ParseUint(idStr, 10, 64)
+			if err != nil || v == 0 || v > uint64(MaxID) {
+				return DecodeTextError{Msg: msgInvalidID, Line: no, Err: err, Version: ver}
+			}
+			id, line = ID(v), rest
+			if ids[id] {
+				return DecodeTextError{Msg: msgDuplicateID, Line: no, Version: ver}
+			}
+			ids[id] = true
+			s.lastID = max(s.lastID, id)
+		} else {
+			id = s.newID()
+		}
+		class, fields := cutClass(line)
+		if class == textboxClass {
+			var tb TextBox
+			var err error
+			nelts := 5
+			if ver >= 2 {
+				nelts = 6
+			}
+			elts := strings.SplitN(fields, " ", nelts)
+			if len(elts) != nelts {
+				return DecodeTextError{Msg: msgInvalidTextBox, Line: no, Version: ver}
+			}
+			tb.Bounds.X, err = ParseFloat32(elts[0])
+			if err != nil {
+				return DecodeTextError{Msg: msgInvalidTextBox, Line: no, Err: err, Version: ver}
+			}
+			tb.Bounds.Y, err = ParseFloat32(elts[1])
+			if err != nil {
+				return DecodeTextError{Msg: msgInvalidTextBox, Line: no, Err: err, Version: ver}
+			}
+			tb.Bounds.Width, err = ParseFloat32(elts[2])
+			if err != nil {
+				return DecodeTextError{Msg: msgInvalidTextBox, Line: no, Err: err, Version: ver}
+			}
+			tb.Bounds.Height, err = ParseFloat32(elts[3])
+			if err != nil {
+				return DecodeTextError{Msg: msgInvalidTextBox, Line: no, Err: err, Version: ver}
+			}
+			if ver >= 2 {
+				rot, err := strconv.ParseInt(elts[4], 10, 32)
+				if err != nil {
+					return DecodeTextError{Msg: msgInvalidTextBox, Line: no, Err: err, Version: ver}
+				}
+				tb.Rot = int32(rot)
+			}
+			tb.Content, err = strconv.Unquote(elts[nelts-1])
+			if err != nil {
+				return DecodeTextError{Msg: msgInvalidTextBox, Line: no, Err: err, Version: ver}
+			}
+			tb.ID = id
+			s.TextBoxes = append(s.TextBoxes, tb)
+		} else if defIdx := pathDefs.Index(string(class)); defIdx >= 0 {
+			p.ID, p.DefIdx = id, defIdx
+			if ver >= 3 {
+				var err error
+				if p, err = decodePathFields(p, fields); err != nil {
+					return DecodeTextError{Msg: msgInvalidPath, Line: no, Err: err, Version: ver}
+				}
+			} else if _, err := fmt.Sscanf(fields, "%f %f %f %f", &p.Start.X, &p.Start.Y, &p.End.X, &p.End.Y); err != nil {
+				return DecodeTextError{Msg: msgInvalidPath, Line: no, Err: err, Version: ver}
+			}
+			s.Paths = append(s.Paths, p)
+		} else if defIdx := buildingDefs.Index(string(class)); defIdx >= 0 {
+			b.ID, b.DefIdx = id, defIdx
+			if ver >= 5 {
+				var mirror int
+				if _, err := fmt.Sscanf(fields, "%f %f %d %d %f %d %d", &b.Pos.X, &b.Pos.Y, &b.Rot, &mirror,
+					&b.Mods.Clock, &b.Mods.Shards, &b.Mods.Somersloops); err != nil {
+					return DecodeTextError{Msg: msgInvalidBuilding, Line: no, Err: err, Version: ver}
+				}
+				if mirror != 0 && mirror != 1 {
+					return DecodeTextError{Msg: msgInvalidBuilding, Line: no, Version: ver}
+				}
+				b.Mirror = mirror == 1
+				// the default clock speed is saved as 100%, 0% is out of range
+				err := ErrClockRange
+				if b.Mods.Clock != 0 {
+					b.Mods = b.Mods.Normalized()
+					err = b.Mods.Validate(b.Def())
+				}
+				if err != nil {
+					return DecodeTextError{Msg: msgInvalidModifiers, Line: no, Err: err, Version: ver}
+				}
+			} else if ver >= 2 {
+				var mirror int
+				if _, err := fmt.Sscanf(fields, "%f %f %d %d", &b.Pos.X, &b.Pos.Y, &b.Rot, &mirror); err != nil {
+					return DecodeTextError{Msg: msgInvalidBuilding, Line: no, Err: err, Version: ver}
+				}
+				if mirror != 0 && mirror != 1 {
+					return DecodeTextError{Msg: msgInvalidBuilding, Line: no, Version: ver}
+				}
+				b.Mirror = mirror == 1
+			} else if _, err := fmt.Sscanf(fields, "%f %f %d", &b.Pos.X, &b.Pos.Y, &b.Rot); err != nil {
+				return DecodeTextError{Msg: msgInvalidBuilding, Line: no, Err: err, Version: ver}
+			}
+			s.Buildings = append(s.Buildings, b)
+		} else if pack, _, ok := strings.Cut(class, PackSeparator); ok && !isPackLoaded(pack) {
+			// saves older than version 4 do not list their packs
+			return DecodeTextError{Msg: msgMissingPacks, Err: errors.New(pack), Line: no, Version: ver}
+		} else {
+			return DecodeTextError{Msg: msgInvalidClass, Line: no, Version: ver}
+		}
+		no++
+	}
+
+	return nil
+}
+
+// cutClass splits an object line, after its ID, into its class and its fields.
+//
+// Classes may contain spaces, the longest known class followed by a space is used, defaulting to
+// the first word.
+func cutClass(line string) (class, fields string) {
+	class, fields, _ = strings.Cut(line, " ")
+	match := func(c string) {
+		if len(c) > len(class) && strings.HasPrefix(line, c+" ") {
+			class, fields = c, line[len(c)+1:]
+		}
+	}
+	for _, def := range buildingDefs {
+		match(def.Class)
+	}
+	for _, def := range pathDefs {
+		match(def.Class)
+	}
+	return class, fields
+}
+
+// decodePathFields decodes the fields of a path line following its class: start, end, spline flag
+// and vertices coordinates, power lines have neither vertices nor spline flag
+func decodePathFields(p Path, fields string) (Path, error) {
+	elts := strings.Fields(fields)
+	if len(elts) < 5 || len(elts)%2 == 0 {
+		return p, errors.New("invalid number of fields")
+	}
+	if nv := (len(elts) - 5) / 2; nv > MAX_PATH_VERTICES {
+		return p, fmt.Errorf("too many vertices: %d > %d", nv, MAX_PATH_VERTICES)
+	}
+	coords := make([]float32, 0, len(elts)-1)
+	for i, elt := range elts {
+		if i == 4 {
+			continue
+		}
+		v, err := ParseFloat32(elt)
+		if err != nil {
+			return p, err
+		}
+		coords = append(coords, v)
+	}
+	switch elts[4] {
+	case "0":
+		p.Spline = false
+	case "1":
+		p.Spline = true
+	default:
+		return p, errors.New("invalid spline flag")
+	}
+	p.Start, p.End = vec2(coords[0], coords[1]), vec2(coords[2], coords[3])
+	p.Vertices = PathVertices{}
+	for i := 4; i < len(coords); i += 2 {
+		p.Vertices.Append(vec2(coords[i], coords[i+1]))
+	}
+	if p.Def().IsPowerLine && (p.Spline || p.Vertices.Len() > 0) {
+		return p, errors.New("power lines are a single straight segment")
+	}
+	return p, nil
+}
+
+// WriteHistory writes the scene operations history, one operation per line.
+//
+// Done operations are prefixed with '+', undone ones with '-' and the saved position is marked.
+func (s *Scene) WriteHistory(w io.Writer) error {
+	for i, op := range s.history {
+		mark := "+"
+		if i >= s.historyPos {
+			mark = "-"
+		}
+		saved := ""
+		if i+1 == s.savedHistoryPos {
+			saved = " (saved)"
+		}
+		_, err := fmt.Fprintf(w, "%s %d %s%s sel=%+v old=%+v new=%+v\n", mark, i, op.Type, saved, op.Sel, op.Old, op.New)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
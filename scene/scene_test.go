@@ -0,0 +1,584 @@
+package scene
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+
+	rl "github.com/bonoboris/satisfied/rlmath"
+)
+
+// assertCollection fails the test if the scene objects differ from want
+func assertCollection(t *testing.T, s *Scene, want ObjectCollection) {
+	t.Helper()
+	if !slices.Equal(s.Buildings, want.Buildings) {
+		t.Errorf("buildings = %v, want %v", s.Buildings, want.Buildings)
+	}
+	if !slices.Equal(s.Paths, want.Paths) {
+		t.Errorf("paths = %v, want %v", s.Paths, want.Paths)
+	}
+	if !slices.Equal(s.TextBoxes, want.TextBoxes) {
+		t.Errorf("textboxes = %v, want %v", s.TextBoxes, want.TextBoxes)
+	}
+}
+
+// assertSelection fails the test if the selection indices differ from want (bounds are ignored)
+func assertSelection(t *testing.T, sel, want ObjectSelection) {
+	t.Helper()
+	if !slices.Equal(sel.BuildingIdxs, want.BuildingIdxs) ||
+		!slices.Equal(sel.PathIdxs, want.PathIdxs) ||
+		!slices.Equal(sel.TextBoxIdxs, want.TextBoxIdxs) {
+		t.Errorf("selection = %v %v %v, want %v %v %v",
+			sel.BuildingIdxs, sel.PathIdxs, sel.TextBoxIdxs,
+			want.BuildingIdxs, want.PathIdxs, want.TextBoxIdxs)
+	}
+}
+
+// assertSelectionValid fails the test if the selection indices are out of the scene bounds
+func assertSelectionValid(t *testing.T, s *Scene, sel ObjectSelection) {
+	t.Helper()
+	for _, idx := range sel.BuildingIdxs {
+		if idx < 0 || idx >= len(s.Buildings) {
+			t.Errorf("selected building %d out of range [0, %d[", idx, len(s.Buildings))
+		}
+	}
+	for _, elt := range sel.PathIdxs {
+		if elt.Idx < 0 || elt.Idx >= len(s.Paths) {
+			t.Errorf("selected path %d out of range [0, %d[", elt.Idx, len(s.Paths))
+		}
+	}
+	for _, idx := range sel.TextBoxIdxs {
+		if idx < 0 || idx >= len(s.TextBoxes) {
+			t.Errorf("selected text box %d out of range [0, %d[", idx, len(s.TextBoxes))
+		}
+	}
+}
+
+func TestSceneOpAdd(t *testing.T) {
+	s := newTestScene()
+	orig := s.ObjectCollection.Clone()
+	added := ObjectCollection{
+		Buildings: []Building{building(defSplitter, 60, 0, 0)},
+		Paths:     []Path{path(defPipe, 60, 10, 60, 20), path(defBelt, 70, 10, 80, 10)},
+		TextBoxes: []TextBox{textBox(60, 30, 5, 5, "added")},
+	}
+	want := ObjectCollection{
+		Buildings: append(slices.Clone(orig.Buildings), added.Buildings...),
+		Paths:     append(slices.Clone(orig.Paths), added.Paths...),
+		TextBoxes: append(slices.Clone(orig.TextBoxes), added.TextBoxes...),
+	}
+	// added objects are given new IDs
+	want.Buildings[3].ID = 9
+	want.Paths[3].ID, want.Paths[4].ID = 10, 11
+	want.TextBoxes[2].ID = 12
+
+	s.AddObjects(added)
+	assertCollection(t, s, want)
+
+	// the operation must not alias the caller collection
+	added.Buildings[0].Pos = vec2(-100, -100)
+	if s.Buildings[3].Pos != vec2(60, 0) {
+		t.Errorf("AddObjects: scene building modified through the added collection")
+	}
+
+	sel, ok := s.Undo()
+	if !ok {
+		t.Fatal("Undo() = false, want true")
+	}
+	if !sel.IsEmpty() {
+		t.Errorf("Undo() selection = %v, want empty", sel)
+	}
+	assertCollection(t, s, orig)
+
+	sel, ok = s.Redo()
+	if !ok {
+		t.Fatal("Redo() = false, want true")
+	}
+	assertCollection(t, s, want)
+	assertSelectionValid(t, s, sel)
+	assertSelection(t, sel, ObjectSelection{
+		BuildingIdxs: []int{3},
+		PathIdxs:     []PathSel{{Idx: 3, Start: true, End: true}, {Idx: 4, Start: true, End: true}},
+		TextBoxIdxs:  []int{2},
+	})
+	if wantBounds := rl.NewRectangle(58, -2, 22, 37); sel.Bounds != wantBounds {
+		t.Errorf("Redo() selection bounds = %v, want %v", sel.Bounds, wantBounds)
+	}
+}
+
+func TestSceneOpDelete(t *testing.T) {
+	s := newTestScene()
+	orig := s.ObjectCollection.Clone()
+	sel := ObjectSelection{
+		BuildingIdxs: []int{0, 2},
+		// path 2 start only: must not be deleted
+		PathIdxs:    []PathSel{{Idx: 1, Start: true, End: true}, {Idx: 2, Start: true}},
+		TextBoxIdxs: []int{1},
+	}
+	want := ObjectCollection{
+		Buildings: []Building{orig.Buildings[1]},
+		Paths:     []Path{orig.Paths[0], orig.Paths[2]},
+		TextBoxes: []TextBox{orig.TextBoxes[0]},
+	}
+
+	s.DeleteObjects(sel)
+	assertCollection(t, s, want)
+
+	// the operation must not alias the caller selection
+	sel.BuildingIdxs[0] = 1
+
+	undoSel, ok := s.Undo()
+	if !ok {
+		t.Fatal("Undo() = false, want true")
+	}
+	// undo must restore the original order
+	assertCollection(t, s, orig)
+	assertSelection(t, undoSel, ObjectSelection{
+		BuildingIdxs: []int{0, 2},
+		PathIdxs:     []PathSel{{Idx: 1, Start: true, End: true}, {Idx: 2, Start: true}},
+		TextBoxIdxs:  []int{1},
+	})
+
+	redoSel, ok := s.Redo()
+	if !ok {
+		t.Fatal("Redo() = false, want true")
+	}
+	assertCollection(t, s, want)
+	if !redoSel.IsEmpty() {
+		t.Errorf("Redo() selection = %v, want empty", redoSel)
+	}
+}
+
+func TestSceneOpModify(t *testing.T) {
+	s := newTestScene()
+	orig := s.ObjectCollection.Clone()
+	sel := ObjectSelection{
+		BuildingIdxs: []int{1},
+		PathIdxs:     []PathSel{{Idx: 0, End: true}, {Idx: 2, Start: true, End: true}},
+		TextBoxIdxs:  []int{0},
+	}
+	new := ObjectCollection{
+		Buildings: []Building{building(defFoundation, 20, 10, 180)},
+		Paths:     []Path{path(defBelt, 0, 20, 10, 30), path(defBelt, 20, 30, 30, 40)},
+		TextBoxes: []TextBox{textBox(0, 50, 10, 5, "modified")},
+	}
+	want := orig.Clone()
+	want.Buildings[1] = new.Buildings[0]
+	want.Paths[0] = new.Paths[0]
+	want.Paths[2] = new.Paths[1]
+	want.TextBoxes[0] = new.TextBoxes[0]
+	// modified objects keep their IDs
+	want.Buildings[1].ID = orig.Buildings[1].ID
+	want.Paths[0].ID, want.Paths[2].ID = orig.Paths[0].ID, orig.Paths[2].ID
+	want.TextBoxes[0].ID = orig.TextBoxes[0].ID
+
+	s.ModifyObjects(sel, new)
+	assertCollection(t, s, want)
+
+	undoSel, ok := s.Undo()
+	if !ok {
+		t.Fatal("Undo() = false, want true")
+	}
+	assertCollection(t, s, orig)
+	assertSelection(t, undoSel, sel)
+	// bounds are recomputed from the restored objects
+	restored := sel.Clone()
+	restored.RecomputeBounds(orig)
+	if undoSel.Bounds != restored.Bounds {
+		t.Errorf("Undo() selection bounds = %v, want %v", undoSel.Bounds, restored.Bounds)
+	}
+
+	redoSel, ok := s.Redo()
+	if !ok {
+		t.Fatal("Redo() = false, want true")
+	}
+	assertCollection(t, s, want)
+	assertSelection(t, redoSel, sel)
+	modified := sel.Clone()
+	modified.RecomputeBounds(want)
+	if redoSel.Bounds != modified.Bounds {
+		t.Errorf("Redo() selection bounds = %v, want %v", redoSel.Bounds, modified.Bounds)
+	}
+}
+
+func TestSceneHistory(t *testing.T) {
+	s := &Scene{}
+	if s.HasUndo() || s.HasRedo() || s.IsModified() {
+		t.Fatalf("empty scene: HasUndo=%v HasRedo=%v IsModified=%v, want all false",
+			s.HasUndo(), s.HasRedo(), s.IsModified())
+	}
+	if _, ok := s.Undo(); ok {
+		t.Error("empty scene: Undo() = true, want false")
+	}
+	if _, ok := s.Redo(); ok {
+		t.Error("empty scene: Redo() = true, want false")
+	}
+
+	s.AddBuilding(building(defConstructor, 0, 0, 0))
+	s.AddPath(path(defBelt, 10, 0, 20, 0))
+	s.AddTextBox(textBox(0, 10, 5, 5, "text"))
+	states := []ObjectCollection{{}}
+	states = append(states, ObjectCollection{Buildings: slices.Clone(s.Buildings)})
+	states = append(states, ObjectCollection{Buildings: slices.Clone(s.Buildings), Paths: slices.Clone(s.Paths)})
+	states = append(states, s.ObjectCollection.Clone())
+	if rev := s.Revision(); rev != 3 {
+		t.Errorf("Revision() = %d, want 3", rev)
+	}
+
+	// undo all
+	for i := len(states) - 2; i >= 0; i-- {
+		if !s.HasUndo() {
+			t.Fatalf("HasUndo() = false at state %d", i+1)
+		}
+		s.Undo()
+		assertCollection(t, s, states[i])
+	}
+	if s.HasUndo() || !s.HasRedo() {
+		t.Errorf("after undo all: HasUndo=%v HasRedo=%v, want false true", s.HasUndo(), s.HasRedo())
+	}
+	// redo all
+	for i := 1; i < len(states); i++ {
+		s.Redo()
+		assertCollection(t, s, states[i])
+	}
+	if !s.HasUndo() || s.HasRedo() {
+		t.Errorf("after redo all: HasUndo=%v HasRedo=%v, want true false", s.HasUndo(), s.HasRedo())
+	}
+	if rev := s.Revision(); rev != 9 {
+		t.Errorf("Revision() = %d, want 9", rev)
+	}
+
+	// a new operation discards the undone operations
+	s.Undo()
+	s.Undo()
+	s.DeleteObjects(ObjectSelection{BuildingIdxs: []int{0}})
+	if s.HasRedo() {
+		t.Error("HasRedo() = true after a new operation, want false")
+	}
+	assertCollection(t, s, ObjectCollection{})
+	s.Undo()
+	assertCollection(t, s, states[1])
+}
+
+func TestSceneModified(t *testing.T) {
+	s := &Scene{}
+	s.AddBuilding(building(defConstructor, 0, 0, 0))
+	if !s.IsModified() {
+		t.Error("IsModified() = false after an operation")
+	}
+	s.ResetModified()
+	if s.IsModified() {
+		t.Error("IsModified() = true after ResetModified")
+	}
+	s.AddPath(path(defBelt, 10, 0, 20, 0))
+	if !s.IsModified() {
+		t.Error("IsModified() = false after an operation")
+	}
+	s.Undo()
+	if s.IsModified() {
+		t.Error("IsModified() = true after undoing back to the saved state")
+	}
+	s.Undo()
+	if !s.IsModified() {
+		t.Error("IsModified() = false after undoing past the saved state")
+	}
+	s.Redo()
+	s.SetModified()
+	if !s.IsModified() {
+		t.Error("IsModified() = false after SetModified")
+	}
+}
+
+func TestSceneWriteHistory(t *testing.T) {
+	s := &Scene{}
+	s.AddBuilding(building(defConstructor, 0, 0, 0))
+	s.ResetModified()
+	s.DeleteObjects(ObjectSelection{BuildingIdxs: []int{0}})
+	s.Undo()
+
+	var buf bytes.Buffer
+	if err := s.WriteHistory(&buf); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("WriteHistory() = %q, want 2 lines", buf.String())
+	}
+	if !strings.HasPrefix(lines[0], "+ 0 add (saved)") {
+		t.Errorf("WriteHistory() line 1 = %q, want prefix %q", lines[0], "+ 0 add (saved)")
+	}
+	if !strings.HasPrefix(lines[1], "- 1 delete sel=") {
+		t.Errorf("WriteHistory() line 2 = %q, want prefix %q", lines[1], "- 1 delete sel=")
+	}
+}
+
+func TestSceneGetObjectAt(t *testing.T) {
+	s := newTestScene()
+	tests := []struct {
+		name string
+		pos  rl.Vector2
+		sel  ObjectSelection
+		want Object
+	}{
+		{"nothing", vec2(100, 100), ObjectSelection{}, Object{}},
+		{"building", vec2(1, 1), ObjectSelection{}, Object{Type: TypeBuilding, Idx: 0}},
+		{"path start", vec2(0, 20), ObjectSelection{}, Object{Type: TypePathStart, Idx: 0}},
+		{"path end", vec2(10, 20), ObjectSelection{}, Object{Type: TypePathEnd, Idx: 0}},
+		{"path body", vec2(5, 20.5), ObjectSelection{}, Object{Type: TypePath, Idx: 0}},
+		{"text box", vec2(25, 52), ObjectSelection{}, Object{Type: TypeTextBox, Idx: 1}},
+		// path 0 start overlaps building 0 bottom edge
+		{"path over building", vec2(0, 4.5), ObjectSelection{}, Object{Type: TypeBuilding, Idx: 0}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := s.GetObjectAt(tt.pos, tt.sel); got != tt.want {
+				t.Errorf("GetObjectAt(%v) = %v, want %v", tt.pos, got, tt.want)
+			}
+		})
+	}
+
+	// selected objects are picked first
+	s.AddTextBox(textBox(-2, -2, 4, 4, "over building 0"))
+	pos := vec2(0, 0)
+	if got, want := s.GetObjectAt(pos, ObjectSelection{}), (Object{Type: TypeBuilding, Idx: 0}); got != want {
+		t.Errorf("GetObjectAt(%v) = %v, want %v", pos, got, want)
+	}
+	sel := ObjectSelection{TextBoxIdxs: []int{2}}
+	if got, want := s.GetObjectAt(pos, sel), (Object{Type: TypeTextBox, Idx: 2}); got != want {
+		t.Errorf("GetObjectAt(%v, %v) = %v, want %v", pos, sel, got, want)
+	}
+}
+
+func TestSceneIsBuildingValid(t *testing.T) {
+	s := newTestScene()
+	tests := []struct {
+		name   string
+		b      Building
+		ignore int
+		want   bool
+	}{
+		{"free", building(defSplitter, 100, 100, 0), -1, true},
+		{"overlap", building(defSplitter, 2, 2, 0), -1, false},
+		{"overlap ignored", building(defSplitter, 2, 2, 0), 0, true},
+		// rotated constructor is 10 wide, overlapping splitter 2
+		{"unrotated free", building(defConstructor, 46.5, 0, 0), -1, true},
+		{"rotated overlap", building(defConstructor, 46.5, 0, 90), -1, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := s.IsBuildingValid(tt.b, tt.ignore); got != tt.want {
+				t.Errorf("IsBuildingValid(%v, %d) = %v, want %v", tt.b, tt.ignore, got, tt.want)
+			}
+		})
+	}
+	if s.IsPathValid(path(defBelt, 1, 1, 1, 1)) {
+		t.Error("IsPathValid() = true for a zero length path")
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
+// Save / Load
+////////////////////////////////////////////////////////////////////////////////////////////////////
+
+func TestSceneSaveLoadRoundtrip(t *testing.T) {
+	s := newTestScene()
+	s.AddTextBox(textBox(1.5, -2.25, 3, 4, "multi\nline \"quoted\" text"))
+	s.TextBoxes[2].Rot = 30
+	s.Buildings[2].Mirror = true
+	s.Buildings[0].Mods = Modifiers{Clock: 142.5, Shards: 1, Somersloops: 1}
+	s.Paths[2].Vertices = NewPathVertices(vec2(30, 20), vec2(35, 25.5))
+	s.Paths[2].Spline = true
+	s.ResetModified()
+
+	var buf bytes.Buffer
+	if err := s.SaveToText(&buf); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(buf.String(), "#VERSION=5\n#LASTID=9\n#PACKS=\n") {
+		t.Errorf("SaveToText() = %q, want version header", buf.String())
+	}
+
+	var loaded Scene
+	if err := loaded.LoadFromText(&buf); err != nil {
+		t.Fatal(err)
+	}
+	assertCollection(t, &loaded, s.ObjectCollection)
+	if loaded.lastID != s.lastID {
+		t.Errorf("loaded lastID = %d, want %d", loaded.lastID, s.lastID)
+	}
+	if loaded.IsModified() || loaded.HasUndo() {
+		t.Errorf("loaded scene: IsModified=%v HasUndo=%v, want false false", loaded.IsModified(), loaded.HasUndo())
+	}
+}
+
+func TestSceneLoadFromText(t *testing.T) {
+	s := Scene{}
+	err := s.LoadFromText(strings.NewReader("#VERSION=3\n#LASTID=12\n3 Constructor 1 2 90 1\n\n7 Belt 0 0 10 0 1 5 5 10 5\n5 TextBox 0 0 1 1 -45 \"a b\"\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := ObjectCollection{
+		Buildings: []Building{building(defConstructor, 1, 2, 90)},
+		Paths:     []Path{path(defBelt, 0, 0, 10, 0)},
+		TextBoxes: []TextBox{textBox(0, 0, 1, 1, "a b")},
+	}
+	want.Buildings[0].ID, want.Paths[0].ID, want.TextBoxes[0].ID = 3, 7, 5
+	want.Buildings[0].Mirror, want.TextBoxes[0].Rot = true, -45
+	want.Paths[0].Spline, want.Paths[0].Vertices = true, NewPathVertices(vec2(5, 5), vec2(10, 5))
+	assertCollection(t, &s, want)
+	// deleted objects IDs are not reused
+	s.AddBuilding(building(defSplitter, 20, 0, 0))
+	if id := s.Buildings[1].ID; id != 13 {
+		t.Errorf("added building ID = %d, want 13", id)
+	}
+}
+
+// Version 2 saves have no path spline flag and vertices
+func TestSceneLoadFromTextVersion2(t *testing.T) {
+	s := Scene{}
+	err := s.LoadFromText(strings.NewReader("#VERSION=2\n#LASTID=12\n3 Constructor 1 2 90 1\n\n7 Belt 0 0 10 0\n5 TextBox 0 0 1 1 -45 \"a b\"\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := ObjectCollection{
+		Buildings: []Building{building(defConstructor, 1, 2, 90)},
+		Paths:     []Path{path(defBelt, 0, 0, 10, 0)},
+		TextBoxes: []TextBox{textBox(0, 0, 1, 1, "a b")},
+	}
+	want.Buildings[0].ID, want.Paths[0].ID, want.TextBoxes[0].ID = 3, 7, 5
+	want.Buildings[0].Mirror, want.TextBoxes[0].Rot = true, -45
+	assertCollection(t, &s, want)
+}
+
+// Class names may contain spaces, the longest known class is used
+func TestSceneLoadFromTextClassWithSpaces(t *testing.T) {
+	buildings := append(slices.Clone(testBuildingDefs), BuildingDef{Class: "Constructor Mk.2", Dims: vec2(8, 10)})
+	SetDefs(buildings, testPathDefs)
+	t.Cleanup(func() { SetDefs(testBuildingDefs, testPathDefs) })
+
+	s := Scene{}
+	err := s.LoadFromText(strings.NewReader("#VERSION=3\n#LASTID=2\n1 Constructor Mk.2 1 2 90 0\n2 Constructor 3 4 0 0\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(s.Buildings) != 2 || s.Buildings[0].Def().Class != "Constructor Mk.2" || s.Buildings[0].Pos != vec2(1, 2) ||
+		s.Buildings[1].Def().Class != "Constructor" || s.Buildings[1].Pos != vec2(3, 4) {
+		t.Errorf("Buildings = %v, want Constructor Mk.2 at (1,2) and Constructor at (3,4)", s.Buildings)
+	}
+}
+
+// Version 1 saves have no building mirror flag and no text box rotation
+func TestSceneLoadFromTextVersion1(t *testing.T) {
+	s := Scene{}
+	err := s.LoadFromText(strings.NewReader("#VERSION=1\n#LASTID=12\n3 Constructor 1 2 90\n\n7 Belt 0 0 10 0\n5 TextBox 0 0 1 1 \"a b\"\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := ObjectCollection{
+		Buildings: []Building{building(defConstructor, 1, 2, 90)},
+		Paths:     []Path{path(defBelt, 0, 0, 10, 0)},
+		TextBoxes: []TextBox{textBox(0, 0, 1, 1, "a b")},
+	}
+	want.Buildings[0].ID, want.Paths[0].ID, want.TextBoxes[0].ID = 3, 7, 5
+	assertCollection(t, &s, want)
+
+	// the highest accepted IDs, new IDs do not wrap around to zero
+	s = Scene{}
+	if err := s.LoadFromText(strings.NewReader("#VERSION=1\n#LASTID=4294967295\n4294967295 Constructor 1 2 90\n")); err != nil {
+		t.Fatal(err)
+	}
+	if id := s.newID(); id != MaxID+1 {
+		t.Errorf("newID() = %d, want %d", id, MaxID+1)
+	}
+}
+
+// Version 0 saves have no IDs, objects are given IDs in the file order
+func TestSceneLoadFromTextVersion0(t *testing.T) {
+	s := Scene{}
+	err := s.LoadFromText(strings.NewReader("#VERSION=0\nConstructor 1 2 90\n\nBelt 0 0 10 0\nTextBox 0 0 1 1 \"a b\"\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := ObjectCollection{
+		Buildings: []Building{building(defConstructor, 1, 2, 90)},
+		Paths:     []Path{path(defBelt, 0, 0, 10, 0)},
+		TextBoxes: []TextBox{textBox(0, 0, 1, 1, "a b")},
+	}
+	want.Buildings[0].ID, want.Paths[0].ID, want.TextBoxes[0].ID = 1, 2, 3
+	assertCollection(t, &s, want)
+}
+
+func TestSceneLoadFromTextErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		text     string
+		wantMsg  string
+		wantLine int
+	}{
+		{"empty", "", msgEmpty, 0},
+		{"no version", "Constructor 1 2 0\n", msgInvalidVersionLine, 1},
+		{"negative version", "#VERSION=-1\n", msgInvalidVersionNumber, 1},
+		{"future version", "#VERSION=6\n", msgVersionTooHigh, 1},
+		{"no packs v4", "#VERSION=4\n#LASTID=1\n1 Constructor 0 0 0 0\n", msgInvalidPacksLine, 3},
+		{"no last ID", "#VERSION=1\n1 Constructor 0 0 0\n", msgInvalidLastIDLine, 2},
+		{"no ID", "#VERSION=1\n#LASTID=1\nConstructor 0 0 0\n", msgInvalidID, 3},
+		{"zero ID", "#VERSION=1\n#LASTID=1\n0 Constructor 0 0 0\n", msgInvalidID, 3},
+		{"last ID overflow", "#VERSION=1\n#LASTID=4294967296\n1 Constructor 0 0 0\n", msgLastIDTooHigh, 2},
+		{"ID overflow", "#VERSION=1\n#LASTID=1\n4294967296 Constructor 0 0 0\n", msgInvalidID, 3},
+		{"duplicate ID", "#VERSION=1\n#LASTID=2\n1 Constructor 0 0 0\n1 Belt 0 0 1 0\n", msgDuplicateID, 4},
+		{"invalid building v1", "#VERSION=1\n#LASTID=1\n1 Constructor 0 x 0\n", msgInvalidBuilding, 3},
+		{"no mirror v2", "#VERSION=2\n#LASTID=1\n1 Constructor 0 0 0\n", msgInvalidBuilding, 3},
+		{"invalid mirror v2", "#VERSION=2\n#LASTID=1\n1 Constructor 0 0 0 2\n", msgInvalidBuilding, 3},
+		{"no spline flag v3", "#VERSION=3\n#LASTID=1\n1 Belt 0 0 1 0\n", msgInvalidPath, 3},
+		{"odd vertex coordinates v3", "#VERSION=3\n#LASTID=1\n1 Belt 0 0 1 0 0 5\n", msgInvalidPath, 3},
+		{"no modifiers v5", "#VERSION=5\n#LASTID=1\n#PACKS=\n1 Constructor 0 0 0 0\n", msgInvalidBuilding, 4},
+		{"zero clock v5", "#VERSION=5\n#LASTID=1\n#PACKS=\n1 Constructor 0 0 0 0 0 0 0\n", msgInvalidModifiers, 4},
+		{"overclock without shards v5", "#VERSION=5\n#LASTID=1\n#PACKS=\n1 Constructor 0 0 0 0 150 0 0\n", msgInvalidModifiers, 4},
+		{"too many shards v5", "#VERSION=5\n#LASTID=1\n#PACKS=\n1 Constructor 0 0 0 0 250 4 0\n", msgInvalidModifiers, 4},
+		{"too many somersloops v5", "#VERSION=5\n#LASTID=1\n#PACKS=\n1 Constructor 0 0 0 0 100 0 2\n", msgInvalidModifiers, 4},
+		{"not clockable v5", "#VERSION=5\n#LASTID=1\n#PACKS=\n1 Splitter 0 0 0 0 50 0 0\n", msgInvalidModifiers, 4},
+		{"invalid spline flag v3", "#VERSION=3\n#LASTID=1\n1 Belt 0 0 1 0 2\n", msgInvalidPath, 3},
+		{"no text box rotation v2", "#VERSION=2\n#LASTID=1\n1 TextBox 0 0 1 1 \"a\"\n", msgInvalidTextBox, 3},
+		{"unknown class", "#VERSION=0\nSmelter 0 0 0\n", msgInvalidClass, 2},
+		{"invalid building", "#VERSION=0\nConstructor 0 x 0\n", msgInvalidBuilding, 2},
+		{"invalid path", "#VERSION=0\nConstructor 0 0 0\nBelt 0 0 1\n", msgInvalidPath, 3},
+		{"invalid text box", "#VERSION=0\nTextBox 0 0 1 1\n", msgInvalidTextBox, 2},
+		{"unquoted text box", "#VERSION=0\nTextBox 0 0 1 1 text\n", msgInvalidTextBox, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var s Scene
+			err := s.LoadFromText(strings.NewReader(tt.text))
+			var decodeErr DecodeTextError
+			if !errors.As(err, &decodeErr) {
+				t.Fatalf("LoadFromText() error = %v, want a DecodeTextError", err)
+			}
+			if decodeErr.Msg != tt.wantMsg || decodeErr.Line != tt.wantLine {
+				t.Errorf("LoadFromText() error = %q line %d, want %q line %d",
+					decodeErr.Msg, decodeErr.Line, tt.wantMsg, tt.wantLine)
+			}
+		})
+	}
+}
+
+// An operation is stored in the history with its own copies of the objects
+func TestSceneOpNoAliasing(t *testing.T) {
+	s := newTestScene()
+	sel := ObjectSelection{BuildingIdxs: []int{0}}
+	new := ObjectCollection{Buildings: []Building{building(defConstructor, 0, 20, 0)}}
+	s.ModifyObjects(sel, new)
+	new.Buildings[0].Pos = vec2(1000, 1000)
+	s.Undo()
+	s.Redo()
+	if s.Buildings[0].Pos != vec2(0, 20) {
+		t.Errorf("redo after mutating the modify input: building pos = %v, want (0, 20)", s.Buildings[0].Pos)
+	}
+	wantNew := ObjectCollection{Buildings: []Building{building(defConstructor, 0, 20, 0)}}
+	wantNew.Buildings[0].ID = 1
+	if !reflect.DeepEqual(s.history[0].New, wantNew) {
+		t.Errorf("history[0].New = %v", s.history[0].New)
+	}
+}
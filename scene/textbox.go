@@ -0,0 +1,36 @@
+package scene
+
+import (
+	"github.com/bonoboris/satisfied/matrix"
+	rl "github.com/bonoboris/satisfied/rlmath"
+)
+
+// TextBox is a free text annotation in the scene
+type TextBox struct {
+	ID     ID
+	Bounds rl.Rectangle
+	// Rotation in degrees around the bounds top-left corner
+	Rot     int32
+	Content string
+}
+
+// Matrix returns the text box rotation matrix, from the unrotated bounds to world coordinates
+func (tb TextBox) Matrix() matrix.Matrix {
+	return matrix.NewRotateAroundV(tb.Rot, tb.Bounds.Position())
+}
+
+// AABB returns the axis aligned bounding box of the rotated text box
+func (tb TextBox) AABB() rl.Rectangle {
+	if tb.Rot == 0 {
+		return tb.Bounds
+	}
+	return tb.Matrix().ApplyRecAABB(tb.Bounds)
+}
+
+// CheckCollisionPoint returns true if pos is inside the rotated text box
+func (tb TextBox) CheckCollisionPoint(pos rl.Vector2) bool {
+	if tb.Rot != 0 {
+		pos = matrix.NewRotateAroundV(-tb.Rot, tb.Bounds.Position()).ApplyV(pos)
+	}
+	return checkCollisionPointRec(pos, tb.Bounds)
+}
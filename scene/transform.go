@@ -0,0 +1,241 @@
+// transform - Mirroring / rotation / translation of a selection and validity of the transformed objects
+
+package scene
+
+import (
+	"slices"
+
+	"github.com/bonoboris/satisfied/matrix"
+	rl "github.com/bonoboris/satisfied/rlmath"
+)
+
+// Transform represents a mirroring and a rotation around the selection bounds center, followed by a
+// translation
+type Transform struct {
+	// Rotation in degrees, in [0, 360) (multiple of 90 to transform buildings)
+	Rot int32
+	// Horizontal mirroring, applied before the rotation
+	Mirror bool
+	// Translation, already snapped to the grid
+	Translate rl.Vector2
+}
+
+// IsIdentity returns true if the transformation does not move anything
+func (t Transform) IsIdentity() bool {
+	return t.Rot%360 == 0 && !t.Mirror && t.Translate.X == 0 && t.Translate.Y == 0
+}
+
+// Rotate returns the transformation followed by a rotation of angle degrees
+func (t Transform) Rotate(angle int32) Transform {
+	t.Rot = normAngle(t.Rot + angle)
+	return t
+}
+
+// MirrorH returns the transformation followed by an horizontal mirroring (x -> -x)
+func (t Transform) MirrorH() Transform {
+	t.Rot = normAngle(-t.Rot)
+	t.Mirror = !t.Mirror
+	return t
+}
+
+// MirrorV returns the transformation followed by a vertical mirroring (y -> -y)
+func (t Transform) MirrorV() Transform {
+	t.Rot = normAngle(180 - t.Rot)
+	t.Mirror = !t.Mirror
+	return t
+}
+
+// Matrix returns the transformation matrix for a selection with the given bounds
+func (t Transform) Matrix(bounds rl.Rectangle) matrix.Matrix {
+	center := bounds.Center()
+	mat := matrix.NewTranslateV(t.Translate.Add(center)).Rotate(t.Rot)
+	if t.Mirror {
+		mat = mat.MirrorX()
+	}
+	return mat.TranslateV(center.Negate())
+}
+
+// normAngle returns the angle in degrees in [0, 360)
+func normAngle(angle int32) int32 {
+	angle %= 360
+	if angle < 0 {
+		angle += 360
+	}
+	return angle
+}
+
+// Transformed holds the transformed objects of a selection and whether they are valid
+type Transformed struct {
+	ObjectCollection
+	// invalid transformed paths mask
+	InvalidPaths []bool
+	// invalid transformed buildings mask
+	InvalidBuildings []bool
+	// whether the every transformed object is valid
+	IsValid bool
+	// bounds of the transformed selection
+	Bounds rl.Rectangle
+
+	// transformed building bounds buffer (reduce allocs)
+	_buildingBounds []rl.Rectangle
+	// single copy buffer of [Transformed.ComputeArray] (reduce allocs)
+	_copy *Transformed
+}
+
+// Reset clears the transformed objects
+func (t *Transformed) Reset() {
+	t.Paths = t.Paths[:0]
+	t.Buildings = t.Buildings[:0]
+	t.TextBoxes = t.TextBoxes[:0]
+	t.InvalidPaths = t.InvalidPaths[:0]
+	t.InvalidBuildings = t.InvalidBuildings[:0]
+	t.IsValid = false
+	t.Bounds = rl.Rectangle{}
+}
+
+// Compute applies tr to the objects of oc selected by sel and checks the results validity.
+//
+// When duplicate is true, only the fully selected paths are transformed and the transformed
+// buildings are checked against every building of oc (including the selected ones), an identity
+// transformation is always invalid.
+// Otherwise, the selected path ends are moved and the transformed buildings are checked against
+// the non selected buildings.
+func (t *Transformed) Compute(oc ObjectCollection, sel ObjectSelection, tr Transform, duplicate bool) {
+	// fast path for identity transform
+	// TODO: not copying anything would be faster
+	if tr.IsIdentity() {
+		var pathIdxs []int
+		if duplicate {
+			pathIdxs = sel.FullPathIdxs()
+		} else {
+			pathIdxs = sel.AnyPathIdxs()
+		}
+		t.Buildings = CopyIdxs(t.Buildings, oc.Buildings, sel.BuildingIdxs)
+		t.InvalidBuildings = Repeat(t.InvalidBuildings, duplicate, len(sel.BuildingIdxs))
+		t.Paths = CopyIdxs(t.Paths, oc.Paths, pathIdxs)
+		t.InvalidPaths = Repeat(t.InvalidPaths, duplicate, len(pathIdxs))
+		t.TextBoxes = CopyIdxs(t.TextBoxes, oc.TextBoxes, sel.TextBoxIdxs)
+		t.IsValid = !duplicate
+		t.Bounds = sel.Bounds
+		return
+	}
+
+	t.IsValid = true
+
+	var pathIdxs []int
+	if duplicate {
+		// we only want to duplicate paths that are entirely inside the selection
+		pathIdxs = sel.FullPathIdxs()
+	} else {
+		pathIdxs = sel.AnyPathIdxs()
+	}
+
+	ntb := len(sel.TextBoxIdxs)
+	nb := len(sel.BuildingIdxs)
+	np := len(pathIdxs)
+
+	// clears slices
+	t._buildingBounds = slices.Grow(t._buildingBounds[:0], nb)
+	t.Buildings = slices.Grow(t.Buildings[:0], nb)
+	t.InvalidBuildings = slices.Grow(t.InvalidBuildings[:0], nb)
+	t.Paths = slices.Grow(t.Paths[:0], np)
+	t.InvalidPaths = slices.Grow(t.InvalidPaths[:0], np)
+	t.TextBoxes = slices.Grow(t.TextBoxes[:0], ntb)
+
+	mat := tr.Matrix(sel.Bounds)
+	t.Bounds = mat.ApplyRecAABB(sel.Bounds)
+	// buildings can only be rotated by multiple of 90 degrees
+	invalidRot := tr.Rot%90 != 0
+
+	// Buildings
+	// a building matrix is T(pos).R(rot).M^mirror, composed with R(tr.Rot).M^tr.Mirror it becomes
+	// T(pos').R(tr.Rot ± rot).M^(mirror xor tr.Mirror) since M.R(rot) = R(-rot).M
+	for _, idx := range sel.BuildingIdxs {
+		b := oc.Buildings[idx]
+		b.Pos = mat.ApplyV(b.Pos)
+		if tr.Mirror {
+			b.Rot = normAngle(tr.Rot - b.Rot)
+			b.Mirror = !b.Mirror
+		} else {
+			b.Rot = normAngle(tr.Rot + b.Rot)
+		}
+		t.Buildings = append(t.Buildings, b)
+	}
+
+	// TextBoxes
+	// text are never mirrored: the mirrored top-right corner becomes the top-left one
+	for _, idx := range sel.TextBoxIdxs {
+		tb := oc.TextBoxes[idx]
+		tbMat := tb.Matrix()
+		var pos rl.Vector2
+		if tr.Mirror {
+			pos = mat.ApplyV(tbMat.ApplyV(tb.Bounds.TopRight()))
+			tb.Rot = normAngle(tr.Rot - tb.Rot)
+		} else {
+			pos = mat.ApplyV(tbMat.ApplyV(tb.Bounds.TopLeft()))
+			tb.Rot = normAngle(tr.Rot + tb.Rot)
+		}
+		tb.Bounds.X = pos.X
+		tb.Bounds.Y = pos.Y
+		t.TextBoxes = append(t.TextBoxes, tb)
+	}
+
+	// Paths & InvalidPaths
+	if duplicate {
+		for _, idx := range pathIdxs {
+			t.appendPath(oc.Paths[idx].Transform(mat))
+		}
+	} else {
+		for _, elt := range sel.PathIdxs {
+			p := oc.Paths[elt.Idx]
+			switch {
+			case elt.Start && elt.End:
+				p = p.Transform(mat)
+			case elt.Start:
+				// vertices stay in place
+				p.Start = mat.ApplyV(p.Start)
+			case elt.End:
+				p.End = mat.ApplyV(p.End)
+			}
+			t.appendPath(p)
+		}
+	}
+
+	// precompute transformed building bounds & initialize invalid
+	for i := range nb {
+		t._buildingBounds = append(t._buildingBounds, t.Buildings[i].Bounds())
+		t.InvalidBuildings = append(t.InvalidBuildings, invalidRot)
+	}
+	if invalidRot && nb > 0 {
+		t.IsValid = false
+	}
+
+	isSelectedIt := NewMaskIterator(sel.BuildingIdxs)
+	for _, sb := range oc.Buildings {
+		sb := sb.Bounds()
+		// TODO: use t.Buildings bounds only in the skip condition ?
+		if !duplicate && isSelectedIt.Next() || !checkCollisionRecs(t.Bounds, sb) {
+			// skip:
+			//   - building in selection (except when duplicating)
+			//   - building outside transformation outer bounds
+			continue
+		}
+		// check against every transformed building
+		for i, bounds := range t._buildingBounds {
+			// no need to call CheckCollisionRec if t.InvalidBuildings[i] is already true
+			if !t.InvalidBuildings[i] && checkCollisionRecs(bounds, sb) {
+				t.IsValid = false
+				t.InvalidBuildings[i] = true
+			}
+		}
+	}
+}
+
+// appendPath appends a transformed path and its validity
+func (t *Transformed) appendPath(p Path) {
+	t.Paths = append(t.Paths, p)
+	t.InvalidPaths = append(t.InvalidPaths, !p.IsValid())
+	if !p.IsValid() {
+		t.IsValid = false
+	}
+}
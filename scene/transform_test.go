@@ -0,0 +1,294 @@
+package scene
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/bonoboris/satisfied/math32"
+	"github.com/bonoboris/satisfied/matrix"
+	rl "github.com/bonoboris/satisfied/rlmath"
+)
+
+// selectAll returns a selection of every object of oc, with its bounds
+func selectAll(oc ObjectCollection) ObjectSelection {
+	sel := ObjectSelection{
+		BuildingIdxs: Range(0, len(oc.Buildings)),
+		TextBoxIdxs:  Range(0, len(oc.TextBoxes)),
+	}
+	for i := range oc.Paths {
+		sel.PathIdxs = append(sel.PathIdxs, PathSel{Idx: i, Start: true, End: true})
+	}
+	sel.RecomputeBounds(oc)
+	return sel
+}
+
+func TestTransformIdentity(t *testing.T) {
+	oc := testCollection()
+	sel := selectAll(oc)
+	sel.PathIdxs[1].End = false // partial path
+
+	var tr Transformed
+	tr.Compute(oc, sel, Transform{Rot: 360}, false)
+	if !tr.IsValid || tr.Bounds != sel.Bounds {
+		t.Errorf("identity: IsValid=%v Bounds=%v, want true %v", tr.IsValid, tr.Bounds, sel.Bounds)
+	}
+	if !slices.Equal(tr.Buildings, oc.Buildings) || !slices.Equal(tr.Paths, oc.Paths) || !slices.Equal(tr.TextBoxes, oc.TextBoxes) {
+		t.Errorf("identity: transformed objects differ from the original ones")
+	}
+
+	// duplicating in place always overlaps
+	tr.Compute(oc, sel, Transform{}, true)
+	if tr.IsValid {
+		t.Error("identity duplicate: IsValid = true, want false")
+	}
+	if len(tr.Paths) != 2 {
+		t.Errorf("identity duplicate: %d paths, want 2 (partial path excluded)", len(tr.Paths))
+	}
+	if !slices.Equal(tr.InvalidBuildings, []bool{true, true, true}) || !slices.Equal(tr.InvalidPaths, []bool{true, true}) {
+		t.Errorf("identity duplicate: invalid masks = %v %v, want all true", tr.InvalidBuildings, tr.InvalidPaths)
+	}
+}
+
+func TestTransformTranslate(t *testing.T) {
+	oc := testCollection()
+	sel := ObjectSelection{
+		BuildingIdxs: []int{0},
+		PathIdxs:     []PathSel{{Idx: 0, Start: true, End: true}, {Idx: 1, End: true}},
+		TextBoxIdxs:  []int{0},
+	}
+	sel.RecomputeBounds(oc)
+	delta := vec2(0, -100)
+
+	var tr Transformed
+	tr.Compute(oc, sel, Transform{Translate: delta}, false)
+	if !tr.IsValid {
+		t.Fatalf("translate: IsValid = false (%v %v)", tr.InvalidBuildings, tr.InvalidPaths)
+	}
+	if got, want := tr.Buildings[0].Pos, oc.Buildings[0].Pos.Add(delta); got != want {
+		t.Errorf("translate: building pos = %v, want %v", got, want)
+	}
+	if got, want := tr.Paths[0], (Path{DefIdx: defBelt, Start: vec2(0, -80), End: vec2(10, -80)}); got != want {
+		t.Errorf("translate: full path = %v, want %v", got, want)
+	}
+	// only the selected end moves
+	if got, want := tr.Paths[1], (Path{DefIdx: defPipe, Start: vec2(0, 30), End: vec2(0, -60)}); got != want {
+		t.Errorf("translate: path end = %v, want %v", got, want)
+	}
+	if got, want := tr.TextBoxes[0].Bounds, rl.NewRectangle(0, -50, 10, 5); got != want {
+		t.Errorf("translate: text box = %v, want %v", got, want)
+	}
+	if got, want := tr.Bounds, rl.NewRectangle(sel.Bounds.X, sel.Bounds.Y-100, sel.Bounds.Width, sel.Bounds.Height); got != want {
+		t.Errorf("translate: bounds = %v, want %v", got, want)
+	}
+}
+
+func TestTransformRotate(t *testing.T) {
+	oc := ObjectCollection{
+		Buildings: []Building{building(defSplitter, 0, 0, 270)},
+		Paths:     []Path{path(defBelt, 0, 0, 10, 0)},
+	}
+	sel := selectAll(oc)
+	center := sel.Bounds.Center()
+
+	var tr Transformed
+	tr.Compute(oc, sel, Transform{Rot: 90}, false)
+	if !tr.IsValid {
+		t.Fatal("rotate: IsValid = false")
+	}
+	if got := tr.Buildings[0].Rot; got != 0 {
+		t.Errorf("rotate: building rot = %d, want 0 (270 + 90 modulo 360)", got)
+	}
+	// the path rotates around the selection center
+	p := tr.Paths[0]
+	if p.Start.Distance(center) != oc.Paths[0].Start.Distance(center) || p.End.Distance(center) != oc.Paths[0].End.Distance(center) {
+		t.Errorf("rotate: path %v is not a rotation of %v around %v", p, oc.Paths[0], center)
+	}
+	if p.Start.X != p.End.X {
+		t.Errorf("rotate: path %v should be vertical", p)
+	}
+	if got := tr.Bounds.Center(); got != center {
+		t.Errorf("rotate: bounds center = %v, want %v", got, center)
+	}
+
+	// 4 rotations are the identity
+	tr.Compute(oc, sel, Transform{Rot: 360}, false)
+	if tr.Buildings[0] != oc.Buildings[0] || tr.Paths[0] != oc.Paths[0] {
+		t.Errorf("rotate 360: %v %v, want %v %v", tr.Buildings[0], tr.Paths[0], oc.Buildings[0], oc.Paths[0])
+	}
+}
+
+// matrixNear returns true if the matrices are equal up to floating point errors
+func matrixNear(a, b matrix.Matrix) bool {
+	ea := []float32{a.M0, a.M1, a.M2, a.M3, a.M4, a.M5}
+	eb := []float32{b.M0, b.M1, b.M2, b.M3, b.M4, b.M5}
+	for i := range ea {
+		if math32.Abs(ea[i]-eb[i]) > 1e-4 {
+			return false
+		}
+	}
+	return true
+}
+
+// rectNear returns true if the rectangles are equal up to floating point errors
+func rectNear(a, b rl.Rectangle) bool {
+	return math32.Abs(a.X-b.X) < 1e-4 && math32.Abs(a.Y-b.Y) < 1e-4 &&
+		math32.Abs(a.Width-b.Width) < 1e-4 && math32.Abs(a.Height-b.Height) < 1e-4
+}
+
+// Every point of a transformed building (ports included) must be the transformed point of the
+// original building
+func TestTransformMirror(t *testing.T) {
+	oc := ObjectCollection{
+		Buildings: []Building{
+			building(defConstructor, 0, 0, 90),
+			building(defSplitter, 20, 3, 0),
+		},
+		TextBoxes: []TextBox{textBox(0, 20, 10, 5, "text")},
+	}
+	oc.Buildings[1].Mirror = true
+	sel := selectAll(oc)
+
+	tests := []struct {
+		name string
+		tr   Transform
+	}{
+		{"horizontal", Transform{}.MirrorH()},
+		{"vertical", Transform{}.MirrorV()},
+		{"rotate then mirror", Transform{}.Rotate(90).MirrorH()},
+		{"mirror then rotate", Transform{}.MirrorV().Rotate(270)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var tr Transformed
+			tr.Compute(oc, sel, tt.tr, false)
+			if !tr.IsValid {
+				t.Fatalf("IsValid = false (%v)", tr.InvalidBuildings)
+			}
+			mat := tt.tr.Matrix(sel.Bounds)
+			for i, b := range tr.Buildings {
+				if want := mat.Mult(oc.Buildings[i].Matrix()); !matrixNear(b.Matrix(), want) {
+					t.Errorf("building %d = %v, matrix %v, want %v", i, b, b.Matrix(), want)
+				}
+			}
+			// text is never mirrored: the text box covers the mirrored area, unmirrored
+			tb := tr.TextBoxes[0]
+			if got, want := tb.AABB(), mat.ApplyRecAABB(oc.TextBoxes[0].Bounds); !rectNear(got, want) {
+				t.Errorf("text box AABB = %v, want %v", got, want)
+			}
+			if tb.Matrix().IsMirroring() {
+				t.Errorf("text box %v is mirrored", tb)
+			}
+		})
+	}
+
+	// mirroring twice is the identity
+	if tr := (Transform{Rot: 90}).MirrorH().MirrorH(); tr != (Transform{Rot: 90}) {
+		t.Errorf("MirrorH().MirrorH() = %v, want the rotation", tr)
+	}
+	// horizontal and vertical mirroring is a half turn
+	if tr := (Transform{}).MirrorH().MirrorV(); tr != (Transform{Rot: 180}) {
+		t.Errorf("MirrorH().MirrorV() = %v, want a half turn", tr)
+	}
+}
+
+func TestTransformFreeRotate(t *testing.T) {
+	oc := ObjectCollection{
+		Paths:     []Path{path(defBelt, 0, 0, 10, 0)},
+		TextBoxes: []TextBox{textBox(0, 10, 10, 4, "text")},
+	}
+	sel := selectAll(oc)
+	center := sel.Bounds.Center()
+
+	var tr Transformed
+	tr.Compute(oc, sel, Transform{Rot: 45}, false)
+	if !tr.IsValid {
+		t.Fatal("IsValid = false, paths and text boxes can be rotated freely")
+	}
+	mat := matrix.NewRotateAroundV(45, center)
+	if p := tr.Paths[0]; p.Start.Distance(mat.ApplyV(oc.Paths[0].Start)) > 1e-4 || p.End.Distance(mat.ApplyV(oc.Paths[0].End)) > 1e-4 {
+		t.Errorf("path = %v, want a 45° rotation of %v", p, oc.Paths[0])
+	}
+	tb := tr.TextBoxes[0]
+	if tb.Rot != 45 || tb.Bounds.Position().Distance(mat.ApplyV(oc.TextBoxes[0].Bounds.Position())) > 1e-4 {
+		t.Errorf("text box = %v, want a 45° rotation of %v", tb, oc.TextBoxes[0])
+	}
+	// collisions use the rotated text box
+	if inside := mat.Apply(9, 13); !tb.CheckCollisionPoint(inside) {
+		t.Errorf("CheckCollisionPoint(%v) = false, want true", inside)
+	}
+	if outside := vec2(9, 13); tb.CheckCollisionPoint(outside) {
+		t.Errorf("CheckCollisionPoint(%v) = true, want false", outside)
+	}
+
+	// buildings can only be rotated by multiples of 90 degrees
+	oc.Buildings = []Building{building(defSplitter, 50, 50, 0)}
+	sel = selectAll(oc)
+	tr.Compute(oc, sel, Transform{Rot: 45}, false)
+	if tr.IsValid || !slices.Equal(tr.InvalidBuildings, []bool{true}) {
+		t.Errorf("IsValid=%v InvalidBuildings=%v, want false [true]", tr.IsValid, tr.InvalidBuildings)
+	}
+}
+
+func TestTransformCollisions(t *testing.T) {
+	oc := testCollection()
+	// building 0 spans x in [-4, 4], building 1 x in [16, 24]
+	sel := ObjectSelection{BuildingIdxs: []int{0}}
+	sel.RecomputeBounds(oc)
+
+	var tr Transformed
+	tr.Compute(oc, sel, Transform{Translate: vec2(18, 0)}, false)
+	if tr.IsValid || !slices.Equal(tr.InvalidBuildings, []bool{true}) {
+		t.Errorf("drag onto building 1: IsValid=%v InvalidBuildings=%v, want false [true]", tr.IsValid, tr.InvalidBuildings)
+	}
+
+	// a dragged building does not collide with itself
+	tr.Compute(oc, sel, Transform{Translate: vec2(2, 0)}, false)
+	if !tr.IsValid {
+		t.Errorf("small drag: IsValid = false, want true")
+	}
+
+	// a duplicated building collides with its original
+	tr.Compute(oc, sel, Transform{Translate: vec2(2, 0)}, true)
+	if tr.IsValid {
+		t.Errorf("small duplicate: IsValid = true, want false")
+	}
+	tr.Compute(oc, sel, Transform{Translate: vec2(0, -20)}, true)
+	if !tr.IsValid {
+		t.Errorf("duplicate to free space: IsValid = false, want true")
+	}
+
+	// moving a single path end onto its other end makes it invalid
+	sel = ObjectSelection{PathIdxs: []PathSel{{Idx: 0, End: true}}}
+	sel.RecomputeBounds(oc)
+	tr.Compute(oc, sel, Transform{Translate: vec2(-10, 0)}, false)
+	if tr.IsValid || !slices.Equal(tr.InvalidPaths, []bool{true}) {
+		t.Errorf("zero length path: IsValid=%v InvalidPaths=%v, want false [true]", tr.IsValid, tr.InvalidPaths)
+	}
+}
+
+// Applying a transformation with ModifyObjects, then undoing it, restores the scene
+func TestTransformModifyUndo(t *testing.T) {
+	s := newTestScene()
+	orig := s.ObjectCollection.Clone()
+	sel := selectAll(s.ObjectCollection)
+
+	var tr Transformed
+	tr.Compute(s.ObjectCollection, sel, Transform{Rot: 90, Translate: vec2(100, 0)}, false)
+	if !tr.IsValid {
+		t.Fatal("IsValid = false")
+	}
+	s.ModifyObjects(sel, tr.ObjectCollection)
+	assertCollection(t, s, tr.ObjectCollection)
+
+	undoSel, _ := s.Undo()
+	assertCollection(t, s, orig)
+	if undoSel.Bounds != sel.Bounds {
+		t.Errorf("Undo() selection bounds = %v, want %v", undoSel.Bounds, sel.Bounds)
+	}
+	redoSel, _ := s.Redo()
+	assertCollection(t, s, tr.ObjectCollection)
+	// text boxes are moved but not rotated: bounds are within the transformed selection bounds
+	if !checkCollisionRecs(tr.Bounds, redoSel.Bounds) || redoSel.Bounds.Width > tr.Bounds.Width || redoSel.Bounds.Height > tr.Bounds.Height {
+		t.Errorf("Redo() selection bounds = %v, not within %v", redoSel.Bounds, tr.Bounds)
+	}
+}
@@ -0,0 +1,178 @@
+// utils - Slice and geometry helpers used by the scene operations
+
+package scene
+
+import (
+	"slices"
+	"strconv"
+
+	rl "github.com/bonoboris/satisfied/rlmath"
+)
+
+// vec2 returns a new [rl.Vector2] (shorthand for [rl.NewVector2])
+func vec2(x, y float32) rl.Vector2 { return rl.Vector2{X: x, Y: y} }
+
+// CheckCollisionRecLine returns true if the segment [p1, p2] is inside or crosses the rectangle
+func CheckCollisionRecLine(rec rl.Rectangle, p1, p2 rl.Vector2) bool {
+	tl, tr, bl, br := rec.TopLeft(), rec.TopRight(), rec.BottomLeft(), rec.BottomRight()
+	return checkCollisionPointRec(p1, rec) || checkCollisionPointRec(p2, rec) ||
+		checkCollisionSegments(p1, p2, tl, tr) ||
+		checkCollisionSegments(p1, p2, bl, br) ||
+		checkCollisionSegments(p1, p2, tl, bl) ||
+		checkCollisionSegments(p1, p2, tr, br)
+}
+
+// checkCollisionRecs returns true if the rectangles overlap, touching rectangles do not (same as
+// raylib CheckCollisionRecs)
+func checkCollisionRecs(a, b rl.Rectangle) bool {
+	return a.X < b.X+b.Width && a.X+a.Width > b.X && a.Y < b.Y+b.Height && a.Y+a.Height > b.Y
+}
+
+// checkCollisionPointRec returns true if pos is inside the rectangle, its right and bottom edges
+// excluded (same as raylib CheckCollisionPointRec)
+func checkCollisionPointRec(pos rl.Vector2, rec rl.Rectangle) bool {
+	return pos.X >= rec.X && pos.X < rec.X+rec.Width && pos.Y >= rec.Y && pos.Y < rec.Y+rec.Height
+}
+
+// checkCollisionSegments returns true if the segments [a1, a2] and [b1, b2] cross, parallel
+// segments never cross
+func checkCollisionSegments(a1, a2, b1, b2 rl.Vector2) bool {
+	da, db, d := a2.Subtract(a1), b2.Subtract(b1), b1.Subtract(a1)
+	den := cross(da, db)
+	if den == 0 {
+		return false
+	}
+	// a1 + t*da == b1 + u*db
+	t, u := cross(d, db)/den, cross(d, da)/den
+	return t >= 0 && t <= 1 && u >= 0 && u <= 1
+}
+
+// checkCollisionPointCircle returns true if pos is inside the circle
+func checkCollisionPointCircle(pos, center rl.Vector2, radius float32) bool {
+	return pos.DistanceSqr(center) <= radius*radius
+}
+
+// cross returns the z component of the cross product of u and v
+func cross(u, v rl.Vector2) float32 { return u.X*v.Y - u.Y*v.X }
+
+// Range returns a slice of integers [i; j[
+func Range(i, j int) []int {
+	r := make([]int, j-i)
+	for k := range r {
+		r[k] = i + k
+	}
+	return r
+}
+
+// CopyIdxs clears dst and copies the elements at idxs from src into dst
+//
+// It reallocates of cap(dst) < len(idxs)
+func CopyIdxs[T any](dst, src []T, idxs []int) []T {
+	dst = slices.Grow(dst[:0], len(idxs))
+	for _, idx := range idxs {
+		dst = append(dst, src[idx])
+	}
+	return dst
+}
+
+// Repeat clears dst and fills it with n copies of the given value
+//
+// It reallocates of cap(dst) < n
+func Repeat[T any](dst []T, v T, n int) []T {
+	dst = slices.Grow(dst[:0], n)
+	for range n {
+		dst = append(dst, v)
+	}
+	return dst
+}
+
+// SwapDelete efficiently deletes the element at index i by swapping it with the last element
+// and then truncating the slice.
+//
+// Calling [SwapDelete] with i == len(s) - 1 simply removes the last element.
+//
+// The complexity is O(1) but the slice order is not preserved.
+func SwapDelete[T any](s []T, i int) []T {
+	last := len(s) - 1
+	if i < last {
+		s[i], s[last] = s[last], s[i]
+	}
+	return s[:last]
+}
+
+// SwapInsert efficiently inserts the given element at index i by pushing the current i-th element
+// to the back.
+//
+// Calling [SwapInsert] with i == len(s) simply appends the element.
+//
+// This is the reverse of [SwapDelete].
+//
+// The complexity is O(1) but the slice order is not preserved.
+func SwapInsert[T any](s []T, i int, v T) []T {
+	n := len(s)
+	if i == n {
+		s = append(s, v)
+		return s
+	}
+	s = append(s, s[i])
+	s[i] = v
+	return s
+}
+
+// SwapDeleteMany efficiently deletes the elements at given indices idxs by swapping them with the last elements
+// and then truncating the slice.
+//
+// idxs must be sorted in ascending order.
+//
+// The complexity is O(len(indices)) but the slice order is not preserved.
+func SwapDeleteMany[T any](s []T, idxs []int) []T {
+	// reverse order because we are deleting elements
+	for i := len(idxs) - 1; i >= 0; i-- {
+		s = SwapDelete(s, idxs[i])
+	}
+	return s
+}
+
+// SwapInsertMany efficiently inserts the given elements at given indices idxs by pushing the current
+// elements at idxs to the back.
+//
+// Idxs values must all be between 0 and len(s)+len(idxs)-1.
+//
+// This is the reverse of [SwapDeleteMany].
+//
+// The complexity is O(len(indices)) but the slice order is not preserved.
+func SwapInsertMany[T any](s []T, idxs []int, vs []T) []T {
+	for i := 0; i < len(idxs); i++ {
+		s = SwapInsert(s, idxs[i], vs[i])
+	}
+	return s
+}
+
+// SortedIntsIndex returns the index of x in a ascending sorted int slice (-1 if not found)
+//
+// Binary search is used.
+func SortedIntsIndex(a []int, x int) int {
+	i, j := 0, len(a)
+	for i < j {
+		k := int(uint(i+j) >> 1) // avoid overflow when computing h
+		if a[k] == x {
+			return k
+		} else if a[k] < x {
+			i = k + 1
+		} else {
+			j = k
+		}
+	}
+	return -1
+}
+
+// ParseFloat32 parses a string to a float32 value.
+//
+// See: [strconv.ParseFloat]
+func ParseFloat32(s string) (float32, error) {
+	f, err := strconv.ParseFloat(s, 32)
+	if err != nil {
+		return 0, err
+	}
+	return float32(f), nil
+}
@@ -0,0 +1,177 @@
+package scene
+
+import (
+	"math/rand"
+	"slices"
+	"sort"
+	"testing"
+
+	rl "github.com/bonoboris/satisfied/rlmath"
+)
+
+func TestSwapDelete(t *testing.T) {
+	tests := []struct {
+		name string
+		s    []int
+		i    int
+		want []int
+	}{
+		{"first", []int{0, 1, 2, 3}, 0, []int{3, 1, 2}},
+		{"middle", []int{0, 1, 2, 3}, 1, []int{0, 3, 2}},
+		{"last", []int{0, 1, 2, 3}, 3, []int{0, 1, 2}},
+		{"single", []int{0}, 0, []int{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := SwapDelete(tt.s, tt.i); !slices.Equal(got, tt.want) {
+				t.Errorf("SwapDelete() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSwapInsert(t *testing.T) {
+	tests := []struct {
+		name string
+		s    []int
+		i    int
+		v    int
+		want []int
+	}{
+		{"first", []int{3, 1, 2}, 0, 0, []int{0, 1, 2, 3}},
+		{"middle", []int{0, 3, 2}, 1, 1, []int{0, 1, 2, 3}},
+		{"append", []int{0, 1, 2}, 3, 3, []int{0, 1, 2, 3}},
+		{"empty", []int{}, 0, 0, []int{0}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := SwapInsert(tt.s, tt.i, tt.v); !slices.Equal(got, tt.want) {
+				t.Errorf("SwapInsert() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSwapDeleteMany(t *testing.T) {
+	tests := []struct {
+		name string
+		s    []int
+		idxs []int
+		want []int
+	}{
+		{"none", []int{0, 1, 2, 3, 4}, nil, []int{0, 1, 2, 3, 4}},
+		{"all", []int{0, 1, 2, 3, 4}, []int{0, 1, 2, 3, 4}, []int{}},
+		{"tail", []int{0, 1, 2, 3, 4}, []int{3, 4}, []int{0, 1, 2}},
+		{"head", []int{0, 1, 2, 3, 4}, []int{0, 1}, []int{3, 4, 2}},
+		{"scattered", []int{0, 1, 2, 3, 4, 5}, []int{1, 3, 4}, []int{0, 5, 2}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := SwapDeleteMany(tt.s, tt.idxs); !slices.Equal(got, tt.want) {
+				t.Errorf("SwapDeleteMany() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// SwapInsertMany must restore the slice modified by SwapDeleteMany, in the same order
+func TestSwapDeleteInsertManyRoundtrip(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+	for range 1000 {
+		n := rnd.Intn(20)
+		orig := Range(0, n)
+		var idxs []int
+		for i := range n {
+			if rnd.Intn(3) == 0 {
+				idxs = append(idxs, i)
+			}
+		}
+		deleted := CopyIdxs(nil, orig, idxs)
+
+		s := SwapDeleteMany(slices.Clone(orig), idxs)
+		if len(s) != n-len(idxs) {
+			t.Fatalf("SwapDeleteMany(%v, %v): len = %d, want %d", orig, idxs, len(s), n-len(idxs))
+		}
+		rest := slices.Clone(s)
+		sort.Ints(rest)
+		for _, v := range rest {
+			if SortedIntsIndex(idxs, v) >= 0 {
+				t.Fatalf("SwapDeleteMany(%v, %v) = %v: contains deleted element %d", orig, idxs, s, v)
+			}
+		}
+
+		s = SwapInsertMany(s, idxs, deleted)
+		if !slices.Equal(s, orig) {
+			t.Fatalf("SwapInsertMany(SwapDeleteMany(%v, %v)) = %v", orig, idxs, s)
+		}
+	}
+}
+
+func TestCopyIdxsAndRepeat(t *testing.T) {
+	dst := []int{9, 9, 9, 9, 9}
+	dst = CopyIdxs(dst, []int{10, 11, 12, 13}, []int{1, 3})
+	if !slices.Equal(dst, []int{11, 13}) {
+		t.Errorf("CopyIdxs() = %v, want [11 13]", dst)
+	}
+	bs := Repeat([]bool{false}, true, 3)
+	if !slices.Equal(bs, []bool{true, true, true}) {
+		t.Errorf("Repeat() = %v, want [true true true]", bs)
+	}
+}
+
+func TestSortedIntsIndex(t *testing.T) {
+	a := []int{1, 3, 5, 7, 9}
+	for i, v := range a {
+		if got := SortedIntsIndex(a, v); got != i {
+			t.Errorf("SortedIntsIndex(%v, %d) = %d, want %d", a, v, got, i)
+		}
+	}
+	for _, v := range []int{0, 2, 4, 10} {
+		if got := SortedIntsIndex(a, v); got != -1 {
+			t.Errorf("SortedIntsIndex(%v, %d) = %d, want -1", a, v, got)
+		}
+	}
+	if got := SortedIntsIndex(nil, 1); got != -1 {
+		t.Errorf("SortedIntsIndex(nil, 1) = %d, want -1", got)
+	}
+}
+
+func TestCheckCollisionRecLine(t *testing.T) {
+	rec := rl.NewRectangle(0, 0, 10, 10)
+	tests := []struct {
+		p1, p2 rl.Vector2
+		want   bool
+	}{
+		{vec2(2, 2), vec2(4, 4), true},     // inside
+		{vec2(-5, 5), vec2(5, 5), true},    // one end inside
+		{vec2(-5, 5), vec2(15, 5), true},   // crosses 2 sides
+		{vec2(-5, 12), vec2(12, -5), true}, // crosses a corner
+		{vec2(-5, -1), vec2(15, -1), false},
+		{vec2(-5, 30), vec2(30, -5), false},
+		{vec2(12, 0), vec2(12, 10), false}, // parallel to a side
+	}
+	for _, tt := range tests {
+		if got := CheckCollisionRecLine(rec, tt.p1, tt.p2); got != tt.want {
+			t.Errorf("CheckCollisionRecLine(%v, %v, %v) = %v, want %v", rec, tt.p1, tt.p2, got, tt.want)
+		}
+	}
+}
+
+func TestCheckCollisionRecs(t *testing.T) {
+	rec := rl.NewRectangle(0, 0, 10, 10)
+	tests := []struct {
+		other rl.Rectangle
+		want  bool
+	}{
+		{rl.NewRectangle(2, 2, 4, 4), true},    // inside
+		{rl.NewRectangle(-5, -5, 6, 6), true},  // overlapping a corner
+		{rl.NewRectangle(-5, 2, 20, 2), true},  // crossing
+		{rl.NewRectangle(10, 0, 5, 10), false}, // touching
+		{rl.NewRectangle(12, 12, 5, 5), false}, // apart
+	}
+	for _, tt := range tests {
+		if got := checkCollisionRecs(rec, tt.other); got != tt.want {
+			t.Errorf("checkCollisionRecs(%v, %v) = %v, want %v", rec, tt.other, got, tt.want)
+		}
+	}
+}